@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 
 	"github.com/drama-generator/backend/application/services"
 	"github.com/drama-generator/backend/domain/models"
+	"github.com/drama-generator/backend/infrastructure/storage"
 	"github.com/drama-generator/backend/pkg/config"
 	"github.com/drama-generator/backend/pkg/logger"
 	"github.com/drama-generator/backend/pkg/response"
@@ -15,12 +17,16 @@ import (
 
 type AssetHandler struct {
 	assetService *services.AssetService
+	mediaService *services.MediaService
+	taskService  *services.TaskService
 	log          *logger.Logger
 }
 
-func NewAssetHandler(db *gorm.DB, cfg *config.Config, log *logger.Logger) *AssetHandler {
+func NewAssetHandler(db *gorm.DB, cfg *config.Config, log *logger.Logger, localStorage *storage.LocalStorage) *AssetHandler {
 	return &AssetHandler{
-		assetService: services.NewAssetService(db, log),
+		assetService: services.NewAssetService(db, localStorage, log),
+		mediaService: services.NewMediaService(db, localStorage, log),
+		taskService:  services.NewTaskService(db, log),
 		log:          log,
 	}
 }
@@ -218,3 +224,51 @@ func (h *AssetHandler) ImportFromVideoGen(c *gin.Context) {
 
 	response.Success(c, asset)
 }
+
+// BackfillMetadata 为已有素材和生成记录补全媒体元数据（异步任务）
+// force=true 时重新探测全部记录
+func (h *AssetHandler) BackfillMetadata(c *gin.Context) {
+	force := c.Query("force") == "true"
+
+	task, err := h.taskService.CreateTask("media_metadata_backfill", "")
+	if err != nil {
+		h.log.Errorw("Failed to create task", "error", err)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	go h.processMetadataBackfill(task.ID, force)
+
+	response.Success(c, gin.H{
+		"task_id": task.ID,
+		"status":  "pending",
+		"message": "元数据回填任务已创建，正在后台处理...",
+	})
+}
+
+func (h *AssetHandler) processMetadataBackfill(taskID string, force bool) {
+	if err := h.taskService.UpdateTaskStatus(taskID, "processing", 0, "开始探测媒体文件..."); err != nil {
+		h.log.Errorw("Failed to update task status", "error", err)
+	}
+
+	lastProgress := -1
+	result, err := h.mediaService.BackfillMetadata(force, func(done, total int) {
+		progress := done * 100 / total
+		if progress == lastProgress {
+			return
+		}
+		lastProgress = progress
+		h.taskService.UpdateTaskStatus(taskID, "processing", progress, fmt.Sprintf("已处理 %d/%d", done, total))
+	})
+	if err != nil {
+		h.log.Errorw("Metadata backfill failed", "error", err, "task_id", taskID)
+		if updateErr := h.taskService.UpdateTaskError(taskID, err); updateErr != nil {
+			h.log.Errorw("Failed to update task error", "error", updateErr)
+		}
+		return
+	}
+
+	if err := h.taskService.UpdateTaskResult(taskID, result); err != nil {
+		h.log.Errorw("Failed to update task result", "error", err)
+	}
+}
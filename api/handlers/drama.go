@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"strings"
+
 	"github.com/drama-generator/backend/application/services"
 	"github.com/drama-generator/backend/domain/models"
+	"github.com/drama-generator/backend/infrastructure/storage"
 	"github.com/drama-generator/backend/pkg/config"
 	"github.com/drama-generator/backend/pkg/logger"
 	"github.com/drama-generator/backend/pkg/response"
@@ -17,10 +20,10 @@ type DramaHandler struct {
 	log               *logger.Logger
 }
 
-func NewDramaHandler(db *gorm.DB, cfg *config.Config, log *logger.Logger, transferService *services.ResourceTransferService) *DramaHandler {
+func NewDramaHandler(db *gorm.DB, cfg *config.Config, log *logger.Logger, transferService *services.ResourceTransferService, localStorage *storage.LocalStorage) *DramaHandler {
 	return &DramaHandler{
 		db:                db,
-		dramaService:      services.NewDramaService(db, log),
+		dramaService:      services.NewDramaService(db, localStorage, log),
 		videoMergeService: services.NewVideoMergeService(db, transferService, cfg.Storage.LocalPath, cfg.Storage.BaseURL, log),
 		log:               log,
 	}
@@ -156,6 +159,34 @@ func (h *DramaHandler) SaveOutline(c *gin.Context) {
 	response.Success(c, gin.H{"message": "保存成功"})
 }
 
+// SaveBranding 保存剧本品牌包装设置（水印、片头片尾），完成剧集制作时自动应用
+func (h *DramaHandler) SaveBranding(c *gin.Context) {
+
+	dramaID := c.Param("id")
+
+	var req services.SaveBrandingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	drama, err := h.dramaService.SaveBranding(dramaID, &req)
+	if err != nil {
+		switch {
+		case err.Error() == "drama not found":
+			response.NotFound(c, "剧本不存在")
+		case strings.HasSuffix(err.Error(), "not found"), strings.HasSuffix(err.Error(), "must be a video"),
+			strings.HasPrefix(err.Error(), "unknown watermark position"), strings.Contains(err.Error(), "is not accessible"):
+			response.BadRequest(c, err.Error())
+		default:
+			response.InternalError(c, "保存失败")
+		}
+		return
+	}
+
+	response.Success(c, drama)
+}
+
 func (h *DramaHandler) GetCharacters(c *gin.Context) {
 
 	dramaID := c.Param("id")
@@ -271,6 +302,11 @@ func (h *DramaHandler) FinalizeEpisode(c *gin.Context) {
 	// 触发视频合成任务
 	result, err := h.videoMergeService.FinalizeEpisode(episodeID, timelineData)
 	if err != nil {
+		if strings.Contains(err.Error(), "unknown output profile") || strings.Contains(err.Error(), "unknown subtitle mode") ||
+			strings.Contains(err.Error(), "invalid loudness target") {
+			response.BadRequest(c, err.Error())
+			return
+		}
 		h.log.Errorw("Failed to finalize episode", "error", err, "episode_id", episodeID)
 		response.InternalError(c, err.Error())
 		return
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/drama-generator/backend/application/services"
+	"github.com/drama-generator/backend/pkg/config"
+	"github.com/drama-generator/backend/pkg/logger"
+	"github.com/drama-generator/backend/pkg/response"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxOTIOSize 导入的OTIO文件大小上限
+const maxOTIOSize = 20 << 20
+
+type EditInterchangeHandler struct {
+	interchangeService *services.EditInterchangeService
+	log                *logger.Logger
+}
+
+func NewEditInterchangeHandler(db *gorm.DB, cfg *config.Config, log *logger.Logger) *EditInterchangeHandler {
+	return &EditInterchangeHandler{
+		interchangeService: services.NewEditInterchangeService(db, cfg.Storage.LocalPath, cfg.Storage.BaseURL, log),
+		log:                log,
+	}
+}
+
+// ExportEpisode 导出剧集剪辑工程（EDL/FCPXML/OTIO），片段顺序取自已保存的时间线或请求中的片段，否则按分镜顺序
+func (h *EditInterchangeHandler) ExportEpisode(c *gin.Context) {
+	episodeID, err := strconv.ParseUint(c.Param("episode_id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的ID")
+		return
+	}
+
+	var req services.EditExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.interchangeService.ExportEpisode(uint(episodeID), &req)
+	if err != nil {
+		h.handleError(c, "Failed to export episode for editing", err)
+		return
+	}
+
+	if len(result.SkippedScenes) > 0 {
+		skipped := make([]string, len(result.SkippedScenes))
+		for i, number := range result.SkippedScenes {
+			skipped[i] = strconv.Itoa(number)
+		}
+		c.Header("X-Skipped-Scenes", strings.Join(skipped, ","))
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", result.FileName))
+	c.Data(http.StatusOK, result.ContentType, result.Content)
+}
+
+// ImportOTIO 导入OTIO文件生成新的时间线，文件通过表单字段 file 上传或直接作为请求体
+func (h *EditInterchangeHandler) ImportOTIO(c *gin.Context) {
+	var req services.ImportOTIORequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	var reader io.Reader = c.Request.Body
+	if file, err := c.FormFile("file"); err == nil {
+		opened, err := file.Open()
+		if err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		defer opened.Close()
+		reader = opened
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxOTIOSize+1))
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if len(data) > maxOTIOSize {
+		response.BadRequest(c, "文件过大")
+		return
+	}
+
+	timeline, err := h.interchangeService.ImportOTIO(&req, data)
+	if err != nil {
+		h.handleError(c, "Failed to import otio", err)
+		return
+	}
+
+	response.Created(c, timeline)
+}
+
+func (h *EditInterchangeHandler) handleError(c *gin.Context, msg string, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		response.NotFound(c, err.Error())
+	case strings.HasPrefix(err.Error(), "unsupported"), strings.HasPrefix(err.Error(), "invalid otio"),
+		strings.HasPrefix(err.Error(), "no scenes"), strings.HasPrefix(err.Error(), "timeline "),
+		strings.Contains(err.Error(), "outside storage"), strings.Contains(err.Error(), "belongs to another drama"),
+		strings.Contains(err.Error(), "trim"), strings.Contains(err.Error(), "speed must be positive"):
+		response.BadRequest(c, err.Error())
+	default:
+		h.log.Errorw(msg, "error", err)
+		response.InternalError(c, err.Error())
+	}
+}
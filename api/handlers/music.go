@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/drama-generator/backend/application/services"
+	"github.com/drama-generator/backend/infrastructure/storage"
+	"github.com/drama-generator/backend/pkg/config"
+	"github.com/drama-generator/backend/pkg/logger"
+	"github.com/drama-generator/backend/pkg/response"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type MusicHandler struct {
+	musicService *services.MusicService
+	log          *logger.Logger
+}
+
+func NewMusicHandler(db *gorm.DB, cfg *config.Config, log *logger.Logger, localStorage *storage.LocalStorage) *MusicHandler {
+	return &MusicHandler{
+		musicService: services.NewMusicService(db, localStorage, log),
+		log:          log,
+	}
+}
+
+// musicRequestError 曲库请求的参数错误映射为400，其余按服务端错误处理
+func musicRequestError(c *gin.Context, err error) bool {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found") && !strings.Contains(msg, "config"):
+		response.NotFound(c, msg)
+	case strings.HasPrefix(msg, "unknown music"), strings.Contains(msg, "must be audio"), strings.Contains(msg, "no music AI config"):
+		response.BadRequest(c, msg)
+	default:
+		return false
+	}
+	return true
+}
+
+func (h *MusicHandler) CreateTrack(c *gin.Context) {
+	var req services.CreateMusicTrackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	track, err := h.musicService.CreateTrack(&req)
+	if err != nil {
+		if musicRequestError(c, err) {
+			return
+		}
+		h.log.Errorw("Failed to create music track", "error", err)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Created(c, track)
+}
+
+// GenerateTrack 提交音乐生成任务，生成完成后曲目自动加入曲库
+func (h *MusicHandler) GenerateTrack(c *gin.Context) {
+	var req services.GenerateMusicRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	track, err := h.musicService.GenerateTrack(&req)
+	if err != nil {
+		if musicRequestError(c, err) {
+			return
+		}
+		h.log.Errorw("Failed to generate music", "error", err)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, track)
+}
+
+func (h *MusicHandler) UpdateTrack(c *gin.Context) {
+	trackID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的ID")
+		return
+	}
+
+	var req services.UpdateMusicTrackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	track, err := h.musicService.UpdateTrack(uint(trackID), &req)
+	if err != nil {
+		if musicRequestError(c, err) {
+			return
+		}
+		h.log.Errorw("Failed to update music track", "error", err)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, track)
+}
+
+func (h *MusicHandler) DeleteTrack(c *gin.Context) {
+	trackID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的ID")
+		return
+	}
+
+	if err := h.musicService.DeleteTrack(uint(trackID)); err != nil {
+		if musicRequestError(c, err) {
+			return
+		}
+		h.log.Errorw("Failed to delete music track", "error", err)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+func (h *MusicHandler) GetTrack(c *gin.Context) {
+	trackID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的ID")
+		return
+	}
+
+	track, err := h.musicService.GetTrack(uint(trackID))
+	if err != nil {
+		response.NotFound(c, "曲目不存在")
+		return
+	}
+
+	response.Success(c, track)
+}
+
+func (h *MusicHandler) ListTracks(c *gin.Context) {
+	var dramaID *uint
+	if dramaIDStr := c.Query("drama_id"); dramaIDStr != "" {
+		id, err := strconv.ParseUint(dramaIDStr, 10, 32)
+		if err == nil {
+			uid := uint(id)
+			dramaID = &uid
+		}
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	tracks, total, err := h.musicService.ListTracks(dramaID, c.Query("mood"), c.Query("tempo"), c.Query("status"), page, pageSize)
+	if err != nil {
+		h.log.Errorw("Failed to list music tracks", "error", err)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.SuccessWithPagination(c, tracks, total, page, pageSize)
+}
+
+// PlanEpisodeMusic 预览剧集按分镜配乐描述的分段与选曲结果
+func (h *MusicHandler) PlanEpisodeMusic(c *gin.Context) {
+	cues, err := h.musicService.PlanEpisodeMusic(c.Param("episode_id"))
+	if err != nil {
+		if musicRequestError(c, err) {
+			return
+		}
+		h.log.Errorw("Failed to plan episode music", "error", err)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, cues)
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/drama-generator/backend/application/services"
+	"github.com/drama-generator/backend/pkg/logger"
+	"github.com/drama-generator/backend/pkg/response"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PromptTemplateHandler struct {
+	promptService *services.PromptTemplateService
+	log           *logger.Logger
+}
+
+func NewPromptTemplateHandler(db *gorm.DB, log *logger.Logger) *PromptTemplateHandler {
+	return &PromptTemplateHandler{
+		promptService: services.NewPromptTemplateService(db, log),
+		log:           log,
+	}
+}
+
+// ListTemplates 列出全部提示词模板，drama_id 指定时附带该剧本的覆盖版本
+func (h *PromptTemplateHandler) ListTemplates(c *gin.Context) {
+	dramaID, ok := h.queryDramaID(c)
+	if !ok {
+		return
+	}
+
+	templates, err := h.promptService.ListTemplates(dramaID)
+	if err != nil {
+		h.handleError(c, "Failed to list prompt templates", err)
+		return
+	}
+
+	response.Success(c, templates)
+}
+
+func (h *PromptTemplateHandler) GetTemplate(c *gin.Context) {
+	dramaID, ok := h.queryDramaID(c)
+	if !ok {
+		return
+	}
+
+	template, err := h.promptService.GetTemplate(c.Param("key"), dramaID)
+	if err != nil {
+		h.handleError(c, "Failed to get prompt template", err)
+		return
+	}
+
+	response.Success(c, template)
+}
+
+// ListVersions 列出全局模板的版本，drama_id 指定时列出该剧本覆盖的版本
+func (h *PromptTemplateHandler) ListVersions(c *gin.Context) {
+	dramaID, ok := h.queryDramaID(c)
+	if !ok {
+		return
+	}
+
+	versions, err := h.promptService.ListVersions(c.Param("key"), dramaID)
+	if err != nil {
+		h.handleError(c, "Failed to list prompt template versions", err)
+		return
+	}
+
+	response.Success(c, versions)
+}
+
+// CreateVersion 保存模板新版本并启用，请求体带 drama_id 时保存为该剧本的覆盖
+func (h *PromptTemplateHandler) CreateVersion(c *gin.Context) {
+	var req services.CreatePromptTemplateVersionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	template, err := h.promptService.CreateVersion(c.Param("key"), &req)
+	if err != nil {
+		h.handleError(c, "Failed to create prompt template version", err)
+		return
+	}
+
+	response.Created(c, template)
+}
+
+// ActivateVersion 启用指定的历史版本
+func (h *PromptTemplateHandler) ActivateVersion(c *gin.Context) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		response.BadRequest(c, "无效的版本号")
+		return
+	}
+	dramaID, ok := h.queryDramaID(c)
+	if !ok {
+		return
+	}
+
+	template, err := h.promptService.ActivateVersion(c.Param("key"), version, dramaID)
+	if err != nil {
+		h.handleError(c, "Failed to activate prompt template version", err)
+		return
+	}
+
+	response.Success(c, template)
+}
+
+// ResetOverride 取消剧本覆盖，恢复使用全局模板
+func (h *PromptTemplateHandler) ResetOverride(c *gin.Context) {
+	dramaID, err := strconv.ParseUint(c.Param("drama_id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的ID")
+		return
+	}
+
+	if err := h.promptService.ResetOverride(c.Param("key"), uint(dramaID)); err != nil {
+		h.handleError(c, "Failed to reset prompt template override", err)
+		return
+	}
+
+	response.Success(c, gin.H{"message": "已恢复使用全局模板"})
+}
+
+// PreviewTemplate 使用真实剧本数据渲染模板，content 不为空时预览未保存的内容
+func (h *PromptTemplateHandler) PreviewTemplate(c *gin.Context) {
+	var req services.PreviewPromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	preview, err := h.promptService.Preview(c.Param("key"), &req)
+	if err != nil {
+		h.handleError(c, "Failed to preview prompt template", err)
+		return
+	}
+
+	response.Success(c, preview)
+}
+
+// queryDramaID 解析可选的 drama_id 查询参数
+func (h *PromptTemplateHandler) queryDramaID(c *gin.Context) (*uint, bool) {
+	dramaIDStr := c.Query("drama_id")
+	if dramaIDStr == "" {
+		return nil, true
+	}
+	id, err := strconv.ParseUint(dramaIDStr, 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的ID")
+		return nil, false
+	}
+	dramaID := uint(id)
+	return &dramaID, true
+}
+
+func (h *PromptTemplateHandler) handleError(c *gin.Context, msg string, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		response.NotFound(c, err.Error())
+	case strings.HasPrefix(err.Error(), "unknown variable"), strings.HasPrefix(err.Error(), "undefined variable"),
+		strings.HasPrefix(err.Error(), "content is required"):
+		response.BadRequest(c, err.Error())
+	default:
+		h.log.Errorw(msg, "error", err)
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/drama-generator/backend/application/services"
+	"github.com/drama-generator/backend/pkg/logger"
+	"github.com/drama-generator/backend/pkg/response"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxScreenplaySize 导入的剧本文件大小上限
+const maxScreenplaySize = 10 << 20
+
+type ScreenplayHandler struct {
+	screenplayService *services.ScreenplayService
+	log               *logger.Logger
+}
+
+func NewScreenplayHandler(db *gorm.DB, log *logger.Logger) *ScreenplayHandler {
+	return &ScreenplayHandler{
+		screenplayService: services.NewScreenplayService(db, log),
+		log:               log,
+	}
+}
+
+// ImportScreenplay 导入剧本文件（Fountain/FDX/Markdown）生成剧集、角色与场景，
+// 文件通过表单字段 file 上传或直接作为请求体
+func (h *ScreenplayHandler) ImportScreenplay(c *gin.Context) {
+	dramaID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的ID")
+		return
+	}
+
+	var req services.ImportScreenplayRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	var reader io.Reader = c.Request.Body
+	filename := ""
+	if file, err := c.FormFile("file"); err == nil {
+		opened, err := file.Open()
+		if err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		defer opened.Close()
+		reader = opened
+		filename = file.Filename
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxScreenplaySize+1))
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if len(data) > maxScreenplaySize {
+		response.BadRequest(c, "文件过大")
+		return
+	}
+
+	result, err := h.screenplayService.ImportScreenplay(uint(dramaID), &req, filename, data)
+	if err != nil {
+		h.handleError(c, "Failed to import screenplay", err)
+		return
+	}
+
+	response.Created(c, result)
+}
+
+// ExportDrama 导出整部剧的剧本，format 为 fountain、fdx 或 pdf
+func (h *ScreenplayHandler) ExportDrama(c *gin.Context) {
+	dramaID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的ID")
+		return
+	}
+
+	result, err := h.screenplayService.ExportDrama(uint(dramaID), c.DefaultQuery("format", "fountain"))
+	if err != nil {
+		h.handleError(c, "Failed to export drama screenplay", err)
+		return
+	}
+
+	h.sendExport(c, result)
+}
+
+// ExportEpisode 导出单集剧本
+func (h *ScreenplayHandler) ExportEpisode(c *gin.Context) {
+	episodeID, err := strconv.ParseUint(c.Param("episode_id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的ID")
+		return
+	}
+
+	result, err := h.screenplayService.ExportEpisode(uint(episodeID), c.DefaultQuery("format", "fountain"))
+	if err != nil {
+		h.handleError(c, "Failed to export episode screenplay", err)
+		return
+	}
+
+	h.sendExport(c, result)
+}
+
+func (h *ScreenplayHandler) sendExport(c *gin.Context, result *services.ScreenplayExportResult) {
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", result.FileName))
+	c.Data(http.StatusOK, result.ContentType, result.Content)
+}
+
+func (h *ScreenplayHandler) handleError(c *gin.Context, msg string, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		response.NotFound(c, err.Error())
+	case strings.HasPrefix(err.Error(), "unsupported"), strings.HasPrefix(err.Error(), "invalid fdx"),
+		strings.HasPrefix(err.Error(), "no scenes"), strings.HasPrefix(err.Error(), "no episodes"), strings.HasPrefix(err.Error(), "episode "):
+		response.BadRequest(c, err.Error())
+	default:
+		h.log.Errorw(msg, "error", err)
+		response.InternalError(c, err.Error())
+	}
+}
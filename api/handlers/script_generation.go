@@ -1,6 +1,10 @@
 package handlers
 
 import (
+	"errors"
+	"io"
+	"strings"
+
 	"github.com/drama-generator/backend/application/services"
 	"github.com/drama-generator/backend/pkg/config"
 	"github.com/drama-generator/backend/pkg/logger"
@@ -41,6 +45,34 @@ func (h *ScriptGenerationHandler) GenerateOutline(c *gin.Context) {
 	response.Success(c, result)
 }
 
+// GenerateOutlineStream 以SSE推送大纲生成过程：delta 事件为增量文本，result 事件为解析后的大纲
+func (h *ScriptGenerationHandler) GenerateOutlineStream(c *gin.Context) {
+	var req services.GenerateOutlineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	stream := response.NewSSEStream(c)
+	result, err := h.scriptService.GenerateOutlineStream(c.Request.Context(), &req, stream.Delta)
+	if err != nil {
+		h.log.Errorw("Failed to stream outline", "error", err)
+		h.streamError(c, stream, err)
+		return
+	}
+
+	stream.Result(result)
+}
+
+// streamError 尚未开始推送时按普通错误响应返回，否则推送 error 事件
+func (h *ScriptGenerationHandler) streamError(c *gin.Context, stream *response.SSEStream, err error) {
+	if !stream.Started() {
+		response.InternalError(c, err.Error())
+		return
+	}
+	stream.Error("INTERNAL_ERROR", err.Error())
+}
+
 func (h *ScriptGenerationHandler) GenerateCharacters(c *gin.Context) {
 	var req services.GenerateCharactersRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -116,3 +148,113 @@ func (h *ScriptGenerationHandler) GenerateEpisodes(c *gin.Context) {
 
 	response.Success(c, episodes)
 }
+
+// GenerateEpisodesStream 以SSE推送分集剧本生成过程：delta 事件为增量文本，result 事件为保存后的剧集
+func (h *ScriptGenerationHandler) GenerateEpisodesStream(c *gin.Context) {
+	var req services.GenerateEpisodesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	stream := response.NewSSEStream(c)
+	episodes, err := h.scriptService.GenerateEpisodesStream(c.Request.Context(), &req, stream.Delta)
+	if err != nil {
+		h.log.Errorw("Failed to stream episodes", "error", err)
+		h.streamError(c, stream, err)
+		return
+	}
+
+	stream.Result(episodes)
+}
+
+// GenerateEpisodesIncremental 逐集生成分集剧本（异步任务），每批携带前情提要，进度按已完成集数更新
+func (h *ScriptGenerationHandler) GenerateEpisodesIncremental(c *gin.Context) {
+	var req services.GenerateEpisodesIncrementalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if req.StartEpisode > req.EpisodeCount {
+		response.BadRequest(c, "start_episode must be less than or equal to episode_count")
+		return
+	}
+
+	task, err := h.taskService.CreateTask("episode_generation", req.DramaID)
+	if err != nil {
+		h.log.Errorw("Failed to create task", "error", err)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	go h.processEpisodeGeneration(task.ID, &req)
+
+	response.Success(c, gin.H{
+		"task_id": task.ID,
+		"status":  "pending",
+		"message": "剧集生成任务已创建，正在后台逐集生成...",
+	})
+}
+
+// processEpisodeGeneration 后台逐集生成，失败时已生成的剧集保留，可从失败的集数继续
+func (h *ScriptGenerationHandler) processEpisodeGeneration(taskID string, req *services.GenerateEpisodesIncrementalRequest) {
+	h.log.Infow("Starting incremental episode generation", "task_id", taskID, "drama_id", req.DramaID)
+
+	if err := h.taskService.UpdateTaskStatus(taskID, "processing", 0, "开始生成剧集..."); err != nil {
+		h.log.Errorw("Failed to update task status", "error", err)
+	}
+
+	episodes, err := h.scriptService.GenerateEpisodesIncremental(req, func(done, total int, message string) {
+		if err := h.taskService.UpdateTaskStatus(taskID, "processing", done*100/total, message); err != nil {
+			h.log.Errorw("Failed to update task status", "error", err)
+		}
+	})
+	if err != nil {
+		h.log.Errorw("Failed to generate episodes", "error", err, "task_id", taskID, "generated", len(episodes))
+		// 已生成的剧集与下次应开始的集数一并记录，客户端据此继续生成
+		result := gin.H{
+			"episodes":      episodes,
+			"total":         len(episodes),
+			"start_episode": max(req.StartEpisode, 1) + len(episodes),
+		}
+		if updateErr := h.taskService.UpdateTaskErrorWithResult(taskID, err, result); updateErr != nil {
+			h.log.Errorw("Failed to update task error", "error", updateErr)
+		}
+		return
+	}
+
+	result := gin.H{
+		"episodes": episodes,
+		"total":    len(episodes),
+	}
+	if err := h.taskService.UpdateTaskResult(taskID, result); err != nil {
+		h.log.Errorw("Failed to update task result", "error", err)
+		return
+	}
+
+	h.log.Infow("Incremental episode generation completed", "task_id", taskID, "total", len(episodes))
+}
+
+// RegenerateEpisode 结合前情重新生成单集剧本
+func (h *ScriptGenerationHandler) RegenerateEpisode(c *gin.Context) {
+	episodeID := c.Param("episode_id")
+
+	var req services.RegenerateEpisodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	episode, err := h.scriptService.RegenerateEpisode(episodeID, &req)
+	if err != nil {
+		if strings.HasSuffix(err.Error(), "not found") {
+			response.NotFound(c, err.Error())
+			return
+		}
+		h.log.Errorw("Failed to regenerate episode", "error", err, "episode_id", episodeID)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, episode)
+}
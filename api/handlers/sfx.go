@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/drama-generator/backend/application/services"
+	"github.com/drama-generator/backend/infrastructure/storage"
+	"github.com/drama-generator/backend/pkg/config"
+	"github.com/drama-generator/backend/pkg/logger"
+	"github.com/drama-generator/backend/pkg/response"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SFXHandler struct {
+	sfxService  *services.SFXService
+	taskService *services.TaskService
+	sfxDir      string // 音效目录，位于本地存储下的 sfx 子目录
+	log         *logger.Logger
+}
+
+func NewSFXHandler(db *gorm.DB, cfg *config.Config, log *logger.Logger, localStorage *storage.LocalStorage) *SFXHandler {
+	return &SFXHandler{
+		sfxService:  services.NewSFXService(db, localStorage, log),
+		taskService: services.NewTaskService(db, log),
+		sfxDir:      filepath.Join(cfg.Storage.LocalPath, "sfx"),
+		log:         log,
+	}
+}
+
+// sfxRequestError 音效库请求的参数错误映射为400/404，其余按服务端错误处理
+func sfxRequestError(c *gin.Context, err error) bool {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		response.NotFound(c, msg)
+	case strings.Contains(msg, "must be audio"):
+		response.BadRequest(c, msg)
+	default:
+		return false
+	}
+	return true
+}
+
+// ScanLibrary 导入存储目录 sfx/ 下的音效文件
+func (h *SFXHandler) ScanLibrary(c *gin.Context) {
+	result, err := h.sfxService.ScanLibrary(h.sfxDir)
+	if err != nil {
+		if sfxRequestError(c, err) {
+			return
+		}
+		h.log.Errorw("Failed to scan sfx library", "error", err)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+func (h *SFXHandler) CreateSFX(c *gin.Context) {
+	var req services.CreateSFXRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	sfx, err := h.sfxService.CreateSFX(&req)
+	if err != nil {
+		if sfxRequestError(c, err) {
+			return
+		}
+		h.log.Errorw("Failed to create sfx", "error", err)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Created(c, sfx)
+}
+
+func (h *SFXHandler) UpdateSFX(c *gin.Context) {
+	sfxID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的ID")
+		return
+	}
+
+	var req services.UpdateSFXRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	sfx, err := h.sfxService.UpdateSFX(uint(sfxID), &req)
+	if err != nil {
+		if sfxRequestError(c, err) {
+			return
+		}
+		h.log.Errorw("Failed to update sfx", "error", err)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, sfx)
+}
+
+func (h *SFXHandler) DeleteSFX(c *gin.Context) {
+	sfxID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的ID")
+		return
+	}
+
+	if err := h.sfxService.DeleteSFX(uint(sfxID)); err != nil {
+		if sfxRequestError(c, err) {
+			return
+		}
+		h.log.Errorw("Failed to delete sfx", "error", err)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+func (h *SFXHandler) GetSFX(c *gin.Context) {
+	sfxID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的ID")
+		return
+	}
+
+	sfx, err := h.sfxService.GetSFX(uint(sfxID))
+	if err != nil {
+		response.NotFound(c, "音效不存在")
+		return
+	}
+
+	response.Success(c, sfx)
+}
+
+func (h *SFXHandler) ListSFX(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	effects, total, err := h.sfxService.ListSFX(c.Query("category"), c.Query("search"), page, pageSize)
+	if err != nil {
+		h.log.Errorw("Failed to list sfx", "error", err)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.SuccessWithPagination(c, effects, total, page, pageSize)
+}
+
+// MatchDescription 按音效描述检索候选音效
+func (h *SFXHandler) MatchDescription(c *gin.Context) {
+	description := strings.TrimSpace(c.Query("description"))
+	if description == "" {
+		response.BadRequest(c, "description 不能为空")
+		return
+	}
+
+	candidates, err := h.sfxService.MatchDescription(description, c.Query("use_ai") == "true")
+	if err != nil {
+		h.log.Errorw("Failed to match sfx", "error", err)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, candidates)
+}
+
+// MatchEpisode 为剧集分镜匹配音效，请求体可省略；use_ai 时逐个分镜调用模型，创建异步任务在后台处理
+func (h *SFXHandler) MatchEpisode(c *gin.Context) {
+	var req services.MatchSFXRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	episodeID := c.Param("episode_id")
+	if req.UseAI {
+		task, err := h.taskService.CreateTask("sfx_matching", episodeID)
+		if err != nil {
+			h.log.Errorw("Failed to create task", "error", err)
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		go h.processSFXMatching(task.ID, episodeID, &req)
+
+		response.Success(c, gin.H{
+			"task_id": task.ID,
+			"status":  "pending",
+			"message": "音效匹配任务已创建，正在后台处理...",
+		})
+		return
+	}
+
+	matches, err := h.sfxService.MatchEpisode(episodeID, &req, nil)
+	if err != nil {
+		if sfxRequestError(c, err) {
+			return
+		}
+		h.log.Errorw("Failed to match episode sfx", "error", err)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, matches)
+}
+
+// processSFXMatching 后台逐个分镜匹配音效
+func (h *SFXHandler) processSFXMatching(taskID, episodeID string, req *services.MatchSFXRequest) {
+	h.log.Infow("Starting sfx matching", "task_id", taskID, "episode_id", episodeID)
+
+	if err := h.taskService.UpdateTaskStatus(taskID, "processing", 0, "开始匹配音效..."); err != nil {
+		h.log.Errorw("Failed to update task status", "error", err)
+	}
+
+	matches, err := h.sfxService.MatchEpisode(episodeID, req, func(done, total int) {
+		if err := h.taskService.UpdateTaskStatus(taskID, "processing", done*100/total, fmt.Sprintf("已匹配 %d/%d 个分镜", done, total)); err != nil {
+			h.log.Errorw("Failed to update task status", "error", err)
+		}
+	})
+	if err != nil {
+		h.log.Errorw("Failed to match episode sfx", "error", err, "task_id", taskID)
+		if updateErr := h.taskService.UpdateTaskError(taskID, err); updateErr != nil {
+			h.log.Errorw("Failed to update task error", "error", updateErr)
+		}
+		return
+	}
+
+	result := gin.H{
+		"matches": matches,
+		"total":   len(matches),
+	}
+	if err := h.taskService.UpdateTaskResult(taskID, result); err != nil {
+		h.log.Errorw("Failed to update task result", "error", err)
+		return
+	}
+
+	h.log.Infow("SFX matching completed", "task_id", taskID, "total", len(matches))
+}
+
+// AssignSFX 手动为分镜选定音效，sfx_id 为0时清除
+func (h *SFXHandler) AssignSFX(c *gin.Context) {
+	var req struct {
+		SFXID uint `json:"sfx_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	storyboard, err := h.sfxService.AssignSFX(c.Param("id"), req.SFXID)
+	if err != nil {
+		if sfxRequestError(c, err) {
+			return
+		}
+		h.log.Errorw("Failed to assign sfx", "error", err)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, storyboard)
+}
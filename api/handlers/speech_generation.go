@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/drama-generator/backend/application/services"
+	"github.com/drama-generator/backend/infrastructure/storage"
+	"github.com/drama-generator/backend/pkg/config"
+	"github.com/drama-generator/backend/pkg/logger"
+	"github.com/drama-generator/backend/pkg/response"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SpeechGenerationHandler struct {
+	speechService *services.SpeechGenerationService
+	log           *logger.Logger
+}
+
+func NewSpeechGenerationHandler(db *gorm.DB, cfg *config.Config, log *logger.Logger, localStorage *storage.LocalStorage) *SpeechGenerationHandler {
+	return &SpeechGenerationHandler{
+		speechService: services.NewSpeechGenerationService(db, localStorage, log),
+		log:           log,
+	}
+}
+
+// bindSpeechRequest 请求体可省略，省略时使用默认语音配置
+func bindSpeechRequest(c *gin.Context) (*services.GenerateSpeechRequest, bool) {
+	var req services.GenerateSpeechRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, err.Error())
+		return nil, false
+	}
+	return &req, true
+}
+
+// AssignVoice 设置角色音色
+func (h *SpeechGenerationHandler) AssignVoice(c *gin.Context) {
+	characterID := c.Param("id")
+
+	var req services.AssignVoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	character, err := h.speechService.AssignVoice(characterID, &req)
+	if err != nil {
+		if err.Error() == "character not found" {
+			response.NotFound(c, "角色不存在")
+			return
+		}
+		h.log.Errorw("Failed to assign voice", "error", err)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, character)
+}
+
+// GenerateForStoryboard 为分镜对白生成配音
+func (h *SpeechGenerationHandler) GenerateForStoryboard(c *gin.Context) {
+	storyboardID := c.Param("storyboard_id")
+
+	req, ok := bindSpeechRequest(c)
+	if !ok {
+		return
+	}
+
+	generations, err := h.speechService.GenerateForStoryboard(storyboardID, req)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			response.NotFound(c, "分镜不存在")
+			return
+		}
+		h.log.Errorw("Failed to generate speech for storyboard", "error", err)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, generations)
+}
+
+// BatchGenerateForEpisode 为剧集全部对白生成配音
+func (h *SpeechGenerationHandler) BatchGenerateForEpisode(c *gin.Context) {
+	episodeID := c.Param("episode_id")
+
+	req, ok := bindSpeechRequest(c)
+	if !ok {
+		return
+	}
+
+	generations, err := h.speechService.BatchGenerateForEpisode(episodeID, req)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			response.NotFound(c, "剧集不存在")
+			return
+		}
+		h.log.Errorw("Failed to batch generate speech", "error", err)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, generations)
+}
+
+func (h *SpeechGenerationHandler) GetSpeechGeneration(c *gin.Context) {
+	speechGenID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的ID")
+		return
+	}
+
+	generation, err := h.speechService.GetSpeechGeneration(uint(speechGenID))
+	if err != nil {
+		response.NotFound(c, "配音记录不存在")
+		return
+	}
+
+	response.Success(c, generation)
+}
+
+func (h *SpeechGenerationHandler) ListSpeechGenerations(c *gin.Context) {
+	var episodeID *uint
+	if episodeIDStr := c.Query("episode_id"); episodeIDStr != "" {
+		id, err := strconv.ParseUint(episodeIDStr, 10, 32)
+		if err == nil {
+			uid := uint(id)
+			episodeID = &uid
+		}
+	}
+
+	var storyboardID *uint
+	if storyboardIDStr := c.Query("storyboard_id"); storyboardIDStr != "" {
+		id, err := strconv.ParseUint(storyboardIDStr, 10, 32)
+		if err == nil {
+			uid := uint(id)
+			storyboardID = &uid
+		}
+	}
+
+	status := c.Query("status")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	generations, total, err := h.speechService.ListSpeechGenerations(episodeID, storyboardID, status, page, pageSize)
+	if err != nil {
+		h.log.Errorw("Failed to list speech generations", "error", err)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.SuccessWithPagination(c, generations, total, page, pageSize)
+}
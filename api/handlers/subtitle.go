@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/drama-generator/backend/application/services"
+	"github.com/drama-generator/backend/infrastructure/external/ffmpeg"
+	"github.com/drama-generator/backend/pkg/logger"
+	"github.com/drama-generator/backend/pkg/response"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SubtitleHandler struct {
+	subtitleService *services.SubtitleService
+	log             *logger.Logger
+}
+
+func NewSubtitleHandler(db *gorm.DB, log *logger.Logger) *SubtitleHandler {
+	return &SubtitleHandler{
+		subtitleService: services.NewSubtitleService(db, log),
+		log:             log,
+	}
+}
+
+// exportSubtitlesQuery 字幕导出参数，样式参数仅对ASS格式生效
+type exportSubtitlesQuery struct {
+	Format       string  `form:"format"`
+	MergeID      string  `form:"merge_id"`
+	FontName     string  `form:"font_name"`
+	FontSize     int     `form:"font_size"`
+	PrimaryColor string  `form:"primary_color"`
+	OutlineColor string  `form:"outline_color"`
+	Outline      float64 `form:"outline"`
+	Bold         bool    `form:"bold"`
+	MarginV      int     `form:"margin_v"`
+}
+
+// ExportEpisodeSubtitles 导出剧集字幕（SRT/ASS/WebVTT），时间与最近一次正式合成（或指定的合成记录）对齐
+func (h *SubtitleHandler) ExportEpisodeSubtitles(c *gin.Context) {
+	episodeID, err := strconv.ParseUint(c.Param("episode_id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的ID")
+		return
+	}
+
+	var query exportSubtitlesQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	format, err := ffmpeg.ParseSubtitleFormat(query.Format)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	var mergeID *uint
+	if id, ok := parseOptionalUint(query.MergeID); ok {
+		mergeID = &id
+	}
+
+	style := &ffmpeg.SubtitleStyle{
+		FontName:     query.FontName,
+		FontSize:     query.FontSize,
+		PrimaryColor: query.PrimaryColor,
+		OutlineColor: query.OutlineColor,
+		Outline:      query.Outline,
+		Bold:         query.Bold,
+		MarginV:      query.MarginV,
+	}
+
+	content, err := h.subtitleService.ExportEpisodeSubtitles(uint(episodeID), mergeID, format, style)
+	if err != nil {
+		switch {
+		case strings.HasSuffix(err.Error(), "not found"):
+			response.NotFound(c, err.Error())
+		case strings.HasPrefix(err.Error(), "no dialogue"), strings.HasPrefix(err.Error(), "no storyboards"):
+			response.BadRequest(c, err.Error())
+		default:
+			h.log.Errorw("Failed to export subtitles", "error", err, "episode_id", episodeID)
+			response.InternalError(c, err.Error())
+		}
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=episode_%d.%s", episodeID, format))
+	c.Data(http.StatusOK, format.ContentType(), []byte(content))
+}
@@ -0,0 +1,462 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/drama-generator/backend/application/services"
+	"github.com/drama-generator/backend/pkg/logger"
+	"github.com/drama-generator/backend/pkg/response"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TimelineHandler struct {
+	timelineService *services.TimelineService
+	log             *logger.Logger
+}
+
+func NewTimelineHandler(db *gorm.DB, log *logger.Logger) *TimelineHandler {
+	return &TimelineHandler{
+		timelineService: services.NewTimelineService(db, log),
+		log:             log,
+	}
+}
+
+func (h *TimelineHandler) ListTimelines(c *gin.Context) {
+	var dramaID, episodeID *uint
+	if id, ok := parseOptionalUint(c.Query("drama_id")); ok {
+		dramaID = &id
+	}
+	if id, ok := parseOptionalUint(c.Query("episode_id")); ok {
+		episodeID = &id
+	}
+
+	timelines, err := h.timelineService.ListTimelines(dramaID, episodeID)
+	if err != nil {
+		h.log.Errorw("Failed to list timelines", "error", err)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, timelines)
+}
+
+func (h *TimelineHandler) CreateTimeline(c *gin.Context) {
+	var req services.CreateTimelineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	timeline, err := h.timelineService.CreateTimeline(&req)
+	if err != nil {
+		h.handleError(c, "Failed to create timeline", err)
+		return
+	}
+
+	response.Created(c, timeline)
+}
+
+// BuildFromEpisode 根据剧集分镜生成默认时间线
+func (h *TimelineHandler) BuildFromEpisode(c *gin.Context) {
+	episodeID, ok := h.parseID(c, "episode_id")
+	if !ok {
+		return
+	}
+
+	timeline, err := h.timelineService.BuildFromEpisode(episodeID)
+	if err != nil {
+		h.handleError(c, "Failed to build timeline from episode", err)
+		return
+	}
+
+	response.Created(c, timeline)
+}
+
+func (h *TimelineHandler) GetTimeline(c *gin.Context) {
+	timelineID, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	timeline, err := h.timelineService.GetTimeline(timelineID)
+	if err != nil {
+		h.handleError(c, "Failed to get timeline", err)
+		return
+	}
+
+	response.Success(c, timeline)
+}
+
+func (h *TimelineHandler) UpdateTimeline(c *gin.Context) {
+	timelineID, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req services.UpdateTimelineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	timeline, err := h.timelineService.UpdateTimeline(timelineID, &req)
+	if err != nil {
+		h.handleError(c, "Failed to update timeline", err)
+		return
+	}
+
+	response.Success(c, timeline)
+}
+
+func (h *TimelineHandler) DeleteTimeline(c *gin.Context) {
+	timelineID, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.timelineService.DeleteTimeline(timelineID); err != nil {
+		h.handleError(c, "Failed to delete timeline", err)
+		return
+	}
+
+	response.Success(c, gin.H{"message": "删除成功"})
+}
+
+func (h *TimelineHandler) CreateTrack(c *gin.Context) {
+	timelineID, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req services.CreateTrackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	track, err := h.timelineService.CreateTrack(timelineID, &req)
+	if err != nil {
+		h.handleError(c, "Failed to create track", err)
+		return
+	}
+
+	response.Created(c, track)
+}
+
+func (h *TimelineHandler) UpdateTrack(c *gin.Context) {
+	timelineID, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+	trackID, ok := h.parseID(c, "track_id")
+	if !ok {
+		return
+	}
+
+	var req services.UpdateTrackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	track, err := h.timelineService.UpdateTrack(timelineID, trackID, &req)
+	if err != nil {
+		h.handleError(c, "Failed to update track", err)
+		return
+	}
+
+	response.Success(c, track)
+}
+
+func (h *TimelineHandler) DeleteTrack(c *gin.Context) {
+	timelineID, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+	trackID, ok := h.parseID(c, "track_id")
+	if !ok {
+		return
+	}
+
+	if err := h.timelineService.DeleteTrack(timelineID, trackID); err != nil {
+		h.handleError(c, "Failed to delete track", err)
+		return
+	}
+
+	response.Success(c, gin.H{"message": "删除成功"})
+}
+
+// ReorderTracks 调整轨道顺序
+func (h *TimelineHandler) ReorderTracks(c *gin.Context) {
+	timelineID, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req services.ReorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.timelineService.ReorderTracks(timelineID, req.IDs); err != nil {
+		h.handleError(c, "Failed to reorder tracks", err)
+		return
+	}
+
+	response.Success(c, gin.H{"message": "排序成功"})
+}
+
+// ReorderClips 调整轨道内片段顺序（首尾相接重新排列）
+func (h *TimelineHandler) ReorderClips(c *gin.Context) {
+	timelineID, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+	trackID, ok := h.parseID(c, "track_id")
+	if !ok {
+		return
+	}
+
+	var req services.ReorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.timelineService.ReorderClips(timelineID, trackID, req.IDs); err != nil {
+		h.handleError(c, "Failed to reorder clips", err)
+		return
+	}
+
+	response.Success(c, gin.H{"message": "排序成功"})
+}
+
+func (h *TimelineHandler) CreateClip(c *gin.Context) {
+	timelineID, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req services.CreateClipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	clip, err := h.timelineService.CreateClip(timelineID, &req)
+	if err != nil {
+		h.handleError(c, "Failed to create clip", err)
+		return
+	}
+
+	response.Created(c, clip)
+}
+
+func (h *TimelineHandler) UpdateClip(c *gin.Context) {
+	timelineID, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+	clipID, ok := h.parseID(c, "clip_id")
+	if !ok {
+		return
+	}
+
+	var req services.UpdateClipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	clip, err := h.timelineService.UpdateClip(timelineID, clipID, &req)
+	if err != nil {
+		h.handleError(c, "Failed to update clip", err)
+		return
+	}
+
+	response.Success(c, clip)
+}
+
+func (h *TimelineHandler) DeleteClip(c *gin.Context) {
+	timelineID, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+	clipID, ok := h.parseID(c, "clip_id")
+	if !ok {
+		return
+	}
+
+	if err := h.timelineService.DeleteClip(timelineID, clipID); err != nil {
+		h.handleError(c, "Failed to delete clip", err)
+		return
+	}
+
+	response.Success(c, gin.H{"message": "删除成功"})
+}
+
+// SetClipTransition 设置片段入场/出场转场
+func (h *TimelineHandler) SetClipTransition(c *gin.Context) {
+	timelineID, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+	clipID, ok := h.parseID(c, "clip_id")
+	if !ok {
+		return
+	}
+
+	var req services.SetTransitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	clip, err := h.timelineService.SetClipTransition(timelineID, clipID, &req)
+	if err != nil {
+		h.handleError(c, "Failed to set clip transition", err)
+		return
+	}
+
+	response.Success(c, clip)
+}
+
+func (h *TimelineHandler) RemoveClipTransition(c *gin.Context) {
+	timelineID, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+	clipID, ok := h.parseID(c, "clip_id")
+	if !ok {
+		return
+	}
+
+	position := c.DefaultQuery("position", "out")
+	if position != "in" && position != "out" {
+		response.BadRequest(c, "position必须为in或out")
+		return
+	}
+
+	if err := h.timelineService.RemoveClipTransition(timelineID, clipID, position); err != nil {
+		h.handleError(c, "Failed to remove clip transition", err)
+		return
+	}
+
+	response.Success(c, gin.H{"message": "删除成功"})
+}
+
+func (h *TimelineHandler) AddEffect(c *gin.Context) {
+	timelineID, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+	clipID, ok := h.parseID(c, "clip_id")
+	if !ok {
+		return
+	}
+
+	var req services.CreateEffectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	effect, err := h.timelineService.AddEffect(timelineID, clipID, &req)
+	if err != nil {
+		h.handleError(c, "Failed to add effect", err)
+		return
+	}
+
+	response.Created(c, effect)
+}
+
+func (h *TimelineHandler) UpdateEffect(c *gin.Context) {
+	timelineID, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+	clipID, ok := h.parseID(c, "clip_id")
+	if !ok {
+		return
+	}
+	effectID, ok := h.parseID(c, "effect_id")
+	if !ok {
+		return
+	}
+
+	var req services.UpdateEffectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	effect, err := h.timelineService.UpdateEffect(timelineID, clipID, effectID, &req)
+	if err != nil {
+		h.handleError(c, "Failed to update effect", err)
+		return
+	}
+
+	response.Success(c, effect)
+}
+
+func (h *TimelineHandler) DeleteEffect(c *gin.Context) {
+	timelineID, ok := h.parseID(c, "id")
+	if !ok {
+		return
+	}
+	clipID, ok := h.parseID(c, "clip_id")
+	if !ok {
+		return
+	}
+	effectID, ok := h.parseID(c, "effect_id")
+	if !ok {
+		return
+	}
+
+	if err := h.timelineService.DeleteEffect(timelineID, clipID, effectID); err != nil {
+		h.handleError(c, "Failed to delete effect", err)
+		return
+	}
+
+	response.Success(c, gin.H{"message": "删除成功"})
+}
+
+func (h *TimelineHandler) parseID(c *gin.Context, param string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(param), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// handleError 将服务层错误映射为HTTP响应
+func (h *TimelineHandler) handleError(c *gin.Context, msg string, err error) {
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		response.NotFound(c, err.Error())
+	case err.Error() == "track is locked":
+		response.Error(c, http.StatusConflict, "TRACK_LOCKED", "轨道已锁定")
+	case err.Error() == "speed must be positive", strings.HasPrefix(err.Error(), "trim"), strings.HasPrefix(err.Error(), "no storyboards"),
+		strings.HasSuffix(err.Error(), "belongs to another drama"):
+		response.BadRequest(c, err.Error())
+	default:
+		h.log.Errorw(msg, "error", err)
+		response.InternalError(c, err.Error())
+	}
+}
+
+func parseOptionalUint(value string) (uint, bool) {
+	if value == "" {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint(id), true
+}
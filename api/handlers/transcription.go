@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/drama-generator/backend/application/services"
+	"github.com/drama-generator/backend/infrastructure/external/ffmpeg"
+	"github.com/drama-generator/backend/infrastructure/storage"
+	"github.com/drama-generator/backend/pkg/config"
+	"github.com/drama-generator/backend/pkg/logger"
+	"github.com/drama-generator/backend/pkg/response"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TranscriptionHandler struct {
+	transcriptionService *services.TranscriptionService
+	log                  *logger.Logger
+}
+
+func NewTranscriptionHandler(db *gorm.DB, cfg *config.Config, log *logger.Logger, localStorage *storage.LocalStorage) *TranscriptionHandler {
+	return &TranscriptionHandler{
+		transcriptionService: services.NewTranscriptionService(db, localStorage, log),
+		log:                  log,
+	}
+}
+
+// transcriptionRequestError 识别请求的参数错误映射为400/404，其余按服务端错误处理
+func transcriptionRequestError(c *gin.Context, err error) bool {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		response.NotFound(c, msg)
+	case strings.Contains(msg, "must be"), strings.Contains(msg, "is required"), strings.HasPrefix(msg, "invalid"),
+		strings.HasPrefix(msg, "no transcribed"), strings.HasSuffix(msg, "not completed"), strings.HasSuffix(msg, "already has dialogue"):
+		response.BadRequest(c, msg)
+	default:
+		return false
+	}
+	return true
+}
+
+// bindTranscribeRequest 请求体可省略，省略时使用默认语音识别配置
+func bindTranscribeRequest(c *gin.Context) (*services.TranscribeRequest, bool) {
+	var req services.TranscribeRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, err.Error())
+		return nil, false
+	}
+	return &req, true
+}
+
+func parseTranscriptionID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// TranscribeAsset 识别音频/视频素材中的语音
+func (h *TranscriptionHandler) TranscribeAsset(c *gin.Context) {
+	assetID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的ID")
+		return
+	}
+
+	req, ok := bindTranscribeRequest(c)
+	if !ok {
+		return
+	}
+
+	transcription, err := h.transcriptionService.TranscribeAsset(uint(assetID), req)
+	if err != nil {
+		if transcriptionRequestError(c, err) {
+			return
+		}
+		h.log.Errorw("Failed to transcribe asset", "error", err, "asset_id", assetID)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, transcription)
+}
+
+// GetAssetTranscription 获取素材最近一次完成的识别结果
+func (h *TranscriptionHandler) GetAssetTranscription(c *gin.Context) {
+	assetID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的ID")
+		return
+	}
+
+	transcription, err := h.transcriptionService.LatestForAsset(uint(assetID))
+	if err != nil {
+		if transcriptionRequestError(c, err) {
+			return
+		}
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, transcription)
+}
+
+// TranscribeEpisode 识别剧集下全部音频/视频素材
+func (h *TranscriptionHandler) TranscribeEpisode(c *gin.Context) {
+	episodeID := c.Param("episode_id")
+
+	req, ok := bindTranscribeRequest(c)
+	if !ok {
+		return
+	}
+
+	transcriptions, err := h.transcriptionService.TranscribeEpisode(episodeID, req)
+	if err != nil {
+		if transcriptionRequestError(c, err) {
+			return
+		}
+		h.log.Errorw("Failed to transcribe episode", "error", err, "episode_id", episodeID)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, transcriptions)
+}
+
+func (h *TranscriptionHandler) GetTranscription(c *gin.Context) {
+	id, ok := parseTranscriptionID(c)
+	if !ok {
+		return
+	}
+
+	transcription, err := h.transcriptionService.GetTranscription(id)
+	if err != nil {
+		if transcriptionRequestError(c, err) {
+			return
+		}
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, transcription)
+}
+
+func (h *TranscriptionHandler) ListTranscriptions(c *gin.Context) {
+	var assetID *uint
+	if id, ok := parseOptionalUint(c.Query("asset_id")); ok {
+		assetID = &id
+	}
+
+	status := c.Query("status")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	transcriptions, total, err := h.transcriptionService.ListTranscriptions(assetID, status, page, pageSize)
+	if err != nil {
+		h.log.Errorw("Failed to list transcriptions", "error", err)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.SuccessWithPagination(c, transcriptions, total, page, pageSize)
+}
+
+// UpdateTranscription 保存人工校对后的分段
+func (h *TranscriptionHandler) UpdateTranscription(c *gin.Context) {
+	id, ok := parseTranscriptionID(c)
+	if !ok {
+		return
+	}
+
+	var req services.UpdateTranscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	transcription, err := h.transcriptionService.UpdateSegments(id, &req)
+	if err != nil {
+		if transcriptionRequestError(c, err) {
+			return
+		}
+		h.log.Errorw("Failed to update transcription", "error", err, "id", id)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, transcription)
+}
+
+// ApplyToStoryboard 用识别文本回填分镜对白
+func (h *TranscriptionHandler) ApplyToStoryboard(c *gin.Context) {
+	id, ok := parseTranscriptionID(c)
+	if !ok {
+		return
+	}
+
+	var req services.ApplyToStoryboardRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	storyboard, err := h.transcriptionService.ApplyToStoryboard(id, &req)
+	if err != nil {
+		if transcriptionRequestError(c, err) {
+			return
+		}
+		h.log.Errorw("Failed to apply transcription to storyboard", "error", err, "id", id)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, storyboard)
+}
+
+// ApplyToTimeline 将识别结果生成为时间线上的字幕轨道
+func (h *TranscriptionHandler) ApplyToTimeline(c *gin.Context) {
+	id, ok := parseTranscriptionID(c)
+	if !ok {
+		return
+	}
+	timelineID, err := strconv.ParseUint(c.Param("timeline_id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的时间线ID")
+		return
+	}
+
+	var req services.ApplyToTimelineRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	track, err := h.transcriptionService.ApplyToTimeline(id, uint(timelineID), &req)
+	if err != nil {
+		if transcriptionRequestError(c, err) {
+			return
+		}
+		h.log.Errorw("Failed to apply transcription to timeline", "error", err, "id", id, "timeline_id", timelineID)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Created(c, track)
+}
+
+// ExportSubtitles 导出素材字幕（SRT/ASS/WebVTT），时间相对素材起点
+func (h *TranscriptionHandler) ExportSubtitles(c *gin.Context) {
+	id, ok := parseTranscriptionID(c)
+	if !ok {
+		return
+	}
+
+	format, err := ffmpeg.ParseSubtitleFormat(c.Query("format"))
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	content, err := h.transcriptionService.ExportSubtitles(id, format)
+	if err != nil {
+		if transcriptionRequestError(c, err) {
+			return
+		}
+		h.log.Errorw("Failed to export transcription subtitles", "error", err, "id", id)
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=transcription_%d.%s", id, format))
+	c.Data(http.StatusOK, format.ContentType(), []byte(content))
+}
@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"strconv"
+	"strings"
 
 	services2 "github.com/drama-generator/backend/application/services"
+	"github.com/drama-generator/backend/infrastructure/external/ffmpeg"
 	"github.com/drama-generator/backend/pkg/logger"
 	"github.com/drama-generator/backend/pkg/response"
 	"github.com/gin-gonic/gin"
@@ -31,6 +33,11 @@ func (h *VideoMergeHandler) MergeVideos(c *gin.Context) {
 
 	merge, err := h.mergeService.MergeVideos(&req)
 	if err != nil {
+		if strings.HasPrefix(err.Error(), "unknown output profile") || strings.HasPrefix(err.Error(), "unknown subtitle mode") ||
+			strings.HasPrefix(err.Error(), "invalid loudness target") {
+			response.BadRequest(c, err.Error())
+			return
+		}
 		h.log.Errorw("Failed to merge videos", "error", err)
 		response.InternalError(c, err.Error())
 		return
@@ -102,3 +109,11 @@ func (h *VideoMergeHandler) DeleteMerge(c *gin.Context) {
 
 	response.Success(c, gin.H{"message": "Merge deleted successfully"})
 }
+
+// ListProfiles 返回可选的成片输出配置
+func (h *VideoMergeHandler) ListProfiles(c *gin.Context) {
+	response.Success(c, gin.H{
+		"profiles":        ffmpeg.ListOutputProfiles(),
+		"default_profile": ffmpeg.DefaultOutputProfile,
+	})
+}
@@ -1,6 +1,8 @@
 package routes
 
 import (
+	"mime"
+
 	handlers2 "github.com/drama-generator/backend/api/handlers"
 	middlewares2 "github.com/drama-generator/backend/api/middlewares"
 	services2 "github.com/drama-generator/backend/application/services"
@@ -19,6 +21,10 @@ func SetupRouter(cfg *config.Config, db *gorm.DB, log *logger.Logger, localStora
 	r.Use(middlewares2.CORSMiddleware(cfg.Server.CORSOrigins))
 
 	// 静态文件服务（用户上传的文件）
+	// HLS播放列表、切片、WebVTT缩略图轨道与字幕需要正确的Content-Type，部分系统的mime表缺失或映射错误
+	mime.AddExtensionType(".m3u8", "application/vnd.apple.mpegurl")
+	mime.AddExtensionType(".ts", "video/mp2t")
+	mime.AddExtensionType(".vtt", "text/vtt")
 	r.Static("/static", cfg.Storage.LocalPath)
 
 	r.GET("/health", func(c *gin.Context) {
@@ -32,14 +38,14 @@ func SetupRouter(cfg *config.Config, db *gorm.DB, log *logger.Logger, localStora
 	aiService := services2.NewAIService(db, log)
 	localStoragePtr := localStorage.(*storage2.LocalStorage)
 	transferService := services2.NewResourceTransferService(db, log)
-	dramaHandler := handlers2.NewDramaHandler(db, cfg, log, nil)
+	dramaHandler := handlers2.NewDramaHandler(db, cfg, log, nil, localStoragePtr)
 	aiConfigHandler := handlers2.NewAIConfigHandler(db, cfg, log)
 	scriptGenHandler := handlers2.NewScriptGenerationHandler(db, cfg, log)
 	imageGenService := services2.NewImageGenerationService(db, transferService, localStoragePtr, log)
 	imageGenHandler := handlers2.NewImageGenerationHandler(db, cfg, log, transferService, localStoragePtr)
 	videoGenHandler := handlers2.NewVideoGenerationHandler(db, transferService, localStoragePtr, aiService, log)
 	videoMergeHandler := handlers2.NewVideoMergeHandler(db, nil, cfg.Storage.LocalPath, cfg.Storage.BaseURL, log)
-	assetHandler := handlers2.NewAssetHandler(db, cfg, log)
+	assetHandler := handlers2.NewAssetHandler(db, cfg, log, localStoragePtr)
 	characterLibraryService := services2.NewCharacterLibraryService(db, log)
 	characterLibraryHandler := handlers2.NewCharacterLibraryHandler(db, cfg, log, transferService, localStoragePtr)
 	uploadHandler, err := handlers2.NewUploadHandler(cfg, log, characterLibraryService)
@@ -51,6 +57,15 @@ func SetupRouter(cfg *config.Config, db *gorm.DB, log *logger.Logger, localStora
 	taskHandler := handlers2.NewTaskHandler(db, log)
 	framePromptService := services2.NewFramePromptService(db, log)
 	framePromptHandler := handlers2.NewFramePromptHandler(framePromptService, log)
+	timelineHandler := handlers2.NewTimelineHandler(db, log)
+	subtitleHandler := handlers2.NewSubtitleHandler(db, log)
+	editInterchangeHandler := handlers2.NewEditInterchangeHandler(db, cfg, log)
+	speechGenHandler := handlers2.NewSpeechGenerationHandler(db, cfg, log, localStoragePtr)
+	musicHandler := handlers2.NewMusicHandler(db, cfg, log, localStoragePtr)
+	sfxHandler := handlers2.NewSFXHandler(db, cfg, log, localStoragePtr)
+	transcriptionHandler := handlers2.NewTranscriptionHandler(db, cfg, log, localStoragePtr)
+	screenplayHandler := handlers2.NewScreenplayHandler(db, log)
+	promptTemplateHandler := handlers2.NewPromptTemplateHandler(db, log)
 
 	api := r.Group("/api/v1")
 	{
@@ -64,7 +79,10 @@ func SetupRouter(cfg *config.Config, db *gorm.DB, log *logger.Logger, localStora
 			dramas.GET("/:id/characters", dramaHandler.GetCharacters)
 			dramas.PUT("/:id/characters", dramaHandler.SaveCharacters)
 			dramas.PUT("/:id/outline", dramaHandler.SaveOutline)
+			dramas.PUT("/:id/branding", dramaHandler.SaveBranding)
 			dramas.PUT("/:id/episodes", dramaHandler.SaveEpisodes)
+			dramas.POST("/:id/import", screenplayHandler.ImportScreenplay)
+			dramas.GET("/:id/export", screenplayHandler.ExportDrama)
 			dramas.PUT("/:id/progress", dramaHandler.SaveProgress)
 			dramas.GET("/:id", dramaHandler.GetDrama)
 			dramas.PUT("/:id", dramaHandler.UpdateDrama)
@@ -81,11 +99,26 @@ func SetupRouter(cfg *config.Config, db *gorm.DB, log *logger.Logger, localStora
 			aiConfigs.DELETE("/:id", aiConfigHandler.DeleteConfig)
 		}
 
+		// 提示词模板路由
+		promptTemplates := api.Group("/prompt-templates")
+		{
+			promptTemplates.GET("", promptTemplateHandler.ListTemplates)
+			promptTemplates.GET("/:key", promptTemplateHandler.GetTemplate)
+			promptTemplates.GET("/:key/versions", promptTemplateHandler.ListVersions)
+			promptTemplates.POST("/:key/versions", promptTemplateHandler.CreateVersion)
+			promptTemplates.POST("/:key/versions/:version/activate", promptTemplateHandler.ActivateVersion)
+			promptTemplates.DELETE("/:key/overrides/:drama_id", promptTemplateHandler.ResetOverride)
+			promptTemplates.POST("/:key/preview", promptTemplateHandler.PreviewTemplate)
+		}
+
 		generation := api.Group("/generation")
 		{
 			generation.POST("/outline", scriptGenHandler.GenerateOutline)
+			generation.POST("/outline/stream", scriptGenHandler.GenerateOutlineStream)
 			generation.POST("/characters", scriptGenHandler.GenerateCharacters)
 			generation.POST("/episodes", scriptGenHandler.GenerateEpisodes)
+			generation.POST("/episodes/stream", scriptGenHandler.GenerateEpisodesStream)
+			generation.POST("/episodes/incremental", scriptGenHandler.GenerateEpisodesIncremental)
 		}
 
 		// 角色库路由
@@ -108,6 +141,7 @@ func SetupRouter(cfg *config.Config, db *gorm.DB, log *logger.Logger, localStora
 			characters.PUT("/:id/image", characterLibraryHandler.UploadCharacterImage)
 			characters.PUT("/:id/image-from-library", characterLibraryHandler.ApplyLibraryItemToCharacter)
 			characters.POST("/:id/add-to-library", characterLibraryHandler.AddCharacterToLibrary)
+			characters.PUT("/:id/voice", speechGenHandler.AssignVoice)
 		}
 
 		// 文件上传路由
@@ -124,6 +158,10 @@ func SetupRouter(cfg *config.Config, db *gorm.DB, log *logger.Logger, localStora
 			episodes.GET("/:episode_id/storyboards", sceneHandler.GetStoryboardsForEpisode)
 			episodes.POST("/:episode_id/finalize", dramaHandler.FinalizeEpisode)
 			episodes.GET("/:episode_id/download", dramaHandler.DownloadEpisodeVideo)
+			episodes.GET("/:episode_id/subtitles", subtitleHandler.ExportEpisodeSubtitles)
+			episodes.POST("/:episode_id/edit-export", editInterchangeHandler.ExportEpisode)
+			episodes.GET("/:episode_id/script-export", screenplayHandler.ExportEpisode)
+			episodes.POST("/:episode_id/regenerate", scriptGenHandler.RegenerateEpisode)
 		}
 
 		// 任务路由
@@ -162,21 +200,98 @@ func SetupRouter(cfg *config.Config, db *gorm.DB, log *logger.Logger, localStora
 			videos.POST("/episode/:episode_id/batch", videoGenHandler.BatchGenerateForEpisode)
 		}
 
+		// 对白配音路由
+		speech := api.Group("/speech")
+		{
+			speech.GET("", speechGenHandler.ListSpeechGenerations)
+			speech.GET("/:id", speechGenHandler.GetSpeechGeneration)
+			speech.POST("/storyboard/:storyboard_id", speechGenHandler.GenerateForStoryboard)
+			speech.POST("/episode/:episode_id/batch", speechGenHandler.BatchGenerateForEpisode)
+		}
+
+		// 背景音乐曲库路由
+		musicTracks := api.Group("/music")
+		{
+			musicTracks.GET("", musicHandler.ListTracks)
+			musicTracks.POST("", musicHandler.CreateTrack)
+			musicTracks.POST("/generate", musicHandler.GenerateTrack)
+			musicTracks.GET("/episode/:episode_id/plan", musicHandler.PlanEpisodeMusic)
+			musicTracks.GET("/:id", musicHandler.GetTrack)
+			musicTracks.PUT("/:id", musicHandler.UpdateTrack)
+			musicTracks.DELETE("/:id", musicHandler.DeleteTrack)
+		}
+
+		// 音效库路由
+		sfx := api.Group("/sfx")
+		{
+			sfx.GET("", sfxHandler.ListSFX)
+			sfx.POST("", sfxHandler.CreateSFX)
+			sfx.POST("/scan", sfxHandler.ScanLibrary)
+			sfx.GET("/match", sfxHandler.MatchDescription)
+			sfx.POST("/episode/:episode_id/match", sfxHandler.MatchEpisode)
+			sfx.GET("/:id", sfxHandler.GetSFX)
+			sfx.PUT("/:id", sfxHandler.UpdateSFX)
+			sfx.DELETE("/:id", sfxHandler.DeleteSFX)
+		}
+
+		// 语音识别路由
+		transcriptions := api.Group("/transcriptions")
+		{
+			transcriptions.GET("", transcriptionHandler.ListTranscriptions)
+			transcriptions.POST("/episode/:episode_id/batch", transcriptionHandler.TranscribeEpisode)
+			transcriptions.GET("/:id", transcriptionHandler.GetTranscription)
+			transcriptions.PUT("/:id", transcriptionHandler.UpdateTranscription)
+			transcriptions.GET("/:id/subtitles", transcriptionHandler.ExportSubtitles)
+			transcriptions.POST("/:id/storyboard", transcriptionHandler.ApplyToStoryboard)
+			transcriptions.POST("/:id/timeline/:timeline_id", transcriptionHandler.ApplyToTimeline)
+		}
+
 		videoMerges := api.Group("/video-merges")
 		{
 			videoMerges.GET("", videoMergeHandler.ListMerges)
 			videoMerges.POST("", videoMergeHandler.MergeVideos)
+			videoMerges.GET("/profiles", videoMergeHandler.ListProfiles)
 			videoMerges.GET("/:merge_id", videoMergeHandler.GetMerge)
 			videoMerges.DELETE("/:merge_id", videoMergeHandler.DeleteMerge)
 		}
 
+		// 时间线编辑路由
+		timelines := api.Group("/timelines")
+		{
+			timelines.GET("", timelineHandler.ListTimelines)
+			timelines.POST("", timelineHandler.CreateTimeline)
+			timelines.POST("/episode/:episode_id/build", timelineHandler.BuildFromEpisode)
+			timelines.POST("/import/otio", editInterchangeHandler.ImportOTIO)
+			timelines.GET("/:id", timelineHandler.GetTimeline)
+			timelines.PUT("/:id", timelineHandler.UpdateTimeline)
+			timelines.DELETE("/:id", timelineHandler.DeleteTimeline)
+
+			timelines.POST("/:id/tracks", timelineHandler.CreateTrack)
+			timelines.PUT("/:id/tracks/reorder", timelineHandler.ReorderTracks)
+			timelines.PUT("/:id/tracks/:track_id", timelineHandler.UpdateTrack)
+			timelines.DELETE("/:id/tracks/:track_id", timelineHandler.DeleteTrack)
+			timelines.PUT("/:id/tracks/:track_id/clips/reorder", timelineHandler.ReorderClips)
+
+			timelines.POST("/:id/clips", timelineHandler.CreateClip)
+			timelines.PUT("/:id/clips/:clip_id", timelineHandler.UpdateClip)
+			timelines.DELETE("/:id/clips/:clip_id", timelineHandler.DeleteClip)
+			timelines.PUT("/:id/clips/:clip_id/transition", timelineHandler.SetClipTransition)
+			timelines.DELETE("/:id/clips/:clip_id/transition", timelineHandler.RemoveClipTransition)
+			timelines.POST("/:id/clips/:clip_id/effects", timelineHandler.AddEffect)
+			timelines.PUT("/:id/clips/:clip_id/effects/:effect_id", timelineHandler.UpdateEffect)
+			timelines.DELETE("/:id/clips/:clip_id/effects/:effect_id", timelineHandler.DeleteEffect)
+		}
+
 		assets := api.Group("/assets")
 		{
 			assets.GET("", assetHandler.ListAssets)
 			assets.POST("", assetHandler.CreateAsset)
+			assets.POST("/metadata/backfill", assetHandler.BackfillMetadata)
 			assets.GET("/:id", assetHandler.GetAsset)
 			assets.PUT("/:id", assetHandler.UpdateAsset)
 			assets.DELETE("/:id", assetHandler.DeleteAsset)
+			assets.POST("/:id/transcribe", transcriptionHandler.TranscribeAsset)
+			assets.GET("/:id/transcription", transcriptionHandler.GetAssetTranscription)
 			assets.POST("/import/image/:image_gen_id", assetHandler.ImportFromImageGen)
 			assets.POST("/import/video/:video_gen_id", assetHandler.ImportFromVideoGen)
 		}
@@ -184,6 +299,7 @@ func SetupRouter(cfg *config.Config, db *gorm.DB, log *logger.Logger, localStora
 		storyboards := api.Group("/storyboards")
 		{
 			storyboards.PUT("/:id", storyboardHandler.UpdateStoryboard)
+			storyboards.PUT("/:id/sfx", sfxHandler.AssignSFX)
 			storyboards.POST("/:id/frame-prompt", framePromptHandler.GenerateFramePrompt)
 			storyboards.GET("/:id/frame-prompts", handlers2.GetStoryboardFramePrompts(db, log))
 		}
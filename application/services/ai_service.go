@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -23,7 +24,7 @@ func NewAIService(db *gorm.DB, log *logger.Logger) *AIService {
 }
 
 type CreateAIConfigRequest struct {
-	ServiceType   string            `json:"service_type" binding:"required,oneof=text image video"`
+	ServiceType   string            `json:"service_type" binding:"required,oneof=text image video tts music stt"`
 	Name          string            `json:"name" binding:"required,min=1,max=100"`
 	Provider      string            `json:"provider" binding:"required"`
 	BaseURL       string            `json:"base_url" binding:"required,url"`
@@ -76,6 +77,10 @@ func (s *AIService) CreateConfig(req *CreateAIConfigRequest) (*models.AIServiceC
 				endpoint = "/chat/completions"
 			} else if req.ServiceType == "image" {
 				endpoint = "/images/generations"
+			} else if req.ServiceType == "tts" {
+				endpoint = "/audio/speech"
+			} else if req.ServiceType == "stt" {
+				endpoint = "/audio/transcriptions"
 			} else if req.ServiceType == "video" {
 				endpoint = "/videos"
 				if queryEndpoint == "" {
@@ -87,6 +92,15 @@ func (s *AIService) CreateConfig(req *CreateAIConfigRequest) (*models.AIServiceC
 				endpoint = "/chat/completions"
 			} else if req.ServiceType == "image" {
 				endpoint = "/images/generations"
+			} else if req.ServiceType == "tts" {
+				endpoint = "/audio/speech"
+			} else if req.ServiceType == "stt" {
+				endpoint = "/audio/transcriptions"
+			} else if req.ServiceType == "music" {
+				endpoint = "/suno/submit/music"
+				if queryEndpoint == "" {
+					queryEndpoint = "/suno/fetch/{taskId}"
+				}
 			} else if req.ServiceType == "video" {
 				endpoint = "/video/generations"
 				if queryEndpoint == "" {
@@ -94,18 +108,31 @@ func (s *AIService) CreateConfig(req *CreateAIConfigRequest) (*models.AIServiceC
 				}
 			}
 		case "doubao", "volcengine", "volces":
-			if req.ServiceType == "video" {
+			if req.ServiceType == "tts" {
+				endpoint = "/api/v1/tts"
+			} else if req.ServiceType == "video" {
 				endpoint = "/contents/generations/tasks"
 				if queryEndpoint == "" {
 					queryEndpoint = "/generations/tasks/{taskId}"
 				}
 			}
+		case "suno":
+			if req.ServiceType == "music" {
+				endpoint = "/suno/submit/music"
+				if queryEndpoint == "" {
+					queryEndpoint = "/suno/fetch/{taskId}"
+				}
+			}
 		default:
 			// 默认使用 OpenAI 格式
 			if req.ServiceType == "text" {
 				endpoint = "/chat/completions"
 			} else if req.ServiceType == "image" {
 				endpoint = "/images/generations"
+			} else if req.ServiceType == "tts" {
+				endpoint = "/audio/speech"
+			} else if req.ServiceType == "stt" {
+				endpoint = "/audio/transcriptions"
 			}
 		}
 	}
@@ -396,3 +423,13 @@ func (s *AIService) GenerateText(prompt string, systemPrompt string, options ...
 
 	return client.GenerateText(prompt, systemPrompt, options...)
 }
+
+// GenerateTextStream 流式生成文本，onDelta 接收增量，返回完整文本
+func (s *AIService) GenerateTextStream(ctx context.Context, prompt string, systemPrompt string, onDelta ai.StreamHandler, options ...func(*ai.ChatCompletionRequest)) (string, error) {
+	client, err := s.GetAIClient("text")
+	if err != nil {
+		return "", fmt.Errorf("failed to get AI client: %w", err)
+	}
+
+	return client.GenerateTextStream(ctx, prompt, systemPrompt, onDelta, options...)
+}
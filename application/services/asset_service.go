@@ -6,19 +6,22 @@ import (
 	"strings"
 
 	models "github.com/drama-generator/backend/domain/models"
+	"github.com/drama-generator/backend/infrastructure/storage"
 	"github.com/drama-generator/backend/pkg/logger"
 	"gorm.io/gorm"
 )
 
 type AssetService struct {
-	db  *gorm.DB
-	log *logger.Logger
+	db           *gorm.DB
+	mediaService *MediaService
+	log          *logger.Logger
 }
 
-func NewAssetService(db *gorm.DB, log *logger.Logger) *AssetService {
+func NewAssetService(db *gorm.DB, localStorage *storage.LocalStorage, log *logger.Logger) *AssetService {
 	return &AssetService{
-		db:  db,
-		log: log,
+		db:           db,
+		mediaService: NewMediaService(db, localStorage, log),
+		log:          log,
 	}
 }
 
@@ -104,6 +107,8 @@ func (s *AssetService) CreateAsset(req *CreateAssetRequest) (*models.Asset, erro
 		return nil, fmt.Errorf("failed to create asset: %w", err)
 	}
 
+	go s.mediaService.ProcessAsset(asset.ID)
+
 	return asset, nil
 }
 
@@ -239,6 +244,8 @@ func (s *AssetService) ImportFromImageGen(imageGenID uint) (*models.Asset, error
 		return nil, fmt.Errorf("failed to create asset: %w", err)
 	}
 
+	go s.mediaService.ProcessAsset(asset.ID)
+
 	return asset, nil
 }
 
@@ -275,7 +282,11 @@ func (s *AssetService) ImportFromVideoGen(videoGenID uint) (*models.Asset, error
 		Height:        videoGen.Height,
 	}
 
-	if videoGen.FirstFrameURL != nil {
+	if videoGen.PosterURL != nil {
+		asset.ThumbnailURL = videoGen.PosterURL
+		asset.SpriteURL = videoGen.SpriteURL
+		asset.ThumbnailVTT = videoGen.ThumbnailVTT
+	} else if videoGen.FirstFrameURL != nil {
 		asset.ThumbnailURL = videoGen.FirstFrameURL
 	}
 
@@ -283,5 +294,7 @@ func (s *AssetService) ImportFromVideoGen(videoGenID uint) (*models.Asset, error
 		return nil, fmt.Errorf("failed to create asset: %w", err)
 	}
 
+	go s.mediaService.ProcessAsset(asset.ID)
+
 	return asset, nil
 }
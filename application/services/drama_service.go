@@ -5,22 +5,29 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/drama-generator/backend/domain/models"
+	"github.com/drama-generator/backend/infrastructure/external/ffmpeg"
+	"github.com/drama-generator/backend/infrastructure/storage"
 	"github.com/drama-generator/backend/pkg/logger"
 	"gorm.io/gorm"
 )
 
 type DramaService struct {
-	db  *gorm.DB
-	log *logger.Logger
+	db           *gorm.DB
+	storage      *storage.LocalStorage
+	mediaService *MediaService
+	log          *logger.Logger
 }
 
-func NewDramaService(db *gorm.DB, log *logger.Logger) *DramaService {
+func NewDramaService(db *gorm.DB, localStorage *storage.LocalStorage, log *logger.Logger) *DramaService {
 	return &DramaService{
-		db:  db,
-		log: log,
+		db:           db,
+		storage:      localStorage,
+		mediaService: NewMediaService(db, localStorage, log),
+		log:          log,
 	}
 }
 
@@ -327,6 +334,15 @@ type SaveOutlineRequest struct {
 	Tags    []string `json:"tags"`
 }
 
+// SaveBrandingRequest 品牌包装设置，未提供的字段保持不变
+type SaveBrandingRequest struct {
+	WatermarkURL      *string  `json:"watermark_url"`                                    // 水印图片地址，空字符串表示移除水印
+	WatermarkPosition string   `json:"watermark_position"`                               // top_left/top_right/bottom_left/bottom_right/center
+	WatermarkOpacity  *float64 `json:"watermark_opacity" binding:"omitempty,gt=0,lte=1"` // 水印不透明度
+	IntroAssetID      *uint    `json:"intro_asset_id"`                                   // 片头视频素材ID，0表示移除片头
+	OutroAssetID      *uint    `json:"outro_asset_id"`                                   // 片尾视频素材ID，0表示移除片尾
+}
+
 type SaveCharactersRequest struct {
 	Characters []models.Character `json:"characters" binding:"required"`
 	EpisodeID  *uint              `json:"episode_id"` // 可选：如果提供则关联到指定章节
@@ -378,6 +394,98 @@ func (s *DramaService) SaveOutline(dramaID string, req *SaveOutlineRequest) erro
 	return nil
 }
 
+// SaveBranding 保存剧本的品牌包装设置，合成成片时自动应用
+func (s *DramaService) SaveBranding(dramaID string, req *SaveBrandingRequest) (*models.Drama, error) {
+	var drama models.Drama
+	if err := s.db.Where("id = ? ", dramaID).First(&drama).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("drama not found")
+		}
+		return nil, err
+	}
+
+	updates := map[string]interface{}{
+		"updated_at": time.Now(),
+	}
+
+	if req.WatermarkURL != nil {
+		if *req.WatermarkURL == "" {
+			updates["watermark_url"] = nil
+		} else {
+			if err := s.checkBrandingMedia("watermark", *req.WatermarkURL, models.AssetTypeImage); err != nil {
+				return nil, err
+			}
+			updates["watermark_url"] = *req.WatermarkURL
+		}
+	}
+	if req.WatermarkPosition != "" {
+		position, err := ffmpeg.ParseWatermarkPosition(req.WatermarkPosition)
+		if err != nil {
+			return nil, err
+		}
+		updates["watermark_position"] = string(position)
+	}
+	if req.WatermarkOpacity != nil {
+		updates["watermark_opacity"] = *req.WatermarkOpacity
+	}
+
+	for _, bumper := range []struct {
+		role    string
+		assetID *uint
+	}{{"intro", req.IntroAssetID}, {"outro", req.OutroAssetID}} {
+		role, assetID := bumper.role, bumper.assetID
+		if assetID == nil {
+			continue
+		}
+		column := role + "_asset_id"
+		if *assetID == 0 {
+			updates[column] = nil
+			continue
+		}
+		var asset models.Asset
+		if err := s.db.Where("id = ?", *assetID).First(&asset).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%s asset not found", role)
+			}
+			return nil, err
+		}
+		if asset.Type != models.AssetTypeVideo {
+			return nil, fmt.Errorf("%s asset must be a video", role)
+		}
+		if err := s.checkBrandingMedia(role, asset.URL, models.AssetTypeVideo); err != nil {
+			return nil, err
+		}
+		updates[column] = *assetID
+	}
+
+	if err := s.db.Model(&drama).Updates(updates).Error; err != nil {
+		s.log.Errorw("Failed to save branding", "error", err)
+		return nil, err
+	}
+	if err := s.db.First(&drama, drama.ID).Error; err != nil {
+		return nil, err
+	}
+
+	s.log.Infow("Branding saved", "drama_id", dramaID)
+	return &drama, nil
+}
+
+// checkBrandingMedia 保存前确认品牌素材可读取：须为本地存储中的文件或http(s)地址，且能探测为对应类型的媒体，
+// 避免错误的地址到合成时才以ffmpeg失败的形式暴露
+func (s *DramaService) checkBrandingMedia(role, url string, assetType models.AssetType) error {
+	local := false
+	if s.storage != nil {
+		_, local = s.storage.ResolvePath(url)
+	}
+	if !local && !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return fmt.Errorf("%s is not accessible: %s is neither in local storage nor an http(s) url", role, url)
+	}
+	if _, err := s.mediaService.ProbeMedia(url, assetType); err != nil {
+		return fmt.Errorf("%s is not accessible: %w", role, err)
+	}
+	return nil
+}
+
 func (s *DramaService) GetCharacters(dramaID string, episodeID *string) ([]models.Character, error) {
 	var drama models.Drama
 	if err := s.db.Where("id = ? ", dramaID).First(&drama).Error; err != nil {
@@ -0,0 +1,518 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	models "github.com/drama-generator/backend/domain/models"
+	"github.com/drama-generator/backend/infrastructure/external/ffmpeg"
+	"github.com/drama-generator/backend/infrastructure/storage"
+	"github.com/drama-generator/backend/pkg/interchange"
+	"github.com/drama-generator/backend/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// 剪辑交换格式
+const (
+	EditFormatEDL    = "edl"
+	EditFormatFCPXML = "fcpxml"
+	EditFormatOTIO   = "otio"
+)
+
+// EditInterchangeService 将剪辑顺序导出为 EDL/FCPXML/OTIO，供 Premiere、Resolve 等剪辑软件精修，并支持导入OTIO生成时间线
+type EditInterchangeService struct {
+	db              *gorm.DB
+	mergeService    *VideoMergeService
+	timelineService *TimelineService
+	mediaService    *MediaService
+	ffmpeg          *ffmpeg.FFmpeg
+	storage         *storage.LocalStorage
+	storagePath     string
+	log             *logger.Logger
+}
+
+func NewEditInterchangeService(db *gorm.DB, storagePath, baseURL string, log *logger.Logger) *EditInterchangeService {
+	localStorage, err := storage.NewLocalStorage(storagePath, baseURL)
+	if err != nil {
+		log.Errorw("Failed to initialize storage for edit interchange", "error", err, "path", storagePath)
+	}
+
+	return &EditInterchangeService{
+		db:              db,
+		mergeService:    NewVideoMergeService(db, nil, storagePath, baseURL, log),
+		timelineService: NewTimelineService(db, log),
+		mediaService:    NewMediaService(db, localStorage, log),
+		ffmpeg:          ffmpeg.NewFFmpeg(log),
+		storage:         localStorage,
+		storagePath:     storagePath,
+		log:             log,
+	}
+}
+
+// EditExportRequest 导出剪辑工程请求，片段顺序的来源与完成剧集接口一致
+type EditExportRequest struct {
+	Format     string         `json:"format" binding:"required"` // edl、fcpxml、otio
+	TimelineID *uint          `json:"timeline_id"`               // 使用已保存的时间线
+	Clips      []TimelineClip `json:"clips"`                     // 前端提供的片段顺序
+	MediaRoot  string         `json:"media_root"`                // 剪辑工作站上存储目录的挂载路径，为空时引用服务器上的路径
+	MergeID    *uint          `json:"merge_id"`                  // 与指定合成记录的片头对齐，为空时使用最近一次完成的正式合成
+}
+
+// EditExportResult 导出结果
+type EditExportResult struct {
+	Content       []byte
+	FileName      string
+	ContentType   string
+	SkippedScenes []int // 缺少视频而未导出的分镜编号
+}
+
+// ImportOTIORequest 导入OTIO请求
+type ImportOTIORequest struct {
+	DramaID   uint   `form:"drama_id" binding:"required"`
+	EpisodeID *uint  `form:"episode_id"`
+	Name      string `form:"name"`
+	MediaRoot string `form:"media_root"` // 文件中媒体路径对应存储目录的前缀，用于还原为存储地址
+}
+
+// ExportEpisode 按合成顺序导出剧集的剪辑工程，入出点与转场和合成结果一致
+func (s *EditInterchangeService) ExportEpisode(episodeID uint, req *EditExportRequest) (*EditExportResult, error) {
+	format := strings.ToLower(req.Format)
+	switch format {
+	case EditFormatEDL, EditFormatFCPXML, EditFormatOTIO:
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", req.Format)
+	}
+
+	var episode models.Episode
+	if err := s.db.Preload("Drama").
+		Preload("Storyboards", func(db *gorm.DB) *gorm.DB {
+			return db.Order("storyboards.storyboard_number ASC")
+		}).
+		Where("id = ?", episodeID).First(&episode).Error; err != nil {
+		return nil, errors.New("episode not found")
+	}
+
+	scenes, skipped, err := s.mergeService.BuildEpisodeScenes(&episode, &FinalizeEpisodeRequest{
+		TimelineID: req.TimelineID,
+		Clips:      req.Clips,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(scenes) == 0 {
+		return nil, errors.New("no scenes with videos available for export")
+	}
+	sort.SliceStable(scenes, func(i, j int) bool { return scenes[i].Order < scenes[j].Order })
+
+	storyboardNumbers := make(map[uint]int)
+	for _, sb := range episode.Storyboards {
+		storyboardNumbers[sb.ID] = sb.StoryboardNumber
+	}
+
+	clips := make([]ffmpeg.VideoClip, len(scenes))
+	for i, scene := range scenes {
+		clips[i] = ffmpeg.VideoClip{
+			Duration:   scene.Duration,
+			StartTime:  scene.StartTime,
+			EndTime:    scene.EndTime,
+			Transition: scene.Transition,
+		}
+	}
+	spans := s.ffmpeg.ClipSpans(clips)
+
+	fps, width, height := 0, 0, 0
+	if req.TimelineID != nil {
+		var timeline models.Timeline
+		if err := s.db.Where("id = ?", *req.TimelineID).First(&timeline).Error; err == nil {
+			fps = timeline.FPS
+			if timeline.Resolution != nil {
+				fmt.Sscanf(*timeline.Resolution, "%dx%d", &width, &height)
+			}
+		}
+	}
+
+	edits := make([]interchange.Edit, len(scenes))
+	for i, scene := range scenes {
+		edit := interchange.Edit{
+			Name:      fmt.Sprintf("CLIP %d", i+1),
+			MediaURL:  s.mediaLocation(scene.VideoURL, req.MediaRoot),
+			HasAudio:  true,
+			SourceIn:  scene.StartTime,
+			SourceOut: scene.StartTime + spans[i].End - spans[i].Start,
+			Metadata:  map[string]interface{}{"media_url": scene.VideoURL},
+		}
+		if number, ok := storyboardNumbers[scene.SceneID]; ok {
+			edit.Name = fmt.Sprintf("SHOT %d", number)
+			edit.Metadata["storyboard_id"] = scene.SceneID
+			edit.Metadata["storyboard_number"] = number
+		}
+		if i+1 < len(scenes) && spans[i+1].Start < spans[i].End {
+			edit.Transition = spans[i+1].Transition
+			edit.Overlap = spans[i].End - spans[i+1].Start
+		}
+
+		// 媒体时长与是否有音轨用于剪辑软件中的素材信息，探测失败时不影响导出
+		if meta, err := s.mediaService.ProbeMedia(scene.VideoURL, models.AssetTypeVideo); err == nil {
+			edit.MediaDuration = meta.Duration
+			edit.HasAudio = meta.HasAudio
+			if fps <= 0 && meta.FPS > 0 {
+				fps = int(math.Round(meta.FPS))
+			}
+			if width <= 0 && meta.Width > 0 {
+				width, height = meta.Width, meta.Height
+			}
+		} else {
+			s.log.Warnw("Failed to probe media for export", "url", scene.VideoURL, "error", err)
+		}
+		edits[i] = edit
+	}
+	if fps <= 0 {
+		fps = 30
+	}
+	if width <= 0 || height <= 0 {
+		width, height = 1920, 1080
+	}
+
+	introOffset, err := s.introOffset(episode.ID, req.MergeID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("%s - 第%d集", episode.Drama.Title, episode.EpisodeNum)
+	seq := interchange.NewSequence(name, fps, width, height, edits)
+	seq.Offset(introOffset)
+
+	result := &EditExportResult{
+		FileName:      fmt.Sprintf("episode_%d.%s", episode.ID, format),
+		SkippedScenes: skipped,
+	}
+	switch format {
+	case EditFormatEDL:
+		result.Content = []byte(interchange.WriteEDL(seq))
+		result.ContentType = "text/plain; charset=utf-8"
+	case EditFormatFCPXML:
+		result.Content, err = interchange.WriteFCPXML(seq)
+		result.ContentType = "application/xml; charset=utf-8"
+	case EditFormatOTIO:
+		result.Content, err = interchange.WriteOTIO(seq)
+		result.ContentType = "application/json; charset=utf-8"
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Infow("Episode exported for editing", "episode_id", episodeID, "format", format, "clips", len(edits))
+	return result, nil
+}
+
+// introOffset 返回合成成片中片头的时长，使导出的剪辑点与带片头的成片时间一致；
+// 未指定合成记录时使用最近一次完成的正式合成，与字幕导出一致
+func (s *EditInterchangeService) introOffset(episodeID uint, mergeID *uint) (float64, error) {
+	var videoMerge models.VideoMerge
+	query := s.db.Where("episode_id = ?", episodeID)
+	if mergeID != nil {
+		query = query.Where("id = ?", *mergeID)
+	} else {
+		query = query.Where("status = ? AND is_preview = ?", models.VideoMergeStatusCompleted, false).Order("created_at DESC")
+	}
+
+	err := query.First(&videoMerge).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if mergeID != nil {
+			return 0, errors.New("merge not found")
+		}
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return videoMerge.IntroOffset, nil
+}
+
+// ImportOTIO 将OTIO文件导入为新的时间线：视频轨道与独立的音频轨道分别生成轨道，
+// 转场还原为前后片段重叠并设置出场/入场转场，与合成时的处理方式一致
+func (s *EditInterchangeService) ImportOTIO(req *ImportOTIORequest, data []byte) (*models.Timeline, error) {
+	seq, err := interchange.ReadOTIO(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var drama models.Drama
+	if err := s.db.Where("id = ?", req.DramaID).First(&drama).Error; err != nil {
+		return nil, errors.New("drama not found")
+	}
+	if req.EpisodeID != nil {
+		var episode models.Episode
+		if err := s.db.Where("id = ? AND drama_id = ?", *req.EpisodeID, req.DramaID).First(&episode).Error; err != nil {
+			return nil, errors.New("episode not found")
+		}
+	}
+
+	name := req.Name
+	if name == "" {
+		name = seq.Name
+	}
+	if name == "" {
+		name = "导入的时间线"
+	}
+
+	var timelineID uint
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		timeline := &models.Timeline{
+			DramaID:   req.DramaID,
+			EpisodeID: req.EpisodeID,
+			Name:      name,
+			FPS:       seq.FPS,
+			Status:    models.TimelineStatusDraft,
+		}
+		if seq.Width > 0 && seq.Height > 0 {
+			resolution := fmt.Sprintf("%dx%d", seq.Width, seq.Height)
+			timeline.Resolution = &resolution
+		}
+		if err := tx.Create(timeline).Error; err != nil {
+			return err
+		}
+		timelineID = timeline.ID
+
+		duration := 0
+		order := 0
+		for _, track := range s.importTracks(seq) {
+			volume := 100
+			timelineTrack := &models.TimelineTrack{
+				TimelineID: timeline.ID,
+				Name:       track.Name,
+				Type:       models.TrackTypeVideo,
+				Order:      order,
+				Volume:     &volume,
+			}
+			if track.Kind == interchange.TrackAudio {
+				timelineTrack.Type = models.TrackTypeAudio
+			}
+			if timelineTrack.Name == "" {
+				timelineTrack.Name = fmt.Sprintf("轨道 %d", order+1)
+			}
+			if err := tx.Create(timelineTrack).Error; err != nil {
+				return err
+			}
+			order++
+
+			edits, starts := track.Edits(seq.FPS)
+			for i, edit := range edits {
+				clip := &models.TimelineClip{
+					TrackID:   timelineTrack.ID,
+					Name:      edit.Name,
+					StartTime: toMillis(starts[i]),
+					Duration:  toMillis(edit.SourceOut - edit.SourceIn),
+				}
+				clip.EndTime = clip.StartTime + clip.Duration
+				trimStart, trimEnd := toMillis(edit.SourceIn), toMillis(edit.SourceOut)
+				clip.TrimStart, clip.TrimEnd = &trimStart, &trimEnd
+				speed := 1.0
+				clip.Speed = &speed
+
+				clip.StoryboardID, clip.AssetID, err = s.importMedia(tx, &track.Clips[i], timelineTrack.Type, req)
+				if err != nil {
+					return err
+				}
+				if err := s.timelineService.ValidateClip(tx, req.DramaID, clip); err != nil {
+					return fmt.Errorf("clip %q: %w", edit.Name, err)
+				}
+
+				// 与上一个片段重叠的转场同时作为上一个片段的出场转场与本片段的入场转场
+				if i > 0 && edits[i-1].Overlap > 0 {
+					if clip.TransitionIn, err = createImportedTransition(tx, &edits[i-1]); err != nil {
+						return err
+					}
+				}
+				if edit.Overlap > 0 {
+					if clip.TransitionOut, err = createImportedTransition(tx, &edit); err != nil {
+						return err
+					}
+				}
+
+				if err := tx.Create(clip).Error; err != nil {
+					return err
+				}
+				duration = max(duration, clip.EndTime)
+			}
+		}
+
+		return tx.Model(timeline).Update("duration", duration).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to import timeline: %w", err)
+	}
+
+	s.log.Infow("Timeline imported from OTIO", "timeline_id", timelineID, "drama_id", req.DramaID)
+	return s.timelineService.GetTimeline(timelineID)
+}
+
+// importTracks 需要导入的轨道：音频轨道中与视频片段同源同位置的片段是视频自带的声音，不重复导入
+func (s *EditInterchangeService) importTracks(seq *interchange.Sequence) []interchange.Track {
+	embedded := make(map[string]bool)
+	for _, track := range seq.Tracks {
+		if track.Kind != interchange.TrackVideo {
+			continue
+		}
+		for _, clip := range track.Clips {
+			embedded[fmt.Sprintf("%s@%d", clip.MediaURL, clip.RecordIn)] = true
+		}
+	}
+
+	var tracks []interchange.Track
+	for _, track := range seq.Tracks {
+		if track.Kind == interchange.TrackAudio {
+			filtered := interchange.Track{Kind: track.Kind, Name: track.Name}
+			for _, clip := range track.Clips {
+				if !embedded[fmt.Sprintf("%s@%d", clip.MediaURL, clip.RecordIn)] {
+					filtered.Clips = append(filtered.Clips, clip)
+				}
+			}
+			track = filtered
+		}
+		if len(track.Clips) > 0 {
+			tracks = append(tracks, track)
+		}
+	}
+	return tracks
+}
+
+// importMedia 解析导入片段引用的素材：导出时写入的分镜信息优先，文件与分镜视频一致时直接关联分镜，
+// 否则按地址匹配素材库，仍未找到时登记为新素材；只接受远程地址以及本地存储或media_root下的文件
+func (s *EditInterchangeService) importMedia(tx *gorm.DB, clip *interchange.Clip, trackType models.TrackType, req *ImportOTIORequest) (*uint, *uint, error) {
+	mediaURL := s.storageURL(clip.MediaURL, req.MediaRoot)
+	if mediaURL == "" {
+		if original, ok := clip.Metadata["media_url"].(string); ok {
+			mediaURL = original
+		}
+	}
+	if mediaURL != "" && !strings.HasPrefix(mediaURL, "http://") && !strings.HasPrefix(mediaURL, "https://") {
+		return nil, nil, fmt.Errorf("media is outside storage: %s", mediaURL)
+	}
+
+	// 只关联本剧的分镜，其他剧的分镜按未关联处理
+	var storyboard *models.Storyboard
+	if id, ok := clip.Metadata["storyboard_id"].(float64); ok && id > 0 {
+		var sb models.Storyboard
+		err := tx.Joins("JOIN episodes ON episodes.id = storyboards.episode_id AND episodes.deleted_at IS NULL").
+			Where("storyboards.id = ? AND episodes.drama_id = ?", uint(id), req.DramaID).
+			First(&sb).Error
+		if err == nil {
+			storyboard = &sb
+		}
+	}
+
+	var storyboardID *uint
+	if storyboard != nil {
+		storyboardID = &storyboard.ID
+		if trackType == models.TrackTypeVideo && storyboard.VideoURL != nil && *storyboard.VideoURL == mediaURL {
+			return storyboardID, nil, nil
+		}
+	}
+	if mediaURL == "" {
+		return storyboardID, nil, nil
+	}
+
+	assetType := models.AssetTypeVideo
+	if trackType == models.TrackTypeAudio {
+		assetType = models.AssetTypeAudio
+	}
+
+	var asset models.Asset
+	err := tx.Where("url = ? AND type = ?", mediaURL, assetType).Order("created_at DESC").First(&asset).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		name := clip.Name
+		if name == "" {
+			name = path.Base(mediaURL)
+		}
+		category := "imported"
+		asset = models.Asset{
+			DramaID:      &req.DramaID,
+			EpisodeID:    req.EpisodeID,
+			StoryboardID: storyboardID,
+			Name:         name,
+			Type:         assetType,
+			Category:     &category,
+			URL:          mediaURL,
+		}
+		if storyboard != nil {
+			asset.StoryboardNum = &storyboard.StoryboardNumber
+		}
+		err = tx.Create(&asset).Error
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return storyboardID, &asset.ID, nil
+}
+
+// mediaLocation 剪辑软件中引用的媒体位置：本地存储的文件换算为磁盘路径（指定media_root时换算到该目录下），远程文件保留URL
+func (s *EditInterchangeService) mediaLocation(mediaURL, mediaRoot string) string {
+	if s.storage == nil {
+		return mediaURL
+	}
+	localPath, ok := s.storage.ResolvePath(mediaURL)
+	if !ok {
+		return mediaURL
+	}
+	if mediaRoot == "" {
+		if absPath, err := filepath.Abs(localPath); err == nil {
+			return absPath
+		}
+		return localPath
+	}
+	rel, err := filepath.Rel(s.storagePath, localPath)
+	if err != nil {
+		return localPath
+	}
+	return path.Join(strings.TrimRight(filepath.ToSlash(mediaRoot), "/"), filepath.ToSlash(rel))
+}
+
+// storageURL 将剪辑软件中的媒体路径还原为存储地址：位于media_root或本地存储目录下的文件换算为存储URL，其余原样返回
+func (s *EditInterchangeService) storageURL(location, mediaRoot string) string {
+	if location == "" || s.storage == nil || strings.Contains(location, "://") {
+		return location
+	}
+
+	slashed := filepath.ToSlash(location)
+	roots := []string{mediaRoot, s.storagePath}
+	if absPath, err := filepath.Abs(s.storagePath); err == nil {
+		roots = append(roots, absPath)
+	}
+	for _, root := range roots {
+		if root == "" {
+			continue
+		}
+		prefix := strings.TrimRight(filepath.ToSlash(root), "/") + "/"
+		if strings.HasPrefix(slashed, prefix) {
+			return s.storage.GetURL(strings.TrimPrefix(slashed, prefix))
+		}
+	}
+	return location
+}
+
+// createImportedTransition 创建导入的转场，无法识别的转场类型按淡入淡出处理
+func createImportedTransition(tx *gorm.DB, edit *interchange.Edit) (*uint, error) {
+	transition := &models.ClipTransition{
+		Type:     models.TransitionTypeFade,
+		Duration: toMillis(edit.Overlap),
+	}
+	switch transitionType := models.TransitionType(edit.Transition); transitionType {
+	case models.TransitionTypeFade, models.TransitionTypeCrossFade, models.TransitionTypeSlide,
+		models.TransitionTypeWipe, models.TransitionTypeZoom, models.TransitionTypeDissolve:
+		transition.Type = transitionType
+	}
+	if err := tx.Create(transition).Error; err != nil {
+		return nil, err
+	}
+	return &transition.ID, nil
+}
+
+func toMillis(seconds float64) int {
+	return int(math.Round(seconds * 1000))
+}
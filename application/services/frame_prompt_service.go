@@ -6,6 +6,7 @@ import (
 
 	"github.com/drama-generator/backend/domain/models"
 	"github.com/drama-generator/backend/pkg/logger"
+	promptpkg "github.com/drama-generator/backend/pkg/prompt"
 	"gorm.io/gorm"
 )
 
@@ -13,6 +14,7 @@ import (
 type FramePromptService struct {
 	db        *gorm.DB
 	aiService *AIService
+	prompts   *PromptTemplateService
 	log       *logger.Logger
 }
 
@@ -21,6 +23,7 @@ func NewFramePromptService(db *gorm.DB, log *logger.Logger) *FramePromptService
 	return &FramePromptService{
 		db:        db,
 		aiService: NewAIService(db, log),
+		prompts:   NewPromptTemplateService(db, log),
 		log:       log,
 	}
 }
@@ -67,7 +70,7 @@ type MultiFramePrompt struct {
 func (s *FramePromptService) GenerateFramePrompt(req GenerateFramePromptRequest) (*FramePromptResponse, error) {
 	// 查询分镜信息
 	var storyboard models.Storyboard
-	if err := s.db.Preload("Characters").First(&storyboard, req.StoryboardID).Error; err != nil {
+	if err := s.db.Preload("Characters").Preload("Episode").First(&storyboard, req.StoryboardID).Error; err != nil {
 		return nil, fmt.Errorf("storyboard not found: %w", err)
 	}
 
@@ -161,21 +164,8 @@ func (s *FramePromptService) generateFirstFrame(sb models.Storyboard, scene *mod
 	// 构建上下文信息
 	contextInfo := s.buildStoryboardContext(sb, scene)
 
-	// 构建AI提示词
-	systemPrompt := `你是一个专业的图像生成提示词专家。请根据提供的镜头信息，生成适合用于AI图像生成的提示词。
-
-重要：这是镜头的首帧 - 一个完全静态的画面，展示动作发生之前的初始状态。
-
-要求：
-1. 直接输出提示词，不要任何解释说明
-2. 可以使用中文或英文，用逗号分隔关键词
-3. 只描述静态视觉元素：场景环境、角色姿态、表情、氛围、光线
-4. 不要包含任何动作动词（如：猛然、弹起、坐直、抓住等）
-5. 描述角色处于动作发生前的状态（如：躺在床上、站立、坐着等静态姿态）
-6. 适合动画风格（anime style）
-
-示例格式：
-Anime style, 城市公寓卧室, 凌晨, 昏暗房间, 床上, 年轻男子躺着, 表情平静, 闭眼睡眠, 柔和光线, 静谧氛围, 中景, 平视`
+	// 构建AI提示词（模板 frame.first）
+	systemPrompt := s.prompts.Render(promptpkg.KeyFrameFirst, sb.Episode.DramaID, nil)
 
 	userPrompt := fmt.Sprintf(`镜头信息：
 %s
@@ -208,21 +198,8 @@ func (s *FramePromptService) generateKeyFrame(sb models.Storyboard, scene *model
 	// 构建上下文信息
 	contextInfo := s.buildStoryboardContext(sb, scene)
 
-	// 构建AI提示词
-	systemPrompt := `你是一个专业的图像生成提示词专家。请根据提供的镜头信息，生成适合用于AI图像生成的提示词。
-
-重要：这是镜头的关键帧 - 捕捉动作最激烈、最精彩的瞬间。
-
-要求：
-1. 直接输出提示词，不要任何解释说明
-2. 可以使用中文或英文，用逗号分隔关键词
-3. 重点描述动作的高潮瞬间：身体姿态、运动轨迹、力量感
-4. 包含动态元素：动作模糊、速度线、冲击感
-5. 强调表情和情绪的极致状态
-6. 适合动画风格（anime style）
-
-示例格式：
-Anime style, 城市街道, 白天, 男子全力冲刺, 身体前倾, 动作模糊, 速度线, 汗水飞溅, 表情坚毅, 紧张氛围, 动态镜头, 中景`
+	// 构建AI提示词（模板 frame.key）
+	systemPrompt := s.prompts.Render(promptpkg.KeyFrameKey, sb.Episode.DramaID, nil)
 
 	userPrompt := fmt.Sprintf(`镜头信息：
 %s
@@ -254,21 +231,8 @@ func (s *FramePromptService) generateLastFrame(sb models.Storyboard, scene *mode
 	// 构建上下文信息
 	contextInfo := s.buildStoryboardContext(sb, scene)
 
-	// 构建AI提示词
-	systemPrompt := `你是一个专业的图像生成提示词专家。请根据提供的镜头信息，生成适合用于AI图像生成的提示词。
-
-重要：这是镜头的尾帧 - 一个静态画面，展示动作结束后的最终状态和结果。
-
-要求：
-1. 直接输出提示词，不要任何解释说明
-2. 可以使用中文或英文，用逗号分隔关键词
-3. 只描述静态的最终状态：角色姿态、表情、环境变化
-4. 不要包含动作过程，只展示动作的结果和余韵
-5. 强调情绪的余波和氛围的沉淀
-6. 适合动画风格（anime style）
-
-示例格式：
-Anime style, 房间内, 黄昏, 男子坐在椅子上, 身体放松, 表情疲惫, 长出一口气, 汗水滴落, 平静氛围, 静态镜头, 中景`
+	// 构建AI提示词（模板 frame.last）
+	systemPrompt := s.prompts.Render(promptpkg.KeyFrameLast, sb.Episode.DramaID, nil)
 
 	userPrompt := fmt.Sprintf(`镜头信息：
 %s
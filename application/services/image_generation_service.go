@@ -12,6 +12,7 @@ import (
 	"github.com/drama-generator/backend/pkg/ai"
 	"github.com/drama-generator/backend/pkg/image"
 	"github.com/drama-generator/backend/pkg/logger"
+	promptpkg "github.com/drama-generator/backend/pkg/prompt"
 	"github.com/drama-generator/backend/pkg/utils"
 	"gorm.io/gorm"
 )
@@ -19,8 +20,10 @@ import (
 type ImageGenerationService struct {
 	db              *gorm.DB
 	aiService       *AIService
+	prompts         *PromptTemplateService
 	transferService *ResourceTransferService
 	localStorage    *storage.LocalStorage
+	mediaService    *MediaService
 	log             *logger.Logger
 }
 
@@ -46,8 +49,10 @@ func NewImageGenerationService(db *gorm.DB, transferService *ResourceTransferSer
 	return &ImageGenerationService{
 		db:              db,
 		aiService:       NewAIService(db, log),
+		prompts:         NewPromptTemplateService(db, log),
 		transferService: transferService,
 		localStorage:    localStorage,
+		mediaService:    NewMediaService(db, localStorage, log),
 		log:             log,
 	}
 }
@@ -309,6 +314,11 @@ func (s *ImageGenerationService) completeImageGeneration(imageGenID uint, result
 	s.db.Model(&models.ImageGeneration{}).Where("id = ?", imageGenID).Updates(updates)
 	s.log.Infow("Image generation completed", "id", imageGenID)
 
+	// 服务商未返回尺寸时从图片本身读取
+	if result.Width <= 0 || result.Height <= 0 {
+		go s.mediaService.ProcessImageGeneration(imageGenID)
+	}
+
 	// 如果关联了storyboard，同步更新storyboard的composed_image
 	if imageGen.StoryboardID != nil {
 		if err := s.db.Model(&models.Storyboard{}).Where("id = ?", *imageGen.StoryboardID).Update("composed_image", result.ImageURL).Error; err != nil {
@@ -724,62 +734,8 @@ func (s *ImageGenerationService) extractBackgroundsFromScript(scriptContent stri
 		return nil, fmt.Errorf("failed to get AI client: %w", err)
 	}
 
-	// 构建AI提示词
-	prompt := fmt.Sprintf(`【任务】分析以下剧本内容，提取出所有需要的场景背景信息。
-
-【剧本内容】
-%s
-
-【要求】
-1. 识别剧本中所有不同的场景（地点+时间组合）
-2. 为每个场景生成详细的**中文**图片生成提示词（Prompt）
-3. **重要**：场景描述必须是**纯背景**，不能包含人物、角色、动作等元素
-4. Prompt要求：
-   - **必须使用中文**，不能包含英文字符
-   - 详细描述场景环境、建筑、物品、光线、氛围等
-   - **禁止描述人物、角色、动作、对话等**
-   - 适合AI图片生成模型使用
-   - 风格统一为：电影感、细节丰富、动漫风格、高质量
-5. location、time、atmosphere和prompt字段都使用中文
-6. 提取场景的氛围描述（atmosphere）
-
-【输出JSON格式】
-{
-  "backgrounds": [
-    {
-      "location": "地点名称（中文）",
-      "time": "时间描述（中文）",
-      "atmosphere": "氛围描述（中文）",
-      "prompt": "一个电影感的动漫风格纯背景场景，展现[地点描述]在[时间]的环境。画面呈现[环境细节、建筑、物品、光线等，不包含人物]。风格：细节丰富，高质量，氛围光照。情绪：[环境情绪描述]。"
-    }
-  ]
-}
-
-【示例】
-正确示例（注意：不包含人物）：
-{
-  "backgrounds": [
-    {
-      "location": "维修店内部",
-      "time": "深夜",
-      "atmosphere": "昏暗、孤独、工业感",
-      "prompt": "一个电影感的动漫风格纯背景场景，展现凌乱的维修店内部在深夜的环境。昏暗的日光灯照射下，工作台上散落着各种扳手、螺丝刀和机械零件，墙上挂着油污斑斑的工具挂板和褪色海报，地面有油渍痕迹，角落堆放着废旧轮胎。风格：细节丰富，高质量，昏暗氛围。情绪：孤独、工业感。"
-    },
-    {
-      "location": "城市街道",
-      "time": "黄昏",
-      "atmosphere": "温暖、繁忙、生活气息",
-      "prompt": "一个电影感的动漫风格纯背景场景，展现繁华的城市街道在黄昏时分的环境。夕阳的余晖洒在街道的沥青路面上，两旁的商铺霓虹灯开始点亮，街边有自行车停靠架和公交站牌，远处高楼林立，天空呈现橙红色渐变。风格：细节丰富，高质量，温暖氛围。情绪：生活气息、繁忙。"
-    }
-  ]
-}
-
-【错误示例（包含人物，禁止）】：
-❌ "展现主角站在街道上的场景" - 包含人物
-❌ "人们匆匆而过" - 包含人物
-❌ "角色在房间里活动" - 包含人物
-
-请严格按照JSON格式输出，确保所有字段都使用中文。`, scriptContent)
+	// 构建AI提示词（模板 background.extract）
+	prompt := s.prompts.Render(promptpkg.KeyBackgroundExtract, dramaID, map[string]string{"script": scriptContent})
 
 	response, err := client.GenerateText(prompt, "", ai.WithTemperature(0.7), ai.WithMaxTokens(8000))
 	if err != nil {
@@ -804,13 +760,8 @@ func (s *ImageGenerationService) extractBackgroundsFromScript(scriptContent stri
 	return result.Backgrounds, nil
 }
 
-// extractBackgroundsWithAI 使用AI智能分析场景并提取唯一背景
-func (s *ImageGenerationService) extractBackgroundsWithAI(storyboards []models.Storyboard) ([]BackgroundInfo, error) {
-	if len(storyboards) == 0 {
-		return []BackgroundInfo{}, nil
-	}
-
-	// 构建场景列表文本，使用SceneNumber而不是索引
+// storyboardScenesText 背景合并提示词中的分镜头列表，使用SceneNumber而不是索引
+func storyboardScenesText(storyboards []models.Storyboard) string {
 	var scenesText string
 	for _, storyboard := range storyboards {
 		location := ""
@@ -833,60 +784,25 @@ func (s *ImageGenerationService) extractBackgroundsWithAI(storyboards []models.S
 		scenesText += fmt.Sprintf("镜头%d:\n地点: %s\n时间: %s\n动作: %s\n描述: %s\n\n",
 			storyboard.StoryboardNumber, location, time, action, description)
 	}
-
-	// 构建AI提示词
-	prompt := fmt.Sprintf(`【任务】分析以下分镜头场景，提取出所有需要生成的唯一背景，并返回每个背景对应的场景编号。
-
-【分镜头列表】
-%s
-
-【要求】
-1. 合并相同或相似的场景背景（地点和时间相同或相近）
-2. 为每个唯一背景生成**中文**图片生成提示词（Prompt）
-3. Prompt要求：
-   - **必须使用中文**，不能包含英文字符
-   - 详细描述场景、时间、氛围、风格
-   - 适合AI图片生成模型使用
-   - 风格统一为：电影感、细节丰富、动漫风格、高质量
-4. **重要**：必须返回使用该背景的场景编号数组（scene_numbers）
-5. location、time和prompt字段都使用中文
-6. 每个场景都必须分配到某个背景，确保所有场景编号都被包含
-
-【输出JSON格式】
-{
-  "backgrounds": [
-    {
-      "location": "地点名称（中文）",
-      "time": "时间描述（中文）",
-      "prompt": "一个电影感的动漫风格背景，展现[地点描述]在[时间]的场景。画面呈现[细节描述]。风格：细节丰富，高质量，氛围光照。情绪：[情绪描述]。",
-      "scene_numbers": [1, 2, 3]
-    }
-  ]
+	return scenesText
 }
 
-【示例】
-正确示例：
-{
-  "backgrounds": [
-    {
-      "location": "维修店",
-      "time": "深夜",
-      "prompt": "一个电影感的动漫风格背景，展现凌乱的维修店内部在深夜的场景。昏暗的灯光下，工作台上散落着各种工具和零件，墙上挂着油污的海报。风格：细节丰富，高质量，昏暗氛围。情绪：孤独、工业感。",
-      "scene_numbers": [1, 5, 6, 10, 15]
-    },
-    {
-      "location": "城市全景",
-      "time": "深夜·酸雨",
-      "prompt": "一个电影感的动漫风格背景，展现沿海城市全景在深夜酸雨中的场景。霓虹灯在雨中模糊，高楼大厦笼罩在灰绿色的雨幕中，街道反射着五颜六色的光。风格：细节丰富，高质量，赛博朋克氛围。情绪：压抑、科幻、末世感。",
-      "scene_numbers": [2, 7]
-    }
-  ]
-}
+// extractBackgroundsWithAI 使用AI智能分析场景并提取唯一背景
+func (s *ImageGenerationService) extractBackgroundsWithAI(storyboards []models.Storyboard) ([]BackgroundInfo, error) {
+	if len(storyboards) == 0 {
+		return []BackgroundInfo{}, nil
+	}
+
+	// 按镜头所属剧集的剧本取生效的模板
+	var episode models.Episode
+	if err := s.db.Select("id", "drama_id").Where("id = ?", storyboards[0].EpisodeID).First(&episode).Error; err != nil {
+		s.log.Warnw("Failed to load episode for storyboards", "error", err, "episode_id", storyboards[0].EpisodeID)
+	}
 
-请严格按照JSON格式输出，确保：
-1. prompt字段使用中文
-2. scene_numbers包含所有使用该背景的场景编号
-3. 所有场景都被分配到某个背景`, scenesText)
+	// 构建AI提示词（模板 background.merge）
+	prompt := s.prompts.Render(promptpkg.KeyBackgroundMerge, episode.DramaID, map[string]string{
+		"storyboards": storyboardScenesText(storyboards),
+	})
 
 	// 调用AI服务
 	text, err := s.aiService.GenerateText(prompt, "")
@@ -0,0 +1,322 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	models "github.com/drama-generator/backend/domain/models"
+)
+
+const (
+	// probeTimeout 单个文件探测的超时时间
+	probeTimeout = 2 * time.Minute
+	// maxImageProbeSize 图片探测时最多读取的字节数
+	maxImageProbeSize = 50 << 20
+)
+
+// MediaMetadata 媒体文件元数据
+type MediaMetadata struct {
+	FileSize   int64   `json:"file_size"`
+	MimeType   string  `json:"mime_type"`
+	Format     string  `json:"format"`
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	Duration   float64 `json:"duration"`
+	FPS        float64 `json:"fps"`
+	VideoCodec string  `json:"video_codec"`
+	AudioCodec string  `json:"audio_codec"`
+	HasAudio   bool    `json:"has_audio"`
+}
+
+// MetadataBackfillResult 元数据回填统计
+type MetadataBackfillResult struct {
+	Assets           int `json:"assets"`
+	VideoGenerations int `json:"video_generations"`
+	ImageGenerations int `json:"image_generations"`
+	Failed           int `json:"failed"`
+}
+
+// ProbeMedia 探测媒体文件元数据：图片使用Go解码器读取头信息，视频/音频使用ffprobe
+func (s *MediaService) ProbeMedia(url string, assetType models.AssetType) (*MediaMetadata, error) {
+	if url == "" {
+		return nil, fmt.Errorf("empty media url")
+	}
+	if assetType == models.AssetTypeImage {
+		if meta, err := s.probeImage(url); err == nil {
+			return meta, nil
+		} else if strings.HasPrefix(url, "data:") {
+			return nil, err
+		}
+		// Go不支持的图片格式（如webp）交给ffprobe
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	input := s.resolveInput(url)
+	info, err := s.ffmpeg.Probe(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &MediaMetadata{
+		FileSize:   info.Size,
+		Width:      info.Width,
+		Height:     info.Height,
+		Duration:   info.Duration,
+		FPS:        math.Round(info.FPS*100) / 100,
+		VideoCodec: info.VideoCodec,
+		AudioCodec: info.AudioCodec,
+		HasAudio:   info.HasAudio,
+	}
+	if stat, err := os.Stat(input); err == nil {
+		meta.FileSize = stat.Size()
+	}
+
+	meta.Format = mediaFormat(url, info.FormatName)
+	meta.MimeType = mime.TypeByExtension("." + meta.Format)
+	if meta.MimeType == "" {
+		prefix := "video/"
+		if !info.HasVideo {
+			prefix = "audio/"
+		}
+		meta.MimeType = prefix + meta.Format
+	}
+	if idx := strings.Index(meta.MimeType, ";"); idx != -1 {
+		meta.MimeType = meta.MimeType[:idx]
+	}
+
+	return meta, nil
+}
+
+// probeImage 读取图片（本地文件、URL或data URI）并解析尺寸与格式
+func (s *MediaService) probeImage(url string) (*MediaMetadata, error) {
+	data, err := s.readImage(url)
+	if err != nil {
+		return nil, err
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	return &MediaMetadata{
+		FileSize: int64(len(data)),
+		MimeType: http.DetectContentType(data),
+		Format:   format,
+		Width:    config.Width,
+		Height:   config.Height,
+	}, nil
+}
+
+func (s *MediaService) readImage(url string) ([]byte, error) {
+	// data:image/png;base64,xxxx
+	if strings.HasPrefix(url, "data:") {
+		_, payload, ok := strings.Cut(url, ",")
+		if !ok {
+			return nil, fmt.Errorf("invalid data uri")
+		}
+		return base64.StdEncoding.DecodeString(payload)
+	}
+
+	input := s.resolveInput(url)
+	if !strings.HasPrefix(input, "http://") && !strings.HasPrefix(input, "https://") {
+		return os.ReadFile(input)
+	}
+
+	client := &http.Client{Timeout: probeTimeout}
+	resp, err := client.Get(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download image: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download image: HTTP %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxImageProbeSize))
+}
+
+// ProbeAsset 探测素材文件并回填文件大小、类型、尺寸、时长、编码等字段
+func (s *MediaService) ProbeAsset(assetID uint) error {
+	var asset models.Asset
+	if err := s.db.First(&asset, assetID).Error; err != nil {
+		return fmt.Errorf("asset not found")
+	}
+
+	meta, err := s.ProbeMedia(asset.URL, asset.Type)
+	if err != nil {
+		return fmt.Errorf("failed to probe asset %d: %w", assetID, err)
+	}
+
+	updates := map[string]interface{}{
+		"file_size": meta.FileSize,
+		"mime_type": meta.MimeType,
+		"format":    meta.Format,
+	}
+	if meta.Width > 0 && meta.Height > 0 {
+		updates["width"] = meta.Width
+		updates["height"] = meta.Height
+	}
+	if asset.Type != models.AssetTypeImage {
+		updates["duration"] = int(math.Round(meta.Duration))
+		updates["has_audio"] = meta.HasAudio
+		updates["audio_codec"] = meta.AudioCodec
+	}
+	if asset.Type == models.AssetTypeVideo {
+		updates["video_codec"] = meta.VideoCodec
+		updates["fps"] = meta.FPS
+	}
+
+	return s.db.Model(&models.Asset{}).Where("id = ?", assetID).Updates(updates).Error
+}
+
+// ProbeVideoGeneration 探测生成视频并回填尺寸、时长与帧率
+func (s *MediaService) ProbeVideoGeneration(videoGenID uint) error {
+	var videoGen models.VideoGeneration
+	if err := s.db.First(&videoGen, videoGenID).Error; err != nil {
+		return fmt.Errorf("video generation not found")
+	}
+	if videoGen.VideoURL == nil || *videoGen.VideoURL == "" {
+		return fmt.Errorf("video is not ready")
+	}
+
+	meta, err := s.ProbeMedia(*videoGen.VideoURL, models.AssetTypeVideo)
+	if err != nil {
+		return fmt.Errorf("failed to probe video generation %d: %w", videoGenID, err)
+	}
+
+	updates := map[string]interface{}{
+		"duration": int(math.Round(meta.Duration)),
+		"fps":      int(math.Round(meta.FPS)),
+	}
+	if meta.Width > 0 && meta.Height > 0 {
+		updates["width"] = meta.Width
+		updates["height"] = meta.Height
+	}
+
+	return s.db.Model(&models.VideoGeneration{}).Where("id = ?", videoGenID).Updates(updates).Error
+}
+
+// ProbeImageGeneration 探测生成图片并回填尺寸
+func (s *MediaService) ProbeImageGeneration(imageGenID uint) error {
+	var imageGen models.ImageGeneration
+	if err := s.db.First(&imageGen, imageGenID).Error; err != nil {
+		return fmt.Errorf("image generation not found")
+	}
+	if imageGen.ImageURL == nil || *imageGen.ImageURL == "" {
+		return fmt.Errorf("image is not ready")
+	}
+
+	meta, err := s.ProbeMedia(*imageGen.ImageURL, models.AssetTypeImage)
+	if err != nil {
+		return fmt.Errorf("failed to probe image generation %d: %w", imageGenID, err)
+	}
+	if meta.Width <= 0 || meta.Height <= 0 {
+		return nil
+	}
+
+	return s.db.Model(&models.ImageGeneration{}).Where("id = ?", imageGenID).Updates(map[string]interface{}{
+		"width":  meta.Width,
+		"height": meta.Height,
+	}).Error
+}
+
+// ProcessImageGeneration 图片生成完成后回填元数据
+func (s *MediaService) ProcessImageGeneration(imageGenID uint) {
+	if err := s.ProbeImageGeneration(imageGenID); err != nil {
+		s.log.Warnw("Failed to probe generated image", "id", imageGenID, "error", err)
+	}
+}
+
+// BackfillMetadata 为缺少元数据的素材与生成记录补全元数据；force 为true时重新探测全部记录
+// onProgress 回调已处理数与总数
+func (s *MediaService) BackfillMetadata(force bool, onProgress func(done, total int)) (*MetadataBackfillResult, error) {
+	var assetIDs, videoGenIDs, imageGenIDs []uint
+
+	assetQuery := s.db.Model(&models.Asset{})
+	if !force {
+		assetQuery = assetQuery.Where("file_size IS NULL OR mime_type IS NULL OR (type <> ? AND width IS NULL)", models.AssetTypeAudio)
+	}
+	if err := assetQuery.Pluck("id", &assetIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to load assets: %w", err)
+	}
+
+	videoQuery := s.db.Model(&models.VideoGeneration{}).
+		Where("status = ? AND video_url IS NOT NULL AND video_url <> ''", models.VideoStatusCompleted)
+	if !force {
+		videoQuery = videoQuery.Where("width IS NULL OR duration IS NULL OR fps IS NULL")
+	}
+	if err := videoQuery.Pluck("id", &videoGenIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to load video generations: %w", err)
+	}
+
+	imageQuery := s.db.Model(&models.ImageGeneration{}).
+		Where("status = ? AND image_url IS NOT NULL AND image_url <> ''", models.ImageStatusCompleted)
+	if !force {
+		imageQuery = imageQuery.Where("width IS NULL OR height IS NULL")
+	}
+	if err := imageQuery.Pluck("id", &imageGenIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to load image generations: %w", err)
+	}
+
+	result := &MetadataBackfillResult{}
+	total := len(assetIDs) + len(videoGenIDs) + len(imageGenIDs)
+	done := 0
+	step := func(err error, counter *int) {
+		if err != nil {
+			s.log.Warnw("Metadata backfill failed", "error", err)
+			result.Failed++
+		} else {
+			*counter++
+		}
+		done++
+		if onProgress != nil {
+			onProgress(done, total)
+		}
+	}
+
+	for _, id := range assetIDs {
+		step(s.ProbeAsset(id), &result.Assets)
+	}
+	for _, id := range videoGenIDs {
+		step(s.ProbeVideoGeneration(id), &result.VideoGenerations)
+	}
+	for _, id := range imageGenIDs {
+		step(s.ProbeImageGeneration(id), &result.ImageGenerations)
+	}
+
+	s.log.Infow("Metadata backfill completed",
+		"assets", result.Assets,
+		"video_generations", result.VideoGenerations,
+		"image_generations", result.ImageGenerations,
+		"failed", result.Failed)
+	return result, nil
+}
+
+// mediaFormat 优先使用文件扩展名，否则取ffprobe容器名的第一项
+func mediaFormat(url, formatName string) string {
+	clean := url
+	if idx := strings.Index(clean, "?"); idx != -1 {
+		clean = clean[:idx]
+	}
+	if ext := strings.TrimPrefix(strings.ToLower(path.Ext(clean)), "."); ext != "" && len(ext) <= 5 {
+		return ext
+	}
+	format, _, _ := strings.Cut(formatName, ",")
+	return format
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	models "github.com/drama-generator/backend/domain/models"
+	"github.com/drama-generator/backend/infrastructure/external/ffmpeg"
+	"github.com/drama-generator/backend/infrastructure/storage"
+	"github.com/drama-generator/backend/pkg/logger"
+	"gorm.io/gorm"
+)
+
+const (
+	// posterWidth 封面图宽度
+	posterWidth = 640
+	// thumbnailTimeout 单个视频生成缩略图的超时时间
+	thumbnailTimeout = 5 * time.Minute
+)
+
+// MediaService 为视频/图片生成封面、拖动预览雪碧图与WebVTT缩略图轨道
+// 生成结果写入存储，并回填剧集、剧本、素材上的缩略图字段，列表页无需加载完整视频
+type MediaService struct {
+	db      *gorm.DB
+	ffmpeg  *ffmpeg.FFmpeg
+	storage *storage.LocalStorage
+	log     *logger.Logger
+}
+
+func NewMediaService(db *gorm.DB, localStorage *storage.LocalStorage, log *logger.Logger) *MediaService {
+	return &MediaService{
+		db:      db,
+		ffmpeg:  ffmpeg.NewFFmpeg(log),
+		storage: localStorage,
+		log:     log,
+	}
+}
+
+// VideoThumbnails 视频缩略图生成结果
+type VideoThumbnails struct {
+	PosterURL string `json:"poster_url"`
+	SpriteURL string `json:"sprite_url"`
+	VTTURL    string `json:"vtt_url"`
+}
+
+// GenerateVideoThumbnails 为视频生成封面、雪碧图与WebVTT缩略图轨道，name 用于区分输出文件
+func (s *MediaService) GenerateVideoThumbnails(videoURL, name string) (*VideoThumbnails, error) {
+	if s.storage == nil {
+		return nil, fmt.Errorf("storage is not available")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), thumbnailTimeout)
+	defer cancel()
+
+	inputPath := s.resolveInput(videoURL)
+
+	workDir, err := os.MkdirTemp("", "drama-thumbnails-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	// 封面取视频10%处（最多第3秒），避开片头黑场
+	posterAt := 0.0
+	if duration, err := s.ffmpeg.GetDuration(inputPath); err == nil {
+		posterAt = math.Min(duration*0.1, 3)
+	}
+
+	posterPath := filepath.Join(workDir, name+"_poster.jpg")
+	if err := s.ffmpeg.ExtractPoster(ctx, inputPath, posterPath, posterAt, posterWidth); err != nil {
+		return nil, err
+	}
+	posterURL, err := s.upload(posterPath, name+"_poster.jpg", "thumbnails/posters")
+	if err != nil {
+		return nil, err
+	}
+
+	sprite, err := s.ffmpeg.GenerateSprite(&ffmpeg.SpriteOptions{
+		InputPath:  inputPath,
+		OutputPath: filepath.Join(workDir, name+"_sprite.jpg"),
+		Context:    ctx,
+	})
+	if err != nil {
+		return nil, err
+	}
+	spriteURL, err := s.upload(sprite.Path, name+"_sprite.jpg", "thumbnails/sprites")
+	if err != nil {
+		return nil, err
+	}
+
+	vttPath := filepath.Join(workDir, name+"_thumbnails.vtt")
+	if err := os.WriteFile(vttPath, []byte(sprite.BuildThumbnailVTT(spriteURL)), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write vtt: %w", err)
+	}
+	vttURL, err := s.upload(vttPath, name+"_thumbnails.vtt", "thumbnails/sprites")
+	if err != nil {
+		return nil, err
+	}
+
+	return &VideoThumbnails{
+		PosterURL: posterURL,
+		SpriteURL: spriteURL,
+		VTTURL:    vttURL,
+	}, nil
+}
+
+// GenerateImageThumbnail 为图片生成缩略图
+func (s *MediaService) GenerateImageThumbnail(imageURL, name string) (string, error) {
+	if s.storage == nil {
+		return "", fmt.Errorf("storage is not available")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), thumbnailTimeout)
+	defer cancel()
+
+	outputPath := filepath.Join(os.TempDir(), fmt.Sprintf("%s_thumb_%d.jpg", name, time.Now().UnixNano()))
+	defer os.Remove(outputPath)
+
+	if err := s.ffmpeg.ScaleImage(ctx, s.resolveInput(imageURL), outputPath, posterWidth); err != nil {
+		return "", err
+	}
+	return s.upload(outputPath, name+"_thumb.jpg", "thumbnails/images")
+}
+
+// ProcessEpisode 为剧集成片生成缩略图，剧本尚无封面时同时使用该封面
+func (s *MediaService) ProcessEpisode(episodeID uint) {
+	var episode models.Episode
+	if err := s.db.First(&episode, episodeID).Error; err != nil {
+		s.log.Warnw("Episode not found for thumbnails", "episode_id", episodeID, "error", err)
+		return
+	}
+	if episode.VideoURL == nil || *episode.VideoURL == "" {
+		return
+	}
+
+	thumbs, err := s.GenerateVideoThumbnails(*episode.VideoURL, fmt.Sprintf("episode_%d", episodeID))
+	if err != nil {
+		s.log.Errorw("Failed to generate episode thumbnails", "episode_id", episodeID, "error", err)
+		return
+	}
+
+	s.db.Model(&models.Episode{}).Where("id = ?", episodeID).Updates(map[string]interface{}{
+		"thumbnail":         thumbs.PosterURL,
+		"sprite_url":        thumbs.SpriteURL,
+		"thumbnail_vtt_url": thumbs.VTTURL,
+	})
+	s.fillDramaThumbnail(episode.DramaID, thumbs.PosterURL)
+
+	s.log.Infow("Episode thumbnails generated", "episode_id", episodeID, "poster", thumbs.PosterURL)
+}
+
+// ProcessVideoGeneration 回填生成视频的元数据并生成封面、雪碧图与WebVTT缩略图轨道，剧本尚无封面时使用该封面
+// 首帧是生成视频的输入，不作为封面复用
+func (s *MediaService) ProcessVideoGeneration(videoGenID uint) {
+	if err := s.ProbeVideoGeneration(videoGenID); err != nil {
+		s.log.Warnw("Failed to probe generated video", "id", videoGenID, "error", err)
+	}
+
+	var videoGen models.VideoGeneration
+	if err := s.db.First(&videoGen, videoGenID).Error; err != nil {
+		s.log.Warnw("Video generation not found for thumbnails", "id", videoGenID, "error", err)
+		return
+	}
+	if videoGen.VideoURL == nil || *videoGen.VideoURL == "" {
+		return
+	}
+
+	thumbs, err := s.GenerateVideoThumbnails(*videoGen.VideoURL, fmt.Sprintf("video_gen_%d", videoGenID))
+	if err != nil {
+		s.log.Errorw("Failed to generate video thumbnails", "id", videoGenID, "error", err)
+		return
+	}
+
+	s.db.Model(&models.VideoGeneration{}).Where("id = ?", videoGenID).Updates(map[string]interface{}{
+		"poster_url":        thumbs.PosterURL,
+		"sprite_url":        thumbs.SpriteURL,
+		"thumbnail_vtt_url": thumbs.VTTURL,
+	})
+	s.fillDramaThumbnail(videoGen.DramaID, thumbs.PosterURL)
+
+	s.log.Infow("Video generation thumbnails generated", "id", videoGenID, "poster", thumbs.PosterURL)
+}
+
+// ProcessAsset 回填素材元数据并生成缩略图；视频素材同时生成雪碧图，已有缩略图的素材只补全雪碧图
+func (s *MediaService) ProcessAsset(assetID uint) {
+	if err := s.ProbeAsset(assetID); err != nil {
+		s.log.Warnw("Failed to probe asset", "asset_id", assetID, "error", err)
+	}
+
+	var asset models.Asset
+	if err := s.db.First(&asset, assetID).Error; err != nil {
+		s.log.Warnw("Asset not found for thumbnails", "asset_id", assetID, "error", err)
+		return
+	}
+
+	name := fmt.Sprintf("asset_%d", assetID)
+	updates := make(map[string]interface{})
+
+	switch asset.Type {
+	case models.AssetTypeVideo:
+		if asset.SpriteURL != nil && *asset.SpriteURL != "" {
+			return
+		}
+		thumbs, err := s.GenerateVideoThumbnails(asset.URL, name)
+		if err != nil {
+			s.log.Errorw("Failed to generate asset thumbnails", "asset_id", assetID, "error", err)
+			return
+		}
+		if asset.ThumbnailURL == nil || *asset.ThumbnailURL == "" {
+			updates["thumbnail_url"] = thumbs.PosterURL
+		}
+		updates["sprite_url"] = thumbs.SpriteURL
+		updates["thumbnail_vtt_url"] = thumbs.VTTURL
+	case models.AssetTypeImage:
+		if asset.ThumbnailURL != nil && *asset.ThumbnailURL != "" {
+			return
+		}
+		thumbURL, err := s.GenerateImageThumbnail(asset.URL, name)
+		if err != nil {
+			s.log.Errorw("Failed to generate asset thumbnail", "asset_id", assetID, "error", err)
+			return
+		}
+		updates["thumbnail_url"] = thumbURL
+	default:
+		return
+	}
+
+	s.db.Model(&models.Asset{}).Where("id = ?", assetID).Updates(updates)
+	s.log.Infow("Asset thumbnails generated", "asset_id", assetID)
+}
+
+// fillDramaThumbnail 剧本尚无封面时设置封面
+func (s *MediaService) fillDramaThumbnail(dramaID uint, posterURL string) {
+	if dramaID == 0 || posterURL == "" {
+		return
+	}
+	s.db.Model(&models.Drama{}).
+		Where("id = ? AND (thumbnail IS NULL OR thumbnail = '')", dramaID).
+		Update("thumbnail", posterURL)
+}
+
+// resolveInput 本地存储的文件直接读取磁盘，其余URL交给ffmpeg拉流
+func (s *MediaService) resolveInput(url string) string {
+	if s.storage == nil {
+		return url
+	}
+	if localPath, ok := s.storage.ResolvePath(url); ok {
+		return localPath
+	}
+	return url
+}
+
+func (s *MediaService) upload(localPath, fileName, category string) (string, error) {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", filepath.Base(localPath), err)
+	}
+	defer file.Close()
+
+	url, err := s.storage.Upload(file, strings.ReplaceAll(fileName, " ", "_"), category)
+	if err != nil {
+		return "", fmt.Errorf("failed to store %s: %w", fileName, err)
+	}
+	return url, nil
+}
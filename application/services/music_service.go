@@ -0,0 +1,730 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	models "github.com/drama-generator/backend/domain/models"
+	"github.com/drama-generator/backend/infrastructure/external/ffmpeg"
+	"github.com/drama-generator/backend/infrastructure/storage"
+	"github.com/drama-generator/backend/pkg/logger"
+	"github.com/drama-generator/backend/pkg/music"
+	"gorm.io/gorm"
+)
+
+// musicMood 情绪分类及用于识别 BgmPrompt 的关键词
+type musicMood struct {
+	Name     string
+	Keywords []string
+}
+
+// musicMoods 情绪分类，按顺序匹配，靠前的优先
+var musicMoods = []musicMood{
+	{"tense", []string{"紧张", "悬疑", "危险", "追逐", "对峙", "压迫", "惊悚", "tense", "suspense", "thriller", "danger"}},
+	{"sad", []string{"悲伤", "忧伤", "伤感", "哀", "离别", "失落", "心碎", "sad", "melancholy", "sorrow", "grief"}},
+	{"romantic", []string{"浪漫", "甜蜜", "爱情", "心动", "温柔", "romantic", "love", "tender"}},
+	{"epic", []string{"史诗", "激昂", "宏大", "热血", "震撼", "壮阔", "epic", "heroic", "triumphant"}},
+	{"mysterious", []string{"神秘", "诡异", "阴森", "mysterious", "eerie", "dark"}},
+	{"comedic", []string{"搞笑", "诙谐", "滑稽", "俏皮", "comedic", "funny", "playful"}},
+	{"happy", []string{"欢快", "愉快", "喜悦", "开心", "明快", "happy", "cheerful", "joyful"}},
+	{"warm", []string{"温馨", "温暖", "治愈", "感动", "warm", "heartwarming"}},
+	{"calm", []string{"平静", "舒缓", "宁静", "安静", "轻柔", "calm", "peaceful", "ambient", "soft"}},
+}
+
+// musicTempoKeywords 节奏关键词，未命中时为 medium
+var musicTempoKeywords = map[string][]string{
+	models.MusicTempoFast: {"快节奏", "激烈", "紧凑", "急促", "动感", "fast", "upbeat", "intense", "driving"},
+	models.MusicTempoSlow: {"慢节奏", "缓慢", "舒缓", "悠扬", "低沉", "slow", "gentle"},
+}
+
+// musicSilenceKeywords BgmPrompt 明确要求无配乐
+var musicSilenceKeywords = []string{"无配乐", "无音乐", "无背景音乐", "静音", "no music", "silence"}
+
+// ValidateMusicMood 校验曲目情绪分类，空字符串表示按标题与标签自动识别
+func ValidateMusicMood(mood string) error {
+	if mood == "" {
+		return nil
+	}
+	for _, m := range musicMoods {
+		if m.Name == mood {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown music mood: %s", mood)
+}
+
+// ValidateMusicTempo 校验曲目节奏，空字符串表示按标题与标签自动识别
+func ValidateMusicTempo(tempo string) error {
+	switch tempo {
+	case "", models.MusicTempoSlow, models.MusicTempoMedium, models.MusicTempoFast:
+		return nil
+	}
+	return fmt.Errorf("unknown music tempo: %s", tempo)
+}
+
+// ClassifyMusicPrompt 从配乐描述中识别情绪与节奏，无法识别情绪时 mood 为空
+func ClassifyMusicPrompt(prompt string) (mood, tempo string) {
+	text := strings.ToLower(prompt)
+	for _, m := range musicMoods {
+		if containsAny(text, m.Keywords) {
+			mood = m.Name
+			break
+		}
+	}
+	tempo = models.MusicTempoMedium
+	for _, t := range []string{models.MusicTempoFast, models.MusicTempoSlow} {
+		if containsAny(text, musicTempoKeywords[t]) {
+			tempo = t
+			break
+		}
+	}
+	return mood, tempo
+}
+
+func containsAny(text string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// MusicService 背景音乐：维护按情绪/节奏标注的曲库、调用音乐生成服务，
+// 并按分镜 BgmPrompt 选曲，供合成时铺在成片音轨下
+type MusicService struct {
+	db              *gorm.DB
+	aiService       *AIService
+	mediaService    *MediaService
+	timelineService *TimelineService
+	localStorage    *storage.LocalStorage
+	ffmpeg          *ffmpeg.FFmpeg
+	log             *logger.Logger
+}
+
+func NewMusicService(db *gorm.DB, localStorage *storage.LocalStorage, log *logger.Logger) *MusicService {
+	return &MusicService{
+		db:              db,
+		aiService:       NewAIService(db, log),
+		mediaService:    NewMediaService(db, localStorage, log),
+		timelineService: NewTimelineService(db, log),
+		localStorage:    localStorage,
+		ffmpeg:          ffmpeg.NewFFmpeg(log),
+		log:             log,
+	}
+}
+
+// CreateMusicTrackRequest 将已上传的音频素材加入曲库
+type CreateMusicTrackRequest struct {
+	DramaID *uint    `json:"drama_id"` // 为空时加入全局曲库
+	AssetID uint     `json:"asset_id" binding:"required"`
+	Title   string   `json:"title"` // 为空时使用素材名称
+	Mood    string   `json:"mood"`  // 为空时按标题与标签识别
+	Tempo   string   `json:"tempo"` // 为空时按标题与标签识别
+	BPM     *int     `json:"bpm"`
+	Tags    []string `json:"tags"`
+}
+
+type UpdateMusicTrackRequest struct {
+	Title *string  `json:"title"`
+	Mood  *string  `json:"mood"`
+	Tempo *string  `json:"tempo"`
+	BPM   *int     `json:"bpm"`
+	Tags  []string `json:"tags"`
+}
+
+// GenerateMusicRequest 调用音乐生成服务生成曲目，完成后自动加入曲库
+type GenerateMusicRequest struct {
+	DramaID *uint  `json:"drama_id"`
+	Prompt  string `json:"prompt" binding:"required"`
+	Title   string `json:"title"`
+	Mood    string `json:"mood"`
+	Tempo   string `json:"tempo"`
+	Model   string `json:"model"`
+}
+
+func (s *MusicService) CreateTrack(req *CreateMusicTrackRequest) (*models.MusicTrack, error) {
+	if err := ValidateMusicMood(req.Mood); err != nil {
+		return nil, err
+	}
+	if err := ValidateMusicTempo(req.Tempo); err != nil {
+		return nil, err
+	}
+	if req.DramaID != nil {
+		var drama models.Drama
+		if err := s.db.Select("id").First(&drama, *req.DramaID).Error; err != nil {
+			return nil, fmt.Errorf("drama not found")
+		}
+	}
+
+	var asset models.Asset
+	if err := s.db.First(&asset, req.AssetID).Error; err != nil {
+		return nil, fmt.Errorf("asset not found")
+	}
+	if asset.Type != models.AssetTypeAudio {
+		return nil, fmt.Errorf("asset must be audio")
+	}
+
+	title := req.Title
+	if title == "" {
+		title = asset.Name
+	}
+	track := &models.MusicTrack{
+		DramaID: req.DramaID,
+		AssetID: &asset.ID,
+		Title:   title,
+		BPM:     req.BPM,
+		Tags:    joinTags(req.Tags),
+		Source:  models.MusicSourceLibrary,
+		Status:  models.MusicStatusCompleted,
+	}
+	track.Mood, track.Tempo = classifyTrack(title, req.Tags, req.Mood, req.Tempo)
+	if asset.Duration != nil {
+		duration := float64(*asset.Duration)
+		track.Duration = &duration
+	}
+
+	if err := s.db.Create(track).Error; err != nil {
+		return nil, fmt.Errorf("failed to create music track: %w", err)
+	}
+
+	s.log.Infow("Music track added", "track_id", track.ID, "mood", track.Mood, "tempo", track.Tempo)
+	return track, nil
+}
+
+func (s *MusicService) UpdateTrack(trackID uint, req *UpdateMusicTrackRequest) (*models.MusicTrack, error) {
+	var track models.MusicTrack
+	if err := s.db.First(&track, trackID).Error; err != nil {
+		return nil, fmt.Errorf("music track not found")
+	}
+
+	updates := map[string]interface{}{}
+	if req.Title != nil {
+		updates["title"] = *req.Title
+	}
+	if req.Mood != nil {
+		if err := ValidateMusicMood(*req.Mood); err != nil {
+			return nil, err
+		}
+		updates["mood"] = *req.Mood
+	}
+	if req.Tempo != nil {
+		if err := ValidateMusicTempo(*req.Tempo); err != nil {
+			return nil, err
+		}
+		updates["tempo"] = *req.Tempo
+	}
+	if req.BPM != nil {
+		updates["bpm"] = *req.BPM
+	}
+	if req.Tags != nil {
+		updates["tags"] = joinTags(req.Tags)
+	}
+
+	if len(updates) > 0 {
+		if err := s.db.Model(&track).Updates(updates).Error; err != nil {
+			return nil, fmt.Errorf("failed to update music track: %w", err)
+		}
+	}
+	if err := s.db.Preload("Asset").First(&track, trackID).Error; err != nil {
+		return nil, err
+	}
+	return &track, nil
+}
+
+// DeleteTrack 从曲库移除曲目，音频素材保留
+func (s *MusicService) DeleteTrack(trackID uint) error {
+	result := s.db.Delete(&models.MusicTrack{}, trackID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("music track not found")
+	}
+	return nil
+}
+
+func (s *MusicService) GetTrack(trackID uint) (*models.MusicTrack, error) {
+	var track models.MusicTrack
+	if err := s.db.Preload("Asset").First(&track, trackID).Error; err != nil {
+		return nil, err
+	}
+	return &track, nil
+}
+
+// ListTracks 列出曲库，指定剧本时包含该剧本的曲目与全局曲目
+func (s *MusicService) ListTracks(dramaID *uint, mood, tempo, status string, page, pageSize int) ([]models.MusicTrack, int64, error) {
+	query := s.db.Model(&models.MusicTrack{})
+
+	if dramaID != nil {
+		query = query.Where("drama_id = ? OR drama_id IS NULL", *dramaID)
+	}
+	if mood != "" {
+		query = query.Where("mood = ?", mood)
+	}
+	if tempo != "" {
+		query = query.Where("tempo = ?", tempo)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var tracks []models.MusicTrack
+	offset := (page - 1) * pageSize
+	if err := query.Preload("Asset").Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&tracks).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return tracks, total, nil
+}
+
+// GenerateTrack 提交音乐生成任务，曲目在生成完成前处于 processing 状态，不参与选曲
+func (s *MusicService) GenerateTrack(req *GenerateMusicRequest) (*models.MusicTrack, error) {
+	if err := ValidateMusicMood(req.Mood); err != nil {
+		return nil, err
+	}
+	if err := ValidateMusicTempo(req.Tempo); err != nil {
+		return nil, err
+	}
+	if s.localStorage == nil {
+		return nil, fmt.Errorf("storage is not available")
+	}
+
+	config, err := s.musicConfig(req.Model)
+	if err != nil {
+		return nil, fmt.Errorf("no music AI config found: %w", err)
+	}
+	model := req.Model
+	if model == "" && len(config.Model) > 0 {
+		model = config.Model[0]
+	}
+	client := music.NewSunoClient(config.BaseURL, config.APIKey, model, config.Endpoint, config.QueryEndpoint)
+
+	title := req.Title
+	if title == "" {
+		title = truncateRunes(req.Prompt, 30)
+	}
+	prompt := req.Prompt
+	track := &models.MusicTrack{
+		DramaID:  req.DramaID,
+		Title:    title,
+		Source:   models.MusicSourceGenerated,
+		Prompt:   &prompt,
+		Provider: config.Provider,
+		Model:    model,
+		Status:   models.MusicStatusProcessing,
+	}
+	track.Mood, track.Tempo = classifyTrack(req.Prompt, nil, req.Mood, req.Tempo)
+
+	opts := []music.MusicOption{music.WithInstrumental(true), music.WithTitle(title)}
+	if track.Mood != "" {
+		opts = append(opts, music.WithTags(track.Mood+", "+track.Tempo+" tempo, cinematic"))
+	}
+	result, err := client.GenerateMusic(req.Prompt, opts...)
+	if err != nil {
+		s.log.Errorw("Failed to submit music generation", "error", err)
+		return nil, fmt.Errorf("failed to submit music generation: %w", err)
+	}
+	track.TaskID = &result.TaskID
+
+	if err := s.db.Create(track).Error; err != nil {
+		return nil, fmt.Errorf("failed to create music track: %w", err)
+	}
+
+	go s.pollTrackStatus(track.ID, client, result.TaskID)
+	return track, nil
+}
+
+func (s *MusicService) pollTrackStatus(trackID uint, client music.MusicClient, taskID string) {
+	maxAttempts := 120
+	interval := 10 * time.Second
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		time.Sleep(interval)
+
+		result, err := client.GetTaskStatus(taskID)
+		if err != nil {
+			s.log.Errorw("Failed to get music task status", "error", err, "task_id", taskID)
+			continue
+		}
+		if result.Completed {
+			s.completeTrack(trackID, result)
+			return
+		}
+		if result.Error != "" {
+			s.updateTrackError(trackID, result.Error)
+			return
+		}
+	}
+
+	s.updateTrackError(trackID, "polling timeout")
+}
+
+// completeTrack 下载生成的音乐到本地存储，登记为音频素材并加入曲库
+func (s *MusicService) completeTrack(trackID uint, result *music.MusicResult) {
+	var track models.MusicTrack
+	if err := s.db.First(&track, trackID).Error; err != nil {
+		s.log.Errorw("Failed to load music track", "error", err, "id", trackID)
+		return
+	}
+
+	audioURL, err := s.localStorage.DownloadFromURL(result.AudioURL, "audio/music")
+	if err != nil {
+		s.updateTrackError(trackID, fmt.Sprintf("failed to download music: %v", err))
+		return
+	}
+
+	duration := result.Duration
+	if meta, err := s.mediaService.ProbeMedia(audioURL, models.AssetTypeAudio); err == nil && meta.Duration > 0 {
+		duration = meta.Duration
+	}
+
+	category := "music"
+	seconds := int(duration + 0.5)
+	asset := models.Asset{
+		DramaID:     track.DramaID,
+		Name:        track.Title,
+		Description: track.Prompt,
+		Type:        models.AssetTypeAudio,
+		Category:    &category,
+		URL:         audioURL,
+		Duration:    &seconds,
+	}
+	if err := s.db.Create(&asset).Error; err != nil {
+		s.updateTrackError(trackID, fmt.Sprintf("failed to create asset: %v", err))
+		return
+	}
+	go s.mediaService.ProcessAsset(asset.ID)
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"asset_id":     asset.ID,
+		"status":       models.MusicStatusCompleted,
+		"completed_at": now,
+		"error_msg":    nil,
+	}
+	if duration > 0 {
+		updates["duration"] = duration
+	}
+	if result.Tags != "" && track.Tags == nil {
+		updates["tags"] = result.Tags
+	}
+	s.db.Model(&models.MusicTrack{}).Where("id = ?", trackID).Updates(updates)
+
+	s.log.Infow("Music track generated", "track_id", trackID, "duration", duration)
+}
+
+func (s *MusicService) updateTrackError(trackID uint, errorMsg string) {
+	s.db.Model(&models.MusicTrack{}).Where("id = ?", trackID).Updates(map[string]interface{}{
+		"status":    models.MusicStatusFailed,
+		"error_msg": errorMsg,
+	})
+	s.log.Errorw("Music generation failed", "track_id", trackID, "error", errorMsg)
+}
+
+func (s *MusicService) musicConfig(modelName string) (*models.AIServiceConfig, error) {
+	if modelName != "" {
+		if config, err := s.aiService.GetConfigForModel("music", modelName); err == nil {
+			return config, nil
+		}
+		s.log.Warnw("Failed to get music config for model, using default", "model", modelName)
+	}
+	return s.aiService.GetDefaultConfig("music")
+}
+
+// MusicCue 一段使用同一首配乐的连续分镜
+type MusicCue struct {
+	StoryboardIDs []uint             `json:"storyboard_ids"`
+	Start         float64            `json:"start"` // 在正片中的起止时间（秒）
+	End           float64            `json:"end"`
+	Prompt        string             `json:"prompt"`
+	Mood          string             `json:"mood"`
+	Tempo         string             `json:"tempo"`
+	Silent        bool               `json:"silent"` // 分镜要求无配乐
+	Track         *models.MusicTrack `json:"track,omitempty"`
+}
+
+// PlanEpisodeMusic 按分镜默认顺序与时长预览剧集选曲，实际合成时按成片中的镜头位置重新计算
+func (s *MusicService) PlanEpisodeMusic(episodeID string) ([]MusicCue, error) {
+	var episode models.Episode
+	if err := s.db.Where("id = ?", episodeID).First(&episode).Error; err != nil {
+		return nil, fmt.Errorf("episode not found")
+	}
+
+	var storyboards []models.Storyboard
+	if err := s.db.Select("id", "duration").Where("episode_id = ?", episode.ID).Order("storyboard_number ASC").Find(&storyboards).Error; err != nil {
+		return nil, err
+	}
+	var spans []storyboardSpan
+	position := 0.0
+	for _, sb := range storyboards {
+		duration := float64(max(sb.Duration, 1))
+		spans = append(spans, storyboardSpan{StoryboardID: sb.ID, Start: position, End: position + duration})
+		position += duration
+	}
+	return s.planCues(episode.DramaID, spans)
+}
+
+// MusicLayers 计算合成任务的配乐音频层；时间线已有音频轨道时以时间线为准，不自动配乐
+func (s *MusicService) MusicLayers(videoMerge *models.VideoMerge, resolvePath func(string) string) ([]ffmpeg.AudioLayer, error) {
+	if videoMerge.TimelineID != nil && timelineHasAudio(s.db, *videoMerge.TimelineID) {
+		return nil, nil
+	}
+
+	spans, err := mergeStoryboardSpans(s.db, s.ffmpeg, s.timelineService, videoMerge)
+	if err != nil {
+		return nil, err
+	}
+	cues, err := s.planCues(videoMerge.DramaID, spans)
+	if err != nil {
+		return nil, err
+	}
+
+	var layers []ffmpeg.AudioLayer
+	for i, cue := range cues {
+		if cue.Track == nil || cue.Track.Asset == nil {
+			continue
+		}
+		// 与下一段配乐交叉淡化，末段播放到成片结束
+		duration := cue.End - cue.Start
+		if i+1 < len(cues) {
+			duration += ffmpeg.DefaultMusicFade
+		}
+		layers = append(layers, ffmpeg.AudioLayer{
+			Path:            resolvePath(cue.Track.Asset.URL),
+			Start:           cue.Start,
+			Duration:        duration,
+			Volume:          ffmpeg.DefaultMusicVolume,
+			FadeIn:          ffmpeg.DefaultMusicFade,
+			FadeOut:         ffmpeg.DefaultMusicFade,
+			Loop:            true,
+			DuckUnderSource: true,
+		})
+	}
+	return layers, nil
+}
+
+// timelineHasAudio 时间线是否已有未静音的音频轨道片段
+func timelineHasAudio(db *gorm.DB, timelineID uint) bool {
+	var count int64
+	db.Model(&models.TimelineClip{}).
+		Joins("JOIN timeline_tracks ON timeline_tracks.id = timeline_clips.track_id").
+		Where("timeline_tracks.timeline_id = ? AND timeline_tracks.type = ? AND timeline_tracks.is_muted = ?", timelineID, models.TrackTypeAudio, false).
+		Where("timeline_clips.deleted_at IS NULL AND timeline_tracks.deleted_at IS NULL").
+		Count(&count)
+	return count > 0
+}
+
+// planCues 将分镜按配乐描述分段并为每段选曲：描述为空的分镜沿用上一段配乐，
+// 情绪与节奏相同的相邻分镜合为一段，避免每个镜头都换曲
+func (s *MusicService) planCues(dramaID uint, spans []storyboardSpan) ([]MusicCue, error) {
+	if len(spans) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uint, len(spans))
+	for i, span := range spans {
+		ids[i] = span.StoryboardID
+	}
+	var storyboards []models.Storyboard
+	s.db.Select("id", "bgm_prompt").Where("id IN ?", ids).Find(&storyboards)
+	prompts := make(map[uint]string, len(storyboards))
+	for _, sb := range storyboards {
+		if sb.BgmPrompt != nil {
+			prompts[sb.ID] = strings.TrimSpace(*sb.BgmPrompt)
+		}
+	}
+
+	var cues []MusicCue
+	for _, span := range spans {
+		prompt := prompts[span.StoryboardID]
+		if len(cues) > 0 {
+			last := &cues[len(cues)-1]
+			if prompt == "" || sameCue(last, prompt) {
+				last.StoryboardIDs = append(last.StoryboardIDs, span.StoryboardID)
+				last.End = span.End
+				continue
+			}
+		}
+		cue := MusicCue{StoryboardIDs: []uint{span.StoryboardID}, Start: span.Start, End: span.End, Prompt: prompt}
+		cue.Mood, cue.Tempo = ClassifyMusicPrompt(prompt)
+		cue.Silent = prompt == "" || containsAny(strings.ToLower(prompt), musicSilenceKeywords)
+		cues = append(cues, cue)
+	}
+
+	var tracks []models.MusicTrack
+	if err := s.db.Preload("Asset").
+		Where("status = ? AND asset_id IS NOT NULL", models.MusicStatusCompleted).
+		Where("drama_id = ? OR drama_id IS NULL", dramaID).
+		Order("id ASC").Find(&tracks).Error; err != nil {
+		return nil, err
+	}
+
+	used := make(map[uint]int)
+	for i := range cues {
+		if cues[i].Silent {
+			continue
+		}
+		if track := pickMusicTrack(tracks, &cues[i], dramaID, used); track != nil {
+			cues[i].Track = track
+			used[track.ID]++
+		} else {
+			s.log.Infow("No music track matches prompt", "prompt", cues[i].Prompt, "mood", cues[i].Mood)
+		}
+	}
+	return cues, nil
+}
+
+// sameCue 配乐描述与当前段相同，或识别出的情绪与节奏一致
+func sameCue(cue *MusicCue, prompt string) bool {
+	if prompt == cue.Prompt {
+		return true
+	}
+	if cue.Silent {
+		return containsAny(strings.ToLower(prompt), musicSilenceKeywords)
+	}
+	mood, tempo := ClassifyMusicPrompt(prompt)
+	return mood != "" && mood == cue.Mood && tempo == cue.Tempo
+}
+
+// pickMusicTrack 按情绪、节奏与标签命中数为曲目打分，同分时优先本剧曲目与使用次数少的曲目
+// 情绪不符且没有标签命中的曲目不会被选中
+func pickMusicTrack(tracks []models.MusicTrack, cue *MusicCue, dramaID uint, used map[uint]int) *models.MusicTrack {
+	prompt := strings.ToLower(cue.Prompt)
+	type candidate struct {
+		track *models.MusicTrack
+		score float64
+	}
+	var candidates []candidate
+	for i := range tracks {
+		track := &tracks[i]
+		tagHits := 0
+		if track.Tags != nil {
+			for _, tag := range strings.Split(*track.Tags, ",") {
+				if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" && strings.Contains(prompt, tag) {
+					tagHits++
+				}
+			}
+		}
+		moodMatch := cue.Mood != "" && track.Mood == cue.Mood
+		if !moodMatch && tagHits == 0 {
+			continue
+		}
+
+		score := float64(tagHits)
+		if moodMatch {
+			score += 4
+		}
+		if track.Tempo == cue.Tempo {
+			score += 2
+		}
+		if track.DramaID != nil && *track.DramaID == dramaID {
+			score += 0.5
+		}
+		score -= float64(used[track.ID])
+		candidates = append(candidates, candidate{track, score})
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	return candidates[0].track
+}
+
+// classifyTrack 未指定情绪/节奏时从标题与标签识别
+func classifyTrack(title string, tags []string, mood, tempo string) (string, string) {
+	detectedMood, detectedTempo := ClassifyMusicPrompt(title + " " + strings.Join(tags, " "))
+	if mood == "" {
+		mood = detectedMood
+	}
+	if tempo == "" {
+		tempo = detectedTempo
+	}
+	return mood, tempo
+}
+
+func joinTags(tags []string) *string {
+	var cleaned []string
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			cleaned = append(cleaned, tag)
+		}
+	}
+	if len(cleaned) == 0 {
+		return nil
+	}
+	joined := strings.Join(cleaned, ",")
+	return &joined
+}
+
+func truncateRunes(text string, n int) string {
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) <= n {
+		return string(runes)
+	}
+	return string(runes[:n])
+}
+
+// storyboardSpan 分镜在正片中的起止时间（秒）
+type storyboardSpan struct {
+	StoryboardID uint
+	Start        float64
+	End          float64
+}
+
+// mergeStoryboardSpans 计算合成任务中各分镜在正片中的位置：时间线合成取主视频轨道，
+// 场景合成按裁剪区间与转场重叠换算；不含片头片尾
+func mergeStoryboardSpans(db *gorm.DB, ff *ffmpeg.FFmpeg, timelineService *TimelineService, videoMerge *models.VideoMerge) ([]storyboardSpan, error) {
+	var spans []storyboardSpan
+	if videoMerge.TimelineID != nil {
+		timeline, err := timelineService.GetTimeline(*videoMerge.TimelineID)
+		if err != nil {
+			return nil, err
+		}
+		for _, track := range timeline.Tracks {
+			if track.Type != models.TrackTypeVideo || track.IsMuted {
+				continue
+			}
+			for _, clip := range track.Clips {
+				if clip.StoryboardID == nil {
+					continue
+				}
+				start := float64(clip.StartTime) / 1000
+				spans = append(spans, storyboardSpan{StoryboardID: *clip.StoryboardID, Start: start, End: start + float64(clip.Duration)/1000})
+			}
+			break
+		}
+		return spans, nil
+	}
+
+	var scenes []models.SceneClip
+	if err := json.Unmarshal(videoMerge.Scenes, &scenes); err != nil {
+		return nil, fmt.Errorf("failed to parse scenes: %w", err)
+	}
+	sort.SliceStable(scenes, func(i, j int) bool { return scenes[i].Order < scenes[j].Order })
+
+	clips := make([]ffmpeg.VideoClip, len(scenes))
+	for i, scene := range scenes {
+		clips[i] = ffmpeg.VideoClip{
+			Duration:   scene.Duration,
+			StartTime:  scene.StartTime,
+			EndTime:    scene.EndTime,
+			Transition: scene.Transition,
+		}
+	}
+	for i, span := range ff.ClipSpans(clips) {
+		if scenes[i].SceneID == 0 {
+			continue
+		}
+		spans = append(spans, storyboardSpan{StoryboardID: scenes[i].SceneID, Start: span.Start, End: span.End})
+	}
+	return spans, nil
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"testing"
+
+	models "github.com/drama-generator/backend/domain/models"
+)
+
+func TestClassifyMusicPrompt(t *testing.T) {
+	tests := []struct {
+		name      string
+		prompt    string
+		wantMood  string
+		wantTempo string
+	}{
+		{"中文情绪与快节奏", "紧张的追逐，快节奏鼓点", "tense", models.MusicTempoFast},
+		{"中文情绪与慢节奏", "悲伤的钢琴，缓慢", "sad", models.MusicTempoSlow},
+		{"英文关键词不区分大小写", "Romantic strings, Upbeat", "romantic", models.MusicTempoFast},
+		{"未命中节奏时为medium", "温馨的家庭晚餐", "warm", models.MusicTempoMedium},
+		{"按情绪顺序优先匹配靠前的分类", "悬疑又神秘的气氛", "tense", models.MusicTempoMedium},
+		{"快节奏优先于慢节奏", "舒缓开头后转为激烈", "calm", models.MusicTempoFast},
+		{"无法识别情绪", "钢琴独奏", "", models.MusicTempoMedium},
+		{"空描述", "", "", models.MusicTempoMedium},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mood, tempo := ClassifyMusicPrompt(tt.prompt)
+			if mood != tt.wantMood || tempo != tt.wantTempo {
+				t.Errorf("ClassifyMusicPrompt(%q) = (%q, %q), want (%q, %q)", tt.prompt, mood, tempo, tt.wantMood, tt.wantTempo)
+			}
+		})
+	}
+}
+
+func TestPickMusicTrack(t *testing.T) {
+	dramaID := uint(7)
+	otherDramaID := uint(8)
+	tags := func(s string) *string { return &s }
+
+	tests := []struct {
+		name   string
+		tracks []models.MusicTrack
+		cue    MusicCue
+		used   map[uint]int
+		wantID uint // 0 表示不选曲
+	}{
+		{
+			name: "情绪与节奏都匹配的曲目优先",
+			tracks: []models.MusicTrack{
+				{ID: 1, Mood: "tense", Tempo: models.MusicTempoSlow},
+				{ID: 2, Mood: "tense", Tempo: models.MusicTempoFast},
+			},
+			cue:    MusicCue{Prompt: "紧张追逐", Mood: "tense", Tempo: models.MusicTempoFast},
+			wantID: 2,
+		},
+		{
+			name: "情绪不符且无标签命中的曲目不会被选中",
+			tracks: []models.MusicTrack{
+				{ID: 1, Mood: "happy", Tempo: models.MusicTempoFast},
+			},
+			cue:    MusicCue{Prompt: "紧张追逐", Mood: "tense", Tempo: models.MusicTempoFast},
+			wantID: 0,
+		},
+		{
+			name: "标签命中时情绪不符也可选中",
+			tracks: []models.MusicTrack{
+				{ID: 1, Mood: "happy", Tempo: models.MusicTempoMedium, Tags: tags("钢琴, 弦乐")},
+			},
+			cue:    MusicCue{Prompt: "钢琴独奏", Tempo: models.MusicTempoMedium},
+			wantID: 1,
+		},
+		{
+			name: "标签命中数多的曲目得分更高",
+			tracks: []models.MusicTrack{
+				{ID: 1, Mood: "sad", Tempo: models.MusicTempoSlow, Tags: tags("钢琴")},
+				{ID: 2, Mood: "sad", Tempo: models.MusicTempoSlow, Tags: tags("钢琴,大提琴")},
+			},
+			cue:    MusicCue{Prompt: "悲伤的钢琴与大提琴", Mood: "sad", Tempo: models.MusicTempoSlow},
+			wantID: 2,
+		},
+		{
+			name: "同分时优先本剧曲目",
+			tracks: []models.MusicTrack{
+				{ID: 1, Mood: "warm", Tempo: models.MusicTempoMedium, DramaID: &otherDramaID},
+				{ID: 2, Mood: "warm", Tempo: models.MusicTempoMedium, DramaID: &dramaID},
+			},
+			cue:    MusicCue{Prompt: "温馨", Mood: "warm", Tempo: models.MusicTempoMedium},
+			wantID: 2,
+		},
+		{
+			name: "使用次数多的曲目让位",
+			tracks: []models.MusicTrack{
+				{ID: 1, Mood: "calm", Tempo: models.MusicTempoSlow},
+				{ID: 2, Mood: "calm", Tempo: models.MusicTempoSlow},
+			},
+			cue:    MusicCue{Prompt: "平静", Mood: "calm", Tempo: models.MusicTempoSlow},
+			used:   map[uint]int{1: 1},
+			wantID: 2,
+		},
+		{
+			name:   "曲库为空",
+			cue:    MusicCue{Prompt: "紧张", Mood: "tense", Tempo: models.MusicTempoMedium},
+			wantID: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			used := tt.used
+			if used == nil {
+				used = map[uint]int{}
+			}
+			got := pickMusicTrack(tt.tracks, &tt.cue, dramaID, used)
+			var gotID uint
+			if got != nil {
+				gotID = got.ID
+			}
+			if gotID != tt.wantID {
+				t.Errorf("pickMusicTrack() = track %d, want %d", gotID, tt.wantID)
+			}
+		})
+	}
+}
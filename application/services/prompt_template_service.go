@@ -0,0 +1,488 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/drama-generator/backend/domain/models"
+	"github.com/drama-generator/backend/pkg/logger"
+	"github.com/drama-generator/backend/pkg/prompt"
+	"gorm.io/gorm"
+)
+
+// 生效模板的来源
+const (
+	PromptSourceDraft   = "draft"   // 预览时传入的未保存内容
+	PromptSourceDrama   = "drama"   // 剧本覆盖
+	PromptSourceGlobal  = "global"  // 全局模板
+	PromptSourceBuiltin = "builtin" // 代码内置的默认内容
+)
+
+// PromptTemplateService 管理可编辑的提示词模板：全局模板与剧本覆盖各自按版本保存，
+// 生成环节通过 Render 取生效版本渲染，数据库中没有可用版本时使用内置内容
+type PromptTemplateService struct {
+	db  *gorm.DB
+	log *logger.Logger
+}
+
+func NewPromptTemplateService(db *gorm.DB, log *logger.Logger) *PromptTemplateService {
+	return &PromptTemplateService{
+		db:  db,
+		log: log,
+	}
+}
+
+// PromptTemplateInfo 模板定义及当前启用的版本
+type PromptTemplateInfo struct {
+	Key            string                 `json:"key"`
+	Name           string                 `json:"name"`
+	Description    string                 `json:"description"`
+	Variables      []prompt.Variable      `json:"variables"` // 可引用的变量，含剧本通用变量
+	DefaultContent string                 `json:"default_content"`
+	Global         *models.PromptTemplate `json:"global,omitempty"`   // 启用的全局版本
+	Override       *models.PromptTemplate `json:"override,omitempty"` // 启用的剧本覆盖版本，仅查询剧本时返回
+	Source         string                 `json:"source"`             // 实际生效的来源：drama、global、builtin
+}
+
+// CreatePromptTemplateVersionRequest 保存模板新版本，保存后即启用
+type CreatePromptTemplateVersionRequest struct {
+	DramaID *uint  `json:"drama_id"` // 为空时修改全局模板
+	Content string `json:"content" binding:"required"`
+	Note    string `json:"note"`
+}
+
+// PreviewPromptTemplateRequest 使用真实剧本数据预览渲染结果
+type PreviewPromptTemplateRequest struct {
+	DramaID   uint   `json:"drama_id" binding:"required"`
+	EpisodeID *uint  `json:"episode_id"` // 分镜、场景类模板取该集的数据，默认第一集
+	Content   string `json:"content"`    // 预览尚未保存的内容，为空时预览该剧本生效的版本
+}
+
+// PromptTemplatePreview 渲染预览结果
+type PromptTemplatePreview struct {
+	Key       string            `json:"key"`
+	Source    string            `json:"source"`
+	Version   int               `json:"version,omitempty"`
+	Variables map[string]string `json:"variables"`
+	Prompt    string            `json:"prompt"`
+}
+
+// builtinVersionNote 内置内容作为第0版时的修改说明
+const builtinVersionNote = "内置模板"
+
+// ListTemplates 列出全部模板，指定剧本时附带该剧本的覆盖版本
+func (s *PromptTemplateService) ListTemplates(dramaID *uint) ([]PromptTemplateInfo, error) {
+	if err := s.checkDrama(dramaID); err != nil {
+		return nil, err
+	}
+
+	var infos []PromptTemplateInfo
+	for _, def := range prompt.Definitions() {
+		info, err := s.templateInfo(&def, dramaID)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, *info)
+	}
+	return infos, nil
+}
+
+func (s *PromptTemplateService) GetTemplate(key string, dramaID *uint) (*PromptTemplateInfo, error) {
+	def, ok := prompt.Lookup(key)
+	if !ok {
+		return nil, fmt.Errorf("prompt template not found")
+	}
+	if err := s.checkDrama(dramaID); err != nil {
+		return nil, err
+	}
+	return s.templateInfo(def, dramaID)
+}
+
+// ListVersions 列出全局模板或剧本覆盖的全部版本，新版本在前；全局模板末尾附带内置内容作为第0版
+func (s *PromptTemplateService) ListVersions(key string, dramaID *uint) ([]models.PromptTemplate, error) {
+	def, ok := prompt.Lookup(key)
+	if !ok {
+		return nil, fmt.Errorf("prompt template not found")
+	}
+	if err := s.checkDrama(dramaID); err != nil {
+		return nil, err
+	}
+
+	var versions []models.PromptTemplate
+	if err := s.scope(key, dramaID).Order("version DESC").Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	if dramaID == nil {
+		builtin := builtinVersion(def)
+		for _, v := range versions {
+			if v.IsActive {
+				builtin.IsActive = false
+				break
+			}
+		}
+		versions = append(versions, *builtin)
+	}
+	return versions, nil
+}
+
+// CreateVersion 保存新版本并启用，同一作用域内的其他版本停用
+func (s *PromptTemplateService) CreateVersion(key string, req *CreatePromptTemplateVersionRequest) (*models.PromptTemplate, error) {
+	def, ok := prompt.Lookup(key)
+	if !ok {
+		return nil, fmt.Errorf("prompt template not found")
+	}
+	if strings.TrimSpace(req.Content) == "" {
+		return nil, fmt.Errorf("content is required")
+	}
+	if err := prompt.Validate(def, req.Content); err != nil {
+		return nil, err
+	}
+	if err := s.checkDrama(req.DramaID); err != nil {
+		return nil, err
+	}
+
+	template := models.PromptTemplate{
+		Key:       key,
+		DramaID:   req.DramaID,
+		Content:   req.Content,
+		Variables: templateVariables(req.Content),
+		IsActive:  true,
+	}
+	if req.Note != "" {
+		template.Note = &req.Note
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var latest int
+		if err := s.scopeTx(tx, key, req.DramaID).Unscoped().Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+		if err := s.scopeTx(tx, key, req.DramaID).Update("is_active", false).Error; err != nil {
+			return err
+		}
+		template.Version = latest + 1
+		return tx.Create(&template).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Infow("Prompt template version created", "key", key, "drama_id", req.DramaID, "version", template.Version)
+	return &template, nil
+}
+
+// ActivateVersion 启用指定版本，用于回滚到历史版本；全局模板启用第0版即恢复使用内置内容
+func (s *PromptTemplateService) ActivateVersion(key string, version int, dramaID *uint) (*models.PromptTemplate, error) {
+	def, ok := prompt.Lookup(key)
+	if !ok {
+		return nil, fmt.Errorf("prompt template not found")
+	}
+	if err := s.checkDrama(dramaID); err != nil {
+		return nil, err
+	}
+
+	if version == 0 && dramaID == nil {
+		if err := s.scope(key, nil).Update("is_active", false).Error; err != nil {
+			return nil, err
+		}
+		s.log.Infow("Prompt template reset to builtin", "key", key)
+		return builtinVersion(def), nil
+	}
+
+	var template models.PromptTemplate
+	if err := s.scope(key, dramaID).Where("version = ?", version).First(&template).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("prompt template version not found")
+		}
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.scopeTx(tx, key, dramaID).Update("is_active", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&template).Update("is_active", true).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	template.IsActive = true
+
+	s.log.Infow("Prompt template version activated", "key", key, "drama_id", dramaID, "version", version)
+	return &template, nil
+}
+
+// ResetOverride 停用剧本覆盖，恢复使用全局模板，覆盖的历史版本保留
+func (s *PromptTemplateService) ResetOverride(key string, dramaID uint) error {
+	if _, ok := prompt.Lookup(key); !ok {
+		return fmt.Errorf("prompt template not found")
+	}
+	if err := s.checkDrama(&dramaID); err != nil {
+		return err
+	}
+	return s.scope(key, &dramaID).Update("is_active", false).Error
+}
+
+// Render 渲染剧本生效的模板，剧本通用变量自动填充；
+// 读取失败或渲染失败时记录警告并使用内置内容，不影响生成流程
+func (s *PromptTemplateService) Render(key string, dramaID uint, vars map[string]string) string {
+	def, ok := prompt.Lookup(key)
+	if !ok {
+		s.log.Errorw("Unknown prompt template", "key", key)
+		return ""
+	}
+
+	values := s.dramaVariables(dramaID)
+	for name, value := range vars {
+		values[name] = value
+	}
+
+	content := def.Content
+	if template, _, err := s.effectiveTemplate(key, dramaID); err != nil {
+		s.log.Warnw("Failed to load prompt template, using builtin", "key", key, "drama_id", dramaID, "error", err)
+	} else if template != nil {
+		content = template.Content
+	}
+
+	text, err := prompt.Render(content, values)
+	if err != nil {
+		s.log.Warnw("Failed to render prompt template, using builtin", "key", key, "drama_id", dramaID, "error", err)
+		text, _ = prompt.Render(def.Content, values)
+	}
+	return text
+}
+
+// Preview 使用剧本（及剧集）的真实数据渲染模板，变量值一并返回便于调试
+func (s *PromptTemplateService) Preview(key string, req *PreviewPromptTemplateRequest) (*PromptTemplatePreview, error) {
+	def, ok := prompt.Lookup(key)
+	if !ok {
+		return nil, fmt.Errorf("prompt template not found")
+	}
+	if err := s.checkDrama(&req.DramaID); err != nil {
+		return nil, err
+	}
+
+	preview := &PromptTemplatePreview{Key: key}
+	content := req.Content
+	if content != "" {
+		if err := prompt.Validate(def, content); err != nil {
+			return nil, err
+		}
+		preview.Source = PromptSourceDraft
+	} else {
+		template, source, err := s.effectiveTemplate(key, req.DramaID)
+		if err != nil {
+			return nil, err
+		}
+		preview.Source = source
+		content = def.Content
+		if template != nil {
+			content = template.Content
+			preview.Version = template.Version
+		}
+	}
+
+	vars, err := s.previewVariables(def, req)
+	if err != nil {
+		return nil, err
+	}
+	preview.Variables = vars
+
+	text, err := prompt.Render(content, vars)
+	if err != nil {
+		return nil, err
+	}
+	preview.Prompt = text
+	return preview, nil
+}
+
+// effectiveTemplate 剧本覆盖优先，其次全局模板，都没有时返回 nil 表示使用内置内容
+func (s *PromptTemplateService) effectiveTemplate(key string, dramaID uint) (*models.PromptTemplate, string, error) {
+	if dramaID != 0 {
+		template, err := s.activeVersion(key, &dramaID)
+		if err != nil || template != nil {
+			return template, PromptSourceDrama, err
+		}
+	}
+	template, err := s.activeVersion(key, nil)
+	if err != nil || template != nil {
+		return template, PromptSourceGlobal, err
+	}
+	return nil, PromptSourceBuiltin, nil
+}
+
+func (s *PromptTemplateService) activeVersion(key string, dramaID *uint) (*models.PromptTemplate, error) {
+	// 没有启用版本是常见情况，用 Find 避免记录 record not found 日志
+	var templates []models.PromptTemplate
+	if err := s.scope(key, dramaID).Where("is_active = ?", true).Order("version DESC").Limit(1).Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	if len(templates) == 0 {
+		return nil, nil
+	}
+	return &templates[0], nil
+}
+
+func (s *PromptTemplateService) templateInfo(def *prompt.Definition, dramaID *uint) (*PromptTemplateInfo, error) {
+	info := &PromptTemplateInfo{
+		Key:            def.Key,
+		Name:           def.Name,
+		Description:    def.Description,
+		Variables:      append(append([]prompt.Variable{}, prompt.DramaVariables...), def.Variables...),
+		DefaultContent: def.Content,
+		Source:         PromptSourceBuiltin,
+	}
+
+	global, err := s.activeVersion(def.Key, nil)
+	if err != nil {
+		return nil, err
+	}
+	if global != nil {
+		info.Global = global
+		info.Source = PromptSourceGlobal
+	}
+
+	if dramaID != nil {
+		override, err := s.activeVersion(def.Key, dramaID)
+		if err != nil {
+			return nil, err
+		}
+		if override != nil {
+			info.Override = override
+			info.Source = PromptSourceDrama
+		}
+	}
+	return info, nil
+}
+
+// scope 全局模板（dramaID 为空）或某剧本覆盖的全部版本
+func (s *PromptTemplateService) scope(key string, dramaID *uint) *gorm.DB {
+	return s.scopeTx(s.db, key, dramaID)
+}
+
+func (s *PromptTemplateService) scopeTx(tx *gorm.DB, key string, dramaID *uint) *gorm.DB {
+	query := tx.Model(&models.PromptTemplate{}).Where("template_key = ?", key)
+	if dramaID == nil {
+		return query.Where("drama_id IS NULL")
+	}
+	return query.Where("drama_id = ?", *dramaID)
+}
+
+func (s *PromptTemplateService) checkDrama(dramaID *uint) error {
+	if dramaID == nil {
+		return nil
+	}
+	var count int64
+	if err := s.db.Model(&models.Drama{}).Where("id = ?", *dramaID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("drama not found")
+	}
+	return nil
+}
+
+// dramaVariables 剧本通用变量，剧本不存在时全部为空
+func (s *PromptTemplateService) dramaVariables(dramaID uint) map[string]string {
+	vars := make(map[string]string, len(prompt.DramaVariables))
+	for _, v := range prompt.DramaVariables {
+		vars[v.Name] = ""
+	}
+	if dramaID == 0 {
+		return vars
+	}
+
+	var drama models.Drama
+	if err := s.db.Where("id = ?", dramaID).First(&drama).Error; err != nil {
+		return vars
+	}
+	vars["drama_title"] = drama.Title
+	vars["drama_style"] = drama.Style
+	if drama.Genre != nil {
+		vars["drama_genre"] = *drama.Genre
+	}
+	if drama.Description != nil {
+		vars["drama_description"] = *drama.Description
+	}
+	return vars
+}
+
+// previewVariables 按模板声明的变量，以与生成环节相同的方式从剧集数据构建变量值
+func (s *PromptTemplateService) previewVariables(def *prompt.Definition, req *PreviewPromptTemplateRequest) (map[string]string, error) {
+	vars := s.dramaVariables(req.DramaID)
+	if len(def.Variables) == 0 {
+		return vars, nil
+	}
+	for _, v := range def.Variables {
+		vars[v.Name] = ""
+	}
+
+	var episode models.Episode
+	query := s.db.Where("drama_id = ?", req.DramaID)
+	if req.EpisodeID != nil {
+		query = query.Where("id = ?", *req.EpisodeID)
+	}
+	if err := query.Order("episode_number ASC").First(&episode).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if req.EpisodeID != nil {
+			return nil, fmt.Errorf("episode not found")
+		}
+		return vars, nil
+	}
+
+	for _, v := range def.Variables {
+		switch v.Name {
+		case "script":
+			if episode.ScriptContent != nil && *episode.ScriptContent != "" {
+				vars["script"] = *episode.ScriptContent
+			} else if episode.Description != nil {
+				vars["script"] = *episode.Description
+			}
+		case "characters":
+			var characters []models.Character
+			if err := s.db.Where("drama_id = ?", req.DramaID).Order("name ASC").Find(&characters).Error; err != nil {
+				return nil, err
+			}
+			vars["characters"] = storyboardCharacterList(characters)
+		case "scenes":
+			var scenes []models.Scene
+			if err := s.db.Where("drama_id = ?", req.DramaID).Order("location ASC, time ASC").Find(&scenes).Error; err != nil {
+				return nil, err
+			}
+			vars["scenes"] = storyboardSceneList(scenes)
+		case "storyboards":
+			var storyboards []models.Storyboard
+			if err := s.db.Where("episode_id = ?", episode.ID).Order("storyboard_number ASC").Find(&storyboards).Error; err != nil {
+				return nil, err
+			}
+			vars["storyboards"] = storyboardScenesText(storyboards)
+		}
+	}
+	return vars, nil
+}
+
+func templateVariables(content string) []byte {
+	names := prompt.Variables(content)
+	if names == nil {
+		names = []string{}
+	}
+	data, _ := json.Marshal(names)
+	return data
+}
+
+// builtinVersion 以全局模板第0版的形式表示内置内容，不对应数据库记录
+func builtinVersion(def *prompt.Definition) *models.PromptTemplate {
+	note := builtinVersionNote
+	return &models.PromptTemplate{
+		Key:       def.Key,
+		Version:   0,
+		Content:   def.Content,
+		Variables: templateVariables(def.Content),
+		IsActive:  true,
+		Note:      &note,
+	}
+}
@@ -0,0 +1,453 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/drama-generator/backend/domain/models"
+	"github.com/drama-generator/backend/pkg/logger"
+	"github.com/drama-generator/backend/pkg/screenplay"
+	"gorm.io/gorm"
+)
+
+// ScreenplayService 剧本文件（Fountain、Final Draft、Markdown）的导入与导出
+type ScreenplayService struct {
+	db  *gorm.DB
+	log *logger.Logger
+}
+
+func NewScreenplayService(db *gorm.DB, log *logger.Logger) *ScreenplayService {
+	return &ScreenplayService{
+		db:  db,
+		log: log,
+	}
+}
+
+// ImportScreenplayRequest 导入剧本请求
+type ImportScreenplayRequest struct {
+	Format    string `form:"format"`    // fountain、fdx、markdown，为空时按文件名与内容判断
+	Overwrite bool   `form:"overwrite"` // 集数已存在时覆盖该集的剧本与场景
+}
+
+// ImportScreenplayResult 导入结果
+type ImportScreenplayResult struct {
+	Title      string             `json:"title"`
+	Format     string             `json:"format"`
+	Episodes   []models.Episode   `json:"episodes"`
+	Characters []models.Character `json:"characters"` // 本次新建的角色
+	SceneCount int                `json:"scene_count"`
+}
+
+// ScreenplayExportResult 导出结果
+type ScreenplayExportResult struct {
+	Content     []byte
+	FileName    string
+	ContentType string
+}
+
+// ImportScreenplay 解析剧本并写入剧集、角色与场景：
+// 未标注集数的剧集顺延到已有剧集之后，说话人按名称复用本剧已有角色并关联到出场剧集，
+// 场景按 地点+时间 去重，同一地点时间出现的次数记为分镜数
+func (s *ScreenplayService) ImportScreenplay(dramaID uint, req *ImportScreenplayRequest, filename string, data []byte) (*ImportScreenplayResult, error) {
+	format := screenplay.DetectFormat(filename, data)
+	if req.Format != "" {
+		var err error
+		if format, err = screenplay.ParseFormat(req.Format); err != nil {
+			return nil, err
+		}
+	}
+
+	sp, err := screenplay.Parse(format, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var drama models.Drama
+	if err := s.db.Where("id = ?", dramaID).First(&drama).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("drama not found")
+		}
+		return nil, err
+	}
+
+	result := &ImportScreenplayResult{Title: sp.Title, Format: string(format)}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var existingEpisodes []models.Episode
+		if err := tx.Where("drama_id = ?", dramaID).Find(&existingEpisodes).Error; err != nil {
+			return err
+		}
+		episodeByNumber := make(map[int]*models.Episode)
+		nextNumber := 1
+		for i := range existingEpisodes {
+			episodeByNumber[existingEpisodes[i].EpisodeNum] = &existingEpisodes[i]
+			nextNumber = max(nextNumber, existingEpisodes[i].EpisodeNum+1)
+		}
+
+		var existingCharacters []models.Character
+		if err := tx.Where("drama_id = ?", dramaID).Find(&existingCharacters).Error; err != nil {
+			return err
+		}
+		characterByName := make(map[string]*models.Character)
+		for i := range existingCharacters {
+			characterByName[existingCharacters[i].Name] = &existingCharacters[i]
+		}
+
+		imported := make(map[int]bool)
+		for _, ep := range sp.Episodes {
+			number := ep.Number
+			if number == 0 {
+				for episodeByNumber[nextNumber] != nil || imported[nextNumber] {
+					nextNumber++
+				}
+				number = nextNumber
+			}
+			if imported[number] {
+				return fmt.Errorf("episode %d appears more than once in screenplay", number)
+			}
+			imported[number] = true
+
+			episode, err := s.saveEpisode(tx, dramaID, number, &ep, episodeByNumber[number], req.Overwrite)
+			if err != nil {
+				return err
+			}
+
+			var characters []models.Character
+			for _, name := range ep.Characters() {
+				character, ok := characterByName[name]
+				if !ok {
+					character = &models.Character{DramaID: dramaID, Name: name, SortOrder: len(characterByName)}
+					if err := tx.Create(character).Error; err != nil {
+						return err
+					}
+					characterByName[name] = character
+					result.Characters = append(result.Characters, *character)
+				}
+				characters = append(characters, *character)
+			}
+			if err := tx.Model(episode).Association("Characters").Replace(characters); err != nil {
+				return err
+			}
+			episode.Characters = characters
+
+			scenes, err := s.saveScenes(tx, dramaID, episode.ID, &ep)
+			if err != nil {
+				return err
+			}
+			episode.Scenes = scenes
+			result.SceneCount += len(scenes)
+			result.Episodes = append(result.Episodes, *episode)
+		}
+
+		totalEpisodes := len(existingEpisodes)
+		for number := range imported {
+			if episodeByNumber[number] == nil {
+				totalEpisodes++
+			}
+		}
+		return tx.Model(&drama).Update("total_episodes", max(drama.TotalEpisodes, totalEpisodes)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Infow("Screenplay imported", "drama_id", dramaID, "format", format,
+		"episodes", len(result.Episodes), "characters", len(result.Characters), "scenes", result.SceneCount)
+	return result, nil
+}
+
+// saveEpisode 新建剧集，或在允许覆盖时更新已有剧集的标题与剧本
+func (s *ScreenplayService) saveEpisode(tx *gorm.DB, dramaID uint, number int, ep *screenplay.Episode, existing *models.Episode, overwrite bool) (*models.Episode, error) {
+	title := ep.Title
+	if title == "" {
+		title = fmt.Sprintf("第%d集", number)
+	}
+	script := ep.Text()
+	duration := estimateScriptDuration(script)
+
+	if existing == nil {
+		episode := &models.Episode{
+			DramaID:       dramaID,
+			EpisodeNum:    number,
+			Title:         title,
+			ScriptContent: &script,
+			Duration:      duration,
+			Status:        "draft",
+		}
+		if err := tx.Create(episode).Error; err != nil {
+			return nil, err
+		}
+		return episode, nil
+	}
+
+	if !overwrite {
+		return nil, fmt.Errorf("episode %d already exists", number)
+	}
+	if err := tx.Model(existing).Updates(map[string]interface{}{
+		"title":          title,
+		"script_content": script,
+		"duration":       duration,
+	}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("episode_id = ?", existing.ID).Delete(&models.Scene{}).Error; err != nil {
+		return nil, err
+	}
+	// 原有分镜保留，但不再引用已删除的场景
+	if err := tx.Model(&models.Storyboard{}).Where("episode_id = ? AND scene_id IS NOT NULL", existing.ID).Update("scene_id", nil).Error; err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// saveScenes 按场景标题生成场景，同一地点与时间只生成一次
+func (s *ScreenplayService) saveScenes(tx *gorm.DB, dramaID, episodeID uint, ep *screenplay.Episode) ([]models.Scene, error) {
+	var scenes []models.Scene
+	index := make(map[string]int)
+	for _, parsed := range ep.Scenes {
+		if parsed.Heading == "" {
+			continue
+		}
+		location := parsed.Location
+		if location == "" {
+			location = parsed.Heading
+		}
+		key := location + "\x00" + parsed.Time
+		if i, ok := index[key]; ok {
+			scenes[i].StoryboardCount++
+			continue
+		}
+		index[key] = len(scenes)
+		scenes = append(scenes, models.Scene{
+			DramaID:         dramaID,
+			EpisodeID:       &episodeID,
+			Location:        truncateRunes(location, 200),
+			Time:            truncateRunes(parsed.Time, 100),
+			Prompt:          sceneImportPrompt(location, parsed),
+			StoryboardCount: 1,
+			Status:          "pending",
+		})
+	}
+
+	for i := range scenes {
+		if err := tx.Create(&scenes[i]).Error; err != nil {
+			return nil, err
+		}
+	}
+	return scenes, nil
+}
+
+// ExportDrama 导出整部剧的剧本（fountain、fdx、pdf），按集数排序
+func (s *ScreenplayService) ExportDrama(dramaID uint, format string) (*ScreenplayExportResult, error) {
+	exportFormat, err := parseExportFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	var drama models.Drama
+	if err := s.db.Where("id = ?", dramaID).First(&drama).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("drama not found")
+		}
+		return nil, err
+	}
+
+	var episodes []models.Episode
+	if err := s.db.Where("drama_id = ?", dramaID).Order("episode_number ASC").Find(&episodes).Error; err != nil {
+		return nil, err
+	}
+	if len(episodes) == 0 {
+		return nil, errors.New("no episodes to export")
+	}
+
+	return s.export(&drama, episodes, exportFormat, fmt.Sprintf("drama_%d", dramaID))
+}
+
+// ExportEpisode 导出单集剧本
+func (s *ScreenplayService) ExportEpisode(episodeID uint, format string) (*ScreenplayExportResult, error) {
+	exportFormat, err := parseExportFormat(format)
+	if err != nil {
+		return nil, err
+	}
+
+	var episode models.Episode
+	if err := s.db.Preload("Drama").Where("id = ?", episodeID).First(&episode).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("episode not found")
+		}
+		return nil, err
+	}
+
+	return s.export(&episode.Drama, []models.Episode{episode}, exportFormat, fmt.Sprintf("episode_%d", episodeID))
+}
+
+func parseExportFormat(format string) (screenplay.Format, error) {
+	exportFormat, err := screenplay.ParseFormat(format)
+	if err != nil {
+		return "", err
+	}
+	if exportFormat == screenplay.FormatMarkdown {
+		return "", fmt.Errorf("unsupported export format: %s", format)
+	}
+	return exportFormat, nil
+}
+
+func (s *ScreenplayService) export(drama *models.Drama, episodes []models.Episode, format screenplay.Format, name string) (*ScreenplayExportResult, error) {
+	var characters []models.Character
+	if err := s.db.Where("drama_id = ?", drama.ID).Find(&characters).Error; err != nil {
+		return nil, err
+	}
+
+	sp := &screenplay.Screenplay{Title: drama.Title}
+	for i := range episodes {
+		ep, err := s.buildEpisode(&episodes[i], characters)
+		if err != nil {
+			return nil, err
+		}
+		sp.Episodes = append(sp.Episodes, *ep)
+	}
+
+	content, err := screenplay.Write(format, sp)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Infow("Screenplay exported", "drama_id", drama.ID, "format", format, "episodes", len(episodes))
+	return &ScreenplayExportResult{
+		Content:     content,
+		FileName:    name + "." + format.Extension(),
+		ContentType: format.ContentType(),
+	}, nil
+}
+
+// buildEpisode 有分镜时按分镜的场景、动作与对白组织剧本，否则解析剧集剧本正文
+func (s *ScreenplayService) buildEpisode(episode *models.Episode, dramaCharacters []models.Character) (*screenplay.Episode, error) {
+	ep := &screenplay.Episode{Number: episode.EpisodeNum, Title: episode.Title}
+
+	var storyboards []models.Storyboard
+	if err := s.db.Preload("Background").Preload("Characters").
+		Where("episode_id = ?", episode.ID).Order("storyboard_number ASC").Find(&storyboards).Error; err != nil {
+		return nil, err
+	}
+
+	if len(storyboards) == 0 {
+		script := ""
+		if episode.ScriptContent != nil {
+			script = strings.TrimSpace(*episode.ScriptContent)
+		}
+		if parsed, err := screenplay.Parse(screenplay.FormatMarkdown, []byte(script)); err == nil {
+			for _, parsedEpisode := range parsed.Episodes {
+				ep.Scenes = append(ep.Scenes, parsedEpisode.Scenes...)
+			}
+		} else if script != "" {
+			ep.Scenes = []screenplay.Scene{{Elements: []screenplay.Element{{Type: screenplay.ElementAction, Text: script}}}}
+		}
+		return ep, nil
+	}
+
+	for _, sb := range storyboards {
+		location, timeOfDay := "", ""
+		if sb.Background != nil {
+			location, timeOfDay = sb.Background.Location, sb.Background.Time
+		} else {
+			if sb.Location != nil {
+				location = strings.TrimSpace(*sb.Location)
+			}
+			if sb.Time != nil {
+				timeOfDay = strings.TrimSpace(*sb.Time)
+			}
+		}
+		heading := location
+		if timeOfDay != "" {
+			heading = strings.TrimSpace(location + " - " + timeOfDay)
+		}
+		// 连续分镜场景相同时归入同一场
+		if len(ep.Scenes) == 0 || (heading != "" && heading != ep.Scenes[len(ep.Scenes)-1].Heading) {
+			ep.Scenes = append(ep.Scenes, screenplay.Scene{Heading: heading, Location: location, Time: timeOfDay})
+		}
+		scene := &ep.Scenes[len(ep.Scenes)-1]
+
+		action := sb.Action
+		if action == nil || strings.TrimSpace(*action) == "" {
+			action = sb.Description
+		}
+		if action != nil && strings.TrimSpace(*action) != "" {
+			scene.Elements = append(scene.Elements, screenplay.Element{Type: screenplay.ElementAction, Text: strings.TrimSpace(*action)})
+		}
+
+		if sb.Dialogue == nil {
+			continue
+		}
+		for _, line := range parseDialogueLines(*sb.Dialogue) {
+			scene.Elements = append(scene.Elements, exportDialogueElement(line, sb.Characters, dramaCharacters))
+		}
+	}
+	return ep, nil
+}
+
+// exportDialogueElement 说话人按角色名称匹配；旁白作为旁白角色的对白，无法归属角色的独白作为动作
+func exportDialogueElement(line DialogueLine, storyboardCharacters, dramaCharacters []models.Character) screenplay.Element {
+	el := screenplay.Element{Type: screenplay.ElementDialogue, Character: line.Speaker, Text: line.Text}
+	if line.Speaker == "" && line.Marker != narratorMarker {
+		el.Parenthetical = line.Marker
+	}
+	if character := matchSpeaker(line, storyboardCharacters, dramaCharacters); character != nil {
+		el.Character = character.Name
+	} else if line.Marker == narratorMarker {
+		el.Character = narratorMarker
+	}
+	if el.Character == "" {
+		el.Type = screenplay.ElementAction
+		if line.Marker != "" {
+			el.Text = "（" + line.Marker + "）" + line.Text
+		}
+		el.Parenthetical = ""
+	}
+	return el
+}
+
+// sceneInteriorNames 场景标题中的内外景标记
+var sceneInteriorNames = map[string]string{
+	"INT": "内景", "EXT": "外景", "INT/EXT": "内外景", "EXT/INT": "内外景", "I/E": "内外景",
+	"内": "内景", "外": "外景", "内外": "内外景", "内/外": "内外景",
+}
+
+// sceneImportPrompt 场景图提示词，与AI提取场景的提示词结构一致，环境细节取首段动作描述
+func sceneImportPrompt(location string, scene screenplay.Scene) string {
+	prompt := fmt.Sprintf("一个电影感的纯背景场景，展现%s的环境", location)
+	if scene.Time != "" {
+		prompt = fmt.Sprintf("一个电影感的纯背景场景，展现%s在%s的环境", location, scene.Time)
+	}
+	if interior := sceneInteriorNames[scene.Interior]; interior != "" {
+		prompt += "（" + interior + "）"
+	}
+	prompt += "。"
+	for _, el := range scene.Elements {
+		if el.Type == screenplay.ElementAction {
+			detail := strings.TrimRight(truncateRunes(strings.ReplaceAll(el.Text, "\n", " "), 120), "。.!！")
+			prompt += "画面呈现" + detail + "，不包含人物。"
+			return prompt
+		}
+	}
+	return prompt + "画面不包含人物。"
+}
+
+// estimateScriptDuration 按朗读语速估算剧本时长（秒）：中文约每秒4.5字，英文约每秒2.5词
+func estimateScriptDuration(script string) int {
+	hanChars := 0
+	for _, r := range script {
+		if unicode.Is(unicode.Han, r) {
+			hanChars++
+		}
+	}
+	words := 0
+	for _, field := range strings.FieldsFunc(script, func(r rune) bool {
+		return !unicode.IsLetter(r) || unicode.Is(unicode.Han, r)
+	}) {
+		if field != "" {
+			words++
+		}
+	}
+	return int(float64(hanChars)/4.5 + float64(words)/2.5 + 0.5)
+}
@@ -0,0 +1,447 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/drama-generator/backend/domain/models"
+	"github.com/drama-generator/backend/pkg/ai"
+	promptpkg "github.com/drama-generator/backend/pkg/prompt"
+	"github.com/drama-generator/backend/pkg/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// outlineEpisodesKey 剧本元数据中保存分集规划的字段
+	outlineEpisodesKey = "outline_episodes"
+	// storyMemoryKey 剧本元数据中保存前情摘要的字段
+	storyMemoryKey = "story_memory"
+	// recentEpisodeRecaps 前情提要中原样保留梗概的最近集数
+	recentEpisodeRecaps = 3
+	// storySummaryThreshold 更早剧集的梗概超过该字数时压缩为故事摘要
+	storySummaryThreshold = 1200
+	// maxEpisodeBatchSize 每次请求最多生成的集数
+	maxEpisodeBatchSize = 5
+)
+
+// GenerateEpisodesIncrementalRequest 逐集生成剧本请求
+type GenerateEpisodesIncrementalRequest struct {
+	DramaID      string           `json:"drama_id" binding:"required"`
+	Outline      string           `json:"outline"`
+	EpisodeCount int              `json:"episode_count" binding:"required,min=1,max=100"` // 生成到第几集
+	StartEpisode int              `json:"start_episode"`                                  // 从第几集开始，默认第1集，之前的剧集作为前情
+	BatchSize    int              `json:"batch_size"`                                     // 每次请求生成的集数，默认1，最多5
+	Episodes     []EpisodeOutline `json:"episodes"`                                       // 分集规划，为空时使用生成大纲时保存的规划
+	Temperature  float64          `json:"temperature"`
+}
+
+// RegenerateEpisodeRequest 重新生成单集剧本请求
+type RegenerateEpisodeRequest struct {
+	Outline      string  `json:"outline"`
+	Instructions string  `json:"instructions"` // 对本集的修改意见
+	Temperature  float64 `json:"temperature"`
+}
+
+// EpisodeProgressFunc 逐集生成的进度回调
+type EpisodeProgressFunc func(done, total int, message string)
+
+// generatedEpisode AI返回的单集剧本
+type generatedEpisode struct {
+	EpisodeNumber int    `json:"episode_number"`
+	Title         string `json:"title"`
+	Description   string `json:"description"`
+	ScriptContent string `json:"script_content"`
+	Duration      int    `json:"duration"`
+}
+
+// storyMemory 逐集生成过程中的前情记忆：更早剧集压缩为摘要，最近几集保留梗概；
+// 摘要保存在剧本元数据中，重新生成单集时复用，无需再次压缩
+type storyMemory struct {
+	Summary    string `json:"summary"`    // 已压缩的前情摘要
+	Summarized int    `json:"summarized"` // 摘要覆盖到的集数
+}
+
+// episodeContext 生成一批剧集共用的上下文
+type episodeContext struct {
+	drama      *models.Drama
+	outline    string
+	characters string
+	plans      map[int]EpisodeOutline
+}
+
+// GenerateEpisodesIncremental 按批次逐集生成剧本，每批的提示词携带大纲、角色设定、
+// 本批及后续分集规划与前情提要；已存在的同集数剧集会被覆盖，失败时保留已生成的剧集
+func (s *ScriptGenerationService) GenerateEpisodesIncremental(req *GenerateEpisodesIncrementalRequest, progress EpisodeProgressFunc) ([]models.Episode, error) {
+	start := req.StartEpisode
+	if start <= 0 {
+		start = 1
+	}
+	if start > req.EpisodeCount {
+		return nil, fmt.Errorf("start_episode must be less than or equal to episode_count")
+	}
+	batchSize := req.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	if batchSize > maxEpisodeBatchSize {
+		batchSize = maxEpisodeBatchSize
+	}
+
+	ctx, err := s.loadEpisodeContext(req.DramaID, req.Outline, req.Episodes)
+	if err != nil {
+		return nil, err
+	}
+
+	memory := loadStoryMemory(ctx.drama)
+	total := req.EpisodeCount - start + 1
+	var episodes []models.Episode
+	for from := start; from <= req.EpisodeCount; from += batchSize {
+		to := min(from+batchSize-1, req.EpisodeCount)
+		if progress != nil {
+			progress(from-start, total, fmt.Sprintf("正在生成第%d-%d集", from, to))
+		}
+
+		batch, err := s.generateEpisodeBatch(ctx, memory, from, to, "", req.Temperature)
+		if err != nil {
+			return episodes, fmt.Errorf("第%d集生成失败（已完成%d集）: %w", from, len(episodes), err)
+		}
+		episodes = append(episodes, batch...)
+	}
+
+	if progress != nil {
+		progress(total, total, "生成完成")
+	}
+	s.log.Infow("Episodes generated incrementally", "drama_id", req.DramaID, "from", start, "to", req.EpisodeCount, "batch_size", batchSize)
+	return episodes, nil
+}
+
+// RegenerateEpisode 重新生成单集剧本，前情取自之前各集已保存的梗概与前情摘要
+func (s *ScriptGenerationService) RegenerateEpisode(episodeID string, req *RegenerateEpisodeRequest) (*models.Episode, error) {
+	var episode models.Episode
+	if err := s.db.Where("id = ?", episodeID).First(&episode).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("episode not found")
+		}
+		return nil, err
+	}
+
+	ctx, err := s.loadEpisodeContext(strconv.FormatUint(uint64(episode.DramaID), 10), req.Outline, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	episodes, err := s.generateEpisodeBatch(ctx, loadStoryMemory(ctx.drama), episode.EpisodeNum, episode.EpisodeNum, req.Instructions, req.Temperature)
+	if err != nil {
+		return nil, fmt.Errorf("生成失败: %w", err)
+	}
+
+	s.log.Infow("Episode regenerated", "episode_id", episode.ID, "episode_number", episode.EpisodeNum)
+	return &episodes[0], nil
+}
+
+func (s *ScriptGenerationService) loadEpisodeContext(dramaID, outline string, plans []EpisodeOutline) (*episodeContext, error) {
+	var drama models.Drama
+	if err := s.db.Where("id = ?", dramaID).First(&drama).Error; err != nil {
+		return nil, fmt.Errorf("drama not found")
+	}
+
+	var characters []models.Character
+	if err := s.db.Where("drama_id = ?", drama.ID).Order("sort_order ASC, id ASC").Find(&characters).Error; err != nil {
+		return nil, err
+	}
+
+	if outline == "" {
+		outline = dramaOutline(&drama)
+	}
+
+	if len(plans) == 0 {
+		plans = loadOutlineEpisodes(&drama)
+	}
+	planByNumber := make(map[int]EpisodeOutline, len(plans))
+	for i, plan := range plans {
+		number := plan.EpisodeNumber
+		if number == 0 {
+			number = i + 1
+		}
+		planByNumber[number] = plan
+	}
+
+	return &episodeContext{
+		drama:      &drama,
+		outline:    outline,
+		characters: characterSheet(characters),
+		plans:      planByNumber,
+	}, nil
+}
+
+// generateEpisodeBatch 生成第 from 至 to 集并保存
+func (s *ScriptGenerationService) generateEpisodeBatch(ctx *episodeContext, memory *storyMemory, from, to int, instructions string, temperature float64) ([]models.Episode, error) {
+	recap, err := s.storySoFar(ctx.drama, memory, from)
+	if err != nil {
+		return nil, err
+	}
+
+	systemPrompt := s.prompts.Render(promptpkg.KeyEpisodesIncremental, ctx.drama.ID, nil)
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "剧本大纲：\n%s\n%s", ctx.outline, ctx.characters)
+	if recap != "" {
+		fmt.Fprintf(&prompt, "\n前情提要：\n%s\n", recap)
+	}
+	if plans := planLines(ctx.plans, from, to); plans != "" {
+		fmt.Fprintf(&prompt, "\n本次创作的分集规划：\n%s", plans)
+	}
+	// 后续两集的规划用于埋设伏笔
+	if plans := planLines(ctx.plans, to+1, to+2); plans != "" {
+		fmt.Fprintf(&prompt, "\n后续分集规划（仅供埋设伏笔参考，不要提前展开）：\n%s", plans)
+	}
+	if instructions != "" {
+		fmt.Fprintf(&prompt, "\n修改意见：\n%s\n", instructions)
+	}
+	if from == to {
+		fmt.Fprintf(&prompt, "\n请创作第%d集的详细剧本，episodes数组只包含第%d集。", from, from)
+	} else {
+		fmt.Fprintf(&prompt, "\n请创作第%d集到第%d集的详细剧本，episodes数组必须按顺序包含这 %d 集。", from, to, to-from+1)
+	}
+
+	if temperature == 0 {
+		temperature = 0.8
+	}
+	count := to - from + 1
+	text, err := s.aiService.GenerateText(
+		prompt.String(),
+		systemPrompt,
+		ai.WithTemperature(temperature),
+		ai.WithMaxTokens(2000+count*1200),
+	)
+	if err != nil {
+		s.log.Errorw("Failed to generate episode batch", "error", err, "from", from, "to", to)
+		return nil, err
+	}
+
+	var result struct {
+		Episodes []generatedEpisode `json:"episodes"`
+	}
+	if err := utils.SafeParseAIJSON(text, &result); err != nil {
+		s.log.Errorw("Failed to parse episodes JSON", "error", err, "raw_response", text[:minInt(500, len(text))])
+		return nil, fmt.Errorf("解析 AI 返回结果失败: %w", err)
+	}
+	if len(result.Episodes) < count {
+		return nil, fmt.Errorf("AI 返回 %d 集，少于要求的 %d 集", len(result.Episodes), count)
+	}
+
+	// 集数以请求为准，忽略AI返回的编号
+	var episodes []models.Episode
+	for i := 0; i < count; i++ {
+		episode, err := s.saveGeneratedEpisode(ctx.drama.ID, from+i, &result.Episodes[i])
+		if err != nil {
+			return nil, err
+		}
+		episodes = append(episodes, *episode)
+	}
+
+	// 保存的摘要若覆盖了本批剧集，内容已过时
+	if saved := loadStoryMemory(ctx.drama); saved.Summarized >= from {
+		if err := s.saveStoryMemory(ctx.drama, &storyMemory{}); err != nil {
+			s.log.Warnw("Failed to reset story memory", "error", err, "drama_id", ctx.drama.ID)
+		}
+	}
+	return episodes, nil
+}
+
+// saveGeneratedEpisode 保存生成的剧集，同集数已存在时覆盖标题、梗概与剧本
+func (s *ScriptGenerationService) saveGeneratedEpisode(dramaID uint, number int, generated *generatedEpisode) (*models.Episode, error) {
+	duration := generated.Duration
+	if duration == 0 {
+		duration = 180
+	}
+	if strings.TrimSpace(generated.ScriptContent) == "" {
+		return nil, fmt.Errorf("第%d集剧本内容为空", number)
+	}
+
+	var episode models.Episode
+	err := s.db.Where("drama_id = ? AND episode_number = ?", dramaID, number).First(&episode).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	episode.DramaID = dramaID
+	episode.EpisodeNum = number
+	episode.Title = generated.Title
+	episode.Description = &generated.Description
+	episode.ScriptContent = &generated.ScriptContent
+	episode.Duration = duration
+	if episode.ID == 0 {
+		episode.Status = "draft"
+		err = s.db.Create(&episode).Error
+	} else {
+		err = s.db.Model(&episode).Updates(map[string]interface{}{
+			"title":          episode.Title,
+			"description":    generated.Description,
+			"script_content": generated.ScriptContent,
+			"duration":       duration,
+		}).Error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save episode %d: %w", number, err)
+	}
+	return &episode, nil
+}
+
+// storySoFar 第 number 集之前的前情提要：最近几集保留梗概，更早的剧集压缩为摘要，
+// 摘要随生成进度滚动累积，避免长剧集的提示词无限增长
+func (s *ScriptGenerationService) storySoFar(drama *models.Drama, memory *storyMemory, number int) (string, error) {
+	var prior []models.Episode
+	if err := s.db.Select("id", "episode_number", "title", "description", "script_content").
+		Where("drama_id = ? AND episode_number < ?", drama.ID, number).
+		Order("episode_number ASC").Find(&prior).Error; err != nil {
+		return "", err
+	}
+	if len(prior) == 0 {
+		return "", nil
+	}
+
+	recentFrom := max(len(prior)-recentEpisodeRecaps, 0)
+	recent, older := prior[recentFrom:], prior[:recentFrom]
+
+	// 摘要覆盖了最近几集或之后的剧集时不能使用，重新压缩
+	if len(older) == 0 || memory.Summarized > older[len(older)-1].EpisodeNum {
+		*memory = storyMemory{}
+	}
+
+	// 摘要只覆盖到 older 的末尾，摘要之后新变为“更早”的剧集梗概追加在摘要后
+	var pending []string
+	for _, ep := range older {
+		if ep.EpisodeNum > memory.Summarized {
+			pending = append(pending, episodeRecap(&ep))
+		}
+	}
+	if olderText := strings.TrimSpace(memory.Summary + "\n" + strings.Join(pending, "\n")); len([]rune(olderText)) > storySummaryThreshold {
+		summary, err := s.summarizeStory(drama.ID, olderText)
+		if err != nil {
+			return "", err
+		}
+		memory.Summary = summary
+		memory.Summarized = older[len(older)-1].EpisodeNum
+		pending = nil
+		if err := s.saveStoryMemory(drama, memory); err != nil {
+			s.log.Warnw("Failed to save story memory", "error", err, "drama_id", drama.ID)
+		}
+	}
+
+	var recap strings.Builder
+	if memory.Summary != "" {
+		fmt.Fprintf(&recap, "【第1-%d集摘要】%s\n", memory.Summarized, memory.Summary)
+	}
+	for _, line := range pending {
+		recap.WriteString(line + "\n")
+	}
+	for _, ep := range recent {
+		recap.WriteString(episodeRecap(&ep) + "\n")
+	}
+	return strings.TrimSpace(recap.String()), nil
+}
+
+// summarizeStory 将多集梗概压缩为一段前情摘要
+func (s *ScriptGenerationService) summarizeStory(dramaID uint, text string) (string, error) {
+	systemPrompt := s.prompts.Render(promptpkg.KeyStorySummary, dramaID, nil)
+
+	summary, err := s.aiService.GenerateText(text, systemPrompt, ai.WithTemperature(0.3), ai.WithMaxTokens(1200))
+	if err != nil {
+		s.log.Errorw("Failed to summarize story so far", "error", err)
+		return "", fmt.Errorf("前情摘要生成失败: %w", err)
+	}
+	return strings.TrimSpace(summary), nil
+}
+
+// episodeRecap 单集梗概，未保存梗概时取剧本开头
+func episodeRecap(ep *models.Episode) string {
+	recap := ""
+	if ep.Description != nil {
+		recap = strings.TrimSpace(*ep.Description)
+	}
+	if recap == "" && ep.ScriptContent != nil {
+		recap = truncateRunes(*ep.ScriptContent, 150)
+	}
+	return fmt.Sprintf("第%d集《%s》：%s", ep.EpisodeNum, ep.Title, recap)
+}
+
+// planLines 指定集数范围内的分集规划
+func planLines(plans map[int]EpisodeOutline, from, to int) string {
+	var lines strings.Builder
+	for number := from; number <= to; number++ {
+		if plan, ok := plans[number]; ok {
+			fmt.Fprintf(&lines, "第%d集《%s》：%s\n", number, plan.Title, plan.Summary)
+		}
+	}
+	return lines.String()
+}
+
+// saveOutlineEpisodes 将分集规划保存到剧本元数据，保留元数据中的其他字段
+func (s *ScriptGenerationService) saveOutlineEpisodes(drama *models.Drama, episodes []EpisodeOutline) error {
+	if len(episodes) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(episodes, func(i, j int) bool { return episodes[i].EpisodeNumber < episodes[j].EpisodeNumber })
+	return s.saveMetadataField(drama, outlineEpisodesKey, episodes)
+}
+
+// saveStoryMemory 将前情摘要保存到剧本元数据
+func (s *ScriptGenerationService) saveStoryMemory(drama *models.Drama, memory *storyMemory) error {
+	return s.saveMetadataField(drama, storyMemoryKey, memory)
+}
+
+// saveMetadataField 更新剧本元数据中的单个字段，保留其他字段
+func (s *ScriptGenerationService) saveMetadataField(drama *models.Drama, key string, value interface{}) error {
+	metadata := make(map[string]interface{})
+	if drama.Metadata != nil {
+		if err := json.Unmarshal(drama.Metadata, &metadata); err != nil {
+			s.log.Warnw("Failed to unmarshal existing metadata", "error", err)
+		}
+	}
+	metadata[key] = value
+
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Model(drama).Update("metadata", metadataJSON).Error; err != nil {
+		return err
+	}
+	drama.Metadata = metadataJSON
+	return nil
+}
+
+// loadOutlineEpisodes 读取生成大纲时保存的分集规划
+func loadOutlineEpisodes(drama *models.Drama) []EpisodeOutline {
+	if drama.Metadata == nil {
+		return nil
+	}
+	var metadata struct {
+		Episodes []EpisodeOutline `json:"outline_episodes"`
+	}
+	if err := json.Unmarshal(drama.Metadata, &metadata); err != nil {
+		return nil
+	}
+	return metadata.Episodes
+}
+
+// loadStoryMemory 读取保存的前情摘要，没有时返回空记忆
+func loadStoryMemory(drama *models.Drama) *storyMemory {
+	memory := &storyMemory{}
+	if drama.Metadata == nil {
+		return memory
+	}
+	var metadata struct {
+		Memory *storyMemory `json:"story_memory"`
+	}
+	if err := json.Unmarshal(drama.Metadata, &metadata); err != nil || metadata.Memory == nil {
+		return memory
+	}
+	return metadata.Memory
+}
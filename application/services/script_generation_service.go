@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strconv"
@@ -8,6 +9,7 @@ import (
 	"github.com/drama-generator/backend/domain/models"
 	"github.com/drama-generator/backend/pkg/ai"
 	"github.com/drama-generator/backend/pkg/logger"
+	promptpkg "github.com/drama-generator/backend/pkg/prompt"
 	"github.com/drama-generator/backend/pkg/utils"
 	"gorm.io/gorm"
 )
@@ -15,6 +17,7 @@ import (
 type ScriptGenerationService struct {
 	db        *gorm.DB
 	aiService *AIService
+	prompts   *PromptTemplateService
 	log       *logger.Logger
 }
 
@@ -22,6 +25,7 @@ func NewScriptGenerationService(db *gorm.DB, log *logger.Logger) *ScriptGenerati
 	return &ScriptGenerationService{
 		db:        db,
 		aiService: NewAIService(db, log),
+		prompts:   NewPromptTemplateService(db, log),
 		log:       log,
 	}
 }
@@ -75,30 +79,32 @@ type EpisodeOutline struct {
 	Duration      int      `json:"duration"`
 }
 
+// textGenerator 文本生成方式：一次性返回或流式返回，解析与保存逻辑共用
+type textGenerator func(prompt string, systemPrompt string, options ...func(*ai.ChatCompletionRequest)) (string, error)
+
+// streamGenerator 流式生成，增量文本交给 onDelta
+func (s *ScriptGenerationService) streamGenerator(ctx context.Context, onDelta ai.StreamHandler) textGenerator {
+	return func(prompt string, systemPrompt string, options ...func(*ai.ChatCompletionRequest)) (string, error) {
+		return s.aiService.GenerateTextStream(ctx, prompt, systemPrompt, onDelta, options...)
+	}
+}
+
 func (s *ScriptGenerationService) GenerateOutline(req *GenerateOutlineRequest) (*OutlineResult, error) {
+	return s.generateOutline(req, s.aiService.GenerateText)
+}
+
+// GenerateOutlineStream 流式生成大纲，生成过程中推送增量文本，结束后解析并保存
+func (s *ScriptGenerationService) GenerateOutlineStream(ctx context.Context, req *GenerateOutlineRequest, onDelta ai.StreamHandler) (*OutlineResult, error) {
+	return s.generateOutline(req, s.streamGenerator(ctx, onDelta))
+}
+
+func (s *ScriptGenerationService) generateOutline(req *GenerateOutlineRequest, generate textGenerator) (*OutlineResult, error) {
 	var drama models.Drama
 	if err := s.db.Where("id = ?", req.DramaID).First(&drama).Error; err != nil {
 		return nil, fmt.Errorf("drama not found")
 	}
 
-	systemPrompt := `你是专业短剧编剧。根据主题和剧集数量，创作完整的短剧大纲，规划好每一集的剧情走向。
-
-要求：
-1. 剧情紧凑，矛盾冲突强烈，节奏快
-2. 必须规划好每一集的核心剧情
-3. 每集有明确冲突和转折点，集与集之间有连贯性和悬念
-
-**重要：必须输出完整有效的JSON，确保所有字段完整，特别是episodes数组必须完整闭合！**
-
-JSON格式（紧凑，summary和episodes字段必须完整）：
-{"title":"剧名","summary":"200-250字剧情概述，包含故事背景、主要矛盾、核心冲突、完整走向","genre":"类型","tags":["标签1","标签2","标签3"],"episodes":[{"episode_number":1,"title":"标题","summary":"80字剧情概要"},{"episode_number":2,"title":"标题","summary":"80字剧情概要"}],"key_scenes":["场景1","场景2","场景3"]}
-
-关键要求：
-- summary控制在200-250字，简洁清晰
-- episodes必须生成用户要求的完整集数
-- 每集summary控制在80字左右
-- 确保JSON完整闭合，不要截断
-- 不要添加任何JSON外的文字说明`
+	systemPrompt := s.prompts.Render(promptpkg.KeyOutline, drama.ID, nil)
 
 	userPrompt := fmt.Sprintf(`请为以下主题创作短剧大纲：
 
@@ -134,7 +140,7 @@ JSON格式（紧凑，summary和episodes字段必须完整）：
 		"episode_count", length,
 		"max_tokens", maxTokens)
 
-	text, err := s.aiService.GenerateText(
+	text, err := generate(
 		userPrompt,
 		systemPrompt,
 		ai.WithTemperature(temperature),
@@ -170,6 +176,11 @@ JSON格式（紧凑，summary和episodes字段必须完整）：
 		s.log.Errorw("Failed to update drama", "error", err)
 	}
 
+	// 分集规划保存到元数据，逐集生成剧本时作为每集的写作依据
+	if err := s.saveOutlineEpisodes(&drama, result.Episodes); err != nil {
+		s.log.Warnw("Failed to save outline episodes", "error", err, "drama_id", req.DramaID)
+	}
+
 	s.log.Infow("Outline generated", "drama_id", req.DramaID)
 	return &result, nil
 }
@@ -185,43 +196,11 @@ func (s *ScriptGenerationService) GenerateCharacters(req *GenerateCharactersRequ
 		count = 5
 	}
 
-	systemPrompt := `你是一个专业的角色分析师，擅长从剧本中提取和分析角色信息。
-
-你的任务是根据提供的剧本内容，提取并整理剧中出现的所有角色的详细设定。
-
-要求：
-1. 仔细阅读剧本，识别所有出现的角色
-2. 根据剧本中的对话、行为和描述，总结角色的性格特点
-3. 提取角色在剧本中的关键信息：背景、动机、目标、关系等
-4. 角色之间的关系必须基于剧本中的实际描述
-5. 外貌描述必须极其详细，如果剧本中有描述则使用，如果没有则根据角色设定合理推断，便于AI绘画生成角色形象
-6. 优先提取主要角色和重要配角，次要角色可以简略
-
-请严格按照以下 JSON 格式输出，不要添加任何其他文字：
-
-{
-  "characters": [
-    {
-      "name": "角色名",
-      "role": "主角/重要配角/配角",
-      "description": "角色背景和简介（200-300字，包括：出身背景、成长经历、核心动机、与其他角色的关系、在故事中的作用）",
-      "personality": "性格特点（详细描述，100-150字，包括：主要性格特征、行为习惯、价值观、优点缺点、情绪表达方式、对待他人的态度等）",
-      "appearance": "外貌描述（极其详细，150-200字，必须包括：确切年龄、精确身高、体型身材、肤色质感、发型发色发长、眼睛颜色形状、面部特征（如眉毛、鼻子、嘴唇）、着装风格、服装颜色材质、配饰细节、标志性特征、整体气质风格等，描述要具体到可以直接用于AI绘画）",
-      "voice_style": "说话风格和语气特点（详细描述，50-80字，包括：语速语调、用词习惯、口头禅、说话时的情绪特征等）"
-    }
-  ]
-}
-
-注意：
-- 必须基于剧本内容提取角色，不要凭空创作
-- 优先提取主要角色和重要配角，数量根据剧本实际情况确定
-- description、personality、appearance、voice_style都必须详细描述，字数要充足
-- appearance外貌描述是重中之重，必须极其详细具体，要能让AI准确生成角色形象
-- 如果剧本中角色信息不完整，可以根据角色设定合理补充，但要符合剧本整体风格`
+	systemPrompt := s.prompts.Render(promptpkg.KeyCharacters, drama.ID, nil)
 
 	outlineText := req.Outline
 	if outlineText == "" {
-		outlineText = fmt.Sprintf("剧名：%s\n简介：%s\n类型：%s", drama.Title, drama.Description, drama.Genre)
+		outlineText = dramaOutline(&drama)
 	}
 
 	userPrompt := fmt.Sprintf(`剧本内容：
@@ -301,6 +280,15 @@ func (s *ScriptGenerationService) GenerateCharacters(req *GenerateCharactersRequ
 }
 
 func (s *ScriptGenerationService) GenerateEpisodes(req *GenerateEpisodesRequest) ([]models.Episode, error) {
+	return s.generateEpisodes(req, s.aiService.GenerateText)
+}
+
+// GenerateEpisodesStream 流式生成分集剧本，生成过程中推送增量文本，结束后解析并保存
+func (s *ScriptGenerationService) GenerateEpisodesStream(ctx context.Context, req *GenerateEpisodesRequest, onDelta ai.StreamHandler) ([]models.Episode, error) {
+	return s.generateEpisodes(req, s.streamGenerator(ctx, onDelta))
+}
+
+func (s *ScriptGenerationService) generateEpisodes(req *GenerateEpisodesRequest, generate textGenerator) ([]models.Episode, error) {
 	var drama models.Drama
 	if err := s.db.Where("id = ? ", req.DramaID).First(&drama).Error; err != nil {
 		return nil, fmt.Errorf("drama not found")
@@ -310,64 +298,13 @@ func (s *ScriptGenerationService) GenerateEpisodes(req *GenerateEpisodesRequest)
 	var characters []models.Character
 	s.db.Where("drama_id = ?", req.DramaID).Find(&characters)
 
-	var characterList string
-	if len(characters) > 0 {
-		characterList = "\n角色设定：\n"
-		for _, char := range characters {
-			characterList += fmt.Sprintf("- %s", char.Name)
-			if char.Role != nil {
-				characterList += fmt.Sprintf("（%s）", *char.Role)
-			}
-			if char.Description != nil {
-				characterList += fmt.Sprintf("：%s", *char.Description)
-			}
-			if char.Personality != nil {
-				characterList += fmt.Sprintf(" | 性格：%s", *char.Personality)
-			}
-			characterList += "\n"
-		}
-	} else {
-		characterList = "\n（注意：尚未设定角色，请根据大纲创作合理的角色出场）\n"
-	}
-
-	systemPrompt := `你是一个专业的短剧编剧。你擅长根据分集规划创作详细的剧情内容。
-
-你的任务是根据大纲中的分集规划，将每一集的概要扩展为详细的剧情叙述。每集约180秒（3分钟），需要充实的内容。
+	characterList := characterSheet(characters)
 
-工作流程：
-1. 大纲中已提供每集的剧情规划（80-100字概要）
-2. 你需要将每集概要扩展为400-500字的详细剧情叙述
-3. 严格按照分集规划的数量和走向展开，不能遗漏任何一集
-
-详细要求：
-1. script_content用400-500字详细叙述，包括：
-   - 具体场景和环境描写
-   - 角色的行动、对话要点、情绪变化
-   - 冲突的产生过程和激化细节
-   - 关键情节点和转折
-   - 为下一集埋下的伏笔
-2. 每集有明确的冲突和转折点
-3. 集与集之间有连贯性和悬念
-4. 充分展现角色性格和关系演变
-5. 内容详实，足以支撑180秒时长
-
-JSON格式（紧凑）：
-{"episodes":[{"episode_number":1,"title":"标题","description":"简短梗概","script_content":"400-500字详细剧情叙述","duration":210}]}
-
-格式说明：
-1. script_content为叙述文，不是场景对话格式
-2. 每集包含开场铺垫、冲突发展、高潮转折、结局悬念
-3. duration根据剧情复杂度设置在150-300秒
-
-关键要求：
-- 大纲规划了几集就必须生成几集
-- 严格按照分集规划的故事线展开
-- 每一集都要有完整的400-500字详细内容
-- 绝对不能遗漏任何一集`
+	systemPrompt := s.prompts.Render(promptpkg.KeyEpisodes, drama.ID, nil)
 
 	outlineText := req.Outline
 	if outlineText == "" {
-		outlineText = fmt.Sprintf("剧名：%s\n简介：%s\n类型：%s", drama.Title, drama.Description, drama.Genre)
+		outlineText = dramaOutline(&drama)
 	}
 
 	userPrompt := fmt.Sprintf(`剧本大纲：
@@ -403,7 +340,7 @@ JSON格式（紧凑）：
 		"max_tokens", maxTokens,
 		"estimated_per_episode", perEpisodeTokens)
 
-	text, err := s.aiService.GenerateText(
+	text, err := generate(
 		userPrompt,
 		systemPrompt,
 		ai.WithTemperature(0.8),
@@ -499,6 +436,29 @@ JSON格式（紧凑）：
 	return episodes, nil
 }
 
+// characterSheet 剧本生成提示词中的角色设定
+func characterSheet(characters []models.Character) string {
+	if len(characters) == 0 {
+		return "\n（注意：尚未设定角色，请根据大纲创作合理的角色出场）\n"
+	}
+
+	characterList := "\n角色设定：\n"
+	for _, char := range characters {
+		characterList += fmt.Sprintf("- %s", char.Name)
+		if char.Role != nil {
+			characterList += fmt.Sprintf("（%s）", *char.Role)
+		}
+		if char.Description != nil {
+			characterList += fmt.Sprintf("：%s", *char.Description)
+		}
+		if char.Personality != nil {
+			characterList += fmt.Sprintf(" | 性格：%s", *char.Personality)
+		}
+		characterList += "\n"
+	}
+	return characterList
+}
+
 // GenerateScenesForEpisode 已废弃，使用 StoryboardService.GenerateStoryboard 替代
 // ParseScript 已废弃，使用 GenerateCharacters 替代
 
@@ -509,3 +469,15 @@ func minInt(a, b int) int {
 	}
 	return b
 }
+
+// dramaOutline 未提供大纲时以剧名、简介与类型作为大纲
+func dramaOutline(drama *models.Drama) string {
+	outline := "剧名：" + drama.Title
+	if drama.Description != nil {
+		outline += "\n简介：" + *drama.Description
+	}
+	if drama.Genre != nil {
+		outline += "\n类型：" + *drama.Genre
+	}
+	return outline
+}
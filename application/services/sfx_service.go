@@ -0,0 +1,575 @@
+package services
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	models "github.com/drama-generator/backend/domain/models"
+	"github.com/drama-generator/backend/infrastructure/external/ffmpeg"
+	"github.com/drama-generator/backend/infrastructure/storage"
+	"github.com/drama-generator/backend/pkg/logger"
+	"github.com/drama-generator/backend/pkg/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultSFXVolume 音效相对原声的默认音量
+	DefaultSFXVolume = 0.8
+	// sfxFadeOut 音效长于镜头时在镜头结束处淡出（秒）
+	sfxFadeOut = 0.3
+	// sfxCategory 音效素材的分类，也是音效目录在存储中的位置
+	sfxCategory = "sfx"
+	// maxSFXCandidates 交给AI排序的候选数量上限
+	maxSFXCandidates = 10
+)
+
+// sfxExtensions 导入目录时识别的音频格式
+var sfxExtensions = map[string]bool{
+	".wav": true, ".mp3": true, ".ogg": true, ".flac": true, ".m4a": true, ".aac": true,
+}
+
+// SFXService 音效库：导入与标注授权音效，按分镜 SoundEffect 描述匹配音效，
+// 并在合成时将选定音效放在对应镜头开始处
+type SFXService struct {
+	db              *gorm.DB
+	aiService       *AIService
+	mediaService    *MediaService
+	timelineService *TimelineService
+	localStorage    *storage.LocalStorage
+	ffmpeg          *ffmpeg.FFmpeg
+	log             *logger.Logger
+}
+
+func NewSFXService(db *gorm.DB, localStorage *storage.LocalStorage, log *logger.Logger) *SFXService {
+	return &SFXService{
+		db:              db,
+		aiService:       NewAIService(db, log),
+		mediaService:    NewMediaService(db, localStorage, log),
+		timelineService: NewTimelineService(db, log),
+		localStorage:    localStorage,
+		ffmpeg:          ffmpeg.NewFFmpeg(log),
+		log:             log,
+	}
+}
+
+// CreateSFXRequest 将已上传的音频素材加入音效库
+type CreateSFXRequest struct {
+	AssetID  uint     `json:"asset_id" binding:"required"`
+	Name     string   `json:"name"` // 为空时使用素材名称
+	Category string   `json:"category"`
+	Keywords []string `json:"keywords"`
+}
+
+type UpdateSFXRequest struct {
+	Name     *string  `json:"name"`
+	Category *string  `json:"category"`
+	Keywords []string `json:"keywords"`
+}
+
+// MatchSFXRequest 剧集音效匹配参数
+type MatchSFXRequest struct {
+	UseAI     bool `json:"use_ai"`    // 关键词候选交由文本模型按描述重新排序，逐个分镜调用模型，以异步任务执行
+	Overwrite bool `json:"overwrite"` // 重新匹配已选定音效的分镜
+}
+
+// SFXCandidate 音效匹配候选
+type SFXCandidate struct {
+	SFX   *models.SFX `json:"sfx"`
+	Score int         `json:"score"`
+}
+
+// SFXMatch 分镜音效匹配结果
+type SFXMatch struct {
+	StoryboardID     uint           `json:"storyboard_id"`
+	StoryboardNumber int            `json:"storyboard_number"`
+	Description      string         `json:"description"`
+	Candidates       []SFXCandidate `json:"candidates"`
+	SelectedID       *uint          `json:"selected_id,omitempty"`
+	Kept             bool           `json:"kept"` // 已有选定音效，未重新匹配
+}
+
+// SFXScanResult 音效目录导入结果
+type SFXScanResult struct {
+	Added   int `json:"added"`
+	Skipped int `json:"skipped"` // 已在音效库中的文件
+}
+
+// ScanLibrary 导入音效目录中的音频文件：子目录名作为分类，目录名与文件名拆分为关键词；
+// 已导入的文件按访问地址去重，可重复执行
+func (s *SFXService) ScanLibrary(dir string) (*SFXScanResult, error) {
+	if s.localStorage == nil {
+		return nil, fmt.Errorf("storage is not available")
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("sfx directory not found: %s", dir)
+	}
+
+	result := &SFXScanResult{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !sfxExtensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		url := s.localStorage.GetURL(sfxCategory + "/" + rel)
+
+		var count int64
+		s.db.Model(&models.Asset{}).Where("url = ?", url).Count(&count)
+		if count > 0 {
+			result.Skipped++
+			return nil
+		}
+
+		name := strings.TrimSuffix(filepath.Base(rel), filepath.Ext(rel))
+		category := ""
+		if dirName := filepath.Dir(rel); dirName != "." {
+			category = dirName
+		}
+		keywords := splitSFXKeywords(category + " " + name)
+
+		if _, err := s.createSFX(url, path, name, category, keywords); err != nil {
+			return err
+		}
+		result.Added++
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan sfx directory: %w", err)
+	}
+
+	s.log.Infow("SFX library scanned", "dir", dir, "added", result.Added, "skipped", result.Skipped)
+	return result, nil
+}
+
+func (s *SFXService) createSFX(url, localPath, name, category string, keywords []string) (*models.SFX, error) {
+	assetCategory := sfxCategory
+	asset := models.Asset{
+		Name:     name,
+		Type:     models.AssetTypeAudio,
+		Category: &assetCategory,
+		URL:      url,
+	}
+	if localPath != "" {
+		asset.LocalPath = &localPath
+	}
+	if err := s.db.Create(&asset).Error; err != nil {
+		return nil, fmt.Errorf("failed to create asset: %w", err)
+	}
+
+	sfx := &models.SFX{
+		AssetID:  asset.ID,
+		Name:     name,
+		Category: category,
+		Keywords: joinTags(keywords),
+	}
+	if meta, err := s.mediaService.ProbeMedia(url, models.AssetTypeAudio); err == nil && meta.Duration > 0 {
+		sfx.Duration = &meta.Duration
+	}
+	if err := s.db.Create(sfx).Error; err != nil {
+		return nil, fmt.Errorf("failed to create sfx: %w", err)
+	}
+	go s.mediaService.ProcessAsset(asset.ID)
+	return sfx, nil
+}
+
+func (s *SFXService) CreateSFX(req *CreateSFXRequest) (*models.SFX, error) {
+	var asset models.Asset
+	if err := s.db.First(&asset, req.AssetID).Error; err != nil {
+		return nil, fmt.Errorf("asset not found")
+	}
+	if asset.Type != models.AssetTypeAudio {
+		return nil, fmt.Errorf("asset must be audio")
+	}
+
+	name := req.Name
+	if name == "" {
+		name = asset.Name
+	}
+	keywords := req.Keywords
+	if len(keywords) == 0 {
+		keywords = splitSFXKeywords(req.Category + " " + name)
+	}
+	sfx := &models.SFX{
+		AssetID:  asset.ID,
+		Name:     name,
+		Category: req.Category,
+		Keywords: joinTags(keywords),
+	}
+	if asset.Duration != nil {
+		duration := float64(*asset.Duration)
+		sfx.Duration = &duration
+	}
+	if err := s.db.Create(sfx).Error; err != nil {
+		return nil, fmt.Errorf("failed to create sfx: %w", err)
+	}
+	return sfx, nil
+}
+
+func (s *SFXService) UpdateSFX(sfxID uint, req *UpdateSFXRequest) (*models.SFX, error) {
+	var sfx models.SFX
+	if err := s.db.First(&sfx, sfxID).Error; err != nil {
+		return nil, fmt.Errorf("sfx not found")
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Category != nil {
+		updates["category"] = *req.Category
+	}
+	if req.Keywords != nil {
+		updates["keywords"] = joinTags(req.Keywords)
+	}
+	if len(updates) > 0 {
+		if err := s.db.Model(&sfx).Updates(updates).Error; err != nil {
+			return nil, fmt.Errorf("failed to update sfx: %w", err)
+		}
+	}
+	return s.GetSFX(sfxID)
+}
+
+// DeleteSFX 从音效库移除音效并解除分镜上的选择，音频素材保留
+func (s *SFXService) DeleteSFX(sfxID uint) error {
+	result := s.db.Delete(&models.SFX{}, sfxID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("sfx not found")
+	}
+	s.db.Model(&models.Storyboard{}).Where("sfx_id = ?", sfxID).Update("sfx_id", nil)
+	return nil
+}
+
+func (s *SFXService) GetSFX(sfxID uint) (*models.SFX, error) {
+	var sfx models.SFX
+	if err := s.db.Preload("Asset").First(&sfx, sfxID).Error; err != nil {
+		return nil, err
+	}
+	return &sfx, nil
+}
+
+func (s *SFXService) ListSFX(category, search string, page, pageSize int) ([]models.SFX, int64, error) {
+	query := s.db.Model(&models.SFX{})
+
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+	if search != "" {
+		like := "%" + search + "%"
+		query = query.Where("name LIKE ? OR keywords LIKE ?", like, like)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var effects []models.SFX
+	offset := (page - 1) * pageSize
+	if err := query.Preload("Asset").Order("category ASC, name ASC").Offset(offset).Limit(pageSize).Find(&effects).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return effects, total, nil
+}
+
+// AssignSFX 手动为分镜选定音效，sfxID 为0时清除
+func (s *SFXService) AssignSFX(storyboardID string, sfxID uint) (*models.Storyboard, error) {
+	var storyboard models.Storyboard
+	if err := s.db.Where("id = ?", storyboardID).First(&storyboard).Error; err != nil {
+		return nil, fmt.Errorf("storyboard not found")
+	}
+
+	var value interface{}
+	if sfxID != 0 {
+		var sfx models.SFX
+		if err := s.db.Select("id").First(&sfx, sfxID).Error; err != nil {
+			return nil, fmt.Errorf("sfx not found")
+		}
+		value = sfxID
+	}
+	if err := s.db.Model(&storyboard).Update("sfx_id", value).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.First(&storyboard, storyboard.ID).Error; err != nil {
+		return nil, err
+	}
+	return &storyboard, nil
+}
+
+// MatchEpisode 为剧集中有音效描述的分镜匹配音效并保存首选结果；已选定音效的分镜默认保留
+// onProgress 不为nil时每处理完一个分镜回调一次
+func (s *SFXService) MatchEpisode(episodeID string, req *MatchSFXRequest, onProgress func(done, total int)) ([]SFXMatch, error) {
+	var episode models.Episode
+	if err := s.db.Where("id = ?", episodeID).First(&episode).Error; err != nil {
+		return nil, fmt.Errorf("episode not found")
+	}
+
+	var storyboards []models.Storyboard
+	if err := s.db.Select("id", "storyboard_number", "sound_effect", "sfx_id").
+		Where("episode_id = ? AND sound_effect IS NOT NULL AND sound_effect <> ''", episode.ID).
+		Order("storyboard_number ASC").Find(&storyboards).Error; err != nil {
+		return nil, err
+	}
+
+	library, err := s.loadLibrary()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []SFXMatch
+	for i, sb := range storyboards {
+		if onProgress != nil && i > 0 {
+			onProgress(i, len(storyboards))
+		}
+		match := SFXMatch{
+			StoryboardID:     sb.ID,
+			StoryboardNumber: sb.StoryboardNumber,
+			Description:      *sb.SoundEffect,
+		}
+		if sb.SFXID != nil && !req.Overwrite {
+			match.SelectedID = sb.SFXID
+			match.Kept = true
+			matches = append(matches, match)
+			continue
+		}
+
+		match.Candidates = matchSFX(library, match.Description)
+		if req.UseAI && len(match.Candidates) > 1 {
+			match.Candidates = s.rankWithAI(match.Description, match.Candidates)
+		}
+
+		var selected interface{}
+		if len(match.Candidates) > 0 {
+			match.SelectedID = &match.Candidates[0].SFX.ID
+			selected = *match.SelectedID
+		}
+		s.db.Model(&models.Storyboard{}).Where("id = ?", sb.ID).Update("sfx_id", selected)
+		matches = append(matches, match)
+	}
+
+	s.log.Infow("SFX matched for episode", "episode_id", episodeID, "storyboards", len(matches), "use_ai", req.UseAI)
+	return matches, nil
+}
+
+// MatchDescription 按描述检索音效候选，供分镜编辑时预览
+func (s *SFXService) MatchDescription(description string, useAI bool) ([]SFXCandidate, error) {
+	library, err := s.loadLibrary()
+	if err != nil {
+		return nil, err
+	}
+	candidates := matchSFX(library, description)
+	if useAI && len(candidates) > 1 {
+		candidates = s.rankWithAI(description, candidates)
+	}
+	return candidates, nil
+}
+
+func (s *SFXService) loadLibrary() ([]models.SFX, error) {
+	var library []models.SFX
+	if err := s.db.Preload("Asset").Order("id ASC").Find(&library).Error; err != nil {
+		return nil, err
+	}
+	return library, nil
+}
+
+// rankWithAI 由文本模型从关键词候选中按描述挑选并排序；模型调用或解析失败时保留关键词排序
+func (s *SFXService) rankWithAI(description string, candidates []SFXCandidate) []SFXCandidate {
+	if len(candidates) > maxSFXCandidates {
+		candidates = candidates[:maxSFXCandidates]
+	}
+
+	var list strings.Builder
+	for _, c := range candidates {
+		keywords := ""
+		if c.SFX.Keywords != nil {
+			keywords = *c.SFX.Keywords
+		}
+		fmt.Fprintf(&list, "- id: %d，名称：%s，分类：%s，关键词：%s\n", c.SFX.ID, c.SFX.Name, c.SFX.Category, keywords)
+	}
+	prompt := fmt.Sprintf(`你是影视音效剪辑师。请根据镜头的音效描述，从候选音效中挑选合适的音效，按匹配程度从高到低排序，不合适的不要列出。
+
+音效描述：%s
+
+候选音效：
+%s
+请严格按照JSON格式输出：{"ids": [候选id, ...]}`, description, list.String())
+
+	text, err := s.aiService.GenerateText(prompt, "")
+	if err != nil {
+		s.log.Warnw("AI sfx ranking failed, using keyword ranking", "error", err)
+		return candidates
+	}
+	var result struct {
+		IDs []uint `json:"ids"`
+	}
+	if err := utils.SafeParseAIJSON(text, &result); err != nil {
+		s.log.Warnw("Failed to parse AI sfx ranking, using keyword ranking", "error", err)
+		return candidates
+	}
+
+	byID := make(map[uint]SFXCandidate, len(candidates))
+	for _, c := range candidates {
+		byID[c.SFX.ID] = c
+	}
+	ranked := make([]SFXCandidate, 0, len(result.IDs))
+	for _, id := range result.IDs {
+		if c, ok := byID[id]; ok {
+			ranked = append(ranked, c)
+			delete(byID, id)
+		}
+	}
+	if len(ranked) == 0 {
+		s.log.Warnw("AI sfx ranking returned no known candidates, using keyword ranking", "description", description)
+		return candidates
+	}
+	return ranked
+}
+
+// SFXLayers 计算合成任务的音效音频层：每个镜头的选定音效（未选定时取关键词首选）从镜头开始处播放，
+// 长于镜头的部分在镜头结束处淡出；时间线已有音频轨道时以时间线为准
+func (s *SFXService) SFXLayers(videoMerge *models.VideoMerge, resolvePath func(string) string) ([]ffmpeg.AudioLayer, error) {
+	if videoMerge.TimelineID != nil && timelineHasAudio(s.db, *videoMerge.TimelineID) {
+		return nil, nil
+	}
+
+	spans, err := mergeStoryboardSpans(s.db, s.ffmpeg, s.timelineService, videoMerge)
+	if err != nil {
+		return nil, err
+	}
+	if len(spans) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uint, len(spans))
+	for i, span := range spans {
+		ids[i] = span.StoryboardID
+	}
+	var storyboards []models.Storyboard
+	s.db.Select("id", "sound_effect", "sfx_id").Where("id IN ?", ids).Find(&storyboards)
+	byStoryboard := make(map[uint]models.Storyboard, len(storyboards))
+	for _, sb := range storyboards {
+		byStoryboard[sb.ID] = sb
+	}
+
+	library, err := s.loadLibrary()
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.SFX, len(library))
+	for i := range library {
+		byID[library[i].ID] = &library[i]
+	}
+
+	var layers []ffmpeg.AudioLayer
+	for _, span := range spans {
+		sb := byStoryboard[span.StoryboardID]
+		var sfx *models.SFX
+		if sb.SFXID != nil {
+			sfx = byID[*sb.SFXID]
+		} else if sb.SoundEffect != nil && *sb.SoundEffect != "" {
+			if candidates := matchSFX(library, *sb.SoundEffect); len(candidates) > 0 {
+				sfx = candidates[0].SFX
+			}
+		}
+		if sfx == nil || sfx.Asset == nil {
+			continue
+		}
+		layers = append(layers, ffmpeg.AudioLayer{
+			Path:     resolvePath(sfx.Asset.URL),
+			Start:    span.Start,
+			Duration: span.End - span.Start,
+			Volume:   DefaultSFXVolume,
+			FadeOut:  sfxFadeOut,
+		})
+	}
+	return layers, nil
+}
+
+// matchSFX 关键词匹配：音效关键词出现在描述中、或描述中的词组出现在音效名称与关键词中均计分，
+// 越长的词越具体，得分越高；中文描述没有分词，另按相邻两字计分
+func matchSFX(library []models.SFX, description string) []SFXCandidate {
+	text := strings.ToLower(description)
+	phrases := splitSFXKeywords(description)
+	var bigrams []string
+	for _, phrase := range phrases {
+		bigrams = append(bigrams, hanBigrams(phrase)...)
+	}
+
+	var candidates []SFXCandidate
+	for i := range library {
+		sfx := &library[i]
+		haystack := strings.ToLower(sfx.Name + "," + sfx.Category)
+		var keywords []string
+		if sfx.Keywords != nil {
+			haystack += "," + strings.ToLower(*sfx.Keywords)
+			keywords = strings.Split(strings.ToLower(*sfx.Keywords), ",")
+		}
+
+		score := 0
+		for _, keyword := range keywords {
+			if keyword = strings.TrimSpace(keyword); keyword != "" && strings.Contains(text, keyword) {
+				score += utf8.RuneCountInString(keyword)
+			}
+		}
+		for _, phrase := range phrases {
+			if utf8.RuneCountInString(phrase) >= 2 && strings.Contains(haystack, phrase) {
+				score += utf8.RuneCountInString(phrase)
+			}
+		}
+		for _, bigram := range bigrams {
+			if strings.Contains(haystack, bigram) {
+				score++
+			}
+		}
+		if score > 0 {
+			candidates = append(candidates, SFXCandidate{SFX: sfx, Score: score})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+	return candidates
+}
+
+// splitSFXKeywords 按空白、标点与下划线拆分关键词，去掉纯数字（如文件编号）并去重
+func splitSFXKeywords(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r) || r == '_' || r == '/'
+	})
+	seen := make(map[string]bool, len(fields))
+	var keywords []string
+	for _, field := range fields {
+		if seen[field] || strings.IndexFunc(field, func(r rune) bool { return !unicode.IsDigit(r) }) < 0 {
+			continue
+		}
+		seen[field] = true
+		keywords = append(keywords, field)
+	}
+	return keywords
+}
+
+// hanBigrams 取词组中相邻两个汉字组成的二元组
+func hanBigrams(phrase string) []string {
+	runes := []rune(phrase)
+	var bigrams []string
+	for i := 0; i+1 < len(runes); i++ {
+		if unicode.Is(unicode.Han, runes[i]) && unicode.Is(unicode.Han, runes[i+1]) {
+			bigrams = append(bigrams, string(runes[i:i+2]))
+		}
+	}
+	return bigrams
+}
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	models "github.com/drama-generator/backend/domain/models"
+	"github.com/drama-generator/backend/infrastructure/external/ffmpeg"
+	"github.com/drama-generator/backend/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// SubtitleMode 合成时的字幕处理方式
+const (
+	SubtitleModeBurn = "burn" // 烧录到画面
+	SubtitleModeSoft = "soft" // 封装为可开关的字幕流
+)
+
+var (
+	// dialogueQuotePattern 匹配 角色名："台词" 形式的对白
+	dialogueQuotePattern = regexp.MustCompile(`([^\s：:"“”]+)[：:]\s*["“]([^"”]+)["”]`)
+	// dialogueMarkerPattern 匹配 （独白）（旁白） 等前缀标记
+	dialogueMarkerPattern = regexp.MustCompile(`^[（(]([^）)]{1,6})[）)]\s*`)
+)
+
+// SubtitleService 根据分镜对白生成与成片时间对齐的字幕
+type SubtitleService struct {
+	db              *gorm.DB
+	timelineService *TimelineService
+	ffmpeg          *ffmpeg.FFmpeg
+	log             *logger.Logger
+}
+
+func NewSubtitleService(db *gorm.DB, log *logger.Logger) *SubtitleService {
+	return &SubtitleService{
+		db:              db,
+		timelineService: NewTimelineService(db, log),
+		ffmpeg:          ffmpeg.NewFFmpeg(log),
+		log:             log,
+	}
+}
+
+// ValidateSubtitleMode 校验字幕处理方式，空字符串表示不处理
+func ValidateSubtitleMode(mode string) error {
+	switch mode {
+	case "", SubtitleModeBurn, SubtitleModeSoft:
+		return nil
+	default:
+		return fmt.Errorf("unknown subtitle mode: %s", mode)
+	}
+}
+
+// CuesFromScenes 按合成片段顺序生成字幕，时间按裁剪区间与转场重叠换算到成片
+func (s *SubtitleService) CuesFromScenes(scenes []models.SceneClip) []ffmpeg.SubtitleCue {
+	ordered := make([]models.SceneClip, len(scenes))
+	copy(ordered, scenes)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Order < ordered[j].Order })
+
+	var ids []uint
+	clips := make([]ffmpeg.VideoClip, len(ordered))
+	for i, scene := range ordered {
+		clips[i] = ffmpeg.VideoClip{
+			Duration:   scene.Duration,
+			StartTime:  scene.StartTime,
+			EndTime:    scene.EndTime,
+			Transition: scene.Transition,
+		}
+		if scene.SceneID != 0 {
+			ids = append(ids, scene.SceneID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var storyboards []models.Storyboard
+	s.db.Select("id", "dialogue").Where("id IN ?", ids).Find(&storyboards)
+	dialogues := make(map[uint]string)
+	for _, sb := range storyboards {
+		if sb.Dialogue != nil {
+			dialogues[sb.ID] = *sb.Dialogue
+		}
+	}
+
+	// 转场重叠区间内显示下一个镜头的字幕
+	var cues []ffmpeg.SubtitleCue
+	spans := s.ffmpeg.ClipSpans(clips)
+	for i, span := range spans {
+		end := span.End
+		if i+1 < len(spans) && spans[i+1].Start < end {
+			end = spans[i+1].Start
+		}
+		cues = append(cues, splitDialogue(dialogues[ordered[i].SceneID], span.Start, end)...)
+	}
+	return ffmpeg.NormalizeCues(cues)
+}
+
+// CuesFromTimeline 根据时间线生成字幕：有文字轨道时以文字轨道为准，否则使用主视频轨道片段对应分镜的对白
+func (s *SubtitleService) CuesFromTimeline(timelineID uint) ([]ffmpeg.SubtitleCue, error) {
+	timeline, err := s.timelineService.GetTimeline(timelineID)
+	if err != nil {
+		return nil, err
+	}
+
+	var textCues, dialogueCues []ffmpeg.SubtitleCue
+	mainTrackFound := false
+	for _, track := range timeline.Tracks {
+		switch {
+		case track.Type == models.TrackTypeText && !track.IsMuted:
+			for _, clip := range track.Clips {
+				text := clip.Name
+				if text == "" && clip.Storyboard != nil && clip.Storyboard.Dialogue != nil {
+					text = *clip.Storyboard.Dialogue
+				}
+				start := float64(clip.StartTime) / 1000
+				textCues = append(textCues, ffmpeg.SubtitleCue{Start: start, End: start + float64(clip.Duration)/1000, Text: text})
+			}
+		case track.Type == models.TrackTypeVideo && !track.IsMuted && !mainTrackFound:
+			mainTrackFound = true
+			for _, clip := range track.Clips {
+				if clip.Storyboard == nil || clip.Storyboard.Dialogue == nil {
+					continue
+				}
+				start := float64(clip.StartTime) / 1000
+				dialogueCues = append(dialogueCues, splitDialogue(*clip.Storyboard.Dialogue, start, start+float64(clip.Duration)/1000)...)
+			}
+		}
+	}
+
+	// 文字轨道没有有效字幕（如全为空文字片段）时按分镜对白生成
+	if cues := ffmpeg.NormalizeCues(textCues); len(cues) > 0 {
+		return cues, nil
+	}
+	return ffmpeg.NormalizeCues(dialogueCues), nil
+}
+
+// CuesForMerge 生成与合成结果对齐的字幕，应用了片头的合成按片头时长整体后移
+func (s *SubtitleService) CuesForMerge(videoMerge *models.VideoMerge) ([]ffmpeg.SubtitleCue, error) {
+	var cues []ffmpeg.SubtitleCue
+	if videoMerge.TimelineID != nil {
+		timelineCues, err := s.CuesFromTimeline(*videoMerge.TimelineID)
+		if err != nil {
+			return nil, err
+		}
+		cues = timelineCues
+	} else {
+		var scenes []models.SceneClip
+		if err := json.Unmarshal(videoMerge.Scenes, &scenes); err != nil {
+			return nil, fmt.Errorf("failed to parse scenes: %w", err)
+		}
+		cues = s.CuesFromScenes(scenes)
+	}
+
+	for i := range cues {
+		cues[i].Start += videoMerge.IntroOffset
+		cues[i].End += videoMerge.IntroOffset
+	}
+	return cues, nil
+}
+
+// EpisodeCues 生成剧集字幕：指定合成记录时与其对齐，否则使用最近一次完成的正式合成，
+// 尚未合成时按分镜默认顺序与时长计算
+func (s *SubtitleService) EpisodeCues(episodeID uint, mergeID *uint) ([]ffmpeg.SubtitleCue, *models.VideoMerge, error) {
+	var videoMerge models.VideoMerge
+	query := s.db.Where("episode_id = ?", episodeID)
+	if mergeID != nil {
+		query = query.Where("id = ?", *mergeID)
+	} else {
+		query = query.Where("status = ? AND is_preview = ?", models.VideoMergeStatusCompleted, false).Order("created_at DESC")
+	}
+
+	err := query.First(&videoMerge).Error
+	if err == nil {
+		cues, err := s.CuesForMerge(&videoMerge)
+		return cues, &videoMerge, err
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, err
+	}
+	if mergeID != nil {
+		return nil, nil, errors.New("merge not found")
+	}
+
+	var storyboards []models.Storyboard
+	if err := s.db.Where("episode_id = ?", episodeID).Order("storyboard_number ASC").Find(&storyboards).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(storyboards) == 0 {
+		return nil, nil, errors.New("no storyboards found for this episode")
+	}
+
+	scenes := make([]models.SceneClip, len(storyboards))
+	for i, sb := range storyboards {
+		scenes[i] = models.SceneClip{SceneID: sb.ID, Duration: float64(sb.Duration), Order: i}
+	}
+	return s.CuesFromScenes(scenes), nil, nil
+}
+
+// ExportEpisodeSubtitles 导出剧集字幕文件内容
+func (s *SubtitleService) ExportEpisodeSubtitles(episodeID uint, mergeID *uint, format ffmpeg.SubtitleFormat, style *ffmpeg.SubtitleStyle) (string, error) {
+	cues, videoMerge, err := s.EpisodeCues(episodeID, mergeID)
+	if err != nil {
+		return "", err
+	}
+	if len(cues) == 0 {
+		return "", errors.New("no dialogue found for this episode")
+	}
+
+	width, height := s.mergeResolution(videoMerge)
+	return ffmpeg.BuildSubtitles(cues, format, style, width, height), nil
+}
+
+// mergeResolution 字幕坐标系使用的成片分辨率：优先使用合成记录的探测结果，旧记录探测已存储的成片，
+// 探测失败时使用输出配置，仍无法确定时为1080p横屏
+func (s *SubtitleService) mergeResolution(videoMerge *models.VideoMerge) (int, int) {
+	if videoMerge == nil {
+		return 1920, 1080
+	}
+	if videoMerge.Width != nil && videoMerge.Height != nil && *videoMerge.Width > 0 && *videoMerge.Height > 0 {
+		return *videoMerge.Width, *videoMerge.Height
+	}
+	if videoMerge.MergedURL != nil && *videoMerge.MergedURL != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if info, err := s.ffmpeg.Probe(ctx, *videoMerge.MergedURL); err == nil && info.Width > 0 && info.Height > 0 {
+			return info.Width, info.Height
+		}
+	}
+	if videoMerge.Profile != "" {
+		if profile, err := ffmpeg.GetOutputProfile(videoMerge.Profile); err == nil {
+			return profile.Width, profile.Height
+		}
+	}
+	return 1920, 1080
+}
+
+// splitDialogue 将一个镜头的对白拆分为多条字幕，按字数比例分配镜头时长
+func splitDialogue(dialogue string, start, end float64) []ffmpeg.SubtitleCue {
+	lines := parseDialogue(dialogue)
+	if len(lines) == 0 || end <= start {
+		return nil
+	}
+
+	total := 0
+	for _, line := range lines {
+		total += utf8.RuneCountInString(line)
+	}
+
+	cues := make([]ffmpeg.SubtitleCue, 0, len(lines))
+	cursor := start
+	for _, line := range lines {
+		length := (end - start) * float64(utf8.RuneCountInString(line)) / float64(total)
+		cues = append(cues, ffmpeg.SubtitleCue{Start: cursor, End: cursor + length, Text: line})
+		cursor += length
+	}
+	return cues
+}
+
+// DialogueLine 对白中的一句台词
+type DialogueLine struct {
+	Speaker string `json:"speaker"`          // 角色名，独白/旁白为空
+	Marker  string `json:"marker,omitempty"` // 独白、旁白等前缀标记
+	Text    string `json:"text"`
+}
+
+// parseDialogue 提取对白中的台词文本
+func parseDialogue(dialogue string) []string {
+	var lines []string
+	for _, line := range parseDialogueLines(dialogue) {
+		lines = append(lines, line.Text)
+	}
+	return lines
+}
+
+// parseDialogueLines 拆分对白：角色名："台词" 取说话人与引号内容，独白/旁白去掉前缀标记
+func parseDialogueLines(dialogue string) []DialogueLine {
+	dialogue = strings.TrimSpace(dialogue)
+	if dialogue == "" {
+		return nil
+	}
+
+	var lines []DialogueLine
+	for _, match := range dialogueQuotePattern.FindAllStringSubmatch(dialogue, -1) {
+		if text := strings.TrimSpace(match[2]); text != "" {
+			lines = append(lines, DialogueLine{Speaker: match[1], Text: text})
+		}
+	}
+	if len(lines) > 0 {
+		return lines
+	}
+
+	for _, text := range strings.Split(dialogue, "\n") {
+		text = strings.TrimSpace(text)
+		var marker string
+		if match := dialogueMarkerPattern.FindStringSubmatch(text); match != nil {
+			marker = match[1]
+			text = strings.TrimSpace(text[len(match[0]):])
+		}
+		if text != "" {
+			lines = append(lines, DialogueLine{Marker: marker, Text: text})
+		}
+	}
+	return lines
+}
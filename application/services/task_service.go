@@ -73,6 +73,26 @@ func (s *TaskService) UpdateTaskError(taskID string, err error) error {
 		}).Error
 }
 
+// UpdateTaskErrorWithResult 更新任务错误并保留已完成部分的结果，便于客户端从中断处继续
+func (s *TaskService) UpdateTaskErrorWithResult(taskID string, err error, result interface{}) error {
+	resultJSON, marshalErr := json.Marshal(result)
+	if marshalErr != nil {
+		return fmt.Errorf("failed to marshal result: %w", marshalErr)
+	}
+
+	now := time.Now()
+	return s.db.Model(&models.AsyncTask{}).
+		Where("id = ?", taskID).
+		Updates(map[string]interface{}{
+			"status":       "failed",
+			"error":        err.Error(),
+			"result":       string(resultJSON),
+			"progress":     0,
+			"completed_at": &now,
+			"updated_at":   time.Now(),
+		}).Error
+}
+
 // UpdateTaskResult 更新任务结果
 func (s *TaskService) UpdateTaskResult(taskID string, result interface{}) error {
 	resultJSON, err := json.Marshal(result)
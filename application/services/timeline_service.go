@@ -0,0 +1,1227 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	models "github.com/drama-generator/backend/domain/models"
+	"github.com/drama-generator/backend/infrastructure/external/ffmpeg"
+	"github.com/drama-generator/backend/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// 时间线中的所有时间（start_time/end_time/duration/trim/fade/转场时长）统一使用毫秒
+
+type TimelineService struct {
+	db  *gorm.DB
+	log *logger.Logger
+}
+
+func NewTimelineService(db *gorm.DB, log *logger.Logger) *TimelineService {
+	return &TimelineService{
+		db:  db,
+		log: log,
+	}
+}
+
+type CreateTimelineRequest struct {
+	DramaID     uint    `json:"drama_id" binding:"required"`
+	EpisodeID   *uint   `json:"episode_id"`
+	Name        string  `json:"name" binding:"required,max=200"`
+	Description *string `json:"description"`
+	FPS         int     `json:"fps"`
+	Resolution  *string `json:"resolution"`
+}
+
+type UpdateTimelineRequest struct {
+	Name        *string                `json:"name" binding:"omitempty,max=200"`
+	Description *string                `json:"description"`
+	FPS         *int                   `json:"fps"`
+	Resolution  *string                `json:"resolution"`
+	Status      *models.TimelineStatus `json:"status" binding:"omitempty,oneof=draft editing completed exporting"`
+}
+
+type CreateTrackRequest struct {
+	Name   string           `json:"name" binding:"required,max=100"`
+	Type   models.TrackType `json:"type" binding:"required,oneof=video audio text"`
+	Order  *int             `json:"order"`
+	Volume *int             `json:"volume"`
+}
+
+type UpdateTrackRequest struct {
+	Name     *string `json:"name" binding:"omitempty,max=100"`
+	Order    *int    `json:"order"`
+	IsLocked *bool   `json:"is_locked"`
+	IsMuted  *bool   `json:"is_muted"`
+	Volume   *int    `json:"volume"`
+}
+
+type ReorderRequest struct {
+	IDs []uint `json:"ids" binding:"required,min=1"`
+}
+
+type CreateClipRequest struct {
+	TrackID      uint     `json:"track_id" binding:"required"`
+	AssetID      *uint    `json:"asset_id"`
+	StoryboardID *uint    `json:"storyboard_id"`
+	Name         string   `json:"name"`
+	StartTime    int      `json:"start_time" binding:"min=0"`
+	Duration     int      `json:"duration" binding:"required,min=1"`
+	TrimStart    *int     `json:"trim_start"`
+	TrimEnd      *int     `json:"trim_end"`
+	Speed        *float64 `json:"speed"`
+	Volume       *int     `json:"volume"`
+	FadeIn       *int     `json:"fade_in"`
+	FadeOut      *int     `json:"fade_out"`
+}
+
+type UpdateClipRequest struct {
+	TrackID   *uint    `json:"track_id"`
+	Name      *string  `json:"name"`
+	StartTime *int     `json:"start_time" binding:"omitempty,min=0"`
+	Duration  *int     `json:"duration" binding:"omitempty,min=1"`
+	TrimStart *int     `json:"trim_start"`
+	TrimEnd   *int     `json:"trim_end"`
+	Speed     *float64 `json:"speed"`
+	Volume    *int     `json:"volume"`
+	IsMuted   *bool    `json:"is_muted"`
+	FadeIn    *int     `json:"fade_in"`
+	FadeOut   *int     `json:"fade_out"`
+}
+
+type SetTransitionRequest struct {
+	Position string                 `json:"position" binding:"required,oneof=in out"`
+	Type     models.TransitionType  `json:"type" binding:"required"`
+	Duration int                    `json:"duration" binding:"required,min=1"`
+	Easing   *string                `json:"easing"`
+	Config   map[string]interface{} `json:"config"`
+}
+
+type CreateEffectRequest struct {
+	Type      models.EffectType      `json:"type" binding:"required,oneof=filter color blur brightness contrast saturation"`
+	Name      string                 `json:"name"`
+	IsEnabled *bool                  `json:"is_enabled"`
+	Order     *int                   `json:"order"`
+	Config    map[string]interface{} `json:"config"`
+}
+
+type UpdateEffectRequest struct {
+	Name      *string                `json:"name"`
+	IsEnabled *bool                  `json:"is_enabled"`
+	Order     *int                   `json:"order"`
+	Config    map[string]interface{} `json:"config"`
+}
+
+func (s *TimelineService) ListTimelines(dramaID, episodeID *uint) ([]models.Timeline, error) {
+	query := s.db.Model(&models.Timeline{})
+	if dramaID != nil {
+		query = query.Where("drama_id = ?", *dramaID)
+	}
+	if episodeID != nil {
+		query = query.Where("episode_id = ?", *episodeID)
+	}
+
+	var timelines []models.Timeline
+	if err := query.Order("updated_at DESC").Find(&timelines).Error; err != nil {
+		return nil, err
+	}
+	return timelines, nil
+}
+
+func (s *TimelineService) CreateTimeline(req *CreateTimelineRequest) (*models.Timeline, error) {
+	var drama models.Drama
+	if err := s.db.Where("id = ?", req.DramaID).First(&drama).Error; err != nil {
+		return nil, errors.New("drama not found")
+	}
+
+	if req.EpisodeID != nil {
+		var episode models.Episode
+		if err := s.db.Where("id = ? AND drama_id = ?", *req.EpisodeID, req.DramaID).First(&episode).Error; err != nil {
+			return nil, errors.New("episode not found")
+		}
+	}
+
+	timeline := &models.Timeline{
+		DramaID:     req.DramaID,
+		EpisodeID:   req.EpisodeID,
+		Name:        req.Name,
+		Description: req.Description,
+		FPS:         req.FPS,
+		Resolution:  req.Resolution,
+		Status:      models.TimelineStatusDraft,
+	}
+	if timeline.FPS <= 0 {
+		timeline.FPS = 30
+	}
+
+	if err := s.db.Create(timeline).Error; err != nil {
+		return nil, fmt.Errorf("failed to create timeline: %w", err)
+	}
+
+	s.log.Infow("Timeline created", "timeline_id", timeline.ID, "drama_id", req.DramaID)
+	return timeline, nil
+}
+
+// GetTimeline 获取完整时间线（轨道按order排序，片段按开始时间排序）
+func (s *TimelineService) GetTimeline(timelineID uint) (*models.Timeline, error) {
+	var timeline models.Timeline
+	err := s.db.Where("id = ?", timelineID).
+		Preload("Tracks", func(db *gorm.DB) *gorm.DB {
+			return db.Order("timeline_tracks.`order` ASC, timeline_tracks.id ASC")
+		}).
+		Preload("Tracks.Clips", func(db *gorm.DB) *gorm.DB {
+			return db.Order("timeline_clips.start_time ASC, timeline_clips.id ASC")
+		}).
+		Preload("Tracks.Clips.Asset").
+		Preload("Tracks.Clips.Storyboard").
+		Preload("Tracks.Clips.InTransition").
+		Preload("Tracks.Clips.OutTransition").
+		Preload("Tracks.Clips.Effects", func(db *gorm.DB) *gorm.DB {
+			return db.Order("clip_effects.`order` ASC, clip_effects.id ASC")
+		}).
+		First(&timeline).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("timeline not found")
+		}
+		return nil, err
+	}
+	return &timeline, nil
+}
+
+func (s *TimelineService) UpdateTimeline(timelineID uint, req *UpdateTimelineRequest) (*models.Timeline, error) {
+	var timeline models.Timeline
+	if err := s.db.Where("id = ?", timelineID).First(&timeline).Error; err != nil {
+		return nil, errors.New("timeline not found")
+	}
+
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.FPS != nil && *req.FPS > 0 {
+		updates["fps"] = *req.FPS
+	}
+	if req.Resolution != nil {
+		updates["resolution"] = *req.Resolution
+	}
+	if req.Status != nil {
+		updates["status"] = *req.Status
+	}
+
+	if len(updates) > 0 {
+		if err := s.db.Model(&timeline).Updates(updates).Error; err != nil {
+			return nil, fmt.Errorf("failed to update timeline: %w", err)
+		}
+	}
+
+	return s.GetTimeline(timelineID)
+}
+
+// DeleteTimeline 删除时间线及其所有轨道、片段、转场和特效
+func (s *TimelineService) DeleteTimeline(timelineID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var timeline models.Timeline
+		if err := tx.Where("id = ?", timelineID).First(&timeline).Error; err != nil {
+			return errors.New("timeline not found")
+		}
+
+		var trackIDs []uint
+		if err := tx.Model(&models.TimelineTrack{}).Where("timeline_id = ?", timelineID).Pluck("id", &trackIDs).Error; err != nil {
+			return err
+		}
+
+		if len(trackIDs) > 0 {
+			var clips []models.TimelineClip
+			if err := tx.Where("track_id IN ?", trackIDs).Find(&clips).Error; err != nil {
+				return err
+			}
+			for i := range clips {
+				if err := s.deleteClipTx(tx, &clips[i]); err != nil {
+					return err
+				}
+			}
+			if err := tx.Where("timeline_id = ?", timelineID).Delete(&models.TimelineTrack{}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Delete(&timeline).Error; err != nil {
+			return err
+		}
+
+		s.log.Infow("Timeline deleted", "timeline_id", timelineID)
+		return nil
+	})
+}
+
+// CreateTrack 添加轨道，未指定order时追加到末尾
+func (s *TimelineService) CreateTrack(timelineID uint, req *CreateTrackRequest) (*models.TimelineTrack, error) {
+	track, err := s.createTrack(s.db, timelineID, req)
+	if err != nil {
+		return nil, err
+	}
+	s.touchTimeline(timelineID)
+	return track, nil
+}
+
+// createTrack 在给定事务内添加轨道，供需要与其他写入一起提交的调用方使用
+func (s *TimelineService) createTrack(tx *gorm.DB, timelineID uint, req *CreateTrackRequest) (*models.TimelineTrack, error) {
+	var timeline models.Timeline
+	if err := tx.Where("id = ?", timelineID).First(&timeline).Error; err != nil {
+		return nil, errors.New("timeline not found")
+	}
+
+	order := 0
+	if req.Order != nil {
+		order = *req.Order
+	} else {
+		var maxOrder *int
+		tx.Model(&models.TimelineTrack{}).Where("timeline_id = ?", timelineID).Select("MAX(`order`)").Scan(&maxOrder)
+		if maxOrder != nil {
+			order = *maxOrder + 1
+		}
+	}
+
+	volume := 100
+	if req.Volume != nil {
+		volume = *req.Volume
+	}
+
+	track := &models.TimelineTrack{
+		TimelineID: timelineID,
+		Name:       req.Name,
+		Type:       req.Type,
+		Order:      order,
+		Volume:     &volume,
+	}
+
+	if err := tx.Create(track).Error; err != nil {
+		return nil, fmt.Errorf("failed to create track: %w", err)
+	}
+	return track, nil
+}
+
+// UpdateTrack 更新轨道属性（包括锁定、静音）
+func (s *TimelineService) UpdateTrack(timelineID, trackID uint, req *UpdateTrackRequest) (*models.TimelineTrack, error) {
+	track, err := s.getTrack(timelineID, trackID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Order != nil {
+		updates["order"] = *req.Order
+	}
+	if req.IsLocked != nil {
+		updates["is_locked"] = *req.IsLocked
+	}
+	if req.IsMuted != nil {
+		updates["is_muted"] = *req.IsMuted
+	}
+	if req.Volume != nil {
+		updates["volume"] = *req.Volume
+	}
+
+	if len(updates) > 0 {
+		if err := s.db.Model(track).Updates(updates).Error; err != nil {
+			return nil, fmt.Errorf("failed to update track: %w", err)
+		}
+	}
+
+	s.touchTimeline(timelineID)
+	return s.getTrack(timelineID, trackID)
+}
+
+func (s *TimelineService) DeleteTrack(timelineID, trackID uint) error {
+	track, err := s.getTrack(timelineID, trackID)
+	if err != nil {
+		return err
+	}
+	if track.IsLocked {
+		return errors.New("track is locked")
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var clips []models.TimelineClip
+		if err := tx.Where("track_id = ?", trackID).Find(&clips).Error; err != nil {
+			return err
+		}
+		for i := range clips {
+			if err := s.deleteClipTx(tx, &clips[i]); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(track).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	s.refreshDuration(timelineID)
+	return nil
+}
+
+// ReorderTracks 按给定的轨道ID顺序重新设置order
+func (s *TimelineService) ReorderTracks(timelineID uint, trackIDs []uint) error {
+	var tracks []models.TimelineTrack
+	if err := s.db.Where("timeline_id = ? AND id IN ?", timelineID, trackIDs).Find(&tracks).Error; err != nil {
+		return err
+	}
+	if len(tracks) != len(trackIDs) {
+		return errors.New("track not found")
+	}
+
+	// 锁定的轨道不允许改变位置
+	trackMap := make(map[uint]models.TimelineTrack, len(tracks))
+	for _, track := range tracks {
+		trackMap[track.ID] = track
+	}
+	for i, id := range trackIDs {
+		if track := trackMap[id]; track.IsLocked && track.Order != i {
+			return errors.New("track is locked")
+		}
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for i, id := range trackIDs {
+			if err := tx.Model(&models.TimelineTrack{}).Where("id = ?", id).Update("order", i).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reorder tracks: %w", err)
+	}
+
+	s.touchTimeline(timelineID)
+	return nil
+}
+
+// ReorderClips 按给定的片段ID顺序在轨道上首尾相接地重新排列（从0开始）
+func (s *TimelineService) ReorderClips(timelineID, trackID uint, clipIDs []uint) error {
+	track, err := s.getTrack(timelineID, trackID)
+	if err != nil {
+		return err
+	}
+	if track.IsLocked {
+		return errors.New("track is locked")
+	}
+
+	var clips []models.TimelineClip
+	if err := s.db.Where("track_id = ? AND id IN ?", trackID, clipIDs).Find(&clips).Error; err != nil {
+		return err
+	}
+	if len(clips) != len(clipIDs) {
+		return errors.New("clip not found")
+	}
+
+	clipMap := make(map[uint]models.TimelineClip, len(clips))
+	for _, clip := range clips {
+		clipMap[clip.ID] = clip
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		cursor := 0
+		for _, id := range clipIDs {
+			clip := clipMap[id]
+			if err := tx.Model(&models.TimelineClip{}).Where("id = ?", id).Updates(map[string]interface{}{
+				"start_time": cursor,
+				"end_time":   cursor + clip.Duration,
+			}).Error; err != nil {
+				return err
+			}
+			cursor += clip.Duration
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reorder clips: %w", err)
+	}
+
+	s.refreshDuration(timelineID)
+	return nil
+}
+
+func (s *TimelineService) CreateClip(timelineID uint, req *CreateClipRequest) (*models.TimelineClip, error) {
+	track, err := s.getTrack(timelineID, req.TrackID)
+	if err != nil {
+		return nil, err
+	}
+	if track.IsLocked {
+		return nil, errors.New("track is locked")
+	}
+
+	if err := validateClipTiming(req.TrimStart, req.TrimEnd, req.Speed); err != nil {
+		return nil, err
+	}
+	if err := s.checkClipSources(timelineID, req.AssetID, req.StoryboardID); err != nil {
+		return nil, err
+	}
+
+	clip := &models.TimelineClip{
+		TrackID:      req.TrackID,
+		AssetID:      req.AssetID,
+		StoryboardID: req.StoryboardID,
+		Name:         req.Name,
+		StartTime:    req.StartTime,
+		EndTime:      req.StartTime + req.Duration,
+		Duration:     req.Duration,
+		TrimStart:    req.TrimStart,
+		TrimEnd:      req.TrimEnd,
+		Speed:        req.Speed,
+		Volume:       req.Volume,
+		FadeIn:       req.FadeIn,
+		FadeOut:      req.FadeOut,
+	}
+	if clip.Speed == nil {
+		speed := 1.0
+		clip.Speed = &speed
+	}
+
+	if err := s.db.Create(clip).Error; err != nil {
+		return nil, fmt.Errorf("failed to create clip: %w", err)
+	}
+
+	s.refreshDuration(timelineID)
+	return s.getClip(timelineID, clip.ID)
+}
+
+func (s *TimelineService) UpdateClip(timelineID, clipID uint, req *UpdateClipRequest) (*models.TimelineClip, error) {
+	clip, err := s.getClip(timelineID, clipID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkTrackUnlocked(clip.TrackID); err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+	if req.TrackID != nil && *req.TrackID != clip.TrackID {
+		target, err := s.getTrack(timelineID, *req.TrackID)
+		if err != nil {
+			return nil, err
+		}
+		if target.IsLocked {
+			return nil, errors.New("track is locked")
+		}
+		updates["track_id"] = *req.TrackID
+	}
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+
+	startTime := clip.StartTime
+	duration := clip.Duration
+	if req.StartTime != nil {
+		startTime = *req.StartTime
+	}
+	if req.Duration != nil {
+		duration = *req.Duration
+	}
+	if req.StartTime != nil || req.Duration != nil {
+		updates["start_time"] = startTime
+		updates["duration"] = duration
+		updates["end_time"] = startTime + duration
+	}
+
+	// 只修改一端时与另一端的现有值一起校验
+	trimStart, trimEnd := clip.TrimStart, clip.TrimEnd
+	if req.TrimStart != nil {
+		trimStart = req.TrimStart
+	}
+	if req.TrimEnd != nil {
+		trimEnd = req.TrimEnd
+	}
+	if err := validateClipTiming(trimStart, trimEnd, req.Speed); err != nil {
+		return nil, err
+	}
+	if req.TrimStart != nil {
+		updates["trim_start"] = *req.TrimStart
+	}
+	if req.TrimEnd != nil {
+		updates["trim_end"] = *req.TrimEnd
+	}
+	if req.Speed != nil {
+		updates["speed"] = *req.Speed
+	}
+	if req.Volume != nil {
+		updates["volume"] = *req.Volume
+	}
+	if req.IsMuted != nil {
+		updates["is_muted"] = *req.IsMuted
+	}
+	if req.FadeIn != nil {
+		updates["fade_in"] = *req.FadeIn
+	}
+	if req.FadeOut != nil {
+		updates["fade_out"] = *req.FadeOut
+	}
+
+	if len(updates) > 0 {
+		if err := s.db.Model(&models.TimelineClip{}).Where("id = ?", clipID).Updates(updates).Error; err != nil {
+			return nil, fmt.Errorf("failed to update clip: %w", err)
+		}
+	}
+
+	s.refreshDuration(timelineID)
+	return s.getClip(timelineID, clipID)
+}
+
+func (s *TimelineService) DeleteClip(timelineID, clipID uint) error {
+	clip, err := s.getClip(timelineID, clipID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkTrackUnlocked(clip.TrackID); err != nil {
+		return err
+	}
+
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		return s.deleteClipTx(tx, clip)
+	}); err != nil {
+		return err
+	}
+
+	s.refreshDuration(timelineID)
+	return nil
+}
+
+// SetClipTransition 设置片段的入场或出场转场（已存在则覆盖）
+func (s *TimelineService) SetClipTransition(timelineID, clipID uint, req *SetTransitionRequest) (*models.TimelineClip, error) {
+	clip, err := s.getClip(timelineID, clipID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkTrackUnlocked(clip.TrackID); err != nil {
+		return nil, err
+	}
+
+	column := "transition_out"
+	existing := clip.TransitionOut
+	if req.Position == "in" {
+		column = "transition_in"
+		existing = clip.TransitionIn
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if existing != nil {
+			return tx.Model(&models.ClipTransition{ID: *existing}).
+				Select("type", "duration", "easing", "config").
+				Updates(&models.ClipTransition{
+					Type:     req.Type,
+					Duration: req.Duration,
+					Easing:   req.Easing,
+					Config:   req.Config,
+				}).Error
+		}
+
+		transition := &models.ClipTransition{
+			Type:     req.Type,
+			Duration: req.Duration,
+			Easing:   req.Easing,
+			Config:   req.Config,
+		}
+		if err := tx.Create(transition).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.TimelineClip{}).Where("id = ?", clipID).Update(column, transition.ID).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set transition: %w", err)
+	}
+
+	s.touchTimeline(timelineID)
+	return s.getClip(timelineID, clipID)
+}
+
+func (s *TimelineService) RemoveClipTransition(timelineID, clipID uint, position string) error {
+	clip, err := s.getClip(timelineID, clipID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkTrackUnlocked(clip.TrackID); err != nil {
+		return err
+	}
+
+	column := "transition_out"
+	existing := clip.TransitionOut
+	if position == "in" {
+		column = "transition_in"
+		existing = clip.TransitionIn
+	}
+	if existing == nil {
+		return errors.New("transition not found")
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.TimelineClip{}).Where("id = ?", clipID).Update(column, nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.ClipTransition{}, *existing).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	s.touchTimeline(timelineID)
+	return nil
+}
+
+func (s *TimelineService) AddEffect(timelineID, clipID uint, req *CreateEffectRequest) (*models.ClipEffect, error) {
+	clip, err := s.getClip(timelineID, clipID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkTrackUnlocked(clip.TrackID); err != nil {
+		return nil, err
+	}
+
+	effect := &models.ClipEffect{
+		ClipID:    clipID,
+		Type:      req.Type,
+		Name:      req.Name,
+		IsEnabled: true,
+		Order:     len(clip.Effects),
+		Config:    req.Config,
+	}
+	if req.IsEnabled != nil {
+		effect.IsEnabled = *req.IsEnabled
+	}
+	if req.Order != nil {
+		effect.Order = *req.Order
+	}
+
+	if err := s.db.Create(effect).Error; err != nil {
+		return nil, fmt.Errorf("failed to create effect: %w", err)
+	}
+
+	// is_enabled 为false时Create会使用数据库默认值true，需要单独更新
+	if !effect.IsEnabled {
+		s.db.Model(effect).Update("is_enabled", false)
+	}
+
+	s.touchTimeline(timelineID)
+	return effect, nil
+}
+
+func (s *TimelineService) UpdateEffect(timelineID, clipID, effectID uint, req *UpdateEffectRequest) (*models.ClipEffect, error) {
+	clip, err := s.getClip(timelineID, clipID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkTrackUnlocked(clip.TrackID); err != nil {
+		return nil, err
+	}
+
+	var effect models.ClipEffect
+	if err := s.db.Where("id = ? AND clip_id = ?", effectID, clipID).First(&effect).Error; err != nil {
+		return nil, errors.New("effect not found")
+	}
+
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.IsEnabled != nil {
+		updates["is_enabled"] = *req.IsEnabled
+	}
+	if req.Order != nil {
+		updates["order"] = *req.Order
+	}
+
+	if len(updates) > 0 {
+		if err := s.db.Model(&effect).Updates(updates).Error; err != nil {
+			return nil, fmt.Errorf("failed to update effect: %w", err)
+		}
+	}
+	// config 为JSON序列化字段，需通过结构体更新
+	if req.Config != nil {
+		if err := s.db.Model(&effect).Select("config").Updates(&models.ClipEffect{Config: req.Config}).Error; err != nil {
+			return nil, fmt.Errorf("failed to update effect: %w", err)
+		}
+	}
+
+	s.touchTimeline(timelineID)
+	if err := s.db.First(&effect, effectID).Error; err != nil {
+		return nil, err
+	}
+	return &effect, nil
+}
+
+func (s *TimelineService) DeleteEffect(timelineID, clipID, effectID uint) error {
+	clip, err := s.getClip(timelineID, clipID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkTrackUnlocked(clip.TrackID); err != nil {
+		return err
+	}
+
+	result := s.db.Where("id = ? AND clip_id = ?", effectID, clipID).Delete(&models.ClipEffect{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("effect not found")
+	}
+
+	s.touchTimeline(timelineID)
+	return nil
+}
+
+// BuildFromEpisode 根据剧集分镜生成默认时间线：一条视频轨道按分镜顺序首尾相接，外加一条空音频轨道
+func (s *TimelineService) BuildFromEpisode(episodeID uint) (*models.Timeline, error) {
+	var episode models.Episode
+	if err := s.db.Preload("Drama").
+		Preload("Storyboards", func(db *gorm.DB) *gorm.DB {
+			return db.Order("storyboards.storyboard_number ASC")
+		}).
+		Where("id = ?", episodeID).First(&episode).Error; err != nil {
+		return nil, errors.New("episode not found")
+	}
+
+	if len(episode.Storyboards) == 0 {
+		return nil, errors.New("no storyboards found for this episode")
+	}
+
+	var timelineID uint
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		timeline := &models.Timeline{
+			DramaID:   episode.DramaID,
+			EpisodeID: &episode.ID,
+			Name:      fmt.Sprintf("%s - 第%d集", episode.Drama.Title, episode.EpisodeNum),
+			FPS:       30,
+			Status:    models.TimelineStatusDraft,
+		}
+		if err := tx.Create(timeline).Error; err != nil {
+			return err
+		}
+		timelineID = timeline.ID
+
+		volume := 100
+		videoTrack := &models.TimelineTrack{
+			TimelineID: timeline.ID,
+			Name:       "视频轨道 1",
+			Type:       models.TrackTypeVideo,
+			Order:      0,
+			Volume:     &volume,
+		}
+		if err := tx.Create(videoTrack).Error; err != nil {
+			return err
+		}
+
+		audioTrack := &models.TimelineTrack{
+			TimelineID: timeline.ID,
+			Name:       "音频轨道 1",
+			Type:       models.TrackTypeAudio,
+			Order:      1,
+			Volume:     &volume,
+		}
+		if err := tx.Create(audioTrack).Error; err != nil {
+			return err
+		}
+
+		cursor := 0
+		for _, storyboard := range episode.Storyboards {
+			duration := storyboard.Duration * 1000
+			if duration <= 0 {
+				duration = 5000
+			}
+
+			storyboardID := storyboard.ID
+			speed := 1.0
+			clip := &models.TimelineClip{
+				TrackID:      videoTrack.ID,
+				StoryboardID: &storyboardID,
+				Name:         fmt.Sprintf("镜头 %d", storyboard.StoryboardNumber),
+				StartTime:    cursor,
+				EndTime:      cursor + duration,
+				Duration:     duration,
+				Speed:        &speed,
+			}
+
+			// 优先关联素材库中该分镜最新的视频
+			var asset models.Asset
+			if err := tx.Where("storyboard_id = ? AND type = ? AND episode_id = ?",
+				storyboard.ID, models.AssetTypeVideo, episode.ID).
+				Order("created_at DESC").
+				First(&asset).Error; err == nil {
+				assetID := asset.ID
+				clip.AssetID = &assetID
+			}
+
+			if err := tx.Create(clip).Error; err != nil {
+				return err
+			}
+			cursor += duration
+		}
+
+		return tx.Model(timeline).Update("duration", cursor).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build timeline: %w", err)
+	}
+
+	s.log.Infow("Default timeline built from episode", "episode_id", episodeID, "timeline_id", timelineID)
+	return s.GetTimeline(timelineID)
+}
+
+// ResolveClipVideoURL 解析片段的视频地址：素材库视频优先，其次分镜视频
+func (s *TimelineService) ResolveClipVideoURL(clip *models.TimelineClip) string {
+	if clip.AssetID != nil && clip.Asset.ID != 0 && clip.Asset.Type == models.AssetTypeVideo {
+		return clip.Asset.URL
+	}
+	if clip.Storyboard != nil && clip.Storyboard.VideoURL != nil {
+		return *clip.Storyboard.VideoURL
+	}
+	return ""
+}
+
+// ResolveStoryboardImageURL 解析分镜用于生成运镜片段的图片：合成图优先，其次最近一次生成成功的分镜图片
+func (s *TimelineService) ResolveStoryboardImageURL(storyboard *models.Storyboard) string {
+	if storyboard.ComposedImage != nil && *storyboard.ComposedImage != "" {
+		return *storyboard.ComposedImage
+	}
+
+	var imageGen models.ImageGeneration
+	err := s.db.Where("storyboard_id = ? AND status = ? AND image_url IS NOT NULL AND image_url <> ''",
+		storyboard.ID, models.ImageStatusCompleted).
+		Order("created_at DESC").
+		First(&imageGen).Error
+	if err != nil {
+		return ""
+	}
+	return *imageGen.ImageURL
+}
+
+// BuildSceneClips 将时间线的主视频轨道（order最小且未静音的视频轨道）转换为合成用的场景片段
+// stillFallback 为true时，缺少视频的分镜使用分镜图片生成运镜片段；返回仍然缺少素材的分镜编号
+func (s *TimelineService) BuildSceneClips(timelineID uint, episodeID uint, stillFallback bool) ([]models.SceneClip, []int, error) {
+	timeline, err := s.GetTimeline(timelineID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var episode models.Episode
+	if err := s.db.Select("id", "drama_id").Where("id = ?", episodeID).First(&episode).Error; err != nil {
+		return nil, nil, errors.New("episode not found")
+	}
+	// 未绑定剧集的时间线也必须属于同一部剧
+	if timeline.DramaID != episode.DramaID || (timeline.EpisodeID != nil && *timeline.EpisodeID != episodeID) {
+		return nil, nil, errors.New("timeline does not belong to this episode")
+	}
+
+	var mainTrack *models.TimelineTrack
+	for i := range timeline.Tracks {
+		if timeline.Tracks[i].Type == models.TrackTypeVideo && !timeline.Tracks[i].IsMuted {
+			mainTrack = &timeline.Tracks[i]
+			break
+		}
+	}
+	if mainTrack == nil {
+		return nil, nil, errors.New("timeline has no video track")
+	}
+
+	clips := mainTrack.Clips
+	sort.SliceStable(clips, func(i, j int) bool {
+		return clips[i].StartTime < clips[j].StartTime
+	})
+
+	var sceneClips []models.SceneClip
+	var skipped []int
+	for i := range clips {
+		clip := &clips[i]
+		videoURL := s.ResolveClipVideoURL(clip)
+		var imageURL string
+		if videoURL == "" && stillFallback && clip.Storyboard != nil {
+			imageURL = s.ResolveStoryboardImageURL(clip.Storyboard)
+		}
+		if videoURL == "" && imageURL == "" {
+			if clip.Storyboard != nil {
+				skipped = append(skipped, clip.Storyboard.StoryboardNumber)
+			}
+			s.log.Warnw("No video available for timeline clip, skipping", "clip_id", clip.ID)
+			continue
+		}
+
+		var sceneID uint
+		if clip.StoryboardID != nil {
+			sceneID = *clip.StoryboardID
+		}
+
+		sceneClip := models.SceneClip{
+			SceneID:  sceneID,
+			VideoURL: videoURL,
+			ImageURL: imageURL,
+			Duration: float64(clip.Duration) / 1000,
+			Order:    len(sceneClips),
+		}
+		if imageURL != "" && clip.Storyboard.Movement != nil {
+			sceneClip.Motion = string(ffmpeg.MotionFromMovement(*clip.Storyboard.Movement))
+		}
+		if clip.TrimStart != nil {
+			sceneClip.StartTime = float64(*clip.TrimStart) / 1000
+		}
+		if clip.TrimEnd != nil {
+			sceneClip.EndTime = float64(*clip.TrimEnd) / 1000
+		}
+		if clip.TransitionOut != nil && clip.OutTransition.ID != 0 {
+			sceneClip.Transition = map[string]interface{}{
+				"type":     string(clip.OutTransition.Type),
+				"duration": float64(clip.OutTransition.Duration) / 1000,
+			}
+		}
+		sceneClips = append(sceneClips, sceneClip)
+	}
+
+	return sceneClips, skipped, nil
+}
+
+// BuildRenderOptions 将完整时间线转换为FFmpeg多轨渲染参数（毫秒转换为秒，音量百分比转换为倍数）
+// 入场/出场转场在未设置淡入淡出时映射为同等时长的淡入淡出，与重叠片段叠加后形成交叉淡化
+func (s *TimelineService) BuildRenderOptions(timelineID uint, outputPath string) (*ffmpeg.TimelineRenderOptions, error) {
+	timeline, err := s.GetTimeline(timelineID)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &ffmpeg.TimelineRenderOptions{
+		OutputPath: outputPath,
+		FPS:        timeline.FPS,
+		Duration:   float64(timeline.Duration) / 1000,
+	}
+	if timeline.Resolution != nil {
+		fmt.Sscanf(*timeline.Resolution, "%dx%d", &opts.Width, &opts.Height)
+	}
+
+	for _, track := range timeline.Tracks {
+		renderTrack := ffmpeg.RenderTrack{
+			Type:    ffmpeg.RenderTrackType(track.Type),
+			Order:   track.Order,
+			Volume:  percentToGain(track.Volume),
+			IsMuted: track.IsMuted,
+		}
+
+		for i := range track.Clips {
+			clip := &track.Clips[i]
+			renderClip := ffmpeg.RenderClip{
+				Start:    float64(clip.StartTime) / 1000,
+				Duration: float64(clip.Duration) / 1000,
+				Speed:    1.0,
+				Volume:   percentToGain(clip.Volume),
+				IsMuted:  clip.IsMuted,
+			}
+
+			switch track.Type {
+			case models.TrackTypeText:
+				renderClip.Text = clip.Name
+				if renderClip.Text == "" && clip.Storyboard != nil && clip.Storyboard.Dialogue != nil {
+					renderClip.Text = *clip.Storyboard.Dialogue
+				}
+			case models.TrackTypeAudio:
+				if clip.AssetID != nil && clip.Asset.ID != 0 {
+					renderClip.URL = clip.Asset.URL
+				}
+			default:
+				renderClip.URL = s.ResolveClipVideoURL(clip)
+				if clip.Storyboard != nil {
+					renderClip.Label = fmt.Sprintf("SHOT %d", clip.Storyboard.StoryboardNumber)
+					// 缺少视频时附带分镜图片，是否用于生成运镜画面由调用方决定
+					if renderClip.URL == "" {
+						renderClip.ImageURL = s.ResolveStoryboardImageURL(clip.Storyboard)
+						if clip.Storyboard.Movement != nil {
+							renderClip.Motion = ffmpeg.MotionFromMovement(*clip.Storyboard.Movement)
+						}
+					}
+				}
+			}
+
+			if clip.TrimStart != nil {
+				renderClip.TrimStart = float64(*clip.TrimStart) / 1000
+			}
+			if clip.TrimEnd != nil {
+				renderClip.TrimEnd = float64(*clip.TrimEnd) / 1000
+			}
+			if clip.Speed != nil && *clip.Speed > 0 {
+				renderClip.Speed = *clip.Speed
+			}
+			if clip.FadeIn != nil {
+				renderClip.FadeIn = float64(*clip.FadeIn) / 1000
+			} else if clip.TransitionIn != nil && clip.InTransition.ID != 0 {
+				renderClip.FadeIn = float64(clip.InTransition.Duration) / 1000
+			}
+			if clip.FadeOut != nil {
+				renderClip.FadeOut = float64(*clip.FadeOut) / 1000
+			} else if clip.TransitionOut != nil && clip.OutTransition.ID != 0 {
+				renderClip.FadeOut = float64(clip.OutTransition.Duration) / 1000
+			}
+
+			for _, effect := range clip.Effects {
+				if !effect.IsEnabled {
+					continue
+				}
+				renderClip.Effects = append(renderClip.Effects, ffmpeg.RenderEffect{
+					Type:   string(effect.Type),
+					Config: effect.Config,
+				})
+			}
+
+			renderTrack.Clips = append(renderTrack.Clips, renderClip)
+		}
+
+		opts.Tracks = append(opts.Tracks, renderTrack)
+	}
+
+	return opts, nil
+}
+
+func percentToGain(volume *int) float64 {
+	if volume == nil {
+		return 1.0
+	}
+	return float64(*volume) / 100
+}
+
+// validateClipTiming 校验片段的速度与源素材裁剪区间，避免无效的 -ss/-to 到渲染时才失败
+func validateClipTiming(trimStart, trimEnd *int, speed *float64) error {
+	if speed != nil && *speed <= 0 {
+		return errors.New("speed must be positive")
+	}
+	if (trimStart != nil && *trimStart < 0) || (trimEnd != nil && *trimEnd < 0) {
+		return errors.New("trim must not be negative")
+	}
+	if trimStart != nil && trimEnd != nil && *trimEnd <= *trimStart {
+		return errors.New("trim_end must be greater than trim_start")
+	}
+	return nil
+}
+
+// checkClipSources 片段引用的素材与分镜必须属于时间线所在的剧本，未归属剧本的素材可在任意剧本使用
+func (s *TimelineService) checkClipSources(timelineID uint, assetID, storyboardID *uint) error {
+	var timeline models.Timeline
+	if err := s.db.Select("id", "drama_id").Where("id = ?", timelineID).First(&timeline).Error; err != nil {
+		return errors.New("timeline not found")
+	}
+	return checkDramaSources(s.db, timeline.DramaID, assetID, storyboardID)
+}
+
+// ValidateClip 与 CreateClip 相同的片段校验，供在事务中直接写入片段的导入流程使用
+func (s *TimelineService) ValidateClip(tx *gorm.DB, dramaID uint, clip *models.TimelineClip) error {
+	if err := validateClipTiming(clip.TrimStart, clip.TrimEnd, clip.Speed); err != nil {
+		return err
+	}
+	return checkDramaSources(tx, dramaID, clip.AssetID, clip.StoryboardID)
+}
+
+func checkDramaSources(tx *gorm.DB, dramaID uint, assetID, storyboardID *uint) error {
+	if assetID != nil {
+		var asset models.Asset
+		if err := tx.Select("id", "drama_id").Where("id = ?", *assetID).First(&asset).Error; err != nil {
+			return errors.New("asset not found")
+		}
+		if asset.DramaID != nil && *asset.DramaID != dramaID {
+			return errors.New("asset belongs to another drama")
+		}
+	}
+	if storyboardID != nil {
+		var storyboard models.Storyboard
+		if err := tx.Preload("Episode").Where("id = ?", *storyboardID).First(&storyboard).Error; err != nil {
+			return errors.New("storyboard not found")
+		}
+		if storyboard.Episode.DramaID != dramaID {
+			return errors.New("storyboard belongs to another drama")
+		}
+	}
+	return nil
+}
+
+func (s *TimelineService) getTrack(timelineID, trackID uint) (*models.TimelineTrack, error) {
+	var track models.TimelineTrack
+	if err := s.db.Where("id = ? AND timeline_id = ?", trackID, timelineID).First(&track).Error; err != nil {
+		return nil, errors.New("track not found")
+	}
+	return &track, nil
+}
+
+func (s *TimelineService) getClip(timelineID, clipID uint) (*models.TimelineClip, error) {
+	var clip models.TimelineClip
+	err := s.db.Joins("JOIN timeline_tracks ON timeline_tracks.id = timeline_clips.track_id AND timeline_tracks.deleted_at IS NULL").
+		Where("timeline_clips.id = ? AND timeline_tracks.timeline_id = ?", clipID, timelineID).
+		Preload("Asset").
+		Preload("Storyboard").
+		Preload("InTransition").
+		Preload("OutTransition").
+		Preload("Effects", func(db *gorm.DB) *gorm.DB {
+			return db.Order("clip_effects.`order` ASC, clip_effects.id ASC")
+		}).
+		First(&clip).Error
+	if err != nil {
+		return nil, errors.New("clip not found")
+	}
+	return &clip, nil
+}
+
+func (s *TimelineService) checkTrackUnlocked(trackID uint) error {
+	var track models.TimelineTrack
+	if err := s.db.Where("id = ?", trackID).First(&track).Error; err != nil {
+		return errors.New("track not found")
+	}
+	if track.IsLocked {
+		return errors.New("track is locked")
+	}
+	return nil
+}
+
+// deleteClipTx 删除片段及其转场、特效
+func (s *TimelineService) deleteClipTx(tx *gorm.DB, clip *models.TimelineClip) error {
+	if err := tx.Where("clip_id = ?", clip.ID).Delete(&models.ClipEffect{}).Error; err != nil {
+		return err
+	}
+	var transitionIDs []uint
+	if clip.TransitionIn != nil {
+		transitionIDs = append(transitionIDs, *clip.TransitionIn)
+	}
+	if clip.TransitionOut != nil {
+		transitionIDs = append(transitionIDs, *clip.TransitionOut)
+	}
+	if len(transitionIDs) > 0 {
+		if err := tx.Delete(&models.ClipTransition{}, transitionIDs).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Delete(&models.TimelineClip{}, clip.ID).Error
+}
+
+// refreshDuration 以所有轨道中最晚的片段结束时间作为时间线总时长
+func (s *TimelineService) refreshDuration(timelineID uint) {
+	var maxEnd *int
+	s.db.Model(&models.TimelineClip{}).
+		Joins("JOIN timeline_tracks ON timeline_tracks.id = timeline_clips.track_id AND timeline_tracks.deleted_at IS NULL").
+		Where("timeline_tracks.timeline_id = ?", timelineID).
+		Select("MAX(timeline_clips.end_time)").
+		Scan(&maxEnd)
+
+	duration := 0
+	if maxEnd != nil {
+		duration = *maxEnd
+	}
+
+	s.db.Model(&models.Timeline{}).Where("id = ?", timelineID).Updates(map[string]interface{}{
+		"duration": duration,
+		"status":   gorm.Expr("CASE WHEN status = ? THEN ? ELSE status END", models.TimelineStatusDraft, models.TimelineStatusEditing),
+	})
+}
+
+// touchTimeline 编辑后将草稿状态的时间线标记为编辑中
+func (s *TimelineService) touchTimeline(timelineID uint) {
+	s.db.Model(&models.Timeline{}).Where("id = ? AND status = ?", timelineID, models.TimelineStatusDraft).
+		Update("status", models.TimelineStatusEditing)
+}
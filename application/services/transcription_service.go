@@ -0,0 +1,646 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	models "github.com/drama-generator/backend/domain/models"
+	"github.com/drama-generator/backend/infrastructure/external/ffmpeg"
+	"github.com/drama-generator/backend/infrastructure/storage"
+	"github.com/drama-generator/backend/pkg/logger"
+	"github.com/drama-generator/backend/pkg/stt"
+	"gorm.io/gorm"
+)
+
+// LocalSTTProvider 本地替代语音识别，未配置语音识别服务时使用
+const LocalSTTProvider = "local"
+
+// transcriptTrackName 识别结果生成的字幕轨道默认名称
+const transcriptTrackName = "识别字幕"
+
+// TranscriptionService 识别音频/视频素材中的语音，结果带时间戳保存，可回填分镜对白或生成时间线字幕轨道
+type TranscriptionService struct {
+	db              *gorm.DB
+	aiService       *AIService
+	timelineService *TimelineService
+	localStorage    *storage.LocalStorage
+	ffmpeg          *ffmpeg.FFmpeg
+	log             *logger.Logger
+}
+
+func NewTranscriptionService(db *gorm.DB, localStorage *storage.LocalStorage, log *logger.Logger) *TranscriptionService {
+	return &TranscriptionService{
+		db:              db,
+		aiService:       NewAIService(db, log),
+		timelineService: NewTimelineService(db, log),
+		localStorage:    localStorage,
+		ffmpeg:          ffmpeg.NewFFmpeg(log),
+		log:             log,
+	}
+}
+
+// TranscribeRequest 语音识别参数
+type TranscribeRequest struct {
+	Model     string `json:"model"`     // 识别模型，为空时使用默认语音识别配置
+	Language  string `json:"language"`  // 语言代码，如 zh、en，为空时自动识别
+	Prompt    string `json:"prompt"`    // 提示词，为空时使用剧本角色名
+	Overwrite bool   `json:"overwrite"` // 批量识别时重新识别已有结果的素材
+}
+
+// UpdateTranscriptionRequest 人工校对后的分段
+type UpdateTranscriptionRequest struct {
+	Segments []models.TranscriptSegment `json:"segments" binding:"required"`
+}
+
+// ApplyToStoryboardRequest 回填分镜对白参数
+type ApplyToStoryboardRequest struct {
+	StoryboardID *uint    `json:"storyboard_id"` // 为空时使用素材所属分镜
+	Start        *float64 `json:"start"`         // 只取该时间范围内的分段（素材时间，秒）
+	End          *float64 `json:"end"`
+	Overwrite    bool     `json:"overwrite"` // 分镜已有对白时覆盖
+}
+
+// ApplyToTimelineRequest 生成字幕轨道参数
+type ApplyToTimelineRequest struct {
+	TrackName string   `json:"track_name"`
+	Offset    *float64 `json:"offset"` // 素材起点在时间线上的位置（秒）；为空时按时间线中引用该素材的片段换算
+}
+
+// TranscribeAsset 识别单个素材，识别在后台进行
+func (s *TranscriptionService) TranscribeAsset(assetID uint, req *TranscribeRequest) (*models.Transcription, error) {
+	var asset models.Asset
+	if err := s.db.Where("id = ?", assetID).First(&asset).Error; err != nil {
+		return nil, errors.New("asset not found")
+	}
+	if asset.Type != models.AssetTypeAudio && asset.Type != models.AssetTypeVideo {
+		return nil, errors.New("asset must be audio or video")
+	}
+
+	transcription, err := s.createTranscription(&asset, req)
+	if err != nil {
+		return nil, err
+	}
+	go s.processTranscription(transcription.ID)
+	return transcription, nil
+}
+
+// TranscribeEpisode 识别剧集下全部音频/视频素材；已识别或识别中的素材默认跳过
+func (s *TranscriptionService) TranscribeEpisode(episodeID string, req *TranscribeRequest) ([]*models.Transcription, error) {
+	var ep models.Episode
+	if err := s.db.Where("id = ?", episodeID).First(&ep).Error; err != nil {
+		return nil, errors.New("episode not found")
+	}
+
+	var assets []models.Asset
+	if err := s.db.Where("episode_id = ? AND type IN ?", ep.ID, []models.AssetType{models.AssetTypeAudio, models.AssetTypeVideo}).
+		Order("storyboard_num ASC, id ASC").Find(&assets).Error; err != nil {
+		return nil, err
+	}
+
+	var transcriptions []*models.Transcription
+	for i := range assets {
+		if !req.Overwrite {
+			var count int64
+			s.db.Model(&models.Transcription{}).
+				Where("asset_id = ? AND status IN ?", assets[i].ID, []models.TranscriptionStatus{models.TranscriptionStatusCompleted, models.TranscriptionStatusProcessing}).
+				Count(&count)
+			if count > 0 {
+				continue
+			}
+		}
+		transcription, err := s.createTranscription(&assets[i], req)
+		if err != nil {
+			return nil, err
+		}
+		transcriptions = append(transcriptions, transcription)
+	}
+
+	go func() {
+		for _, t := range transcriptions {
+			s.processTranscription(t.ID)
+		}
+	}()
+
+	s.log.Infow("Episode transcription started", "episode_id", ep.ID, "assets", len(transcriptions))
+	return transcriptions, nil
+}
+
+func (s *TranscriptionService) createTranscription(asset *models.Asset, req *TranscribeRequest) (*models.Transcription, error) {
+	provider, model := s.resolveModel(req.Model)
+
+	prompt := req.Prompt
+	if prompt == "" {
+		prompt = s.characterPrompt(asset.DramaID)
+	}
+
+	transcription := &models.Transcription{
+		AssetID:  asset.ID,
+		Provider: provider,
+		Model:    model,
+		Language: req.Language,
+		Status:   models.TranscriptionStatusPending,
+	}
+	if prompt != "" {
+		transcription.Prompt = &prompt
+	}
+	if err := s.db.Create(transcription).Error; err != nil {
+		s.log.Errorw("Failed to create transcription", "error", err, "asset_id", asset.ID)
+		return nil, err
+	}
+	return transcription, nil
+}
+
+// characterPrompt 以剧本角色名作为识别提示词，减少人名识别错误
+func (s *TranscriptionService) characterPrompt(dramaID *uint) string {
+	if dramaID == nil {
+		return ""
+	}
+	var names []string
+	s.db.Model(&models.Character{}).Where("drama_id = ?", *dramaID).Pluck("name", &names)
+	return strings.Join(names, "，")
+}
+
+func (s *TranscriptionService) processTranscription(transcriptionID uint) {
+	var transcription models.Transcription
+	if err := s.db.Preload("Asset").Where("id = ?", transcriptionID).First(&transcription).Error; err != nil {
+		s.log.Errorw("Failed to load transcription", "error", err, "id", transcriptionID)
+		return
+	}
+	if transcription.Asset == nil {
+		s.updateTranscriptionError(transcriptionID, "asset not found")
+		return
+	}
+
+	s.db.Model(&models.Transcription{}).Where("id = ?", transcriptionID).Update("status", models.TranscriptionStatusProcessing)
+
+	// 统一抽取为单声道低码率音频，视频素材也只上传音轨
+	audioFile, err := os.CreateTemp("", "stt_*.mp3")
+	if err != nil {
+		s.updateTranscriptionError(transcriptionID, fmt.Sprintf("failed to create temp file: %v", err))
+		return
+	}
+	audioFile.Close()
+	defer os.Remove(audioFile.Name())
+
+	if err := s.ffmpeg.ExtractSpeechAudio(context.Background(), s.resolveInput(transcription.Asset.URL), audioFile.Name()); err != nil {
+		s.updateTranscriptionError(transcriptionID, err.Error())
+		return
+	}
+
+	client, err := s.getSTTClient(transcription.Provider, transcription.Model)
+	if err != nil {
+		s.updateTranscriptionError(transcriptionID, err.Error())
+		return
+	}
+
+	opts := []stt.STTOption{stt.WithLanguage(transcription.Language)}
+	if transcription.Model != "" {
+		opts = append(opts, stt.WithModel(transcription.Model))
+	}
+	if transcription.Prompt != nil {
+		opts = append(opts, stt.WithPrompt(*transcription.Prompt))
+	}
+
+	result, err := client.Transcribe(audioFile.Name(), opts...)
+	if err != nil {
+		s.updateTranscriptionError(transcriptionID, err.Error())
+		return
+	}
+
+	segments := make([]models.TranscriptSegment, len(result.Segments))
+	for i, seg := range result.Segments {
+		segments[i] = models.TranscriptSegment{Start: seg.Start, End: seg.End, Text: seg.Text}
+	}
+	text := result.Text
+	if text == "" {
+		text = segmentsText(segments)
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":       models.TranscriptionStatusCompleted,
+		"text":         text,
+		"completed_at": now,
+		"error_msg":    nil,
+	}
+	if result.Language != "" {
+		updates["language"] = result.Language
+	}
+	if result.Duration > 0 {
+		updates["duration"] = result.Duration
+	}
+	if err := s.saveTranscription(transcriptionID, updates, segments); err != nil {
+		s.updateTranscriptionError(transcriptionID, fmt.Sprintf("failed to save transcription: %v", err))
+		return
+	}
+
+	s.log.Infow("Transcription completed", "id", transcriptionID, "asset_id", transcription.AssetID, "segments", len(segments))
+}
+
+// saveTranscription 分段字段使用JSON序列化，需通过结构体更新才能生效
+func (s *TranscriptionService) saveTranscription(transcriptionID uint, updates map[string]interface{}, segments []models.TranscriptSegment) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Transcription{ID: transcriptionID}).Select("segments").
+			Updates(&models.Transcription{Segments: segments}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Transcription{}).Where("id = ?", transcriptionID).Updates(updates).Error
+	})
+}
+
+func (s *TranscriptionService) updateTranscriptionError(transcriptionID uint, errorMsg string) {
+	s.db.Model(&models.Transcription{}).Where("id = ?", transcriptionID).Updates(map[string]interface{}{
+		"status":    models.TranscriptionStatusFailed,
+		"error_msg": errorMsg,
+	})
+	s.log.Errorw("Transcription failed", "id", transcriptionID, "error", errorMsg)
+}
+
+// resolveInput 本地存储的文件直接读取磁盘，其余URL交给ffmpeg拉流
+func (s *TranscriptionService) resolveInput(url string) string {
+	if s.localStorage != nil {
+		if localPath, ok := s.localStorage.ResolvePath(url); ok {
+			return localPath
+		}
+	}
+	return url
+}
+
+// resolveModel 解析识别使用的服务与模型；未配置语音识别服务时使用本地替代实现
+func (s *TranscriptionService) resolveModel(modelName string) (string, string) {
+	config, err := s.sttConfig(modelName)
+	if err != nil {
+		return LocalSTTProvider, ""
+	}
+	model := modelName
+	if model == "" && len(config.Model) > 0 {
+		model = config.Model[0]
+	}
+	return config.Provider, model
+}
+
+func (s *TranscriptionService) sttConfig(modelName string) (*models.AIServiceConfig, error) {
+	if modelName != "" {
+		if config, err := s.aiService.GetConfigForModel("stt", modelName); err == nil {
+			return config, nil
+		}
+		s.log.Warnw("Failed to get stt config for model, using default", "model", modelName)
+	}
+	return s.aiService.GetDefaultConfig("stt")
+}
+
+// getSTTClient 根据配置创建语音识别客户端
+func (s *TranscriptionService) getSTTClient(provider, modelName string) (stt.STTClient, error) {
+	if provider == LocalSTTProvider {
+		return stt.NewLocalSTTClient(s.detectSpeech), nil
+	}
+
+	config, err := s.sttConfig(modelName)
+	if err != nil {
+		return nil, fmt.Errorf("no stt AI config found: %w", err)
+	}
+
+	model := modelName
+	if model == "" && len(config.Model) > 0 {
+		model = config.Model[0]
+	}
+	return stt.NewWhisperClient(config.BaseURL, config.APIKey, model, config.Endpoint), nil
+}
+
+// detectSpeech 本地替代识别使用的有声区间检测
+func (s *TranscriptionService) detectSpeech(audioPath string) ([]stt.Segment, error) {
+	spans, err := s.ffmpeg.DetectSpeech(context.Background(), audioPath)
+	if err != nil {
+		return nil, err
+	}
+	segments := make([]stt.Segment, len(spans))
+	for i, span := range spans {
+		segments[i] = stt.Segment{Start: span.Start, End: span.End}
+	}
+	return segments, nil
+}
+
+func (s *TranscriptionService) GetTranscription(transcriptionID uint) (*models.Transcription, error) {
+	var transcription models.Transcription
+	if err := s.db.Where("id = ?", transcriptionID).First(&transcription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("transcription not found")
+		}
+		return nil, err
+	}
+	return &transcription, nil
+}
+
+// LatestForAsset 素材最近一次完成的识别结果
+func (s *TranscriptionService) LatestForAsset(assetID uint) (*models.Transcription, error) {
+	var transcription models.Transcription
+	err := s.db.Where("asset_id = ? AND status = ?", assetID, models.TranscriptionStatusCompleted).
+		Order("completed_at DESC, id DESC").First(&transcription).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("transcription not found")
+		}
+		return nil, err
+	}
+	return &transcription, nil
+}
+
+func (s *TranscriptionService) ListTranscriptions(assetID *uint, status string, page, pageSize int) ([]models.Transcription, int64, error) {
+	query := s.db.Model(&models.Transcription{})
+	if assetID != nil {
+		query = query.Where("asset_id = ?", *assetID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var transcriptions []models.Transcription
+	offset := (page - 1) * pageSize
+	if err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&transcriptions).Error; err != nil {
+		return nil, 0, err
+	}
+	return transcriptions, total, nil
+}
+
+// UpdateSegments 保存人工校对后的分段，全文随分段重新生成
+func (s *TranscriptionService) UpdateSegments(transcriptionID uint, req *UpdateTranscriptionRequest) (*models.Transcription, error) {
+	transcription, err := s.GetTranscription(transcriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if transcription.Status != models.TranscriptionStatusCompleted {
+		return nil, errors.New("transcription is not completed")
+	}
+
+	for i, seg := range req.Segments {
+		if seg.Start < 0 || seg.End <= seg.Start {
+			return nil, fmt.Errorf("invalid segment %d: end must be after start", i)
+		}
+		req.Segments[i].Text = strings.TrimSpace(seg.Text)
+	}
+
+	updates := map[string]interface{}{"text": segmentsText(req.Segments)}
+	if err := s.saveTranscription(transcriptionID, updates, req.Segments); err != nil {
+		return nil, err
+	}
+	return s.GetTranscription(transcriptionID)
+}
+
+// ApplyToStoryboard 用识别文本回填分镜对白，每个分段一行
+func (s *TranscriptionService) ApplyToStoryboard(transcriptionID uint, req *ApplyToStoryboardRequest) (*models.Storyboard, error) {
+	transcription, asset, err := s.completedTranscription(transcriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	storyboardID := req.StoryboardID
+	if storyboardID == nil {
+		storyboardID = asset.StoryboardID
+	}
+	if storyboardID == nil {
+		return nil, errors.New("storyboard_id is required for assets without a storyboard")
+	}
+
+	// 素材属于某部剧时只能回填该剧的分镜
+	query := s.db.Where("storyboards.id = ?", *storyboardID)
+	if asset.DramaID != nil {
+		query = query.Joins("JOIN episodes ON episodes.id = storyboards.episode_id AND episodes.deleted_at IS NULL").
+			Where("episodes.drama_id = ?", *asset.DramaID)
+	}
+	var storyboard models.Storyboard
+	if err := query.First(&storyboard).Error; err != nil {
+		return nil, errors.New("storyboard not found")
+	}
+	if storyboard.Dialogue != nil && strings.TrimSpace(*storyboard.Dialogue) != "" && !req.Overwrite {
+		return nil, errors.New("storyboard already has dialogue")
+	}
+
+	var lines []string
+	for _, seg := range transcription.Segments {
+		if strings.TrimSpace(seg.Text) == "" || (req.Start != nil && seg.End <= *req.Start) || (req.End != nil && seg.Start >= *req.End) {
+			continue
+		}
+		lines = append(lines, seg.Text)
+	}
+	if len(lines) == 0 {
+		return nil, errors.New("no transcribed text in range")
+	}
+
+	dialogue := strings.Join(lines, "\n")
+	if err := s.db.Model(&storyboard).Updates(map[string]interface{}{
+		"dialogue":   dialogue,
+		"updated_at": time.Now(),
+	}).Error; err != nil {
+		return nil, err
+	}
+	storyboard.Dialogue = &dialogue
+
+	s.log.Infow("Storyboard dialogue filled from transcription", "transcription_id", transcriptionID, "storyboard_id", storyboard.ID, "lines", len(lines))
+	return &storyboard, nil
+}
+
+// ApplyToTimeline 在时间线上新建文字轨道，每个分段一个字幕片段
+// 未指定偏移时按时间线中引用该素材的片段换算：跳过裁剪掉的部分并按播放速度缩放
+func (s *TranscriptionService) ApplyToTimeline(transcriptionID, timelineID uint, req *ApplyToTimelineRequest) (*models.TimelineTrack, error) {
+	transcription, asset, err := s.completedTranscription(transcriptionID)
+	if err != nil {
+		return nil, err
+	}
+	timeline, err := s.timelineService.GetTimeline(timelineID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 本地识别只检测有声区间，分段没有文字，不能生成字幕
+	segments := textSegments(transcription.Segments)
+	if len(segments) == 0 {
+		return nil, errors.New("no transcribed text to place")
+	}
+
+	var clips []models.TimelineClip
+	if req.Offset != nil {
+		if *req.Offset < 0 {
+			return nil, errors.New("offset must be non-negative")
+		}
+		start := int(*req.Offset * 1000)
+		for _, seg := range segments {
+			clips = append(clips, subtitleClip(seg.Text, start+int(seg.Start*1000), int((seg.End-seg.Start)*1000)))
+		}
+	} else {
+		placed := false
+		for _, track := range timeline.Tracks {
+			if track.Type == models.TrackTypeText {
+				continue
+			}
+			for i := range track.Clips {
+				if !s.clipUsesAsset(&track.Clips[i], asset) {
+					continue
+				}
+				placed = true
+				clips = append(clips, mapSegmentsToClip(segments, &track.Clips[i])...)
+			}
+			// 同一素材在多个轨道上出现时只取第一个轨道，避免重复字幕
+			if placed {
+				break
+			}
+		}
+		if !placed {
+			return nil, errors.New("asset is not used on this timeline, offset is required")
+		}
+	}
+	if len(clips) == 0 {
+		return nil, errors.New("no transcribed segments to place")
+	}
+
+	name := req.TrackName
+	if name == "" {
+		name = transcriptTrackName
+	}
+	// 轨道与字幕片段一起提交，片段写入失败时不留下空轨道
+	var track *models.TimelineTrack
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		track, err = s.timelineService.createTrack(tx, timelineID, &CreateTrackRequest{Name: name, Type: models.TrackTypeText})
+		if err != nil {
+			return err
+		}
+		for i := range clips {
+			clips[i].TrackID = track.ID
+		}
+		if err := tx.Create(&clips).Error; err != nil {
+			return fmt.Errorf("failed to create subtitle clips: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.timelineService.touchTimeline(timelineID)
+	s.timelineService.refreshDuration(timelineID)
+
+	track.Clips = clips
+	s.log.Infow("Subtitle track created from transcription", "transcription_id", transcriptionID, "timeline_id", timelineID, "clips", len(clips))
+	return track, nil
+}
+
+// textSegments 返回有文字的分段，文字去除首尾空白
+func textSegments(segments []models.TranscriptSegment) []models.TranscriptSegment {
+	var result []models.TranscriptSegment
+	for _, seg := range segments {
+		if seg.Text = strings.TrimSpace(seg.Text); seg.Text != "" {
+			result = append(result, seg)
+		}
+	}
+	return result
+}
+
+// clipUsesAsset 片段直接引用该素材，或通过分镜引用的视频就是该素材
+func (s *TranscriptionService) clipUsesAsset(clip *models.TimelineClip, asset *models.Asset) bool {
+	if clip.AssetID != nil {
+		return *clip.AssetID == asset.ID
+	}
+	return asset.Type == models.AssetTypeVideo && s.timelineService.ResolveClipVideoURL(clip) == asset.URL
+}
+
+// mapSegmentsToClip 将素材时间的分段换算到片段在时间线上的位置，裁剪区间外的部分截掉
+func mapSegmentsToClip(segments []models.TranscriptSegment, clip *models.TimelineClip) []models.TimelineClip {
+	speed := 1.0
+	if clip.Speed != nil && *clip.Speed > 0 {
+		speed = *clip.Speed
+	}
+	trimStart := 0.0
+	if clip.TrimStart != nil {
+		trimStart = float64(*clip.TrimStart) / 1000
+	}
+	sourceEnd := trimStart + float64(clip.Duration)/1000*speed
+
+	var clips []models.TimelineClip
+	for _, seg := range segments {
+		start, end := math.Max(seg.Start, trimStart), math.Min(seg.End, sourceEnd)
+		if end <= start {
+			continue
+		}
+		timelineStart := clip.StartTime + int((start-trimStart)/speed*1000)
+		clips = append(clips, subtitleClip(seg.Text, timelineStart, int((end-start)/speed*1000)))
+	}
+	return clips
+}
+
+func subtitleClip(text string, start, duration int) models.TimelineClip {
+	speed := 1.0
+	return models.TimelineClip{
+		Name:      truncateRunes(text, 200),
+		StartTime: start,
+		EndTime:   start + duration,
+		Duration:  duration,
+		Speed:     &speed,
+	}
+}
+
+// Cues 识别结果转为字幕，时间相对素材起点
+func (s *TranscriptionService) Cues(transcriptionID uint) ([]ffmpeg.SubtitleCue, *models.Asset, error) {
+	transcription, asset, err := s.completedTranscription(transcriptionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	cues := make([]ffmpeg.SubtitleCue, 0, len(transcription.Segments))
+	for _, seg := range transcription.Segments {
+		cues = append(cues, ffmpeg.SubtitleCue{Start: seg.Start, End: seg.End, Text: seg.Text})
+	}
+	cues = ffmpeg.NormalizeCues(cues)
+	if len(cues) == 0 {
+		return nil, nil, errors.New("no transcribed text")
+	}
+	return cues, asset, nil
+}
+
+// ExportSubtitles 导出素材字幕文件内容
+func (s *TranscriptionService) ExportSubtitles(transcriptionID uint, format ffmpeg.SubtitleFormat) (string, error) {
+	cues, asset, err := s.Cues(transcriptionID)
+	if err != nil {
+		return "", err
+	}
+	width, height := 1920, 1080
+	if asset.Width != nil && asset.Height != nil && *asset.Width > 0 && *asset.Height > 0 {
+		width, height = *asset.Width, *asset.Height
+	}
+	return ffmpeg.BuildSubtitles(cues, format, nil, width, height), nil
+}
+
+func (s *TranscriptionService) completedTranscription(transcriptionID uint) (*models.Transcription, *models.Asset, error) {
+	var transcription models.Transcription
+	if err := s.db.Preload("Asset").Where("id = ?", transcriptionID).First(&transcription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errors.New("transcription not found")
+		}
+		return nil, nil, err
+	}
+	if transcription.Status != models.TranscriptionStatusCompleted {
+		return nil, nil, errors.New("transcription is not completed")
+	}
+	if transcription.Asset == nil {
+		return nil, nil, errors.New("asset not found")
+	}
+	return &transcription, transcription.Asset, nil
+}
+
+// segmentsText 分段文本拼接为全文
+func segmentsText(segments []models.TranscriptSegment) string {
+	var parts []string
+	for _, seg := range segments {
+		if seg.Text != "" {
+			parts = append(parts, seg.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
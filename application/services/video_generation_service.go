@@ -19,6 +19,7 @@ type VideoGenerationService struct {
 	log             *logger.Logger
 	localStorage    *storage.LocalStorage
 	aiService       *AIService
+	mediaService    *MediaService
 }
 
 func NewVideoGenerationService(db *gorm.DB, transferService *ResourceTransferService, localStorage *storage.LocalStorage, aiService *AIService, log *logger.Logger) *VideoGenerationService {
@@ -27,6 +28,7 @@ func NewVideoGenerationService(db *gorm.DB, transferService *ResourceTransferSer
 		localStorage:    localStorage,
 		transferService: transferService,
 		aiService:       aiService,
+		mediaService:    NewMediaService(db, localStorage, log),
 		log:             log,
 	}
 
@@ -379,6 +381,8 @@ func (s *VideoGenerationService) completeVideoGeneration(videoGenID uint, videoU
 	}
 
 	s.log.Infow("Video generation completed", "id", videoGenID, "url", videoURL)
+
+	go s.mediaService.ProcessVideoGeneration(videoGenID)
 }
 
 func (s *VideoGenerationService) updateVideoGenError(videoGenID uint, errorMsg string) {
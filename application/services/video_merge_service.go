@@ -1,37 +1,65 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	models "github.com/drama-generator/backend/domain/models"
 	"github.com/drama-generator/backend/infrastructure/external/ffmpeg"
+	"github.com/drama-generator/backend/infrastructure/storage"
 	"github.com/drama-generator/backend/pkg/logger"
 	"github.com/drama-generator/backend/pkg/video"
 	"gorm.io/gorm"
 )
 
+// LocalMergeProvider 使用本地FFmpeg完成合成，无需远程视频服务
+const LocalMergeProvider = "local"
+
 type VideoMergeService struct {
 	db              *gorm.DB
-	aiService       *AIService
 	transferService *ResourceTransferService
+	timelineService *TimelineService
+	mediaService    *MediaService
+	subtitleService *SubtitleService
+	musicService    *MusicService
+	sfxService      *SFXService
 	ffmpeg          *ffmpeg.FFmpeg
+	storage         *storage.LocalStorage
 	storagePath     string
 	baseURL         string
 	log             *logger.Logger
 }
 
 func NewVideoMergeService(db *gorm.DB, transferService *ResourceTransferService, storagePath, baseURL string, log *logger.Logger) *VideoMergeService {
+	localStorage, err := storage.NewLocalStorage(storagePath, baseURL)
+	if err != nil {
+		log.Errorw("Failed to initialize storage for video merge", "error", err, "path", storagePath)
+	}
+	// 只有存储内的本地文件可作为合成素材，请求中的其他本地路径会被拒绝
+	ff := ffmpeg.NewFFmpeg(log)
+	ff.AllowLocalRoot(storagePath)
+
 	return &VideoMergeService{
 		db:              db,
-		aiService:       NewAIService(db, log),
 		transferService: transferService,
-		ffmpeg:          ffmpeg.NewFFmpeg(log),
+		timelineService: NewTimelineService(db, log),
+		mediaService:    NewMediaService(db, localStorage, log),
+		subtitleService: NewSubtitleService(db, log),
+		musicService:    NewMusicService(db, localStorage, log),
+		sfxService:      NewSFXService(db, localStorage, log),
+		ffmpeg:          ff,
+		storage:         localStorage,
 		storagePath:     storagePath,
 		baseURL:         baseURL,
 		log:             log,
@@ -39,12 +67,45 @@ func NewVideoMergeService(db *gorm.DB, transferService *ResourceTransferService,
 }
 
 type MergeVideoRequest struct {
-	EpisodeID string             `json:"episode_id" binding:"required"`
-	DramaID   string             `json:"drama_id" binding:"required"`
-	Title     string             `json:"title"`
-	Scenes    []models.SceneClip `json:"scenes" binding:"required,min=1"`
-	Provider  string             `json:"provider"`
-	Model     string             `json:"model"`
+	EpisodeID     string                `json:"episode_id" binding:"required"`
+	DramaID       string                `json:"drama_id" binding:"required"`
+	TimelineID    *uint                 `json:"timeline_id"`
+	Title         string                `json:"title"`
+	Scenes        []models.SceneClip    `json:"scenes" binding:"required,min=1"`
+	Provider      string                `json:"provider"`
+	Model         string                `json:"model"`
+	Profile       string                `json:"profile"`        // 输出配置名称，见 GET /video-merges/profiles
+	PackageHLS    bool                  `json:"package_hls"`    // 合成后切片为多码率HLS
+	Preview       bool                  `json:"preview"`        // 预览渲染：低分辨率快速输出，结果单独存放，不覆盖剧集成片
+	StillFallback bool                  `json:"still_fallback"` // 时间线合成时，缺少视频的分镜使用分镜图片生成运镜片段
+	Subtitles     string                `json:"subtitles"`      // 字幕处理：burn 烧录、soft 软字幕；为空时仅生成WebVTT字幕文件
+	SubtitleStyle *ffmpeg.SubtitleStyle `json:"subtitle_style"` // 字幕字体与样式
+	SkipBranding  bool                  `json:"skip_branding"`  // 不应用剧本的水印与片头片尾
+	SkipMusic     bool                  `json:"skip_music"`     // 不按分镜配乐描述自动铺背景音乐
+	SkipSFX       bool                  `json:"skip_sfx"`       // 不在镜头开始处放置分镜音效
+	// 目标响度（LUFS），为空时使用 -16；SkipLoudnorm 为true时保持各片段原有音量
+	LoudnessTarget *float64 `json:"loudness_target"`
+	SkipLoudnorm   bool     `json:"skip_loudnorm"`
+}
+
+// 可设置的目标响度范围（LUFS）
+const (
+	minLoudnessTarget = -36.0
+	maxLoudnessTarget = -8.0
+)
+
+// resolveLoudnessTarget 解析合成任务的目标响度，返回0表示不做响度处理
+func resolveLoudnessTarget(target *float64, skip bool) (float64, error) {
+	if skip {
+		return 0, nil
+	}
+	if target == nil {
+		return ffmpeg.DefaultLoudnessTarget, nil
+	}
+	if *target < minLoudnessTarget || *target > maxLoudnessTarget {
+		return 0, fmt.Errorf("invalid loudness target: %.1f (must be between %.0f and %.0f LUFS)", *target, minLoudnessTarget, maxLoudnessTarget)
+	}
+	return *target, nil
 }
 
 func (s *VideoMergeService) MergeVideos(req *MergeVideoRequest) (*models.VideoMerge, error) {
@@ -56,14 +117,33 @@ func (s *VideoMergeService) MergeVideos(req *MergeVideoRequest) (*models.VideoMe
 
 	// 验证所有场景都有视频
 	for i, scene := range req.Scenes {
-		if scene.VideoURL == "" {
+		if scene.VideoURL == "" && scene.ImageURL == "" {
 			return nil, fmt.Errorf("scene %d has no video", i+1)
 		}
 	}
 
 	provider := req.Provider
-	if provider == "" {
-		provider = "doubao"
+	if provider == "" || req.Preview {
+		// 预览需要烧录镜头编号与时间码，只能本地合成
+		provider = LocalMergeProvider
+	}
+
+	if req.Profile != "" {
+		if _, err := ffmpeg.GetOutputProfile(req.Profile); err != nil {
+			return nil, err
+		}
+	}
+	if err := ValidateSubtitleMode(req.Subtitles); err != nil {
+		return nil, err
+	}
+	// 预览追求速度，不做响度处理
+	loudnessTarget, err := resolveLoudnessTarget(req.LoudnessTarget, req.SkipLoudnorm || req.Preview)
+	if err != nil {
+		return nil, err
+	}
+	var subtitleStyle []byte
+	if req.SubtitleStyle != nil {
+		subtitleStyle, _ = json.Marshal(req.SubtitleStyle)
 	}
 
 	// 序列化场景列表
@@ -80,19 +160,34 @@ func (s *VideoMergeService) MergeVideos(req *MergeVideoRequest) (*models.VideoMe
 	dramaID, _ := strconv.ParseUint(req.DramaID, 10, 32)
 
 	videoMerge := &models.VideoMerge{
-		EpisodeID: uint(epID),
-		DramaID:   uint(dramaID),
-		Title:     req.Title,
-		Provider:  provider,
-		Model:     &req.Model,
-		Scenes:    scenesJSON,
-		Status:    models.VideoMergeStatusPending,
+		EpisodeID:      uint(epID),
+		DramaID:        uint(dramaID),
+		TimelineID:     req.TimelineID,
+		Title:          req.Title,
+		Provider:       provider,
+		Profile:        req.Profile,
+		PackageHLS:     req.PackageHLS && !req.Preview,
+		IsPreview:      req.Preview,
+		StillFallback:  req.StillFallback,
+		SubtitleMode:   req.Subtitles,
+		SubtitleStyle:  subtitleStyle,
+		SkipBranding:   req.SkipBranding,
+		SkipMusic:      req.SkipMusic,
+		SkipSFX:        req.SkipSFX,
+		LoudnessTarget: loudnessTarget,
+		Model:          &req.Model,
+		Scenes:         scenesJSON,
+		Status:         models.VideoMergeStatusPending,
 	}
 
 	if err := s.db.Create(videoMerge).Error; err != nil {
 		return nil, fmt.Errorf("failed to create merge record: %w", err)
 	}
 
+	if req.TimelineID != nil && !req.Preview {
+		s.db.Model(&models.Timeline{}).Where("id = ?", *req.TimelineID).Update("status", models.TimelineStatusExporting)
+	}
+
 	go s.processMergeVideo(videoMerge.ID)
 
 	return videoMerge, nil
@@ -107,9 +202,50 @@ func (s *VideoMergeService) processMergeVideo(mergeID uint) {
 
 	s.db.Model(&videoMerge).Update("status", models.VideoMergeStatusProcessing)
 
-	client, err := s.getVideoClient(videoMerge.Provider)
-	if err != nil {
-		s.updateMergeError(mergeID, err.Error())
+	ctx, done := registerMergeJob(mergeID)
+	defer done()
+
+	// 进度分配：合成，配乐与音效占10%，品牌包装占15%，响度母带处理占10%，字幕烧录占15%，HLS切片占最后20%
+	report := s.progressReporter(mergeID)
+	drama := s.loadBranding(&videoMerge)
+	hlsStart := 100.0
+	if videoMerge.PackageHLS {
+		hlsStart = 80
+	}
+	subtitleStart := hlsStart
+	if videoMerge.SubtitleMode == SubtitleModeBurn {
+		subtitleStart = hlsStart - 15
+	}
+	masteringStart := subtitleStart
+	if videoMerge.LoudnessTarget != 0 {
+		masteringStart = subtitleStart - 10
+	}
+	brandingStart := masteringStart
+	if drama != nil {
+		brandingStart = masteringStart - 15
+	}
+	mergeEnd := brandingStart
+	if !videoMerge.IsPreview && (!videoMerge.SkipMusic || !videoMerge.SkipSFX) {
+		mergeEnd = brandingStart - 10
+	}
+	mergeProgress := scaleProgress(report, 0, mergeEnd)
+	postProcess := &mergePostProcess{
+		drama:     drama,
+		audioBed:  scaleProgress(report, mergeEnd, brandingStart),
+		branding:  scaleProgress(report, brandingStart, masteringStart),
+		mastering: scaleProgress(report, masteringStart, subtitleStart),
+		subtitles: scaleProgress(report, subtitleStart, hlsStart),
+		hls:       scaleProgress(report, hlsStart, 100),
+	}
+
+	// 关联了时间线的合成任务直接渲染完整的多轨时间线
+	if videoMerge.TimelineID != nil {
+		result, err := s.renderTimeline(ctx, &videoMerge, mergeProgress)
+		if err != nil {
+			s.handleMergeError(mergeID, err)
+			return
+		}
+		s.finishLocalMerge(ctx, &videoMerge, result, postProcess)
 		return
 	}
 
@@ -120,26 +256,426 @@ func (s *VideoMergeService) processMergeVideo(mergeID uint) {
 		return
 	}
 
-	// 调用视频合并API
-	result, err := s.mergeVideoClips(client, scenes)
+	// 合成始终由本地FFmpeg完成，不依赖任何远程视频服务配置
+	result, err := s.mergeVideoClips(ctx, scenes, videoMerge.Profile, videoMerge.IsPreview, videoMerge.LoudnessTarget, mergeProgress)
 	if err != nil {
-		s.updateMergeError(mergeID, err.Error())
+		s.handleMergeError(mergeID, err)
 		return
 	}
+	s.finishLocalMerge(ctx, &videoMerge, result, postProcess)
+}
 
-	if !result.Completed {
-		s.db.Model(&videoMerge).Updates(map[string]interface{}{
-			"status":  models.VideoMergeStatusProcessing,
-			"task_id": result.TaskID,
-		})
-		go s.pollMergeStatus(mergeID, client, result.TaskID)
+// mergePostProcess 合成完成后的处理步骤：品牌包装所用的剧本配置及各步骤的进度回调
+type mergePostProcess struct {
+	drama     *models.Drama // 为空时不做品牌包装
+	audioBed  ffmpeg.ProgressFunc
+	branding  ffmpeg.ProgressFunc
+	mastering ffmpeg.ProgressFunc
+	subtitles ffmpeg.ProgressFunc
+	hls       ffmpeg.ProgressFunc
+}
+
+// scaleProgress 将子步骤的 0-100 进度映射到整体进度的 [from, to] 区间
+func scaleProgress(report ffmpeg.ProgressFunc, from, to float64) ffmpeg.ProgressFunc {
+	return func(percent float64) {
+		report(from + percent*(to-from)/100)
+	}
+}
+
+// finishLocalMerge 完成本地合成：铺背景音乐与音效、应用剧本品牌包装、统一成片响度、生成字幕（按需烧录或封装）、按需切片HLS后写回结果
+// 配乐音效、品牌包装、响度处理与字幕烧录/封装失败按合成失败处理；HLS切片失败不影响成片，仅记录日志；任务被取消时按取消处理
+func (s *VideoMergeService) finishLocalMerge(ctx context.Context, videoMerge *models.VideoMerge, result *video.VideoResult, progress *mergePostProcess) {
+	// 配乐与音效只铺在正片下，先于片头片尾处理
+	if err := s.applyAudioBed(ctx, videoMerge, result.VideoURL, progress.audioBed); err != nil {
+		s.handleMergeError(videoMerge.ID, err)
+		return
+	}
+
+	// 字幕时间按正片计算，加片头后整体后移；片头时长写回合成记录，供字幕与剪辑工程导出对齐
+	if progress.drama != nil {
+		introDuration, err := s.applyBranding(ctx, videoMerge, progress.drama, result.VideoURL, progress.branding)
+		if err != nil {
+			s.handleMergeError(videoMerge.ID, err)
+			return
+		}
+		videoMerge.IntroOffset = introDuration
+		s.db.Model(&models.VideoMerge{}).Where("id = ?", videoMerge.ID).Update("intro_offset", introDuration)
+		if duration, err := s.ffmpeg.GetDuration(s.resolveLocalPath(result.VideoURL)); err == nil {
+			result.Duration = int(duration + 0.5)
+		}
+	}
+
+	if videoMerge.LoudnessTarget != 0 {
+		if err := s.masterAudio(ctx, videoMerge, result.VideoURL, progress.mastering); err != nil {
+			s.handleMergeError(videoMerge.ID, err)
+			return
+		}
+	}
+
+	s.recordResolution(ctx, videoMerge, result.VideoURL)
+
+	if err := s.applySubtitles(ctx, videoMerge, result.VideoURL, progress.subtitles); err != nil {
+		s.handleMergeError(videoMerge.ID, err)
+		return
+	}
+
+	if videoMerge.PackageHLS {
+		hlsURL, err := s.packageHLS(ctx, videoMerge, result.VideoURL, progress.hls)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				s.handleMergeError(videoMerge.ID, err)
+				return
+			}
+			s.log.Warnw("Failed to package HLS, keeping MP4 only", "error", err, "merge_id", videoMerge.ID)
+		} else {
+			s.db.Model(&models.VideoMerge{}).Where("id = ?", videoMerge.ID).Update("hls_url", hlsURL)
+		}
+	}
+
+	s.completeMerge(videoMerge.ID, result)
+}
+
+// loadBranding 加载合成任务需要应用的剧本品牌配置；预览、显式跳过或剧本未配置品牌包装时返回nil
+func (s *VideoMergeService) loadBranding(videoMerge *models.VideoMerge) *models.Drama {
+	if videoMerge.IsPreview || videoMerge.SkipBranding {
+		return nil
+	}
+	var drama models.Drama
+	if err := s.db.First(&drama, videoMerge.DramaID).Error; err != nil {
+		return nil
+	}
+	if (drama.WatermarkURL == nil || *drama.WatermarkURL == "") && drama.IntroAssetID == nil && drama.OutroAssetID == nil {
+		return nil
+	}
+	return &drama
+}
+
+// applyBranding 为成片叠加剧本水印并拼接片头片尾（原地替换已存储的成片），返回片头时长（秒）
+// 片头片尾素材已被删除或不是视频时跳过该素材
+func (s *VideoMergeService) applyBranding(ctx context.Context, videoMerge *models.VideoMerge, drama *models.Drama, videoURL string, onProgress ffmpeg.ProgressFunc) (float64, error) {
+	inputPath := s.resolveLocalPath(videoURL)
+	if inputPath == videoURL {
+		return 0, fmt.Errorf("merged video is not in local storage: %s", videoURL)
+	}
+
+	position, err := ffmpeg.ParseWatermarkPosition(drama.WatermarkPosition)
+	if err != nil {
+		s.log.Warnw("Invalid watermark position, using default", "drama_id", drama.ID, "error", err)
+		position = ffmpeg.WatermarkTopRight
+	}
+	opts := &ffmpeg.BrandingOptions{
+		InputPath:         inputPath,
+		WatermarkPosition: position,
+		WatermarkOpacity:  drama.WatermarkOpacity,
+		IntroPath:         s.brandingAssetPath(drama.IntroAssetID, "intro"),
+		OutroPath:         s.brandingAssetPath(drama.OutroAssetID, "outro"),
+		Context:           ctx,
+		OnProgress:        onProgress,
+	}
+	if drama.WatermarkURL != nil && *drama.WatermarkURL != "" {
+		opts.WatermarkPath = s.resolveLocalPath(*drama.WatermarkURL)
+	}
+	if opts.WatermarkPath == "" && opts.IntroPath == "" && opts.OutroPath == "" {
+		return 0, nil
+	}
+
+	opts.Profile, _ = resolveOutputProfile(videoMerge.Profile)
+	ext := filepath.Ext(inputPath)
+	opts.OutputPath = strings.TrimSuffix(inputPath, ext) + "_branded" + ext
+
+	introDuration, err := s.ffmpeg.ApplyBranding(opts)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Rename(opts.OutputPath, inputPath); err != nil {
+		os.Remove(opts.OutputPath)
+		return 0, fmt.Errorf("failed to replace merged video: %w", err)
+	}
+
+	s.log.Infow("Branding applied to merged video",
+		"merge_id", videoMerge.ID,
+		"watermark", opts.WatermarkPath != "",
+		"intro", opts.IntroPath != "",
+		"outro", opts.OutroPath != "")
+	return introDuration, nil
+}
+
+// applyAudioBed 按分镜配乐描述从曲库选曲铺在正片原声下（原声出现时自动闪避），并在镜头开始处放置分镜音效
+// （原地替换已存储的成片）；预览合成与没有匹配的曲目与音效时不做处理
+func (s *VideoMergeService) applyAudioBed(ctx context.Context, videoMerge *models.VideoMerge, videoURL string, onProgress ffmpeg.ProgressFunc) error {
+	if videoMerge.IsPreview {
+		return nil
+	}
+
+	var layers []ffmpeg.AudioLayer
+	if !videoMerge.SkipMusic {
+		music, err := s.musicService.MusicLayers(videoMerge, s.resolveLocalPath)
+		if err != nil {
+			return fmt.Errorf("failed to plan music: %w", err)
+		}
+		layers = append(layers, music...)
+	}
+	if !videoMerge.SkipSFX {
+		effects, err := s.sfxService.SFXLayers(videoMerge, s.resolveLocalPath)
+		if err != nil {
+			return fmt.Errorf("failed to plan sound effects: %w", err)
+		}
+		layers = append(layers, effects...)
+	}
+	if len(layers) == 0 {
+		s.log.Infow("No music or sound effects matched for merge, skipping", "merge_id", videoMerge.ID)
+		return nil
+	}
+
+	inputPath := s.resolveLocalPath(videoURL)
+	if inputPath == videoURL {
+		return fmt.Errorf("merged video is not in local storage: %s", videoURL)
+	}
+
+	profile, _ := resolveOutputProfile(videoMerge.Profile)
+	ext := filepath.Ext(inputPath)
+	outputPath := strings.TrimSuffix(inputPath, ext) + "_scored" + ext
+	if err := s.ffmpeg.MixAudioBed(&ffmpeg.AudioBedOptions{
+		InputPath:  inputPath,
+		OutputPath: outputPath,
+		Layers:     layers,
+		Profile:    profile,
+		Context:    ctx,
+		OnProgress: onProgress,
+	}); err != nil {
+		return err
+	}
+	if err := os.Rename(outputPath, inputPath); err != nil {
+		os.Remove(outputPath)
+		return fmt.Errorf("failed to replace merged video: %w", err)
+	}
+
+	s.log.Infow("Audio bed applied", "merge_id", videoMerge.ID, "layers", len(layers))
+	return nil
+}
+
+// brandingAssetPath 片头/片尾素材的本地路径，素材不可用时返回空字符串
+func (s *VideoMergeService) brandingAssetPath(assetID *uint, role string) string {
+	if assetID == nil {
+		return ""
+	}
+	var asset models.Asset
+	if err := s.db.First(&asset, *assetID).Error; err != nil || asset.Type != models.AssetTypeVideo {
+		s.log.Warnw("Branding asset unavailable, skipping", "role", role, "asset_id", *assetID, "error", err)
+		return ""
+	}
+	return s.resolveLocalPath(asset.URL)
+}
+
+// masterAudio 对成片做整体响度统一与限幅（原地替换已存储的成片），并记录处理前后测得的响度
+// 片段已各自统一过响度，这一步处理片头片尾与转场叠化带来的差异，并保证成片峰值不超限
+func (s *VideoMergeService) masterAudio(ctx context.Context, videoMerge *models.VideoMerge, videoURL string, onProgress ffmpeg.ProgressFunc) error {
+	inputPath := s.resolveLocalPath(videoURL)
+	if inputPath == videoURL {
+		return fmt.Errorf("merged video is not in local storage: %s", videoURL)
+	}
+
+	profile, _ := resolveOutputProfile(videoMerge.Profile)
+	ext := filepath.Ext(inputPath)
+	outputPath := strings.TrimSuffix(inputPath, ext) + "_mastered" + ext
+	result, err := s.ffmpeg.MasterAudio(&ffmpeg.MasteringOptions{
+		InputPath:  inputPath,
+		OutputPath: outputPath,
+		Target:     videoMerge.LoudnessTarget,
+		Profile:    profile,
+		Context:    ctx,
+		OnProgress: onProgress,
+	})
+	if err != nil {
+		return err
+	}
+	if result.Input.Silent() {
+		s.log.Infow("Merged video has no audible audio, skipping mastering", "merge_id", videoMerge.ID)
+		return nil
+	}
+	if err := os.Rename(outputPath, inputPath); err != nil {
+		os.Remove(outputPath)
+		return fmt.Errorf("failed to replace merged video: %w", err)
+	}
+
+	updates := map[string]interface{}{
+		"measured_loudness": result.Input.Integrated,
+	}
+	if result.Output != nil && !result.Output.Silent() {
+		updates["loudness"] = result.Output.Integrated
+		updates["true_peak"] = result.Output.TruePeak
+		updates["loudness_range"] = result.Output.Range
+	}
+	s.db.Model(&models.VideoMerge{}).Where("id = ?", videoMerge.ID).Updates(updates)
+
+	s.log.Infow("Audio mastered", "merge_id", videoMerge.ID, "measured_lufs", result.Input.Integrated, "output", result.Output)
+	return nil
+}
+
+// applySubtitles 根据分镜对白生成与成片对齐的字幕：始终输出WebVTT字幕文件供播放器加载，
+// 并按字幕处理方式将字幕烧录进画面或封装为软字幕流（原地替换已存储的成片）
+func (s *VideoMergeService) applySubtitles(ctx context.Context, videoMerge *models.VideoMerge, videoURL string, onProgress ffmpeg.ProgressFunc) error {
+	cues, err := s.subtitleService.CuesForMerge(videoMerge)
+	if err != nil || len(cues) == 0 {
+		if videoMerge.SubtitleMode != "" {
+			s.log.Warnw("No subtitles available for merge, skipping", "merge_id", videoMerge.ID, "error", err)
+		}
+		return nil
+	}
+
+	inputPath := s.resolveLocalPath(videoURL)
+	if inputPath == videoURL {
+		if videoMerge.SubtitleMode != "" {
+			return fmt.Errorf("merged video is not in local storage: %s", videoURL)
+		}
+		return nil
+	}
+
+	width, height := s.subtitleService.mergeResolution(videoMerge)
+
+	vtt := ffmpeg.BuildSubtitles(cues, ffmpeg.SubtitleVTT, nil, width, height)
+	subtitleURL, err := s.storage.Upload(strings.NewReader(vtt), fmt.Sprintf("episode_%d_merge_%d.vtt", videoMerge.EpisodeID, videoMerge.ID), "subtitles")
+	if err != nil {
+		s.log.Warnw("Failed to store subtitles", "merge_id", videoMerge.ID, "error", err)
+	} else {
+		s.db.Model(&models.VideoMerge{}).Where("id = ?", videoMerge.ID).Update("subtitle_url", subtitleURL)
+	}
+
+	if videoMerge.SubtitleMode == "" {
+		return nil
+	}
+
+	var style *ffmpeg.SubtitleStyle
+	if len(videoMerge.SubtitleStyle) > 0 {
+		if err := json.Unmarshal(videoMerge.SubtitleStyle, &style); err != nil {
+			s.log.Warnw("Invalid subtitle style, using defaults", "merge_id", videoMerge.ID, "error", err)
+		}
+	}
+
+	// 烧录使用ASS以应用字体与样式，软字幕使用SRT以兼容mp4的mov_text
+	burn := videoMerge.SubtitleMode == SubtitleModeBurn
+	format := ffmpeg.SubtitleSRT
+	if burn {
+		format = ffmpeg.SubtitleASS
+	}
+	subtitlePath := filepath.Join(os.TempDir(), "drama-video-merge", fmt.Sprintf("subtitle_%d_%d.%s", videoMerge.ID, time.Now().UnixNano(), format))
+	if err := os.WriteFile(subtitlePath, []byte(ffmpeg.BuildSubtitles(cues, format, style, width, height)), 0644); err != nil {
+		return fmt.Errorf("failed to write subtitles: %w", err)
+	}
+	defer os.Remove(subtitlePath)
+
+	profile, _ := resolveOutputProfile(videoMerge.Profile)
+	if videoMerge.IsPreview {
+		if profile == nil {
+			profile, _ = ffmpeg.GetOutputProfile(ffmpeg.DefaultOutputProfile)
+		}
+		profile = profile.Preview()
+	}
+
+	ext := filepath.Ext(inputPath)
+	outputPath := strings.TrimSuffix(inputPath, ext) + "_subtitled" + ext
+	if err := s.ffmpeg.ApplySubtitles(&ffmpeg.SubtitleApplyOptions{
+		InputPath:    inputPath,
+		OutputPath:   outputPath,
+		SubtitlePath: subtitlePath,
+		Burn:         burn,
+		Profile:      profile,
+		Context:      ctx,
+		OnProgress:   onProgress,
+	}); err != nil {
+		return err
+	}
+	if err := os.Rename(outputPath, inputPath); err != nil {
+		os.Remove(outputPath)
+		return fmt.Errorf("failed to replace merged video: %w", err)
+	}
+
+	s.log.Infow("Subtitles applied to merged video", "merge_id", videoMerge.ID, "mode", videoMerge.SubtitleMode, "cues", len(cues))
+	return nil
+}
+
+// recordResolution 探测成片实际分辨率并写回合成记录，字幕坐标系与导出均以此为准
+func (s *VideoMergeService) recordResolution(ctx context.Context, videoMerge *models.VideoMerge, videoURL string) {
+	info, err := s.ffmpeg.Probe(ctx, s.resolveLocalPath(videoURL))
+	if err != nil || info.Width <= 0 || info.Height <= 0 {
+		s.log.Warnw("Failed to probe merged video resolution", "merge_id", videoMerge.ID, "error", err)
 		return
 	}
+	videoMerge.Width = &info.Width
+	videoMerge.Height = &info.Height
+	s.db.Model(&models.VideoMerge{}).Where("id = ?", videoMerge.ID).Updates(map[string]interface{}{
+		"width":  info.Width,
+		"height": info.Height,
+	})
+}
+
+// packageHLS 将已存储的成片切片为HLS，切片先输出到临时目录，再按原文件名存入存储目录 videos/hls 下，返回主播放列表URL
+func (s *VideoMergeService) packageHLS(ctx context.Context, videoMerge *models.VideoMerge, videoURL string, onProgress ffmpeg.ProgressFunc) (string, error) {
+	inputPath := s.resolveLocalPath(videoURL)
+	if inputPath == videoURL {
+		return "", fmt.Errorf("merged video is not in local storage: %s", videoURL)
+	}
+
+	workDir, err := os.MkdirTemp("", "hls_")
+	if err != nil {
+		return "", fmt.Errorf("failed to create hls work directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	if _, err := s.ffmpeg.PackageHLS(&ffmpeg.HLSOptions{
+		InputPath:  inputPath,
+		OutputDir:  workDir,
+		Context:    ctx,
+		OnProgress: onProgress,
+	}); err != nil {
+		return "", err
+	}
+
+	entries, err := os.ReadDir(workDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read hls output: %w", err)
+	}
+
+	// 播放列表按文件名引用切片，存储时保留原文件名
+	category := path.Join("videos", "hls", fmt.Sprintf("episode_%d_%d", videoMerge.EpisodeID, time.Now().Unix()))
+	var masterURL string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		url, err := s.storeHLSFile(filepath.Join(workDir, entry.Name()), category)
+		if err != nil {
+			return "", err
+		}
+		if entry.Name() == ffmpeg.HLSMasterPlaylist {
+			masterURL = url
+		}
+	}
+	if masterURL == "" {
+		return "", fmt.Errorf("hls master playlist not generated")
+	}
+
+	return masterURL, nil
+}
+
+// storeHLSFile 将单个HLS文件存入存储
+func (s *VideoMergeService) storeHLSFile(filePath, category string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open hls file: %w", err)
+	}
+	defer file.Close()
 
-	s.completeMerge(mergeID, result)
+	url, err := s.storage.Save(file, filepath.Base(filePath), category)
+	if err != nil {
+		return "", fmt.Errorf("failed to store hls file: %w", err)
+	}
+	return url, nil
 }
 
-func (s *VideoMergeService) mergeVideoClips(client video.VideoClient, scenes []models.SceneClip) (*video.VideoResult, error) {
+// mergeVideoClips 使用FFmpeg按顺序合成片段，preview 为true时输出带镜头编号与时间码的低分辨率预览
+// loudnessTarget 不为0时各片段先按该目标响度统一音量
+func (s *VideoMergeService) mergeVideoClips(ctx context.Context, scenes []models.SceneClip, profileName string, preview bool, loudnessTarget float64, onProgress ffmpeg.ProgressFunc) (*video.VideoResult, error) {
 	if len(scenes) == 0 {
 		return nil, fmt.Errorf("no scenes to merge")
 	}
@@ -157,15 +693,26 @@ func (s *VideoMergeService) mergeVideoClips(client video.VideoClient, scenes []m
 		totalDuration += scene.Duration
 	}
 
+	// 预览时以分镜编号作为镜头标识
+	var shotNumbers map[uint]int
+	if preview {
+		shotNumbers = s.loadShotNumbers(scenes)
+	}
+
 	// 准备FFmpeg合成选项
 	clips := make([]ffmpeg.VideoClip, len(scenes))
 	for i, scene := range scenes {
 		clips[i] = ffmpeg.VideoClip{
-			URL:        scene.VideoURL,
+			URL:        s.resolveLocalPath(scene.VideoURL),
 			Duration:   scene.Duration,
 			StartTime:  scene.StartTime,
 			EndTime:    scene.EndTime,
 			Transition: scene.Transition,
+			ImageURL:   s.resolveLocalPath(scene.ImageURL),
+			Motion:     ffmpeg.KenBurnsMotion(scene.Motion),
+		}
+		if number, ok := shotNumbers[scene.SceneID]; ok {
+			clips[i].Label = fmt.Sprintf("SHOT %d", number)
 		}
 
 		s.log.Infow("Clip added to merge queue",
@@ -176,66 +723,182 @@ func (s *VideoMergeService) mergeVideoClips(client video.VideoClient, scenes []m
 			"end_time", scene.EndTime)
 	}
 
-	// 创建视频输出目录
-	videoDir := filepath.Join(s.storagePath, "videos", "merged")
-	if err := os.MkdirAll(videoDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create video directory: %w", err)
-	}
+	// 未指定输出配置时由FFmpeg按素材画面方向自动选择
+	profile, ext := resolveOutputProfile(profileName)
 
-	// 生成输出文件名
-	fileName := fmt.Sprintf("merged_%d.mp4", time.Now().Unix())
-	outputPath := filepath.Join(videoDir, fileName)
+	// 先输出到临时文件，再写入存储
+	fileName := fmt.Sprintf("merged_%d%s", time.Now().Unix(), ext)
+	category := "videos/merged"
+	if preview {
+		fileName = fmt.Sprintf("preview_%d.mp4", time.Now().Unix())
+		category = "videos/previews"
+	}
+	outputPath := filepath.Join(os.TempDir(), "drama-video-merge", fileName)
 
 	// 使用FFmpeg合成视频
 	mergedPath, err := s.ffmpeg.MergeVideos(&ffmpeg.MergeOptions{
-		OutputPath: outputPath,
-		Clips:      clips,
+		OutputPath:     outputPath,
+		Clips:          clips,
+		Context:        ctx,
+		OnProgress:     onProgress,
+		Profile:        profile,
+		Preview:        preview,
+		LoudnessTarget: loudnessTarget,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("ffmpeg merge failed: %w", err)
 	}
 
-	s.log.Infow("Video merged successfully", "path", mergedPath)
+	s.log.Infow("Video merged successfully", "path", mergedPath, "preview", preview)
 
-	// 生成访问URL（相对路径）
-	relPath := filepath.Join("videos", "merged", fileName)
-	videoURL := fmt.Sprintf("%s/%s", s.baseURL, relPath)
+	return s.storeMergedVideo(mergedPath, fileName, category, totalDuration)
+}
 
-	result := &video.VideoResult{
-		VideoURL:  videoURL, // 返回可访问的URL
-		Duration:  int(totalDuration),
-		Completed: true,
-		Status:    "completed",
+// loadShotNumbers 查询片段对应的分镜编号
+func (s *VideoMergeService) loadShotNumbers(scenes []models.SceneClip) map[uint]int {
+	var ids []uint
+	for _, scene := range scenes {
+		if scene.SceneID != 0 {
+			ids = append(ids, scene.SceneID)
+		}
+	}
+	numbers := make(map[uint]int)
+	if len(ids) == 0 {
+		return numbers
 	}
 
-	return result, nil
+	var storyboards []models.Storyboard
+	s.db.Select("id", "storyboard_number").Where("id IN ?", ids).Find(&storyboards)
+	for _, sb := range storyboards {
+		numbers[sb.ID] = sb.StoryboardNumber
+	}
+	return numbers
 }
 
-func (s *VideoMergeService) pollMergeStatus(mergeID uint, client video.VideoClient, taskID string) {
-	maxAttempts := 240
-	pollInterval := 5 * time.Second
+// renderTimeline 使用FFmpeg渲染多轨时间线（视频叠加、音频混音、文字、特效）
+func (s *VideoMergeService) renderTimeline(ctx context.Context, videoMerge *models.VideoMerge, onProgress ffmpeg.ProgressFunc) (*video.VideoResult, error) {
+	timelineID, preview := *videoMerge.TimelineID, videoMerge.IsPreview
+
+	// 未指定输出配置时沿用时间线自身的分辨率与帧率
+	profile, ext := resolveOutputProfile(videoMerge.Profile)
+	fileName := fmt.Sprintf("timeline_%d_%d%s", timelineID, time.Now().Unix(), ext)
+	category := "videos/merged"
+	if preview {
+		fileName = fmt.Sprintf("timeline_%d_preview_%d.mp4", timelineID, time.Now().Unix())
+		category = "videos/previews"
+	}
+	outputPath := filepath.Join(os.TempDir(), "drama-video-merge", fileName)
 
-	for i := 0; i < maxAttempts; i++ {
-		time.Sleep(pollInterval)
+	opts, err := s.timelineService.BuildRenderOptions(timelineID, outputPath)
+	if err != nil {
+		return nil, err
+	}
 
-		result, err := client.GetTaskStatus(taskID)
-		if err != nil {
-			s.log.Errorw("Failed to get merge task status", "error", err, "task_id", taskID)
-			continue
+	// 字幕作为独立字幕轨输出时，不再将文字轨道绘制到画面上，避免重复
+	if videoMerge.SubtitleMode != "" {
+		tracks := opts.Tracks[:0]
+		for _, track := range opts.Tracks {
+			if track.Type != ffmpeg.RenderTrackText {
+				tracks = append(tracks, track)
+			}
 		}
+		opts.Tracks = tracks
+	}
 
-		if result.Completed {
-			s.completeMerge(mergeID, result)
-			return
+	// 未开启图片兜底时，缺少视频的片段保持黑场
+	for i := range opts.Tracks {
+		for j := range opts.Tracks[i].Clips {
+			clip := &opts.Tracks[i].Clips[j]
+			clip.URL = s.resolveLocalPath(clip.URL)
+			if videoMerge.StillFallback {
+				clip.ImageURL = s.resolveLocalPath(clip.ImageURL)
+			} else {
+				clip.ImageURL = ""
+			}
 		}
+	}
 
-		if result.Error != "" {
-			s.updateMergeError(mergeID, result.Error)
-			return
+	opts.Context = ctx
+	opts.OnProgress = onProgress
+	opts.Profile = profile
+
+	if preview {
+		// 未指定输出配置时以时间线自身的画面尺寸为基准缩小
+		if profile == nil {
+			base, _ := ffmpeg.GetOutputProfile(ffmpeg.DefaultOutputProfile)
+			if opts.Width > 0 && opts.Height > 0 {
+				base.Width, base.Height = opts.Width, opts.Height
+			}
+			if opts.FPS > 0 {
+				base.FPS = opts.FPS
+			}
+			profile = base
 		}
+		opts.Profile = profile.Preview()
+	}
+
+	if _, err := s.ffmpeg.RenderTimeline(opts); err != nil {
+		return nil, fmt.Errorf("ffmpeg render failed: %w", err)
+	}
+
+	return s.storeMergedVideo(outputPath, fileName, category, opts.Duration)
+}
+
+// resolveOutputProfile 解析输出配置及对应的文件扩展名，名称为空时返回nil
+func resolveOutputProfile(name string) (*ffmpeg.OutputProfile, string) {
+	if name == "" {
+		return nil, ".mp4"
+	}
+	profile, err := ffmpeg.GetOutputProfile(name)
+	if err != nil {
+		return nil, ".mp4"
+	}
+	return profile, profile.Extension()
+}
+
+// resolveLocalPath 将本地存储的访问URL还原为磁盘路径，避免合成时经HTTP回环下载；非本地存储的URL原样返回
+func (s *VideoMergeService) resolveLocalPath(url string) string {
+	if s.storage == nil {
+		return url
+	}
+	if localPath, ok := s.storage.ResolvePath(url); ok {
+		return localPath
+	}
+	return url
+}
+
+// storeMergedVideo 将合成结果写入存储的 category 目录并删除临时文件，时长以实际探测结果为准
+func (s *VideoMergeService) storeMergedVideo(localPath, fileName, category string, expectedDuration float64) (*video.VideoResult, error) {
+	defer os.Remove(localPath)
+
+	duration := expectedDuration
+	if probed, err := s.ffmpeg.GetDuration(localPath); err == nil && probed > 0 {
+		duration = probed
+	} else if err != nil {
+		s.log.Warnw("Failed to probe merged video duration, using expected duration", "error", err)
+	}
+
+	if s.storage == nil {
+		return nil, fmt.Errorf("storage is not available")
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open merged video: %w", err)
 	}
+	defer file.Close()
 
-	s.updateMergeError(mergeID, "timeout: video merge took too long")
+	videoURL, err := s.storage.Upload(file, fileName, category)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store merged video: %w", err)
+	}
+
+	return &video.VideoResult{
+		VideoURL:  videoURL,
+		Duration:  int(math.Round(duration)),
+		Completed: true,
+		Status:    "completed",
+	}, nil
 }
 
 func (s *VideoMergeService) completeMerge(mergeID uint, result *video.VideoResult) {
@@ -257,6 +920,8 @@ func (s *VideoMergeService) completeMerge(mergeID uint, result *video.VideoResul
 		"status":       models.VideoMergeStatusCompleted,
 		"merged_url":   finalVideoURL,
 		"completed_at": now,
+		"progress":     100,
+		"eta_seconds":  nil,
 	}
 
 	if result.Duration > 0 {
@@ -265,13 +930,30 @@ func (s *VideoMergeService) completeMerge(mergeID uint, result *video.VideoResul
 
 	s.db.Model(&models.VideoMerge{}).Where("id = ?", mergeID).Updates(updates)
 
-	// 更新episode的状态和最终视频URL
+	// 预览结果单独记录，不改变剧集状态、成片地址与时间线状态
+	if videoMerge.IsPreview {
+		if videoMerge.EpisodeID != 0 {
+			s.db.Model(&models.Episode{}).Where("id = ?", videoMerge.EpisodeID).Update("preview_url", finalVideoURL)
+		}
+		s.log.Infow("Preview render completed", "id", mergeID, "url", finalVideoURL)
+		return
+	}
+
+	// 更新episode的状态和最终视频URL（未切片/无字幕时清除旧的HLS与字幕地址，避免与新成片不一致）
 	if videoMerge.EpisodeID != 0 {
 		s.db.Model(&models.Episode{}).Where("id = ?", videoMerge.EpisodeID).Updates(map[string]interface{}{
-			"status":    "completed",
-			"video_url": finalVideoURL,
+			"status":       "completed",
+			"video_url":    finalVideoURL,
+			"hls_url":      videoMerge.HLSURL,
+			"subtitle_url": videoMerge.SubtitleURL,
 		})
 		s.log.Infow("Episode finalized", "episode_id", videoMerge.EpisodeID, "video_url", finalVideoURL)
+
+		go s.mediaService.ProcessEpisode(videoMerge.EpisodeID)
+	}
+
+	if videoMerge.TimelineID != nil {
+		s.db.Model(&models.Timeline{}).Where("id = ?", *videoMerge.TimelineID).Update("status", models.TimelineStatusCompleted)
 	}
 
 	s.log.Infow("Video merge completed", "id", mergeID, "url", finalVideoURL)
@@ -282,47 +964,123 @@ func (s *VideoMergeService) updateMergeError(mergeID uint, errorMsg string) {
 		"status":    models.VideoMergeStatusFailed,
 		"error_msg": errorMsg,
 	})
+
+	// 合成失败时时间线回到编辑状态（预览不改变时间线状态）
+	var videoMerge models.VideoMerge
+	if err := s.db.Select("id", "timeline_id", "is_preview").First(&videoMerge, mergeID).Error; err == nil && videoMerge.TimelineID != nil && !videoMerge.IsPreview {
+		s.db.Model(&models.Timeline{}).Where("id = ?", *videoMerge.TimelineID).Update("status", models.TimelineStatusEditing)
+	}
 	s.log.Errorw("Video merge failed", "id", mergeID, "error", errorMsg)
 }
 
-func (s *VideoMergeService) getVideoClient(provider string) (video.VideoClient, error) {
-	config, err := s.aiService.GetDefaultConfig("video")
-	if err != nil {
-		return nil, fmt.Errorf("failed to get video config: %w", err)
-	}
-
-	// 使用第一个模型
-	model := ""
-	if len(config.Model) > 0 {
-		model = config.Model[0]
-	}
-
-	// 根据配置中的 provider 创建对应的客户端
-	var endpoint string
-	var queryEndpoint string
-
-	switch config.Provider {
-	case "runway":
-		return video.NewRunwayClient(config.BaseURL, config.APIKey, model), nil
-	case "pika":
-		return video.NewPikaClient(config.BaseURL, config.APIKey, model), nil
-	case "openai", "sora":
-		return video.NewOpenAISoraClient(config.BaseURL, config.APIKey, model), nil
-	case "minimax":
-		return video.NewMinimaxClient(config.BaseURL, config.APIKey, model), nil
-	case "chatfire":
-		endpoint = "/video/generations"
-		queryEndpoint = "/video/task/{taskId}"
-		return video.NewChatfireClient(config.BaseURL, config.APIKey, model, endpoint, queryEndpoint), nil
-	case "doubao", "volces", "ark":
-		endpoint = "/contents/generations/tasks"
-		queryEndpoint = "/generations/tasks/{taskId}"
-		return video.NewVolcesArkClient(config.BaseURL, config.APIKey, model, endpoint, queryEndpoint), nil
-	default:
-		endpoint = "/contents/generations/tasks"
-		queryEndpoint = "/generations/tasks/{taskId}"
-		return video.NewVolcesArkClient(config.BaseURL, config.APIKey, model, endpoint, queryEndpoint), nil
+// handleMergeError 区分用户取消与真正的失败
+func (s *VideoMergeService) handleMergeError(mergeID uint, err error) {
+	if errors.Is(err, context.Canceled) {
+		s.markMergeCancelled(mergeID)
+		return
+	}
+	s.updateMergeError(mergeID, err.Error())
+}
+
+// markMergeCancelled 标记任务已取消，并将剧集和时间线恢复到合成前的状态
+func (s *VideoMergeService) markMergeCancelled(mergeID uint) {
+	var videoMerge models.VideoMerge
+	if err := s.db.First(&videoMerge, mergeID).Error; err != nil {
+		return
+	}
+	if videoMerge.Status == models.VideoMergeStatusCancelled {
+		return
+	}
+
+	s.db.Model(&videoMerge).Updates(map[string]interface{}{
+		"status":      models.VideoMergeStatusCancelled,
+		"eta_seconds": nil,
+	})
+	if videoMerge.IsPreview {
+		s.log.Infow("Preview render cancelled", "id", mergeID)
+		return
+	}
+
+	if videoMerge.EpisodeID != 0 {
+		s.db.Model(&models.Episode{}).
+			Where("id = ? AND status = ?", videoMerge.EpisodeID, "processing").
+			Update("status", gorm.Expr("CASE WHEN video_url IS NULL OR video_url = '' THEN 'draft' ELSE 'completed' END"))
+	}
+	if videoMerge.TimelineID != nil {
+		s.db.Model(&models.Timeline{}).Where("id = ?", *videoMerge.TimelineID).Update("status", models.TimelineStatusEditing)
+	}
+
+	s.log.Infow("Video merge cancelled", "id", mergeID)
+}
+
+// progressReporter 将ffmpeg进度写入合成记录（每秒最多写一次），并根据已用时间估算剩余时间
+func (s *VideoMergeService) progressReporter(mergeID uint) ffmpeg.ProgressFunc {
+	startedAt := time.Now()
+	var lastUpdate time.Time
+	lastPercent := -1
+
+	return func(percent float64) {
+		p := int(percent)
+		if p == lastPercent || (time.Since(lastUpdate) < time.Second && p < 100) {
+			return
+		}
+		lastPercent = p
+		lastUpdate = time.Now()
+
+		updates := map[string]interface{}{"progress": p}
+		if percent > 1 {
+			elapsed := time.Since(startedAt).Seconds()
+			eta := int(elapsed * (100 - percent) / percent)
+			updates["eta_seconds"] = eta
+		}
+		s.db.Model(&models.VideoMerge{}).Where("id = ?", mergeID).Updates(updates)
+	}
+}
+
+// mergeJobs 记录正在本地运行的合成任务，供取消使用
+// 多个 VideoMergeService 实例（剧集完成、视频合成接口）共享同一份记录
+var mergeJobs = struct {
+	sync.Mutex
+	jobs map[uint]*mergeJob
+}{jobs: make(map[uint]*mergeJob)}
+
+type mergeJob struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func registerMergeJob(mergeID uint) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &mergeJob{cancel: cancel, done: make(chan struct{})}
+
+	mergeJobs.Lock()
+	mergeJobs.jobs[mergeID] = job
+	mergeJobs.Unlock()
+
+	return ctx, func() {
+		mergeJobs.Lock()
+		delete(mergeJobs.jobs, mergeID)
+		mergeJobs.Unlock()
+		cancel()
+		close(job.done)
+	}
+}
+
+// cancelMergeJob 取消正在运行的任务并等待其退出，任务不存在时返回false
+func cancelMergeJob(mergeID uint) bool {
+	mergeJobs.Lock()
+	job, ok := mergeJobs.jobs[mergeID]
+	mergeJobs.Unlock()
+	if !ok {
+		return false
+	}
+
+	job.cancel()
+	select {
+	case <-job.done:
+	case <-time.After(30 * time.Second):
 	}
+	return true
 }
 
 func (s *VideoMergeService) GetMerge(mergeID uint) (*models.VideoMerge, error) {
@@ -358,7 +1116,12 @@ func (s *VideoMergeService) ListMerges(episodeID *string, status string, page, p
 	return merges, total, nil
 }
 
+// DeleteMerge 删除合成记录；若任务仍在本地运行，先终止ffmpeg进程并等待临时文件清理完成
 func (s *VideoMergeService) DeleteMerge(mergeID uint) error {
+	if cancelMergeJob(mergeID) {
+		s.markMergeCancelled(mergeID)
+	}
+
 	result := s.db.Where("id = ? ", mergeID).Delete(&models.VideoMerge{})
 	if result.Error != nil {
 		return result.Error
@@ -381,9 +1144,23 @@ type TimelineClip struct {
 }
 
 // FinalizeEpisodeRequest 完成剧集制作请求
+// 优先使用已保存的时间线（TimelineID），Clips 仅为兼容旧版前端保留
 type FinalizeEpisodeRequest struct {
-	EpisodeID string         `json:"episode_id"`
-	Clips     []TimelineClip `json:"clips"`
+	EpisodeID     string                `json:"episode_id"`
+	TimelineID    *uint                 `json:"timeline_id"`
+	Profile       string                `json:"profile"`        // 输出配置名称，为空时自动选择
+	PackageHLS    bool                  `json:"package_hls"`    // 合成后切片为多码率HLS
+	Preview       bool                  `json:"preview"`        // 仅渲染低分辨率预览，不改变剧集状态与成片
+	StillFallback bool                  `json:"still_fallback"` // 缺少视频的分镜使用合成图或最近生成的分镜图片生成运镜（Ken Burns）片段，而不是跳过
+	Subtitles     string                `json:"subtitles"`      // 字幕处理：burn 烧录、soft 软字幕；为空时仅生成WebVTT字幕文件
+	SubtitleStyle *ffmpeg.SubtitleStyle `json:"subtitle_style"` // 字幕字体与样式
+	SkipBranding  bool                  `json:"skip_branding"`  // 不应用剧本的水印与片头片尾
+	SkipMusic     bool                  `json:"skip_music"`     // 不按分镜配乐描述自动铺背景音乐
+	SkipSFX       bool                  `json:"skip_sfx"`       // 不在镜头开始处放置分镜音效
+	// 目标响度（LUFS），为空时使用 -16；SkipLoudnorm 为true时保持各片段原有音量
+	LoudnessTarget *float64       `json:"loudness_target"`
+	SkipLoudnorm   bool           `json:"skip_loudnorm"`
+	Clips          []TimelineClip `json:"clips"`
 }
 
 // FinalizeEpisode 完成集数制作，根据时间线场景顺序合成最终视频
@@ -394,17 +1171,115 @@ func (s *VideoMergeService) FinalizeEpisode(episodeID string, timelineData *Fina
 		return nil, fmt.Errorf("episode not found")
 	}
 
+	sceneClips, skippedScenes, err := s.BuildEpisodeScenes(&episode, timelineData)
+	if err != nil {
+		return nil, err
+	}
+
+	// 构建分镜ID映射
+	sceneMap := make(map[string]models.Storyboard)
+	for _, scene := range episode.Storyboards {
+		sceneMap[fmt.Sprintf("%d", scene.ID)] = scene
+	}
+
+	// 检查是否至少有一个场景可以合成
+	if len(sceneClips) == 0 {
+		return nil, fmt.Errorf("no scenes with videos available for merging")
+	}
+
+	// 创建视频合成任务
+	title := fmt.Sprintf("%s - 第%d集", episode.Drama.Title, episode.EpisodeNum)
+
+	finalReq := &MergeVideoRequest{
+		EpisodeID: episodeID,
+		DramaID:   fmt.Sprintf("%d", episode.DramaID),
+		Title:     title,
+		Scenes:    sceneClips,
+		Provider:  LocalMergeProvider, // 默认使用本地FFmpeg合成
+	}
+	if timelineData != nil {
+		finalReq.TimelineID = timelineData.TimelineID
+		finalReq.Profile = timelineData.Profile
+		finalReq.PackageHLS = timelineData.PackageHLS
+		finalReq.Preview = timelineData.Preview
+		finalReq.StillFallback = timelineData.StillFallback
+		finalReq.Subtitles = timelineData.Subtitles
+		finalReq.SubtitleStyle = timelineData.SubtitleStyle
+		finalReq.SkipBranding = timelineData.SkipBranding
+		finalReq.SkipMusic = timelineData.SkipMusic
+		finalReq.SkipSFX = timelineData.SkipSFX
+		finalReq.LoudnessTarget = timelineData.LoudnessTarget
+		finalReq.SkipLoudnorm = timelineData.SkipLoudnorm
+	}
+
+	// 执行视频合成
+	videoMerge, err := s.MergeVideos(finalReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start video merge: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"message":      "视频合成任务已创建，正在后台处理",
+		"merge_id":     videoMerge.ID,
+		"episode_id":   episodeID,
+		"scenes_count": len(sceneClips),
+		"preview":      finalReq.Preview,
+	}
+
+	// 预览渲染不改变剧集状态
+	if finalReq.Preview {
+		result["message"] = "预览渲染任务已创建，正在后台处理"
+	} else {
+		s.db.Model(&episode).Updates(map[string]interface{}{
+			"status": "processing",
+		})
+	}
+
+	// 使用图片生成运镜片段的分镜编号
+	var stillScenes []int
+	for _, clip := range sceneClips {
+		if clip.VideoURL == "" {
+			if scene, exists := sceneMap[fmt.Sprintf("%d", clip.SceneID)]; exists {
+				stillScenes = append(stillScenes, scene.StoryboardNumber)
+			}
+		}
+	}
+	if len(stillScenes) > 0 {
+		result["still_scenes"] = stillScenes
+	}
+
+	// 如果有跳过的场景，添加提示信息
+	if len(skippedScenes) > 0 {
+		result["skipped_scenes"] = skippedScenes
+		result["warning"] = fmt.Sprintf("已跳过 %d 个未生成视频的场景（场景编号：%v）", len(skippedScenes), skippedScenes)
+	}
+
+	return result, nil
+}
+
+// BuildEpisodeScenes 按合成顺序解析剧集的场景片段：优先使用已保存的时间线，其次前端提供的片段，
+// 否则按分镜默认顺序；返回缺少素材而被跳过的分镜编号
+func (s *VideoMergeService) BuildEpisodeScenes(episode *models.Episode, timelineData *FinalizeEpisodeRequest) ([]models.SceneClip, []int, error) {
 	// 构建分镜ID映射
 	sceneMap := make(map[string]models.Storyboard)
 	for _, scene := range episode.Storyboards {
 		sceneMap[fmt.Sprintf("%d", scene.ID)] = scene
 	}
 
-	// 根据时间线数据构建场景片段
 	var sceneClips []models.SceneClip
 	var skippedScenes []int
+	stillFallback := timelineData != nil && timelineData.StillFallback
 
-	if timelineData != nil && len(timelineData.Clips) > 0 {
+	if timelineData != nil && timelineData.TimelineID != nil {
+		// 使用已保存的时间线
+		clips, skipped, err := s.timelineService.BuildSceneClips(*timelineData.TimelineID, episode.ID, stillFallback)
+		if err != nil {
+			return nil, nil, err
+		}
+		sceneClips = clips
+		skippedScenes = skipped
+		s.log.Infow("Using saved timeline", "timeline_id", *timelineData.TimelineID, "clips_count", len(sceneClips))
+	} else if timelineData != nil && len(timelineData.Clips) > 0 {
 		// 使用前端提供的时间线数据
 		for _, clip := range timelineData.Clips {
 			// 优先使用素材库中的视频（通过AssetID）
@@ -441,6 +1316,20 @@ func (s *VideoMergeService) FinalizeEpisode(episodeID string, timelineData *Fina
 				}
 			}
 
+			// 仍然没有视频时，按需使用分镜图片生成运镜片段
+			var stillClip *models.SceneClip
+			if videoURL == "" && stillFallback {
+				if scene, exists := sceneMap[clip.StoryboardID]; exists {
+					stillClip = s.buildStillClip(&scene, clip.Duration)
+				}
+			}
+			if stillClip != nil {
+				stillClip.Order = clip.Order
+				stillClip.Transition = clip.Transition
+				sceneClips = append(sceneClips, *stillClip)
+				continue
+			}
+
 			// 如果仍然没有视频URL，跳过该片段
 			if videoURL == "" {
 				s.log.Warnw("No video available for clip, skipping", "clip", clip)
@@ -470,7 +1359,7 @@ func (s *VideoMergeService) FinalizeEpisode(episodeID string, timelineData *Fina
 	} else {
 		// 没有时间线数据，使用默认场景顺序
 		if len(episode.Storyboards) == 0 {
-			return nil, fmt.Errorf("no scenes found for this episode")
+			return nil, nil, fmt.Errorf("no scenes found for this episode")
 		}
 
 		order := 0
@@ -495,6 +1384,15 @@ func (s *VideoMergeService) FinalizeEpisode(episodeID string, timelineData *Fina
 					"video_url", videoURL)
 			}
 
+			if videoURL == "" && stillFallback {
+				if stillClip := s.buildStillClip(&scene, float64(scene.Duration)); stillClip != nil {
+					stillClip.Order = order
+					sceneClips = append(sceneClips, *stillClip)
+					order++
+					continue
+				}
+			}
+
 			// 跳过没有视频的场景
 			if videoURL == "" {
 				s.log.Warnw("Scene has no video, skipping", "storyboard_number", scene.StoryboardNumber)
@@ -513,45 +1411,31 @@ func (s *VideoMergeService) FinalizeEpisode(episodeID string, timelineData *Fina
 		}
 	}
 
-	// 检查是否至少有一个场景可以合成
-	if len(sceneClips) == 0 {
-		return nil, fmt.Errorf("no scenes with videos available for merging")
-	}
-
-	// 创建视频合成任务
-	title := fmt.Sprintf("%s - 第%d集", episode.Drama.Title, episode.EpisodeNum)
+	return sceneClips, skippedScenes, nil
+}
 
-	finalReq := &MergeVideoRequest{
-		EpisodeID: episodeID,
-		DramaID:   fmt.Sprintf("%d", episode.DramaID),
-		Title:     title,
-		Scenes:    sceneClips,
-		Provider:  "doubao", // 默认使用doubao
+// buildStillClip 为缺少视频的分镜构建图片运镜片段，分镜没有可用图片时返回nil
+func (s *VideoMergeService) buildStillClip(scene *models.Storyboard, duration float64) *models.SceneClip {
+	imageURL := s.timelineService.ResolveStoryboardImageURL(scene)
+	if imageURL == "" {
+		return nil
 	}
-
-	// 执行视频合成
-	videoMerge, err := s.MergeVideos(finalReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to start video merge: %w", err)
+	if duration <= 0 {
+		duration = float64(scene.Duration)
 	}
 
-	// 更新episode状态为processing
-	s.db.Model(&episode).Updates(map[string]interface{}{
-		"status": "processing",
-	})
-
-	result := map[string]interface{}{
-		"message":      "视频合成任务已创建，正在后台处理",
-		"merge_id":     videoMerge.ID,
-		"episode_id":   episodeID,
-		"scenes_count": len(sceneClips),
+	clip := &models.SceneClip{
+		SceneID:  scene.ID,
+		ImageURL: imageURL,
+		Duration: duration,
 	}
-
-	// 如果有跳过的场景，添加提示信息
-	if len(skippedScenes) > 0 {
-		result["skipped_scenes"] = skippedScenes
-		result["warning"] = fmt.Sprintf("已跳过 %d 个未生成视频的场景（场景编号：%v）", len(skippedScenes), skippedScenes)
+	if scene.Movement != nil {
+		clip.Motion = string(ffmpeg.MotionFromMovement(*scene.Movement))
 	}
 
-	return result, nil
+	s.log.Infow("Using still image for storyboard without video",
+		"storyboard_number", scene.StoryboardNumber,
+		"duration", duration,
+		"motion", clip.Motion)
+	return clip
 }
@@ -25,6 +25,8 @@ type Asset struct {
 	Category     *string   `gorm:"type:varchar(50);index" json:"category,omitempty"`
 	URL          string    `gorm:"type:varchar(1000);not null" json:"url"`
 	ThumbnailURL *string   `gorm:"type:varchar(1000)" json:"thumbnail_url,omitempty"`
+	SpriteURL    *string   `gorm:"type:varchar(1000)" json:"sprite_url,omitempty"`                                 // 拖动预览雪碧图
+	ThumbnailVTT *string   `gorm:"column:thumbnail_vtt_url;type:varchar(1000)" json:"thumbnail_vtt_url,omitempty"` // WebVTT缩略图轨道
 	LocalPath    *string   `gorm:"type:varchar(500)" json:"local_path,omitempty"`
 
 	FileSize *int64  `json:"file_size,omitempty"`
@@ -34,6 +36,11 @@ type Asset struct {
 	Duration *int    `json:"duration,omitempty"`
 	Format   *string `gorm:"type:varchar(50)" json:"format,omitempty"`
 
+	VideoCodec *string  `gorm:"type:varchar(50)" json:"video_codec,omitempty"`
+	AudioCodec *string  `gorm:"type:varchar(50)" json:"audio_codec,omitempty"`
+	FPS        *float64 `json:"fps,omitempty"`
+	HasAudio   *bool    `json:"has_audio,omitempty"`
+
 	ImageGenID *uint           `gorm:"index" json:"image_gen_id,omitempty"`
 	ImageGen   ImageGeneration `gorm:"foreignKey:ImageGenID" json:"image_gen,omitempty"`
 
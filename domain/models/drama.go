@@ -19,9 +19,15 @@ type Drama struct {
 	Thumbnail     *string        `gorm:"type:varchar(500)" json:"thumbnail"`
 	Tags          datatypes.JSON `gorm:"type:json" json:"tags"`
 	Metadata      datatypes.JSON `gorm:"type:json" json:"metadata"`
-	CreatedAt     time.Time      `gorm:"not null;autoCreateTime" json:"created_at"`
-	UpdatedAt     time.Time      `gorm:"not null;autoUpdateTime" json:"updated_at"`
-	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+	// 品牌包装：合成成片时自动叠加水印、拼接片头片尾
+	WatermarkURL      *string        `gorm:"type:varchar(500)" json:"watermark_url,omitempty"`
+	WatermarkPosition string         `gorm:"type:varchar(20);default:'top_right'" json:"watermark_position"` // top_left/top_right/bottom_left/bottom_right/center
+	WatermarkOpacity  float64        `gorm:"default:0.8" json:"watermark_opacity"`                           // 0-1
+	IntroAssetID      *uint          `json:"intro_asset_id,omitempty"`                                       // 片头视频素材
+	OutroAssetID      *uint          `json:"outro_asset_id,omitempty"`                                       // 片尾视频素材
+	CreatedAt         time.Time      `gorm:"not null;autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time      `gorm:"not null;autoUpdateTime" json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
 
 	Episodes   []Episode   `gorm:"foreignKey:DramaID" json:"episodes,omitempty"`
 	Characters []Character `gorm:"foreignKey:DramaID" json:"characters,omitempty"`
@@ -41,6 +47,8 @@ type Character struct {
 	Appearance      *string        `gorm:"type:text" json:"appearance"`
 	Personality     *string        `gorm:"type:text" json:"personality"`
 	VoiceStyle      *string        `gorm:"type:varchar(200)" json:"voice_style"`
+	Voice           *string        `gorm:"type:varchar(100)" json:"voice"` // 配音音色ID，为空时使用语音服务的默认音色
+	VoiceSpeed      *float64       `json:"voice_speed"`                    // 配音语速倍率
 	ImageURL        *string        `gorm:"type:varchar(500)" json:"image_url"`
 	ReferenceImages datatypes.JSON `gorm:"type:json" json:"reference_images"`
 	SeedValue       *string        `gorm:"type:varchar(100)" json:"seed_value"`
@@ -71,7 +79,12 @@ type Episode struct {
 	Duration      int            `gorm:"default:0" json:"duration"` // 总时长（秒）
 	Status        string         `gorm:"type:varchar(20);default:'draft'" json:"status"`
 	VideoURL      *string        `gorm:"type:varchar(500)" json:"video_url"`
+	HLSURL        *string        `gorm:"column:hls_url;type:varchar(500)" json:"hls_url,omitempty"` // HLS主播放列表地址
+	SubtitleURL   *string        `gorm:"type:varchar(500)" json:"subtitle_url,omitempty"`           // WebVTT字幕文件地址
+	PreviewURL    *string        `gorm:"type:varchar(500)" json:"preview_url,omitempty"`            // 最近一次预览渲染的视频地址
 	Thumbnail     *string        `gorm:"type:varchar(500)" json:"thumbnail"`
+	SpriteURL     *string        `gorm:"type:varchar(500)" json:"sprite_url,omitempty"`                                 // 拖动预览雪碧图
+	ThumbnailVTT  *string        `gorm:"column:thumbnail_vtt_url;type:varchar(500)" json:"thumbnail_vtt_url,omitempty"` // WebVTT缩略图轨道
 	CreatedAt     time.Time      `gorm:"not null;autoCreateTime" json:"created_at"`
 	UpdatedAt     time.Time      `gorm:"not null;autoUpdateTime" json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
@@ -105,6 +118,7 @@ type Storyboard struct {
 	VideoPrompt      *string        `gorm:"type:text" json:"video_prompt"`
 	BgmPrompt        *string        `gorm:"type:text" json:"bgm_prompt"`
 	SoundEffect      *string        `gorm:"size:255" json:"sound_effect"`
+	SFXID            *uint          `gorm:"column:sfx_id;index" json:"sfx_id,omitempty"` // 音效库中选定的音效
 	Dialogue         *string        `gorm:"type:text" json:"dialogue"`
 	Description      *string        `gorm:"type:text" json:"description"`
 	Duration         int            `gorm:"default:5" json:"duration"`
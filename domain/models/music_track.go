@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MusicTrack 背景音乐曲库中的一首曲目，音频本身存储为音频素材
+// 剧本ID为空的曲目为全局曲库，所有剧本均可使用
+type MusicTrack struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	DramaID *uint  `gorm:"index" json:"drama_id,omitempty"`
+	AssetID *uint  `gorm:"index" json:"asset_id,omitempty"`
+	Asset   *Asset `gorm:"foreignKey:AssetID" json:"asset,omitempty"`
+
+	Title    string      `gorm:"type:varchar(200);not null" json:"title"`
+	Mood     string      `gorm:"type:varchar(30);index" json:"mood"`  // 情绪：tense、sad、happy 等，见 MusicMoods
+	Tempo    string      `gorm:"type:varchar(20);index" json:"tempo"` // 节奏：slow、medium、fast
+	BPM      *int        `json:"bpm,omitempty"`
+	Tags     *string     `gorm:"type:varchar(500)" json:"tags,omitempty"` // 逗号分隔的风格/乐器标签，参与提示词匹配
+	Duration *float64    `json:"duration,omitempty"`                      // 秒
+	Source   MusicSource `gorm:"type:varchar(20);not null;default:'library'" json:"source"`
+
+	// 生成曲目的任务信息，曲库上传的曲目为空
+	Prompt      *string          `gorm:"type:text" json:"prompt,omitempty"`
+	Provider    string           `gorm:"type:varchar(50)" json:"provider,omitempty"`
+	Model       string           `gorm:"type:varchar(100)" json:"model,omitempty"`
+	TaskID      *string          `gorm:"type:varchar(200)" json:"task_id,omitempty"`
+	Status      MusicTrackStatus `gorm:"type:varchar(20);not null;default:'completed';index" json:"status"`
+	ErrorMsg    *string          `gorm:"type:text" json:"error_msg,omitempty"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+}
+
+func (MusicTrack) TableName() string {
+	return "music_tracks"
+}
+
+type MusicSource string
+
+const (
+	MusicSourceLibrary   MusicSource = "library"
+	MusicSourceGenerated MusicSource = "generated"
+)
+
+type MusicTrackStatus string
+
+const (
+	MusicStatusProcessing MusicTrackStatus = "processing"
+	MusicStatusCompleted  MusicTrackStatus = "completed"
+	MusicStatusFailed     MusicTrackStatus = "failed"
+)
+
+// 曲目节奏
+const (
+	MusicTempoSlow   = "slow"
+	MusicTempoMedium = "medium"
+	MusicTempoFast   = "fast"
+)
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// PromptTemplate 提示词模板的一个版本，每次修改都保存为新版本，同一作用域内只有一个启用版本
+// 剧本ID为空的是全局模板，非空的是该剧本的覆盖，渲染时优先使用剧本覆盖
+type PromptTemplate struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Key       string         `gorm:"column:template_key;type:varchar(100);not null;index:idx_prompt_templates_scope" json:"key"` // 见 prompt.Key* 常量
+	DramaID   *uint          `gorm:"index:idx_prompt_templates_scope" json:"drama_id,omitempty"`
+	Version   int            `gorm:"not null" json:"version"`
+	Content   string         `gorm:"type:text;not null" json:"content"`
+	Variables datatypes.JSON `gorm:"type:json" json:"variables"` // 模板引用的变量名
+	IsActive  bool           `gorm:"default:false;index" json:"is_active"`
+	Note      *string        `gorm:"type:varchar(500)" json:"note,omitempty"` // 修改说明
+}
+
+func (PromptTemplate) TableName() string {
+	return "prompt_templates"
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SFX 音效库中的一条音效，音频本身存储为音频素材
+type SFX struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	AssetID uint   `gorm:"not null;index" json:"asset_id"`
+	Asset   *Asset `gorm:"foreignKey:AssetID" json:"asset,omitempty"`
+
+	Name     string   `gorm:"type:varchar(200);not null" json:"name"`
+	Category string   `gorm:"type:varchar(100);index" json:"category"`     // 分类，导入目录时取子目录名
+	Keywords *string  `gorm:"type:varchar(500)" json:"keywords,omitempty"` // 逗号分隔的关键词，用于匹配分镜音效描述
+	Duration *float64 `json:"duration,omitempty"`                          // 秒
+}
+
+func (SFX) TableName() string {
+	return "sound_effects"
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// SpeechGeneration 分镜对白中一句台词的配音
+type SpeechGeneration struct {
+	ID            uint                   `gorm:"primarykey" json:"id"`
+	DramaID       uint                   `gorm:"not null;index" json:"drama_id"`
+	EpisodeID     uint                   `gorm:"not null;index" json:"episode_id"`
+	StoryboardID  uint                   `gorm:"not null;index" json:"storyboard_id"`
+	CharacterID   *uint                  `gorm:"index" json:"character_id,omitempty"`
+	LineIndex     int                    `gorm:"not null;default:0" json:"line_index"` // 台词在分镜对白中的序号，从0开始
+	Speaker       string                 `gorm:"size:100" json:"speaker"`              // 对白中的说话人，旁白为空
+	Text          string                 `gorm:"type:text;not null" json:"text"`
+	Provider      string                 `gorm:"size:50;not null" json:"provider"`
+	Model         string                 `gorm:"size:100" json:"model"`
+	Voice         string                 `gorm:"size:100" json:"voice"`
+	Speed         float64                `gorm:"default:1" json:"speed"`
+	AudioURL      *string                `gorm:"type:text" json:"audio_url,omitempty"`
+	LocalPath     *string                `gorm:"type:text" json:"local_path,omitempty"`
+	Duration      *float64               `json:"duration,omitempty"` // 秒
+	AssetID       *uint                  `gorm:"index" json:"asset_id,omitempty"`
+	IsPlaceholder bool                   `gorm:"default:false" json:"is_placeholder"` // 未配置语音服务时由本地替代实现生成的占位音频
+	Status        SpeechGenerationStatus `gorm:"size:20;not null;default:'pending'" json:"status"`
+	ErrorMsg      *string                `gorm:"type:text" json:"error_msg,omitempty"`
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+	CompletedAt   *time.Time             `json:"completed_at,omitempty"`
+
+	Storyboard *Storyboard `gorm:"foreignKey:StoryboardID" json:"storyboard,omitempty"`
+	Character  *Character  `gorm:"foreignKey:CharacterID" json:"character,omitempty"`
+}
+
+func (SpeechGeneration) TableName() string {
+	return "speech_generations"
+}
+
+type SpeechGenerationStatus string
+
+const (
+	SpeechStatusPending    SpeechGenerationStatus = "pending"
+	SpeechStatusProcessing SpeechGenerationStatus = "processing"
+	SpeechStatusCompleted  SpeechGenerationStatus = "completed"
+	SpeechStatusFailed     SpeechGenerationStatus = "failed"
+)
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Transcription 音频/视频素材的语音识别结果
+type Transcription struct {
+	ID          uint                `gorm:"primarykey" json:"id"`
+	AssetID     uint                `gorm:"not null;index" json:"asset_id"`
+	Provider    string              `gorm:"size:50;not null" json:"provider"`
+	Model       string              `gorm:"size:100" json:"model"`
+	Language    string              `gorm:"size:20" json:"language"` // 请求指定或服务识别出的语言
+	Prompt      *string             `gorm:"type:text" json:"prompt,omitempty"`
+	Text        *string             `gorm:"type:text" json:"text,omitempty"`
+	Segments    []TranscriptSegment `gorm:"type:text;serializer:json" json:"segments"`
+	Duration    *float64            `json:"duration,omitempty"` // 秒
+	Status      TranscriptionStatus `gorm:"size:20;not null;default:'pending'" json:"status"`
+	ErrorMsg    *string             `gorm:"type:text" json:"error_msg,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+	CompletedAt *time.Time          `json:"completed_at,omitempty"`
+
+	Asset *Asset `gorm:"foreignKey:AssetID" json:"asset,omitempty"`
+}
+
+// TranscriptSegment 一段带时间戳的识别文本，时间相对素材起点（秒）
+type TranscriptSegment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+func (Transcription) TableName() string {
+	return "transcriptions"
+}
+
+type TranscriptionStatus string
+
+const (
+	TranscriptionStatusPending    TranscriptionStatus = "pending"
+	TranscriptionStatusProcessing TranscriptionStatus = "processing"
+	TranscriptionStatusCompleted  TranscriptionStatus = "completed"
+	TranscriptionStatusFailed     TranscriptionStatus = "failed"
+)
@@ -46,6 +46,10 @@ type VideoGeneration struct {
 	MinioURL  *string `gorm:"type:varchar(1000)" json:"minio_url,omitempty"`
 	LocalPath *string `gorm:"type:varchar(500)" json:"local_path,omitempty"`
 
+	PosterURL    *string `gorm:"type:varchar(1000)" json:"poster_url,omitempty"`                                 // 封面图
+	SpriteURL    *string `gorm:"type:varchar(1000)" json:"sprite_url,omitempty"`                                 // 拖动预览雪碧图
+	ThumbnailVTT *string `gorm:"column:thumbnail_vtt_url;type:varchar(1000)" json:"thumbnail_vtt_url,omitempty"` // WebVTT缩略图轨道
+
 	Status VideoStatus `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
 	TaskID *string     `gorm:"type:varchar(200);index" json:"task_id,omitempty"`
 
@@ -14,24 +14,48 @@ const (
 	VideoMergeStatusProcessing VideoMergeStatus = "processing"
 	VideoMergeStatusCompleted  VideoMergeStatus = "completed"
 	VideoMergeStatusFailed     VideoMergeStatus = "failed"
+	VideoMergeStatusCancelled  VideoMergeStatus = "cancelled"
 )
 
 type VideoMerge struct {
-	ID          uint             `gorm:"primaryKey;autoIncrement" json:"id"`
-	EpisodeID   uint             `gorm:"not null;index" json:"episode_id"`
-	DramaID     uint             `gorm:"not null;index" json:"drama_id"`
-	Title       string           `gorm:"type:varchar(200)" json:"title"`
-	Provider    string           `gorm:"type:varchar(50);not null" json:"provider"`
-	Model       *string          `gorm:"type:varchar(100)" json:"model,omitempty"`
-	Status      VideoMergeStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
-	Scenes      datatypes.JSON   `gorm:"type:json;not null" json:"scenes"`
-	MergedURL   *string          `gorm:"type:varchar(500)" json:"merged_url,omitempty"`
-	Duration    *int             `gorm:"type:int" json:"duration,omitempty"`
-	TaskID      *string          `gorm:"type:varchar(100)" json:"task_id,omitempty"`
-	ErrorMsg    *string          `gorm:"type:text" json:"error_msg,omitempty"`
-	CreatedAt   time.Time        `gorm:"not null;autoCreateTime" json:"created_at"`
-	CompletedAt *time.Time       `json:"completed_at,omitempty"`
-	DeletedAt   gorm.DeletedAt   `gorm:"index" json:"-"`
+	ID            uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	EpisodeID     uint           `gorm:"not null;index" json:"episode_id"`
+	DramaID       uint           `gorm:"not null;index" json:"drama_id"`
+	TimelineID    *uint          `gorm:"index" json:"timeline_id,omitempty"`
+	Title         string         `gorm:"type:varchar(200)" json:"title"`
+	Provider      string         `gorm:"type:varchar(50);not null" json:"provider"`
+	Profile       string         `gorm:"type:varchar(50)" json:"profile,omitempty"`                 // 输出配置，为空时自动选择
+	PackageHLS    bool           `gorm:"default:false" json:"package_hls"`                          // 合成后是否切片为HLS
+	HLSURL        *string        `gorm:"column:hls_url;type:varchar(500)" json:"hls_url,omitempty"` // HLS主播放列表地址
+	IsPreview     bool           `gorm:"default:false" json:"is_preview"`                           // 预览渲染：低分辨率快速输出，不影响剧集成片
+	SubtitleMode  string         `gorm:"type:varchar(10)" json:"subtitle_mode,omitempty"`           // 字幕处理：burn 烧录、soft 软字幕
+	SubtitleStyle datatypes.JSON `gorm:"type:json" json:"subtitle_style,omitempty"`                 // 字幕样式
+	SubtitleURL   *string        `gorm:"type:varchar(500)" json:"subtitle_url,omitempty"`           // WebVTT字幕文件地址
+	StillFallback bool           `gorm:"default:false" json:"still_fallback"`                       // 缺少视频的分镜使用图片生成运镜片段
+	SkipBranding  bool           `gorm:"default:false" json:"skip_branding"`                        // 不应用剧本的水印与片头片尾
+	IntroOffset   float64        `gorm:"default:0" json:"intro_offset"`                             // 片头时长（秒）：正片在成片中的起始时间，字幕与剪辑工程导出均按此后移
+	SkipMusic     bool           `gorm:"default:false" json:"skip_music"`                           // 不按分镜配乐描述自动铺背景音乐
+	SkipSFX       bool           `gorm:"column:skip_sfx;default:false" json:"skip_sfx"`             // 不在镜头开始处放置分镜音效
+	// 响度统一：各片段按目标响度做两遍EBU R128 loudnorm，成片再整体统一并限幅
+	LoudnessTarget   float64          `gorm:"default:0" json:"loudness_target"` // 目标响度（LUFS），为0时不做响度处理
+	MeasuredLoudness *float64         `json:"measured_loudness,omitempty"`      // 母带处理前成片的整体响度（LUFS）
+	Loudness         *float64         `json:"loudness,omitempty"`               // 成片整体响度（LUFS）
+	TruePeak         *float64         `json:"true_peak,omitempty"`              // 成片真峰值（dBTP）
+	LoudnessRange    *float64         `json:"loudness_range,omitempty"`         // 成片响度范围（LU）
+	Model            *string          `gorm:"type:varchar(100)" json:"model,omitempty"`
+	Status           VideoMergeStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	Scenes           datatypes.JSON   `gorm:"type:json;not null" json:"scenes"`
+	MergedURL        *string          `gorm:"type:varchar(500)" json:"merged_url,omitempty"`
+	Duration         *int             `gorm:"type:int" json:"duration,omitempty"`
+	Width            *int             `json:"width,omitempty"`                       // 成片实际分辨率（探测结果）
+	Height           *int             `json:"height,omitempty"`                      // 成片实际分辨率（探测结果）
+	Progress         int              `gorm:"default:0" json:"progress"`             // 0-100
+	EtaSeconds       *int             `gorm:"type:int" json:"eta_seconds,omitempty"` // 预计剩余时间（秒）
+	TaskID           *string          `gorm:"type:varchar(100)" json:"task_id,omitempty"`
+	ErrorMsg         *string          `gorm:"type:text" json:"error_msg,omitempty"`
+	CreatedAt        time.Time        `gorm:"not null;autoCreateTime" json:"created_at"`
+	CompletedAt      *time.Time       `json:"completed_at,omitempty"`
+	DeletedAt        gorm.DeletedAt   `gorm:"index" json:"-"`
 
 	Episode Episode `gorm:"foreignKey:EpisodeID" json:"episode,omitempty"`
 	Drama   Drama   `gorm:"foreignKey:DramaID" json:"drama,omitempty"`
@@ -45,6 +69,8 @@ type SceneClip struct {
 	Duration   float64                `json:"duration"`
 	Order      int                    `json:"order"`
 	Transition map[string]interface{} `json:"transition"`
+	ImageURL   string                 `json:"image_url,omitempty"` // 没有视频时用于生成运镜片段的图片，时长取 Duration
+	Motion     string                 `json:"motion,omitempty"`    // 运镜方式：zoom_in/zoom_out/pan_left/pan_right
 }
 
 func (v *VideoMerge) TableName() string {
@@ -81,11 +81,23 @@ func AutoMigrate(db *gorm.DB) error {
 		// 生成相关
 		&models.ImageGeneration{},
 		&models.VideoGeneration{},
+		&models.SpeechGeneration{},
+		&models.MusicTrack{},
+		&models.SFX{},
+		&models.Transcription{},
 		&models.VideoMerge{},
 
+		// 时间线编辑
+		&models.Timeline{},
+		&models.TimelineTrack{},
+		&models.ClipTransition{},
+		&models.TimelineClip{},
+		&models.ClipEffect{},
+
 		// AI配置
 		&models.AIServiceConfig{},
 		&models.AIServiceProvider{},
+		&models.PromptTemplate{},
 
 		// 资源管理
 		&models.Asset{},
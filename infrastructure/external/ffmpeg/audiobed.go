@@ -0,0 +1,167 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// DefaultMusicVolume 背景音乐相对原声的默认音量
+	DefaultMusicVolume = 0.3
+	// DefaultMusicFade 背景音乐切换时的淡入淡出时长（秒）
+	DefaultMusicFade = 1.5
+
+	// 原声闪避：原声人声频段超过门限时压低背景音乐，结束后缓慢恢复
+	duckThreshold = 0.03
+	duckRatio     = 8
+	duckAttack    = 20  // 毫秒
+	duckRelease   = 400 // 毫秒
+
+	// duckKeyFilter 侧链只取原声的人声频段，减少原声中音乐、环境声低频对闪避的触发
+	duckKeyFilter = "highpass=f=200,lowpass=f=3500"
+)
+
+// AudioLayer 叠加到成片上的一段音频
+type AudioLayer struct {
+	Path     string
+	Start    float64 // 在成片中的起始时间（秒）
+	Duration float64 // 持续时长（秒），为0时播放到素材结束
+	Offset   float64 // 从素材的第几秒开始播放
+	Volume   float64 // 线性音量，1为原始音量
+	FadeIn   float64
+	FadeOut  float64 // 需要 Duration 大于0才生效
+	Loop     bool    // 素材短于 Duration 时循环播放
+	// DuckUnderSource 原声出现时自动压低。成片没有独立的对白轨（配音不混入成片），
+	// 侧链是整条原声的人声频段，原声中的对白与其他人声频段的声音都会触发闪避
+	DuckUnderSource bool
+}
+
+// AudioBedOptions 成片音频铺底参数
+type AudioBedOptions struct {
+	InputPath  string
+	OutputPath string
+	Layers     []AudioLayer
+	Profile    *OutputProfile // 音频编码配置，为空时按输出容器选择默认配置
+	Context    context.Context
+	OnProgress ProgressFunc
+}
+
+// MixAudioBed 将背景音乐、音效等音频层按时间位置混入成片原声，视频流直接复制
+// 标记 DuckUnderSource 的音频层以原声的人声频段为侧链做压缩，原声出现时自动让位
+func (f *FFmpeg) MixAudioBed(opts *AudioBedOptions) error {
+	duration, err := f.GetDuration(opts.InputPath)
+	if err != nil {
+		return fmt.Errorf("failed to get video duration: %w", err)
+	}
+
+	args := []string{"-i", opts.InputPath}
+	var filters, duckLabels, plainLabels []string
+	inputs := 1
+	for _, layer := range opts.Layers {
+		if layer.Start >= duration {
+			continue
+		}
+		// 循环播放必须限定时长，超出成片的部分截掉
+		if (layer.Loop && layer.Duration <= 0) || layer.Start+layer.Duration > duration {
+			layer.Duration = duration - layer.Start
+		}
+		if layer.Loop {
+			args = append(args, "-stream_loop", "-1")
+		}
+		args = append(args, "-i", layer.Path)
+
+		label := fmt.Sprintf("[l%d]", inputs)
+		filters = append(filters, fmt.Sprintf("[%d:a]%s%s", inputs, audioLayerFilter(layer), label))
+		if layer.DuckUnderSource {
+			duckLabels = append(duckLabels, label)
+		} else {
+			plainLabels = append(plainLabels, label)
+		}
+		inputs++
+	}
+	if inputs == 1 {
+		return fmt.Errorf("no audio layers within video duration")
+	}
+
+	// 无原声时生成静音作为混音基底，闪避也就无从谈起
+	main := "[0:a]aresample=48000,aformat=sample_fmts=fltp:channel_layouts=stereo"
+	if !f.hasAudioStream(opts.InputPath) {
+		main = fmt.Sprintf("anullsrc=channel_layout=stereo:sample_rate=48000,aformat=sample_fmts=fltp,atrim=duration=%.3f", duration)
+	}
+	mixLabels := []string{"[main]"}
+	if len(duckLabels) > 0 {
+		filters = append(filters, main+",asplit=2[main][src]", "[src]"+duckKeyFilter+"[key]")
+		bed := duckLabels[0]
+		if len(duckLabels) > 1 {
+			bed = "[bed]"
+			filters = append(filters, fmt.Sprintf("%samix=inputs=%d:duration=longest:dropout_transition=0:normalize=0%s",
+				strings.Join(duckLabels, ""), len(duckLabels), bed))
+		}
+		filters = append(filters, fmt.Sprintf("%s[key]sidechaincompress=threshold=%.3f:ratio=%d:attack=%d:release=%d[ducked]",
+			bed, duckThreshold, duckRatio, duckAttack, duckRelease))
+		mixLabels = append(mixLabels, "[ducked]")
+	} else {
+		filters = append(filters, main+"[main]")
+	}
+	mixLabels = append(mixLabels, plainLabels...)
+	filters = append(filters, fmt.Sprintf("%samix=inputs=%d:duration=first:dropout_transition=0:normalize=0[outa]",
+		strings.Join(mixLabels, ""), len(mixLabels)))
+
+	filterComplex := strings.Join(filters, ";")
+	profile := profileForOutput(opts.Profile, opts.OutputPath)
+	args = append(args,
+		"-filter_complex", filterComplex,
+		"-map", "0:v?", "-map", "[outa]",
+		"-c:v", "copy",
+		"-t", fmt.Sprintf("%.3f", duration),
+	)
+	args = append(args, profile.AudioArgs()...)
+	args = append(args, profile.ContainerArgs()...)
+	args = append(args, "-y", opts.OutputPath)
+
+	f.log.Infow("Mixing audio bed", "input", opts.InputPath, "layers", inputs-1, "filter", filterComplex)
+
+	output, err := f.run(opts.Context, duration, stageProgress(opts.OnProgress, 0, 100), args...)
+	if err != nil {
+		os.Remove(opts.OutputPath)
+		if opts.Context != nil && opts.Context.Err() != nil {
+			return opts.Context.Err()
+		}
+		f.log.Errorw("FFmpeg audio bed mix failed", "error", err, "output", string(output))
+		return fmt.Errorf("ffmpeg audio bed mix failed: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
+// audioLayerFilter 裁剪、统一格式、音量、淡入淡出，并延迟到成片中的位置
+func audioLayerFilter(layer AudioLayer) string {
+	trim := fmt.Sprintf("atrim=start=%.3f", layer.Offset)
+	if layer.Duration > 0 {
+		trim += fmt.Sprintf(":duration=%.3f", layer.Duration)
+	}
+	volume := layer.Volume
+	if volume <= 0 {
+		volume = 1
+	}
+
+	parts := []string{
+		trim,
+		"asetpts=PTS-STARTPTS",
+		"aresample=48000",
+		"aformat=sample_fmts=fltp:channel_layouts=stereo",
+		fmt.Sprintf("volume=%.3f", volume),
+	}
+	if layer.FadeIn > 0 {
+		parts = append(parts, fmt.Sprintf("afade=t=in:st=0:d=%.3f", layer.FadeIn))
+	}
+	if layer.FadeOut > 0 && layer.Duration > 0 {
+		fade := min(layer.FadeOut, layer.Duration)
+		parts = append(parts, fmt.Sprintf("afade=t=out:st=%.3f:d=%.3f", layer.Duration-fade, fade))
+	}
+
+	delayMs := int(layer.Start * 1000)
+	parts = append(parts, fmt.Sprintf("adelay=%d|%d", delayMs, delayMs))
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,189 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WatermarkPosition 水印位置
+type WatermarkPosition string
+
+const (
+	WatermarkTopLeft     WatermarkPosition = "top_left"
+	WatermarkTopRight    WatermarkPosition = "top_right"
+	WatermarkBottomLeft  WatermarkPosition = "bottom_left"
+	WatermarkBottomRight WatermarkPosition = "bottom_right"
+	WatermarkCenter      WatermarkPosition = "center"
+)
+
+// DefaultWatermarkOpacity 未设置时的水印不透明度
+const DefaultWatermarkOpacity = 0.8
+
+// ParseWatermarkPosition 解析水印位置，为空时为右上角
+func ParseWatermarkPosition(name string) (WatermarkPosition, error) {
+	switch position := WatermarkPosition(strings.ToLower(name)); position {
+	case "":
+		return WatermarkTopRight, nil
+	case WatermarkTopLeft, WatermarkTopRight, WatermarkBottomLeft, WatermarkBottomRight, WatermarkCenter:
+		return position, nil
+	default:
+		return "", fmt.Errorf("unknown watermark position: %s", name)
+	}
+}
+
+// BrandingOptions 品牌包装参数：水印只叠加在正片上，片头片尾按正片的分辨率与帧率统一后拼接
+type BrandingOptions struct {
+	InputPath         string
+	OutputPath        string
+	WatermarkPath     string // 水印图片，为空时不加水印
+	WatermarkPosition WatermarkPosition
+	WatermarkOpacity  float64 // 0-1，为0时使用默认值
+	IntroPath         string  // 片头视频，为空时不加片头
+	OutroPath         string  // 片尾视频，为空时不加片尾
+	Profile           *OutputProfile
+	Context           context.Context
+	OnProgress        ProgressFunc
+}
+
+// ApplyBranding 为成片添加水印与片头片尾，返回片头时长（秒），用于换算正片内容在成片中的时间
+func (f *FFmpeg) ApplyBranding(opts *BrandingOptions) (float64, error) {
+	profile := *profileForOutput(opts.Profile, opts.OutputPath)
+	if width, height := f.getVideoResolution(opts.InputPath); width > 0 && height > 0 {
+		profile.Width, profile.Height = width, height
+	}
+	if info, err := f.Probe(opts.Context, opts.InputPath); err == nil && info.FPS > 0 {
+		profile.FPS = int(info.FPS + 0.5)
+	}
+
+	duration, _ := f.GetDuration(opts.InputPath)
+	var introDuration float64
+	if opts.IntroPath != "" {
+		var err error
+		if introDuration, err = f.GetDuration(opts.IntroPath); err != nil {
+			return 0, fmt.Errorf("failed to probe intro: %w", err)
+		}
+		duration += introDuration
+	}
+	if opts.OutroPath != "" {
+		outroDuration, err := f.GetDuration(opts.OutroPath)
+		if err != nil {
+			return 0, fmt.Errorf("failed to probe outro: %w", err)
+		}
+		duration += outroDuration
+	}
+
+	f.log.Infow("Applying branding",
+		"input", opts.InputPath,
+		"watermark", opts.WatermarkPath,
+		"intro", opts.IntroPath,
+		"outro", opts.OutroPath,
+		"resolution", fmt.Sprintf("%dx%d", profile.Width, profile.Height))
+
+	args := []string{"-i", opts.InputPath}
+	var filters []string
+	mainVideo := "[0:v]"
+	if opts.WatermarkPath != "" {
+		args = append(args, "-i", opts.WatermarkPath)
+		filters = append(filters, watermarkFilter(&profile, opts.WatermarkPosition, opts.WatermarkOpacity, "[0:v]", "[1:v]", "[main]"))
+		mainVideo = "[main]"
+	}
+
+	if opts.IntroPath == "" && opts.OutroPath == "" {
+		// 仅水印：音频直接复制
+		args = append(args, "-filter_complex", strings.Join(filters, ";"), "-map", mainVideo, "-map", "0:a?")
+		args = append(args, profile.VideoArgs()...)
+		args = append(args, "-c:a", "copy")
+	} else {
+		// 片头/正片/片尾分别统一画面与音频格式后拼接，缺少音轨的片段补静音
+		var segments []string
+		inputs := len(args) / 2
+		addSegment := func(path string, video string) {
+			index := 0
+			if path != opts.InputPath {
+				index = inputs
+				args = append(args, "-i", path)
+				inputs++
+				video = fmt.Sprintf("[%d:v]", index)
+			}
+			audio := fmt.Sprintf("[%d:a]", index)
+			if !f.hasAudioStream(path) {
+				segmentDuration, _ := f.GetDuration(path)
+				args = append(args, "-f", "lavfi", "-t", fmt.Sprintf("%.3f", segmentDuration), "-i", "anullsrc=channel_layout=stereo:sample_rate=48000")
+				audio = fmt.Sprintf("[%d:a]", inputs)
+				inputs++
+			}
+			label := fmt.Sprintf("s%d", len(segments))
+			filters = append(filters,
+				fmt.Sprintf("%s%s,format=yuv420p[%sv]", video, profile.NormalizeFilter(), label),
+				fmt.Sprintf("%saresample=48000,aformat=sample_fmts=fltp:channel_layouts=stereo[%sa]", audio, label))
+			segments = append(segments, fmt.Sprintf("[%sv][%sa]", label, label))
+		}
+
+		if opts.IntroPath != "" {
+			addSegment(opts.IntroPath, "")
+		}
+		addSegment(opts.InputPath, mainVideo)
+		if opts.OutroPath != "" {
+			addSegment(opts.OutroPath, "")
+		}
+
+		filters = append(filters, fmt.Sprintf("%sconcat=n=%d:v=1:a=1[outv][outa]", strings.Join(segments, ""), len(segments)))
+		args = append(args, "-filter_complex", strings.Join(filters, ";"), "-map", "[outv]", "-map", "[outa]")
+		args = append(args, profile.VideoArgs()...)
+		args = append(args, profile.AudioArgs()...)
+	}
+	args = append(args, profile.ContainerArgs()...)
+	args = append(args, "-y", opts.OutputPath)
+
+	output, err := f.run(opts.Context, duration, stageProgress(opts.OnProgress, 0, 100), args...)
+	if err != nil {
+		os.Remove(opts.OutputPath)
+		if opts.Context != nil && opts.Context.Err() != nil {
+			return 0, opts.Context.Err()
+		}
+		f.log.Errorw("FFmpeg branding failed", "error", err, "output", string(output))
+		return 0, fmt.Errorf("ffmpeg branding failed: %w, output: %s", err, string(output))
+	}
+	return introDuration, nil
+}
+
+// watermarkFilter 按画面短边的1/6缩放水印并叠加到指定位置，边距为短边的1/30
+func watermarkFilter(profile *OutputProfile, position WatermarkPosition, opacity float64, video, watermark, output string) string {
+	if opacity <= 0 || opacity > 1 {
+		opacity = DefaultWatermarkOpacity
+	}
+	shortSide := min(profile.Width, profile.Height)
+	width := evenRound(float64(shortSide) / 6)
+	margin := shortSide / 30
+
+	x, y := fmt.Sprintf("W-w-%d", margin), fmt.Sprintf("%d", margin)
+	switch position {
+	case WatermarkTopLeft:
+		x = fmt.Sprintf("%d", margin)
+	case WatermarkBottomLeft:
+		x, y = fmt.Sprintf("%d", margin), fmt.Sprintf("H-h-%d", margin)
+	case WatermarkBottomRight:
+		y = fmt.Sprintf("H-h-%d", margin)
+	case WatermarkCenter:
+		x, y = "(W-w)/2", "(H-h)/2"
+	}
+
+	return fmt.Sprintf("%sformat=rgba,scale=%d:-1,colorchannelmixer=aa=%.2f[wm];%s[wm]overlay=x=%s:y=%s:format=auto%s",
+		watermark, width, opacity, video, x, y, output)
+}
+
+// profileForOutput 未指定编码配置时按输出容器选择默认配置
+func profileForOutput(profile *OutputProfile, outputPath string) *OutputProfile {
+	if profile != nil {
+		return profile
+	}
+	name := DefaultOutputProfile
+	if strings.EqualFold(filepath.Ext(outputPath), ".webm") {
+		name = "web_1080p_vp9"
+	}
+	profile, _ = GetOutputProfile(name)
+	return profile
+}
@@ -0,0 +1,250 @@
+package ffmpeg
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// downloadRetries 下载失败后的最大重试次数
+	downloadRetries = 3
+	// cacheMaxAge 超过该时间未被使用的缓存文件会被清理
+	cacheMaxAge = 7 * 24 * time.Hour
+)
+
+// httpClient 下载素材使用的客户端，避免无超时的 http.Get 卡死合成任务
+var httpClient = &http.Client{Timeout: 10 * time.Minute}
+
+// pruneOnce 缓存清理在进程内只执行一次
+var pruneOnce sync.Once
+
+// cacheLocks 按缓存key加锁，避免并发任务重复下载/裁剪同一素材
+var cacheLocks sync.Map
+
+func lockCacheKey(key string) func() {
+	value, _ := cacheLocks.LoadOrStore(key, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// cacheKey 根据输入内容生成内容寻址的缓存key
+func cacheKey(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// localSourceVersion 本地素材的版本标识（大小与修改时间），远程素材与无法访问的文件返回空
+func localSourceVersion(source string) string {
+	if source == "" || strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "data:") {
+		return ""
+	}
+	info, err := os.Stat(source)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d:%d", info.Size(), info.ModTime().UnixNano())
+}
+
+// cachedPath 返回缓存文件路径，命中时刷新修改时间以免被清理
+func (f *FFmpeg) cachedPath(name string) (string, bool) {
+	path := filepath.Join(f.cacheDir, name)
+	if info, err := os.Stat(path); err == nil && info.Size() > 0 {
+		now := time.Now()
+		os.Chtimes(path, now, now)
+		return path, true
+	}
+	return path, false
+}
+
+// fetchSource 获取素材的本地路径
+// 本地文件只接受临时目录与 AllowLocalRoot 登记的存储目录内的路径，直接返回原路径；
+// 远程文件按URL缓存，未变化的素材在多次合成之间复用
+// 返回的路径属于缓存或源文件，调用方不得删除
+func (f *FFmpeg) fetchSource(ctx context.Context, url string) (string, error) {
+	if strings.HasPrefix(url, "data:") {
+		return f.decodeDataURI(url)
+	}
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		if !f.isLocalSource(url) {
+			return "", fmt.Errorf("local file is outside storage: %s", url)
+		}
+		if _, err := os.Stat(url); err != nil {
+			return "", fmt.Errorf("local file not accessible: %w", err)
+		}
+		return url, nil
+	}
+
+	key := cacheKey("source", url)
+	unlock := lockCacheKey(key)
+	defer unlock()
+
+	path, ok := f.cachedPath(key + mediaExt(url))
+	if ok {
+		f.log.Infow("Using cached source", "url", url, "path", path)
+		return path, nil
+	}
+
+	if err := f.downloadWithRetry(ctx, url, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// isLocalSource 本地路径是否位于临时目录或登记的存储目录内
+func (f *FFmpeg) isLocalSource(path string) bool {
+	if within(f.tempDir, path) {
+		return true
+	}
+	for _, root := range f.localRoots {
+		if within(root, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// within path 转为绝对路径后是否位于 root 目录内
+func within(root, path string) bool {
+	if root == "" || path == "" {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(filepath.Clean(root), abs)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// decodeDataURI 将 data:image/png;base64,... 形式的内联图片解码到缓存目录，避免超长参数传给ffmpeg
+func (f *FFmpeg) decodeDataURI(uri string) (string, error) {
+	header, payload, ok := strings.Cut(uri, ",")
+	if !ok || !strings.HasSuffix(header, ";base64") {
+		return "", fmt.Errorf("unsupported data uri")
+	}
+
+	key := cacheKey("data", uri)
+	unlock := lockCacheKey(key)
+	defer unlock()
+
+	ext := ".bin"
+	if _, subtype, ok := strings.Cut(strings.TrimSuffix(strings.TrimPrefix(header, "data:"), ";base64"), "/"); ok {
+		ext = "." + strings.Replace(subtype, "jpeg", "jpg", 1)
+	}
+
+	path, ok := f.cachedPath(key + ext)
+	if ok {
+		return path, nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("invalid data uri: %w", err)
+	}
+	partPath := path + ".part"
+	if err := os.WriteFile(partPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write data uri: %w", err)
+	}
+	if err := os.Rename(partPath, path); err != nil {
+		os.Remove(partPath)
+		return "", fmt.Errorf("failed to write data uri: %w", err)
+	}
+	return path, nil
+}
+
+// downloadWithRetry 下载文件，失败后按指数退避重试
+func (f *FFmpeg) downloadWithRetry(ctx context.Context, url, destPath string) error {
+	backoff := time.Second
+	var lastErr error
+
+	for attempt := 0; attempt <= downloadRetries; attempt++ {
+		if attempt > 0 {
+			f.log.Warnw("Retrying download", "url", url, "attempt", attempt, "error", lastErr)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		lastErr = f.download(ctx, url, destPath)
+		if lastErr == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	return fmt.Errorf("download failed after %d attempts: %w", downloadRetries+1, lastErr)
+}
+
+// download 下载到临时文件后原子重命名，避免中断的下载被当作缓存命中
+func (f *FFmpeg) download(ctx context.Context, url, destPath string) error {
+	f.log.Infow("Downloading video", "url", url, "dest", destPath)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("bad status: %s", resp.Status)
+	}
+
+	partPath := destPath + ".part"
+	out, err := os.Create(partPath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+
+	_, err = io.Copy(out, resp.Body)
+	out.Close()
+	if err != nil {
+		os.Remove(partPath)
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+
+	if err := os.Rename(partPath, destPath); err != nil {
+		os.Remove(partPath)
+		return fmt.Errorf("failed to move file into cache: %w", err)
+	}
+	return nil
+}
+
+// pruneCache 清理长时间未使用的缓存文件
+func (f *FFmpeg) pruneCache() {
+	entries, err := os.ReadDir(f.cacheDir)
+	if err != nil {
+		return
+	}
+
+	cutoff := time.Now().Add(-cacheMaxAge)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() {
+			continue
+		}
+		if info.ModTime().Before(cutoff) {
+			os.Remove(filepath.Join(f.cacheDir, entry.Name()))
+		}
+	}
+}
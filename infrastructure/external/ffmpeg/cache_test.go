@@ -0,0 +1,58 @@
+package ffmpeg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFetchSourceLocalPath(t *testing.T) {
+	root := t.TempDir()
+	storageDir := filepath.Join(root, "storage")
+	tempDir := filepath.Join(root, "temp")
+	for _, dir := range []string{storageDir, tempDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		"storage": filepath.Join(storageDir, "a.mp4"),
+		"temp":    filepath.Join(tempDir, "b.mp4"),
+		"outside": filepath.Join(root, "secret.txt"),
+		"sibling": filepath.Join(root, "storage-other.mp4"),
+	}
+	for _, path := range files {
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f := &FFmpeg{tempDir: tempDir}
+	f.AllowLocalRoot(storageDir)
+
+	tests := []struct {
+		name    string
+		source  string
+		wantErr bool
+	}{
+		{"存储目录内的文件", files["storage"], false},
+		{"临时目录内的文件", files["temp"], false},
+		{"存储目录之外的文件", files["outside"], true},
+		{"经上级目录越界", filepath.Join(storageDir, "..", "secret.txt"), true},
+		{"同名前缀的兄弟目录", files["sibling"], true},
+		{"存储目录内不存在的文件", filepath.Join(storageDir, "missing.mp4"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.fetchSource(context.Background(), tt.source)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("fetchSource(%q) error = %v, wantErr %v", tt.source, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.source {
+				t.Errorf("fetchSource(%q) = %q, want the original path", tt.source, got)
+			}
+		})
+	}
+}
@@ -1,31 +1,51 @@
 package ffmpeg
 
 import (
+	"context"
 	"fmt"
-	"io"
-	"net/http"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/drama-generator/backend/pkg/logger"
 )
 
 type FFmpeg struct {
-	log     *logger.Logger
-	tempDir string
+	log        *logger.Logger
+	tempDir    string
+	cacheDir   string
+	localRoots []string // 允许直接读取的本地素材目录
 }
 
 func NewFFmpeg(log *logger.Logger) *FFmpeg {
 	tempDir := filepath.Join(os.TempDir(), "drama-video-merge")
-	os.MkdirAll(tempDir, 0755)
+	cacheDir := filepath.Join(tempDir, "cache")
+	os.MkdirAll(cacheDir, 0755)
 
-	return &FFmpeg{
-		log:     log,
-		tempDir: tempDir,
+	f := &FFmpeg{
+		log:      log,
+		tempDir:  tempDir,
+		cacheDir: cacheDir,
 	}
+	// 所有实例共用同一缓存目录，进程内只清理一次
+	pruneOnce.Do(func() { go f.pruneCache() })
+	return f
+}
+
+// AllowLocalRoot 登记可直接读取的本地存储目录，合成素材中的本地路径必须位于这些目录或临时目录内
+func (f *FFmpeg) AllowLocalRoot(dir string) {
+	if dir == "" {
+		return
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	f.localRoots = append(f.localRoots, dir)
 }
 
 type VideoClip struct {
@@ -34,11 +54,24 @@ type VideoClip struct {
 	StartTime  float64
 	EndTime    float64
 	Transition map[string]interface{}
+	Label      string         // 镜头标识，预览时烧录在画面上，为空时使用片段序号
+	ImageURL   string         // URL为空时使用的静态图片，按 Duration 生成运镜片段
+	Motion     KenBurnsMotion // 静态图片的运镜方式，默认推近
 }
 
+// defaultConcurrency 默认同时下载/裁剪的片段数
+const defaultConcurrency = 4
+
 type MergeOptions struct {
-	OutputPath string
-	Clips      []VideoClip
+	OutputPath  string
+	Clips       []VideoClip
+	Context     context.Context // 取消时终止正在运行的ffmpeg进程并清理临时文件
+	OnProgress  ProgressFunc    // 整体进度回调（0-100）
+	Concurrency int             // 下载/裁剪并发数，默认4
+	Profile     *OutputProfile  // 输出配置，为空时按首个片段的画面方向自动选择
+	Preview     bool            // 预览模式：在输出配置基础上降低分辨率并烧录镜头编号与时间码
+	// LoudnessTarget 按EBU R128统一各片段响度的目标值（LUFS），为0或预览时不做统一
+	LoudnessTarget float64
 }
 
 func (f *FFmpeg) MergeVideos(opts *MergeOptions) (string, error) {
@@ -46,57 +79,60 @@ func (f *FFmpeg) MergeVideos(opts *MergeOptions) (string, error) {
 		return "", fmt.Errorf("no video clips to merge")
 	}
 
-	f.log.Infow("Starting video merge with trimming", "clips_count", len(opts.Clips))
-
-	// 下载并裁剪所有视频片段
-	trimmedPaths := make([]string, 0, len(opts.Clips))
-	downloadedPaths := make([]string, 0, len(opts.Clips))
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
 
 	for i, clip := range opts.Clips {
-		// 下载原始视频
-		downloadPath := filepath.Join(f.tempDir, fmt.Sprintf("download_%d_%d.mp4", time.Now().Unix(), i))
-		localPath, err := f.downloadVideo(clip.URL, downloadPath)
-		if err != nil {
-			f.cleanup(downloadedPaths)
-			f.cleanup(trimmedPaths)
-			return "", fmt.Errorf("failed to download clip %d: %w", i, err)
+		if clip.URL == "" && clip.ImageURL == "" {
+			return "", fmt.Errorf("clip %d has no video or image", i)
 		}
-		downloadedPaths = append(downloadedPaths, localPath)
-
-		// 裁剪视频片段（根据StartTime和EndTime）
-		trimmedPath := filepath.Join(f.tempDir, fmt.Sprintf("trimmed_%d_%d.mp4", time.Now().Unix(), i))
-		err = f.trimVideo(localPath, trimmedPath, clip.StartTime, clip.EndTime)
-		if err != nil {
-			f.cleanup(downloadedPaths)
-			f.cleanup(trimmedPaths)
-			return "", fmt.Errorf("failed to trim clip %d: %w", i, err)
-		}
-		trimmedPaths = append(trimmedPaths, trimmedPath)
+	}
 
-		f.log.Infow("Clip trimmed",
-			"index", i,
-			"start", clip.StartTime,
-			"end", clip.EndTime,
-			"duration", clip.EndTime-clip.StartTime)
+	profile := opts.Profile
+	if profile == nil {
+		profile = f.detectProfile(ctx, clipSource(opts.Clips[0]))
 	}
+	clips := opts.Clips
+	if opts.Preview {
+		profile = profile.Preview()
+		clips = make([]VideoClip, len(opts.Clips))
+		for i, clip := range opts.Clips {
+			if clip.Label == "" {
+				clip.Label = fmt.Sprintf("SHOT %d", i+1)
+			}
+			clips[i] = clip
+		}
+	} else if opts.LoudnessTarget != 0 {
+		normalized := *profile
+		normalized.LoudnessTarget = opts.LoudnessTarget
+		profile = &normalized
+	}
+
+	f.log.Infow("Starting video merge with trimming", "clips_count", len(opts.Clips), "profile", profile.Name)
 
-	// 清理下载的原始文件
-	f.cleanup(downloadedPaths)
+	// 下载并裁剪所有视频片段（占整体进度的前60%），最终合并占剩余40%
+	trimmedPaths, tempPaths, err := f.prepareClips(ctx, clips, profile, opts.Concurrency, stageProgress(opts.OnProgress, 0, 60))
+	if err != nil {
+		return "", err
+	}
+	defer f.cleanup(tempPaths)
 
 	// 确保输出目录存在
 	outputDir := filepath.Dir(opts.OutputPath)
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		f.cleanup(trimmedPaths)
 		return "", fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	// 合并裁剪后的视频片段（支持转场效果）
-	err := f.concatenateVideosWithTransitions(trimmedPaths, opts.Clips, opts.OutputPath)
-
-	// 清理裁剪后的临时文件
-	f.cleanup(trimmedPaths)
-
+	// 合并裁剪后的视频片段（支持转场效果），缓存中的裁剪结果保留供下次合成复用
+	err = f.concatenateVideosWithTransitions(ctx, trimmedPaths, clips, opts.OutputPath, profile,
+		stageProgress(opts.OnProgress, 60, 100))
 	if err != nil {
+		os.Remove(opts.OutputPath)
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
 		return "", fmt.Errorf("failed to concatenate videos: %w", err)
 	}
 
@@ -104,104 +140,221 @@ func (f *FFmpeg) MergeVideos(opts *MergeOptions) (string, error) {
 	return opts.OutputPath, nil
 }
 
-func (f *FFmpeg) downloadVideo(url, destPath string) (string, error) {
-	f.log.Infow("Downloading video", "url", url, "dest", destPath)
+// prepareClips 使用有限并发的工作池下载并裁剪片段，返回与clips顺序一致的裁剪结果路径，
+// 以及未进入缓存、需由调用方清理的临时文件；任一片段失败会取消其余片段
+func (f *FFmpeg) prepareClips(parent context.Context, clips []VideoClip, profile *OutputProfile, concurrency int, onProgress func(float64)) ([]string, []string, error) {
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	if concurrency > len(clips) {
+		concurrency = len(clips)
+	}
 
-	resp, err := http.Get(url)
-	if err != nil {
-		return "", fmt.Errorf("failed to download: %w", err)
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	var (
+		mu        sync.Mutex
+		firstErr  error
+		tempPaths []string
+		fractions = make([]float64, len(clips))
+	)
+	// 进度回调在持有锁时调用，各片段的并发进度串行上报，回调无需自行加锁
+	reportClip := func(index int) func(float64) {
+		return func(fraction float64) {
+			mu.Lock()
+			defer mu.Unlock()
+			fractions[index] = fraction
+			total := 0.0
+			for _, v := range fractions {
+				total += v
+			}
+			if onProgress != nil {
+				onProgress(total / float64(len(clips)))
+			}
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("bad status: %s", resp.Status)
+	trimmedPaths := make([]string, len(clips))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				path, cached, err := f.prepareClip(ctx, clips[i], profile, reportClip(i))
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = fmt.Errorf("failed to prepare clip %d: %w", i, err)
+					}
+					cancel()
+				} else {
+					trimmedPaths[i] = path
+					if !cached {
+						tempPaths = append(tempPaths, path)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
 	}
 
-	out, err := os.Create(destPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to create file: %w", err)
+	for i := range clips {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
 	}
-	defer out.Close()
+	close(jobs)
+	wg.Wait()
 
-	_, err = io.Copy(out, resp.Body)
+	// 外部取消优先于片段错误返回，便于调用方识别
+	if parent.Err() != nil {
+		f.cleanup(tempPaths)
+		return nil, nil, parent.Err()
+	}
+	if firstErr != nil {
+		f.cleanup(tempPaths)
+		return nil, nil, firstErr
+	}
+	return trimmedPaths, tempPaths, nil
+}
+
+// prepareClip 下载并裁剪单个片段（无视频时由静态图片生成运镜片段），相同素材与裁剪区间的结果直接复用缓存
+// cached 为false时返回的是需要调用方清理的临时文件
+func (f *FFmpeg) prepareClip(ctx context.Context, clip VideoClip, profile *OutputProfile, onProgress func(float64)) (path string, cached bool, err error) {
+	source := clip.URL
+	render := f.trimVideo
+	keyParts := []string{"trim", clip.URL,
+		strconv.FormatFloat(clip.StartTime, 'f', 3, 64),
+		strconv.FormatFloat(clip.EndTime, 'f', 3, 64),
+		strconv.FormatFloat(clip.Duration, 'f', 3, 64),
+		profile.cacheKey()}
+	if clip.URL == "" {
+		source = clip.ImageURL
+		render = f.renderStill
+		keyParts = []string{"still", clip.ImageURL,
+			strconv.FormatFloat(clip.Duration, 'f', 3, 64),
+			string(clip.Motion),
+			profile.cacheKey()}
+	}
+	if profile.BurnIn {
+		keyParts = append(keyParts, clip.Label)
+	}
+	// 本地素材可能被原地覆盖，key 中带上文件大小与修改时间，变化后自动失效
+	keyParts = append(keyParts, localSourceVersion(source))
+	key := cacheKey(keyParts...)
+	unlock := lockCacheKey(key)
+	defer unlock()
+
+	trimmedPath, ok := f.cachedPath(key + ".mp4")
+	if ok {
+		f.log.Infow("Using cached trimmed clip", "source", truncateSource(source), "path", trimmedPath)
+		onProgress(1)
+		return trimmedPath, true, nil
+	}
+
+	sourcePath, err := f.fetchSource(ctx, source)
 	if err != nil {
-		return "", fmt.Errorf("failed to save file: %w", err)
+		return "", false, err
+	}
+
+	// 先输出到临时目录，成功后再移入缓存，避免半成品被复用
+	tempPath := filepath.Join(f.tempDir, fmt.Sprintf("trimmed_%s_%d.mp4", key[:16], time.Now().UnixNano()))
+	if err := render(ctx, sourcePath, tempPath, clip, profile, onProgress); err != nil {
+		os.Remove(tempPath)
+		return "", false, err
 	}
 
-	return destPath, nil
+	f.log.Infow("Clip prepared",
+		"source", truncateSource(source),
+		"start", clip.StartTime,
+		"end", clip.EndTime,
+		"duration", clip.Duration)
+
+	if err := os.Rename(tempPath, trimmedPath); err != nil {
+		f.log.Warnw("Failed to cache trimmed clip", "error", err)
+		return tempPath, false, nil
+	}
+	return trimmedPath, true, nil
 }
 
-func (f *FFmpeg) trimVideo(inputPath, outputPath string, startTime, endTime float64) error {
+// trimVideo 裁剪片段，并按输出配置统一分辨率、帧率与编码，保证后续可直接拼接
+// 使用重新编码而非-c copy以确保输出文件完整性，避免Windows环境下流信息丢失
+func (f *FFmpeg) trimVideo(ctx context.Context, inputPath, outputPath string, clip VideoClip, profile *OutputProfile, onProgress func(float64)) error {
+	startTime, endTime := clip.StartTime, clip.EndTime
 	f.log.Infow("Trimming video",
 		"input", inputPath,
 		"output", outputPath,
 		"start", startTime,
-		"end", endTime)
+		"end", endTime,
+		"profile", profile.Name)
+
+	args := []string{"-i", inputPath}
+	expectedDuration := clip.Duration
+
+	// 无音轨的片段补静音，保证所有片段流结构一致
+	hasAudio := f.hasAudioStream(inputPath)
+	if !hasAudio {
+		args = append(args,
+			"-f", "lavfi", "-i", "anullsrc=channel_layout=stereo:sample_rate=48000",
+			"-map", "0:v:0", "-map", "1:a:0", "-shortest")
+	}
 
-	// 如果startTime和endTime都为0，或者endTime <= startTime，复制整个视频
-	// 使用重新编码而非-c copy以确保输出文件完整性
+	// 如果startTime和endTime都为0，或者endTime <= startTime，保留整个视频
+	// -ss: 开始时间（秒）；-to: 结束时间
+	var window *loudnessWindow
 	if (startTime == 0 && endTime == 0) || endTime <= startTime {
 		f.log.Infow("No valid trim range, re-encoding entire video")
-
-		cmd := exec.Command("ffmpeg",
-			"-i", inputPath,
-			"-c:v", "libx264",
-			"-preset", "fast",
-			"-crf", "23",
-			"-c:a", "aac",
-			"-b:a", "128k",
-			"-movflags", "+faststart",
-			"-y",
-			outputPath,
-		)
-
-		output, err := cmd.CombinedOutput()
-		if err != nil {
-			f.log.Errorw("FFmpeg re-encode failed", "error", err, "output", string(output))
-			return fmt.Errorf("ffmpeg re-encode failed: %w, output: %s", err, string(output))
+	} else {
+		window = &loudnessWindow{start: startTime}
+		args = append(args, "-ss", fmt.Sprintf("%.2f", startTime))
+		if endTime > 0 {
+			// 有明确的结束时间
+			expectedDuration = endTime - startTime
+			window.end = endTime
+			args = append(args, "-to", fmt.Sprintf("%.2f", endTime))
 		}
+	}
 
-		f.log.Infow("Video re-encoded successfully", "output", outputPath)
-		return nil
+	// 按目标响度统一片段音量：先测量裁剪区间的响度（占该片段进度的30%），再在重新编码时做线性增益
+	encodeProgress := onProgress
+	if profile.LoudnessTarget != 0 && hasAudio {
+		target, truePeak := loudnessTarget(profile.LoudnessTarget, 0)
+		measured, err := f.measureLoudness(ctx, inputPath, window, target, truePeak, expectedDuration, stageProgress(onProgress, 0, 0.3))
+		switch {
+		case err != nil && ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil:
+			f.log.Warnw("Failed to measure clip loudness, keeping original volume", "input", inputPath, "error", err)
+		case measured.Silent():
+			f.log.Infow("Clip audio is silent, skipping loudness normalization", "input", inputPath)
+		default:
+			f.log.Infow("Normalizing clip loudness", "measured_lufs", measured.Integrated, "target_lufs", target)
+			args = append(args, "-af", loudnormFilter(measured, target, truePeak)+",aresample=48000")
+		}
+		encodeProgress = stageProgress(onProgress, 0.3, 1)
 	}
 
-	// 使用FFmpeg裁剪视频
-	// -ss: 开始时间（秒）
-	// -to/-t: 结束时间或持续时间
-	// 使用重新编码而非-c copy以确保输出文件完整性，避免Windows环境下流信息丢失
-	var cmd *exec.Cmd
-	if endTime > 0 {
-		// 有明确的结束时间
-		cmd = exec.Command("ffmpeg",
-			"-i", inputPath,
-			"-ss", fmt.Sprintf("%.2f", startTime),
-			"-to", fmt.Sprintf("%.2f", endTime),
-			"-c:v", "libx264",
-			"-preset", "fast",
-			"-crf", "23",
-			"-c:a", "aac",
-			"-b:a", "128k",
-			"-movflags", "+faststart",
-			"-y",
-			outputPath,
-		)
-	} else {
-		// 只有开始时间，裁剪到视频末尾
-		cmd = exec.Command("ffmpeg",
-			"-i", inputPath,
-			"-ss", fmt.Sprintf("%.2f", startTime),
-			"-c:v", "libx264",
-			"-preset", "fast",
-			"-crf", "23",
-			"-c:a", "aac",
-			"-b:a", "128k",
-			"-movflags", "+faststart",
-			"-y",
-			outputPath,
-		)
+	// 分辨率不一致的片段等比缩放并补黑边
+	if width, height := f.getVideoResolution(inputPath); width != profile.Width || height != profile.Height {
+		f.log.Infow("Normalizing clip resolution",
+			"from", fmt.Sprintf("%dx%d", width, height),
+			"to", fmt.Sprintf("%dx%d", profile.Width, profile.Height))
 	}
+	videoFilter := profile.NormalizeFilter()
+	if profile.BurnIn && clip.Label != "" {
+		videoFilter += "," + profile.shotLabelFilter(clip.Label)
+	}
+	args = append(args, "-vf", videoFilter)
+	args = append(args, profile.VideoArgs()...)
+	args = append(args, profile.AudioArgs()...)
+	args = append(args, "-movflags", "+faststart", "-y", outputPath)
 
-	output, err := cmd.CombinedOutput()
+	output, err := f.run(ctx, expectedDuration, encodeProgress, args...)
 	if err != nil {
 		f.log.Errorw("FFmpeg trim failed", "error", err, "output", string(output))
 		return fmt.Errorf("ffmpeg trim failed: %w, output: %s", err, string(output))
@@ -211,38 +364,44 @@ func (f *FFmpeg) trimVideo(inputPath, outputPath string, startTime, endTime floa
 	return nil
 }
 
-func (f *FFmpeg) concatenateVideosWithTransitions(inputPaths []string, clips []VideoClip, outputPath string) error {
+func (f *FFmpeg) concatenateVideosWithTransitions(ctx context.Context, inputPaths []string, clips []VideoClip, outputPath string, profile *OutputProfile, onProgress func(float64)) error {
 	if len(inputPaths) == 0 {
 		return fmt.Errorf("no input paths")
 	}
 
-	// 如果只有一个视频，直接复制
-	if len(inputPaths) == 1 {
+	// 如果只有一个视频，直接复制（容器不同时仅重新封装；需要烧录时间码时重新编码）
+	if len(inputPaths) == 1 && filepath.Ext(inputPaths[0]) == filepath.Ext(outputPath) && !profile.BurnIn {
 		f.log.Infow("Only one clip, copying directly")
-		return f.copyFile(inputPaths[0], outputPath)
+		if err := f.copyFile(inputPaths[0], outputPath); err != nil {
+			return err
+		}
+		if onProgress != nil {
+			onProgress(1)
+		}
+		return nil
 	}
 
-	// 检查是否有转场效果
+	// 检查是否有转场效果（最后一个片段的出场转场无需处理）
 	hasTransitions := false
-	for _, clip := range clips {
-		if clip.Transition != nil && len(clip.Transition) > 0 {
+	for _, clip := range clips[:len(clips)-1] {
+		if transitionType, _ := f.resolveTransition(clip.Transition); transitionType != "" {
 			hasTransitions = true
 			break
 		}
 	}
 
 	// 如果没有转场效果，使用简单拼接
-	if !hasTransitions {
+	if !hasTransitions || len(inputPaths) == 1 {
 		f.log.Infow("No transitions, using simple concatenation")
-		return f.concatenateVideos(inputPaths, outputPath)
+		return f.concatenateVideos(ctx, inputPaths, clips, outputPath, profile, onProgress)
 	}
 
 	// 使用xfade滤镜添加转场效果
 	f.log.Infow("Merging with transitions", "clips_count", len(inputPaths))
-	return f.mergeWithXfade(inputPaths, clips, outputPath)
+	return f.mergeWithXfade(ctx, inputPaths, clips, outputPath, profile, onProgress)
 }
 
-func (f *FFmpeg) concatenateVideos(inputPaths []string, outputPath string) error {
+func (f *FFmpeg) concatenateVideos(ctx context.Context, inputPaths []string, clips []VideoClip, outputPath string, profile *OutputProfile, onProgress func(float64)) error {
 	// 创建文件列表
 	listFile := filepath.Join(f.tempDir, fmt.Sprintf("filelist_%d.txt", time.Now().Unix()))
 	defer os.Remove(listFile)
@@ -260,17 +419,24 @@ func (f *FFmpeg) concatenateVideos(inputPaths []string, outputPath string) error
 	// -f concat: 使用concat demuxer
 	// -safe 0: 允许不安全的文件路径
 	// -i: 输入文件列表
-	// -c copy: 直接复制流，不重新编码（速度快）
-	cmd := exec.Command("ffmpeg",
+	// -c copy: 直接复制流，不重新编码（速度快）；片段在裁剪阶段已按输出配置统一编码
+	// 需要烧录时间码时只重新编码视频，音频仍直接复制
+	args := []string{
 		"-f", "concat",
 		"-safe", "0",
 		"-i", listFile,
-		"-c", "copy",
-		"-y", // 覆盖输出文件
-		outputPath,
-	)
+	}
+	if profile.BurnIn {
+		args = append(args, "-vf", profile.timecodeFilter())
+		args = append(args, profile.VideoArgs()...)
+		args = append(args, "-c:a", "copy")
+	} else {
+		args = append(args, "-c", "copy")
+	}
+	args = append(args, profile.ContainerArgs()...)
+	args = append(args, "-y", outputPath) // 覆盖输出文件
 
-	output, err := cmd.CombinedOutput()
+	output, err := f.run(ctx, totalClipDuration(clips), onProgress, args...)
 	if err != nil {
 		f.log.Errorw("FFmpeg failed", "error", err, "output", string(output))
 		return fmt.Errorf("ffmpeg execution failed: %w, output: %s", err, string(output))
@@ -280,192 +446,112 @@ func (f *FFmpeg) concatenateVideos(inputPaths []string, outputPath string) error
 	return nil
 }
 
-func (f *FFmpeg) mergeWithXfade(inputPaths []string, clips []VideoClip, outputPath string) error {
-	// 使用xfade滤镜进行转场
-	// 构建输入参数
+func (f *FFmpeg) mergeWithXfade(ctx context.Context, inputPaths []string, clips []VideoClip, outputPath string, profile *OutputProfile, onProgress func(float64)) error {
+	// 使用xfade滤镜进行视频转场，acrossfade与之同步进行音频交叉淡化
+	// 例如: [v0][v1]xfade=transition=fade:duration=1:offset=5[vx00] 与 [a0][a1]acrossfade=d=1[ax00]
 	args := []string{}
 	for _, path := range inputPaths {
 		args = append(args, "-i", path)
 	}
 
-	// 检测每个视频是否有音频流
-	audioStreams := make([]bool, len(inputPaths))
-	hasAnyAudio := false
+	// 以裁剪结果的实际时长计算转场偏移，避免声明时长与实际不一致导致音画错位
+	durations := make([]float64, len(inputPaths))
 	for i, path := range inputPaths {
-		audioStreams[i] = f.hasAudioStream(path)
-		if audioStreams[i] {
-			hasAnyAudio = true
+		durations[i] = clipDuration(clips[i])
+		if probed, err := f.GetDuration(path); err == nil && probed > 0 {
+			durations[i] = probed
 		}
-		f.log.Infow("Audio stream detection", "index", i, "path", path, "has_audio", audioStreams[i])
 	}
-	f.log.Infow("Overall audio detection", "has_any_audio", hasAnyAudio, "audio_streams", audioStreams)
 
-	// 检测视频分辨率，找到最大分辨率作为目标分辨率
-	maxWidth := 0
-	maxHeight := 0
+	// 统一每个片段的画面与音频：缺少音轨的片段合成等长静音，确保音视频逐段对齐
+	f.log.Infow("Target resolution", "width", profile.Width, "height", profile.Height)
+	var filters []string
 	for i, path := range inputPaths {
-		width, height := f.getVideoResolution(path)
-		if width > maxWidth {
-			maxWidth = width
-		}
-		if height > maxHeight {
-			maxHeight = height
+		filters = append(filters, fmt.Sprintf("[%d:v]%s,setpts=PTS-STARTPTS[v%d]", i, profile.NormalizeFilter(), i))
+
+		hasAudio := f.hasAudioStream(path)
+		f.log.Infow("Audio stream detection", "index", i, "path", path, "has_audio", hasAudio)
+		if hasAudio {
+			filters = append(filters, fmt.Sprintf(
+				"[%d:a]aresample=48000,aformat=sample_fmts=fltp:channel_layouts=stereo,apad,atrim=duration=%.3f,asetpts=PTS-STARTPTS[a%d]",
+				i, durations[i], i))
+		} else {
+			// anullsrc是源滤镜，不接受输入，使用atrim限定时长
+			filters = append(filters, fmt.Sprintf(
+				"anullsrc=channel_layout=stereo:sample_rate=48000,aformat=sample_fmts=fltp,atrim=duration=%.3f[a%d]",
+				durations[i], i))
 		}
-		f.log.Infow("Video resolution detection", "index", i, "width", width, "height", height)
 	}
-	f.log.Infow("Target resolution", "width", maxWidth, "height", maxHeight)
 
-	// 为每个视频流添加缩放滤镜，统一分辨率
-	var scaleFilters []string
-	for i := 0; i < len(inputPaths); i++ {
-		// 使用scale滤镜缩放到目标分辨率，pad添加黑边保持长宽比
-		scaleFilters = append(scaleFilters,
-			fmt.Sprintf("[%d:v]scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2[v%d]",
-				i, maxWidth, maxHeight, maxWidth, maxHeight, i))
-	}
-
-	// 构建filter_complex
-	// 例如: [0:v][1:v]xfade=transition=fade:duration=1:offset=5[v01];[v01][2:v]xfade=transition=fade:duration=1:offset=10[out]
-	// 构建转场滤镜，使用缩放后的视频流
-	var transitionFilters []string
-	var offset float64 = 0
+	// 逐个交界处理：有转场的使用 xfade+acrossfade，无转场的直接拼接
+	// length 为当前已合成部分的时长，xfade 的 offset = length - 转场时长
+	length := durations[0]
+	videoLabel, audioLabel := "[v0]", "[a0]"
 
 	for i := 0; i < len(inputPaths)-1; i++ {
-		// 获取当前片段的时长
-		clipDuration := clips[i].Duration
-		if clips[i].EndTime > 0 && clips[i].StartTime >= 0 {
-			clipDuration = clips[i].EndTime - clips[i].StartTime
+		next := i + 1
+		outVideo, outAudio := fmt.Sprintf("[vx%02d]", i), fmt.Sprintf("[ax%02d]", i)
+		if next == len(inputPaths)-1 {
+			outVideo, outAudio = "[outv]", "[outa]"
 		}
 
-		// 获取转场类型和时长
-		transitionType := "fade"  // 默认淡入淡出
-		transitionDuration := 1.0 // 默认转场时长为1秒
+		transitionType, transitionDuration := f.resolveTransition(clips[i].Transition)
 
-		if clips[i].Transition != nil {
-			// 读取转场类型
-			if tType, ok := clips[i].Transition["type"].(string); ok && tType != "" {
-				transitionType = f.mapTransitionType(tType)
-				f.log.Infow("Using transition type", "type", tType, "mapped", transitionType)
-			}
-			// 读取转场时长
-			if tDuration, ok := clips[i].Transition["duration"].(float64); ok && tDuration > 0 {
-				transitionDuration = tDuration
-			}
+		// 转场时长不能超过相邻任一片段
+		maxDuration := math.Min(durations[i], durations[next]) - 0.05
+		if transitionDuration > maxDuration {
+			transitionDuration = maxDuration
 		}
 
-		// 计算转场开始的时间点
-		// 转场在两个片段的交界处，从前一个片段结束前 transitionDuration/2 开始
-		// 这样转场效果会平均分布在两个片段的交界处
-		offset += clipDuration - (transitionDuration / 2)
-		if offset < 0 {
-			offset = 0
+		if transitionType == "" || transitionDuration <= 0 {
+			filters = append(filters,
+				fmt.Sprintf("%s[v%d]concat=n=2:v=1:a=0%s", videoLabel, next, outVideo),
+				fmt.Sprintf("%s[a%d]concat=n=2:v=0:a=1%s", audioLabel, next, outAudio))
+			length += durations[next]
+		} else {
+			offset := length - transitionDuration
+			filters = append(filters,
+				fmt.Sprintf("%s[v%d]xfade=transition=%s:duration=%.3f:offset=%.3f%s",
+					videoLabel, next, transitionType, transitionDuration, offset, outVideo),
+				fmt.Sprintf("%s[a%d]acrossfade=d=%.3f:c1=tri:c2=tri%s",
+					audioLabel, next, transitionDuration, outAudio))
+			length += durations[next] - transitionDuration
 		}
 
 		f.log.Infow("Transition settings",
 			"clip_index", i,
 			"type", transitionType,
 			"duration", transitionDuration,
-			"offset", offset,
-			"clip_duration", clipDuration)
+			"timeline_length", length)
 
-		var inputLabel, outputLabel string
-		if i == 0 {
-			inputLabel = fmt.Sprintf("[v0][v1]")
-		} else {
-			inputLabel = fmt.Sprintf("[vx%02d][v%d]", i-1, i+1)
-		}
-
-		if i == len(inputPaths)-2 {
-			outputLabel = "[outv]"
-		} else {
-			outputLabel = fmt.Sprintf("[vx%02d]", i)
-		}
-
-		filterPart := fmt.Sprintf("%sxfade=transition=%s:duration=%.1f:offset=%.1f%s",
-			inputLabel, transitionType, transitionDuration, offset, outputLabel)
-		transitionFilters = append(transitionFilters, filterPart)
-	}
-
-	// 合并缩放和转场滤镜
-	var videoFilters []string
-	videoFilters = append(videoFilters, scaleFilters...)
-	videoFilters = append(videoFilters, transitionFilters...)
-	filterComplex := strings.Join(videoFilters, ";")
-
-	// 音频处理：如果有任何视频包含音频流，则处理音频
-	var fullFilter string
-	if hasAnyAudio {
-		// 为没有音频的视频生成静音轨道，确保所有输入音频流一致
-		var silenceFilters []string
-		for i := 0; i < len(inputPaths); i++ {
-			if !audioStreams[i] {
-				// 计算该视频的时长
-				clipDuration := clips[i].Duration
-				if clips[i].EndTime > 0 && clips[i].StartTime >= 0 {
-					clipDuration = clips[i].EndTime - clips[i].StartTime
-				}
-				// anullsrc是源滤镜，不接受输入，使用duration参数指定时长
-				silenceFilters = append(silenceFilters,
-					fmt.Sprintf("anullsrc=channel_layout=stereo:sample_rate=44100:duration=%.2f[a%d]", clipDuration, i))
-			}
-		}
-
-		// 拼接所有音频流（包括生成的静音流）
-		var audioConcat strings.Builder
-		for i := 0; i < len(inputPaths); i++ {
-			if audioStreams[i] {
-				audioConcat.WriteString(fmt.Sprintf("[%d:a]", i))
-			} else {
-				audioConcat.WriteString(fmt.Sprintf("[a%d]", i))
-			}
-		}
-		audioConcat.WriteString(fmt.Sprintf("concat=n=%d:v=0:a=1[outa]", len(inputPaths)))
+		videoLabel, audioLabel = outVideo, outAudio
+	}
 
-		// 构建完整滤镜：先生成静音流，再拼接音频
-		if len(silenceFilters) > 0 {
-			fullFilter = filterComplex + ";" + strings.Join(silenceFilters, ";") + ";" + audioConcat.String()
-		} else {
-			fullFilter = filterComplex + ";" + audioConcat.String()
-		}
-	} else {
-		// 所有视频都无音频流，只处理视频
-		fullFilter = filterComplex
+	outputVideo := "[outv]"
+	if profile.BurnIn {
+		filters = append(filters, fmt.Sprintf("[outv]%s[outtc]", profile.timecodeFilter()))
+		outputVideo = "[outtc]"
 	}
 
+	fullFilter := strings.Join(filters, ";")
+
 	// 构建完整命令
 	args = append(args,
 		"-filter_complex", fullFilter,
-		"-map", "[outv]",
-	)
-
-	// 仅在有任何音频时映射音频输出
-	if hasAnyAudio {
-		args = append(args, "-map", "[outa]")
-	}
-
-	args = append(args,
-		"-c:v", "libx264",
-		"-preset", "medium",
-		"-crf", "23",
+		"-map", outputVideo,
+		"-map", "[outa]",
 	)
-
-	// 仅在有任何音频时设置音频编码参数
-	if hasAnyAudio {
-		args = append(args,
-			"-c:a", "aac",
-			"-b:a", "128k",
-		)
-	}
-
+	args = append(args, profile.VideoArgs()...)
+	args = append(args, profile.AudioArgs()...)
+	args = append(args, profile.ContainerArgs()...)
 	args = append(args,
 		"-y",
 		outputPath,
 	)
 
-	f.log.Infow("Running FFmpeg with transitions", "filter", fullFilter, "has_any_audio", hasAnyAudio)
+	f.log.Infow("Running FFmpeg with transitions", "filter", fullFilter)
 
-	cmd := exec.Command("ffmpeg", args...)
-	output, err := cmd.CombinedOutput()
+	output, err := f.run(ctx, length, onProgress, args...)
 	if err != nil {
 		f.log.Errorw("FFmpeg xfade failed", "error", err, "output", string(output))
 		return fmt.Errorf("ffmpeg xfade failed: %w, output: %s", err, string(output))
@@ -475,6 +561,84 @@ func (f *FFmpeg) mergeWithXfade(inputPaths []string, clips []VideoClip, outputPa
 	return nil
 }
 
+// resolveTransition 读取片段出场转场的类型与时长（秒），未设置或为硬切时返回空类型
+func (f *FFmpeg) resolveTransition(transition map[string]interface{}) (string, float64) {
+	if len(transition) == 0 {
+		return "", 0
+	}
+
+	tType, _ := transition["type"].(string)
+	switch strings.ToLower(tType) {
+	case "none", "cut":
+		return "", 0
+	}
+
+	transitionDuration := 1.0 // 默认转场时长为1秒
+	switch d := transition["duration"].(type) {
+	case float64:
+		transitionDuration = d
+	case int:
+		transitionDuration = float64(d)
+	}
+	if transitionDuration <= 0 {
+		return "", 0
+	}
+
+	mapped := f.mapTransitionType(tType)
+	f.log.Infow("Using transition type", "type", tType, "mapped", mapped)
+	return mapped, transitionDuration
+}
+
+// clipDuration 片段裁剪后的时长
+func clipDuration(clip VideoClip) float64 {
+	if clip.EndTime > 0 && clip.EndTime > clip.StartTime {
+		return clip.EndTime - clip.StartTime
+	}
+	return clip.Duration
+}
+
+// detectProfile 按首个片段的画面方向选择输出配置，探测失败时使用默认配置
+func (f *FFmpeg) detectProfile(ctx context.Context, url string) *OutputProfile {
+	name := DefaultOutputProfile
+	if sourcePath, err := f.fetchSource(ctx, url); err == nil {
+		width, height := f.getVideoResolution(sourcePath)
+		switch {
+		case height > width:
+			name = "vertical_1080p"
+		case height == width:
+			name = "square_1080p"
+		}
+	}
+
+	profile, _ := GetOutputProfile(name)
+	return profile
+}
+
+// clipSource 片段的素材地址：视频优先，否则为静态图片
+func clipSource(clip VideoClip) string {
+	if clip.URL != "" {
+		return clip.URL
+	}
+	return clip.ImageURL
+}
+
+// truncateSource 日志中截断过长的地址（如data URI）
+func truncateSource(source string) string {
+	if len(source) > 200 {
+		return source[:200] + "..."
+	}
+	return source
+}
+
+// totalClipDuration 计算片段裁剪后的总时长，用于换算进度
+func totalClipDuration(clips []VideoClip) float64 {
+	var total float64
+	for _, clip := range clips {
+		total += clipDuration(clip)
+	}
+	return total
+}
+
 func (f *FFmpeg) mapTransitionType(transType string) string {
 	// 将前端传入的转场类型映射为FFmpeg xfade支持的类型
 	// FFmpeg xfade支持的完整转场列表: https://ffmpeg.org/ffmpeg-filters.html#xfade
@@ -589,6 +753,27 @@ func (f *FFmpeg) getVideoResolution(videoPath string) (int, int) {
 	return width, height
 }
 
+// GetDuration 使用ffprobe获取媒体文件时长（秒）
+func (f *FFmpeg) GetDuration(path string) (float64, error) {
+	cmd := exec.Command("ffprobe",
+		"-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		path,
+	)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe failed: %w", err)
+	}
+
+	var duration float64
+	if _, err := fmt.Sscanf(strings.TrimSpace(string(output)), "%f", &duration); err != nil {
+		return 0, fmt.Errorf("invalid duration output: %s", strings.TrimSpace(string(output)))
+	}
+	return duration, nil
+}
+
 func (f *FFmpeg) copyFile(src, dst string) error {
 	cmd := exec.Command("cp", src, dst)
 	output, err := cmd.CombinedOutput()
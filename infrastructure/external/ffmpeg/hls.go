@@ -0,0 +1,160 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HLSRendition HLS码率档位，Height 指画面短边（竖屏时为宽度）
+type HLSRendition struct {
+	Name         string
+	Height       int
+	VideoBitrate string
+	AudioBitrate string
+}
+
+// DefaultHLSRenditions 默认码率档位，高于源画面的档位会被跳过
+var DefaultHLSRenditions = []HLSRendition{
+	{Name: "1080p", Height: 1080, VideoBitrate: "5000k", AudioBitrate: "128k"},
+	{Name: "720p", Height: 720, VideoBitrate: "2800k", AudioBitrate: "128k"},
+	{Name: "480p", Height: 480, VideoBitrate: "1200k", AudioBitrate: "96k"},
+}
+
+// HLSMasterPlaylist 主播放列表文件名
+const HLSMasterPlaylist = "master.m3u8"
+
+type HLSOptions struct {
+	InputPath       string
+	OutputDir       string // 输出目录：master.m3u8、各档位播放列表与切片平铺存放
+	Renditions      []HLSRendition
+	SegmentDuration int // 切片时长（秒），默认6
+	Context         context.Context
+	OnProgress      ProgressFunc
+}
+
+// PackageHLS 将视频切片为多码率HLS，返回主播放列表路径
+// 所有档位在同一条ffmpeg命令中编码，关键帧按切片时长对齐，保证各档位可无缝切换
+func (f *FFmpeg) PackageHLS(opts *HLSOptions) (string, error) {
+	if opts.SegmentDuration <= 0 {
+		opts.SegmentDuration = 6
+	}
+	renditions := opts.Renditions
+	if len(renditions) == 0 {
+		renditions = DefaultHLSRenditions
+	}
+
+	duration, err := f.GetDuration(opts.InputPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to probe input: %w", err)
+	}
+
+	// 以短边判断档位，竖屏视频同样适用
+	width, height := f.getVideoResolution(opts.InputPath)
+	vertical := height > width
+	shortSide := height
+	if vertical {
+		shortSide = width
+	}
+
+	var selected []HLSRendition
+	for _, rendition := range renditions {
+		if rendition.Height <= shortSide {
+			selected = append(selected, rendition)
+		}
+	}
+	if len(selected) == 0 {
+		// 源画面低于所有档位时，仅保留最低档并按源分辨率输出（libx264要求偶数尺寸，奇数时向下取偶）
+		lowest := renditions[len(renditions)-1]
+		lowest.Height = shortSide &^ 1
+		selected = []HLSRendition{lowest}
+	}
+
+	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create hls directory: %w", err)
+	}
+
+	hasAudio := f.hasAudioStream(opts.InputPath)
+
+	// [0:v]split=N[s0][s1]...;[s0]scale=-2:1080[v0];...
+	var filters []string
+	var splitLabels strings.Builder
+	for i := range selected {
+		splitLabels.WriteString(fmt.Sprintf("[s%d]", i))
+	}
+	filters = append(filters, fmt.Sprintf("[0:v]split=%d%s", len(selected), splitLabels.String()))
+	for i, rendition := range selected {
+		scale := fmt.Sprintf("scale=-2:%d", rendition.Height)
+		if vertical {
+			scale = fmt.Sprintf("scale=%d:-2", rendition.Height)
+		}
+		filters = append(filters, fmt.Sprintf("[s%d]%s[v%d]", i, scale, i))
+	}
+
+	args := []string{
+		"-i", opts.InputPath,
+		"-filter_complex", strings.Join(filters, ";"),
+	}
+
+	var streamMap []string
+	for i := range selected {
+		args = append(args, "-map", fmt.Sprintf("[v%d]", i))
+		if hasAudio {
+			args = append(args, "-map", "0:a:0")
+		}
+	}
+	for i, rendition := range selected {
+		args = append(args,
+			fmt.Sprintf("-c:v:%d", i), "libx264",
+			fmt.Sprintf("-b:v:%d", i), rendition.VideoBitrate,
+			fmt.Sprintf("-maxrate:v:%d", i), rendition.VideoBitrate,
+			fmt.Sprintf("-bufsize:v:%d", i), rendition.VideoBitrate,
+		)
+		entry := fmt.Sprintf("v:%d", i)
+		if hasAudio {
+			args = append(args,
+				fmt.Sprintf("-c:a:%d", i), "aac",
+				fmt.Sprintf("-b:a:%d", i), rendition.AudioBitrate,
+			)
+			entry += fmt.Sprintf(",a:%d", i)
+		}
+		streamMap = append(streamMap, entry+",name:"+rendition.Name)
+	}
+
+	args = append(args,
+		"-preset", "fast",
+		"-pix_fmt", "yuv420p",
+		"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%d)", opts.SegmentDuration),
+		"-sc_threshold", "0",
+		"-f", "hls",
+		"-hls_time", fmt.Sprintf("%d", opts.SegmentDuration),
+		"-hls_playlist_type", "vod",
+		"-hls_flags", "independent_segments",
+		"-hls_segment_filename", filepath.Join(opts.OutputDir, "%v_%04d.ts"),
+		"-master_pl_name", HLSMasterPlaylist,
+		"-var_stream_map", strings.Join(streamMap, " "),
+		"-y",
+		filepath.Join(opts.OutputDir, "%v.m3u8"),
+	)
+
+	f.log.Infow("Packaging HLS",
+		"input", opts.InputPath,
+		"output_dir", opts.OutputDir,
+		"renditions", len(selected))
+
+	output, err := f.run(opts.Context, duration, stageProgress(opts.OnProgress, 0, 100), args...)
+	if err != nil {
+		os.RemoveAll(opts.OutputDir)
+		if opts.Context != nil && opts.Context.Err() != nil {
+			return "", opts.Context.Err()
+		}
+		f.log.Errorw("FFmpeg HLS packaging failed", "error", err, "output", string(output))
+		return "", fmt.Errorf("ffmpeg hls packaging failed: %w, output: %s", err, string(output))
+	}
+
+	masterPath := filepath.Join(opts.OutputDir, HLSMasterPlaylist)
+	f.log.Infow("HLS packaging completed", "master", masterPath)
+	return masterPath, nil
+}
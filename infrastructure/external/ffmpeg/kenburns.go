@@ -0,0 +1,103 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// KenBurnsMotion 静态图片生成运镜片段时的镜头运动方式
+type KenBurnsMotion string
+
+const (
+	KenBurnsZoomIn   KenBurnsMotion = "zoom_in"
+	KenBurnsZoomOut  KenBurnsMotion = "zoom_out"
+	KenBurnsPanLeft  KenBurnsMotion = "pan_left"
+	KenBurnsPanRight KenBurnsMotion = "pan_right"
+)
+
+// kenBurnsMaxZoom 推拉镜头的最大放大倍数
+const kenBurnsMaxZoom = 1.2
+
+// MotionFromMovement 根据分镜的运镜描述选择运动方式，无法识别时默认缓慢推近
+func MotionFromMovement(movement string) KenBurnsMotion {
+	switch {
+	case strings.Contains(movement, "拉"), strings.Contains(strings.ToLower(movement), "zoom out"):
+		return KenBurnsZoomOut
+	case strings.Contains(movement, "左"), strings.Contains(strings.ToLower(movement), "left"):
+		return KenBurnsPanLeft
+	case strings.Contains(movement, "右"), strings.Contains(movement, "摇"), strings.Contains(movement, "移"),
+		strings.Contains(strings.ToLower(movement), "pan"):
+		return KenBurnsPanRight
+	default:
+		return KenBurnsZoomIn
+	}
+}
+
+// kenBurnsFilter 将单张图片展开为指定时长的运镜画面
+// 先放大到输出尺寸的2倍并裁切填满画面，减少zoompan逐帧取整造成的抖动
+func kenBurnsFilter(motion KenBurnsMotion, width, height, fps int, duration float64) string {
+	frames := int(math.Max(1, math.Round(duration*float64(fps))))
+	step := (kenBurnsMaxZoom - 1) / float64(frames)
+
+	zoom := fmt.Sprintf("'1+%.6f*on'", step)
+	x, y := "'iw/2-(iw/zoom/2)'", "'ih/2-(ih/zoom/2)'"
+	switch motion {
+	case KenBurnsZoomOut:
+		zoom = fmt.Sprintf("'%.2f-%.6f*on'", kenBurnsMaxZoom, step)
+	case KenBurnsPanLeft:
+		zoom = fmt.Sprintf("'%.2f'", kenBurnsMaxZoom)
+		x = fmt.Sprintf("'(iw-iw/zoom)*(1-on/%d)'", frames)
+	case KenBurnsPanRight:
+		zoom = fmt.Sprintf("'%.2f'", kenBurnsMaxZoom)
+		x = fmt.Sprintf("'(iw-iw/zoom)*on/%d'", frames)
+	}
+
+	return fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=increase,crop=%d:%d,zoompan=z=%s:x=%s:y=%s:d=%d:s=%dx%d:fps=%d,setsar=1",
+		width*2, height*2, width*2, height*2, zoom, x, y, frames, width, height, fps)
+}
+
+// renderStill 将静态图片渲染为带静音音轨的运镜片段，编码参数与裁剪后的片段一致，可直接参与拼接
+func (f *FFmpeg) renderStill(ctx context.Context, imagePath, outputPath string, clip VideoClip, profile *OutputProfile, onProgress func(float64)) error {
+	duration := clip.Duration
+	if duration <= 0 {
+		return fmt.Errorf("still clip requires a duration")
+	}
+	motion := clip.Motion
+	if motion == "" {
+		motion = KenBurnsZoomIn
+	}
+
+	f.log.Infow("Rendering still clip",
+		"image", imagePath,
+		"output", outputPath,
+		"duration", duration,
+		"motion", motion,
+		"profile", profile.Name)
+
+	videoFilter := kenBurnsFilter(motion, profile.Width, profile.Height, profile.FPS, duration)
+	if profile.BurnIn && clip.Label != "" {
+		videoFilter += "," + profile.shotLabelFilter(clip.Label)
+	}
+
+	args := []string{
+		"-i", imagePath,
+		"-f", "lavfi", "-i", "anullsrc=channel_layout=stereo:sample_rate=48000",
+		"-map", "0:v:0", "-map", "1:a:0",
+		"-vf", videoFilter,
+		"-t", fmt.Sprintf("%.3f", duration),
+	}
+	args = append(args, profile.VideoArgs()...)
+	args = append(args, profile.AudioArgs()...)
+	args = append(args, "-movflags", "+faststart", "-y", outputPath)
+
+	output, err := f.run(ctx, duration, onProgress, args...)
+	if err != nil {
+		os.Remove(outputPath)
+		f.log.Errorw("FFmpeg still render failed", "error", err, "output", string(output))
+		return fmt.Errorf("ffmpeg still render failed: %w, output: %s", err, string(output))
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package ffmpeg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultLoudnessTarget 默认目标整体响度（LUFS），与主流网络视频平台一致
+	DefaultLoudnessTarget = -16.0
+	// DefaultTruePeak 默认真峰值上限（dBTP）
+	DefaultTruePeak = -1.5
+	// loudnessRange 目标响度范围（LU）
+	loudnessRange = 11.0
+	// silenceThreshold 低于该响度视为静音，不做响度统一
+	silenceThreshold = -70.0
+)
+
+// LoudnessStats EBU R128 响度测量结果
+type LoudnessStats struct {
+	Integrated float64 `json:"integrated"` // 整体响度（LUFS）
+	TruePeak   float64 `json:"true_peak"`  // 真峰值（dBTP）
+	Range      float64 `json:"range"`      // 响度范围（LU）
+	Threshold  float64 `json:"threshold"`  // 门限（LUFS）
+	Offset     float64 `json:"offset"`     // 第二遍使用的增益补偿
+}
+
+// Silent 是否为静音（无音轨或全程无声）
+func (s *LoudnessStats) Silent() bool {
+	return math.IsInf(s.Integrated, -1) || math.IsNaN(s.Integrated) || s.Integrated < silenceThreshold
+}
+
+// loudnormReport loudnorm print_format=json 输出，数值均为字符串
+type loudnormReport struct {
+	InputI       string `json:"input_i"`
+	InputTP      string `json:"input_tp"`
+	InputLRA     string `json:"input_lra"`
+	InputThresh  string `json:"input_thresh"`
+	OutputI      string `json:"output_i"`
+	OutputTP     string `json:"output_tp"`
+	OutputLRA    string `json:"output_lra"`
+	OutputThresh string `json:"output_thresh"`
+	TargetOffset string `json:"target_offset"`
+}
+
+// MasteringOptions 成片音频母带处理参数
+type MasteringOptions struct {
+	InputPath  string
+	OutputPath string
+	Target     float64        // 目标整体响度（LUFS），为0时使用默认值
+	TruePeak   float64        // 真峰值上限（dBTP），为0时使用默认值
+	Profile    *OutputProfile // 音频编码配置，为空时按输出容器选择默认配置
+	Context    context.Context
+	OnProgress ProgressFunc
+}
+
+// MasteringResult 母带处理前后的响度
+type MasteringResult struct {
+	Input  LoudnessStats
+	Output *LoudnessStats // 处理后的响度，未能测得时为空
+}
+
+// MasterAudio 对成片音频做两遍EBU R128响度统一并以限幅器控制峰值，视频流直接复制
+// 成片无声（Input.Silent()）时不做处理，也不输出文件
+func (f *FFmpeg) MasterAudio(opts *MasteringOptions) (*MasteringResult, error) {
+	target, truePeak := loudnessTarget(opts.Target, opts.TruePeak)
+	duration, _ := f.GetDuration(opts.InputPath)
+
+	measured, err := f.measureLoudness(opts.Context, opts.InputPath, nil, target, truePeak, duration, stageProgress(opts.OnProgress, 0, 40))
+	if err != nil {
+		return nil, err
+	}
+	if measured.Silent() {
+		f.log.Infow("Audio is silent, skipping mastering", "input", opts.InputPath)
+		return &MasteringResult{Input: *measured}, nil
+	}
+
+	f.log.Infow("Mastering audio",
+		"input", opts.InputPath,
+		"measured_lufs", measured.Integrated,
+		"measured_tp", measured.TruePeak,
+		"target_lufs", target)
+
+	profile := profileForOutput(opts.Profile, opts.OutputPath)
+	filter := fmt.Sprintf("%s:print_format=json,aresample=48000,%s", loudnormFilter(measured, target, truePeak), limiterFilter(truePeak))
+	args := []string{
+		"-i", opts.InputPath,
+		"-map", "0:v?", "-map", "0:a:0",
+		"-c:v", "copy",
+		"-af", filter,
+	}
+	args = append(args, profile.AudioArgs()...)
+	args = append(args, profile.ContainerArgs()...)
+	args = append(args, "-y", opts.OutputPath)
+
+	output, err := f.run(opts.Context, duration, stageProgress(opts.OnProgress, 40, 100), args...)
+	if err != nil {
+		os.Remove(opts.OutputPath)
+		if opts.Context != nil && opts.Context.Err() != nil {
+			return nil, opts.Context.Err()
+		}
+		f.log.Errorw("FFmpeg audio mastering failed", "error", err, "output", string(output))
+		return nil, fmt.Errorf("ffmpeg audio mastering failed: %w, output: %s", err, string(output))
+	}
+
+	result := &MasteringResult{Input: *measured}
+	if report, err := parseLoudnormReport(output); err == nil {
+		result.Output = &LoudnessStats{
+			Integrated: parseLoudness(report.OutputI),
+			TruePeak:   parseLoudness(report.OutputTP),
+			Range:      parseLoudness(report.OutputLRA),
+			Threshold:  parseLoudness(report.OutputThresh),
+		}
+	} else {
+		f.log.Warnw("Failed to parse mastered loudness", "error", err)
+	}
+	return result, nil
+}
+
+// loudnessWindow 测量区间（秒），与片段裁剪区间一致
+type loudnessWindow struct {
+	start, end float64
+}
+
+// measureLoudness loudnorm 第一遍：测量输入（或其中一段）的响度
+func (f *FFmpeg) measureLoudness(ctx context.Context, inputPath string, window *loudnessWindow, target, truePeak, duration float64, onProgress func(float64)) (*LoudnessStats, error) {
+	args := []string{"-i", inputPath}
+	if window != nil {
+		args = append(args, "-ss", fmt.Sprintf("%.2f", window.start))
+		if window.end > 0 {
+			args = append(args, "-to", fmt.Sprintf("%.2f", window.end))
+		}
+	}
+	args = append(args,
+		"-map", "0:a:0", "-vn", "-sn",
+		"-af", fmt.Sprintf("loudnorm=I=%.1f:TP=%.1f:LRA=%.1f:print_format=json", target, truePeak, loudnessRange),
+		"-f", "null", "-")
+
+	output, err := f.run(ctx, duration, onProgress, args...)
+	if err != nil {
+		if ctx != nil && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("ffmpeg loudness measurement failed: %w, output: %s", err, string(output))
+	}
+
+	report, err := parseLoudnormReport(output)
+	if err != nil {
+		return nil, err
+	}
+	return &LoudnessStats{
+		Integrated: parseLoudness(report.InputI),
+		TruePeak:   parseLoudness(report.InputTP),
+		Range:      parseLoudness(report.InputLRA),
+		Threshold:  parseLoudness(report.InputThresh),
+		Offset:     parseLoudness(report.TargetOffset),
+	}, nil
+}
+
+// loudnormFilter loudnorm 第二遍：使用第一遍的测量值做线性增益，保留原有动态
+func loudnormFilter(measured *LoudnessStats, target, truePeak float64) string {
+	return fmt.Sprintf("loudnorm=I=%.1f:TP=%.1f:LRA=%.1f:measured_I=%.2f:measured_TP=%.2f:measured_LRA=%.2f:measured_thresh=%.2f:offset=%.2f:linear=true",
+		target, truePeak, loudnessRange,
+		measured.Integrated, measured.TruePeak, measured.Range, measured.Threshold, measured.Offset)
+}
+
+// limiterFilter 按真峰值上限限幅，防止增益后削波
+func limiterFilter(truePeak float64) string {
+	limit := math.Pow(10, truePeak/20)
+	return fmt.Sprintf("alimiter=limit=%.4f:level=false", math.Max(limit, 0.0625))
+}
+
+// loudnessTarget 填充默认目标响度与真峰值
+func loudnessTarget(target, truePeak float64) (float64, float64) {
+	if target == 0 {
+		target = DefaultLoudnessTarget
+	}
+	if truePeak == 0 {
+		truePeak = DefaultTruePeak
+	}
+	return target, truePeak
+}
+
+// parseLoudnormReport 从ffmpeg日志末尾提取loudnorm的JSON报告
+func parseLoudnormReport(output []byte) (*loudnormReport, error) {
+	text := string(output)
+	end := strings.LastIndex(text, "}")
+	start := strings.LastIndex(text[:max(end, 0)], "{")
+	if start == -1 || end == -1 {
+		return nil, fmt.Errorf("loudnorm report not found in ffmpeg output")
+	}
+	var report loudnormReport
+	if err := json.Unmarshal([]byte(text[start:end+1]), &report); err != nil {
+		return nil, fmt.Errorf("invalid loudnorm report: %w", err)
+	}
+	return &report, nil
+}
+
+// parseLoudness 解析loudnorm数值，静音时为 -inf
+func parseLoudness(value string) float64 {
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return math.Inf(-1)
+	}
+	return number
+}
@@ -0,0 +1,62 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// previewShortSide 预览画面短边像素
+const previewShortSide = 360
+
+// Preview 基于当前配置派生预览配置：保持画面比例，短边缩小到360，使用最快编码预设，
+// 并在画面上烧录镜头编号与时间码，便于快速检查节奏
+func (p *OutputProfile) Preview() *OutputProfile {
+	width, height := p.Width, p.Height
+	shortSide := min(width, height)
+	if shortSide > previewShortSide {
+		width = evenRound(float64(width) * previewShortSide / float64(shortSide))
+		height = evenRound(float64(height) * previewShortSide / float64(shortSide))
+	}
+
+	return &OutputProfile{
+		Name:         p.Name + "_preview",
+		Label:        p.Label + "（预览）",
+		Width:        width,
+		Height:       height,
+		FPS:          p.FPS,
+		Codec:        CodecH264,
+		CRF:          32,
+		Preset:       "ultrafast",
+		AudioBitrate: "64k",
+		BurnIn:       true,
+	}
+}
+
+// shotLabelFilter 在画面左上角烧录镜头编号
+func (p *OutputProfile) shotLabelFilter(label string) string {
+	fontSize := min(p.Width, p.Height) / 16
+	return fmt.Sprintf("drawtext=text='%s':fontsize=%d:fontcolor=white:box=1:boxcolor=black@0.5:boxborderw=6:x=%d:y=%d",
+		escapeDrawText(label), fontSize, fontSize/2, fontSize/2)
+}
+
+// timecodeFilter 在画面底部居中烧录 HH:MM:SS:FF 时间码，从成片第0帧开始计时
+func (p *OutputProfile) timecodeFilter() string {
+	fontSize := min(p.Width, p.Height) / 16
+	return fmt.Sprintf("drawtext=timecode='00\\:00\\:00\\:00':rate=%d:fontsize=%d:fontcolor=white:box=1:boxcolor=black@0.5:boxborderw=6:x=(w-text_w)/2:y=h-text_h-%d",
+		p.FPS, fontSize, fontSize/2)
+}
+
+// escapeDrawText 转义drawtext文本中的特殊字符（单引号无法在引号内转义，直接去掉）
+func escapeDrawText(text string) string {
+	replacer := strings.NewReplacer(
+		"\\", "\\\\",
+		"'", "",
+		":", "\\:",
+		"%", "\\%",
+	)
+	return replacer.Replace(text)
+}
+
+func evenRound(value float64) int {
+	return int(value/2+0.5) * 2
+}
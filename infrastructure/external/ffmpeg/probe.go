@@ -0,0 +1,122 @@
+package ffmpeg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// MediaInfo ffprobe 探测到的媒体信息
+type MediaInfo struct {
+	FormatName string  `json:"format_name"` // 容器格式，如 mov,mp4,m4a,3gp,3g2,mj2
+	Duration   float64 `json:"duration"`    // 秒
+	Size       int64   `json:"size"`
+	BitRate    int64   `json:"bit_rate"`
+	HasVideo   bool    `json:"has_video"`
+	HasAudio   bool    `json:"has_audio"`
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	FPS        float64 `json:"fps"`
+	VideoCodec string  `json:"video_codec"`
+	AudioCodec string  `json:"audio_codec"`
+	SampleRate int     `json:"sample_rate"`
+	Channels   int     `json:"channels"`
+}
+
+type probeOutput struct {
+	Streams []struct {
+		CodecType    string `json:"codec_type"`
+		CodecName    string `json:"codec_name"`
+		Width        int    `json:"width"`
+		Height       int    `json:"height"`
+		AvgFrameRate string `json:"avg_frame_rate"`
+		RFrameRate   string `json:"r_frame_rate"`
+		SampleRate   string `json:"sample_rate"`
+		Channels     int    `json:"channels"`
+		Disposition  struct {
+			AttachedPic int `json:"attached_pic"`
+		} `json:"disposition"`
+	} `json:"streams"`
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+		Size       string `json:"size"`
+		BitRate    string `json:"bit_rate"`
+	} `json:"format"`
+}
+
+// Probe 使用ffprobe读取媒体文件（本地路径或URL）的容器与音视频流信息
+func (f *FFmpeg) Probe(ctx context.Context, input string) (*MediaInfo, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cmd := exec.CommandContext(ctx, "ffprobe",
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		input,
+	)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe failed: %w", err)
+	}
+
+	var probe probeOutput
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("invalid ffprobe output: %w", err)
+	}
+
+	info := &MediaInfo{FormatName: probe.Format.FormatName}
+	info.Duration, _ = strconv.ParseFloat(probe.Format.Duration, 64)
+	info.Size, _ = strconv.ParseInt(probe.Format.Size, 10, 64)
+	info.BitRate, _ = strconv.ParseInt(probe.Format.BitRate, 10, 64)
+
+	for _, stream := range probe.Streams {
+		switch stream.CodecType {
+		case "video":
+			// 音频文件中的封面图不算视频流
+			if info.HasVideo || stream.Disposition.AttachedPic == 1 {
+				continue
+			}
+			info.HasVideo = true
+			info.Width = stream.Width
+			info.Height = stream.Height
+			info.VideoCodec = stream.CodecName
+			info.FPS = parseFrameRate(stream.AvgFrameRate)
+			if info.FPS == 0 {
+				info.FPS = parseFrameRate(stream.RFrameRate)
+			}
+		case "audio":
+			if info.HasAudio {
+				continue
+			}
+			info.HasAudio = true
+			info.AudioCodec = stream.CodecName
+			info.SampleRate, _ = strconv.Atoi(stream.SampleRate)
+			info.Channels = stream.Channels
+		}
+	}
+
+	return info, nil
+}
+
+// parseFrameRate 解析 "30000/1001" 形式的帧率
+func parseFrameRate(rate string) float64 {
+	num, den, ok := strings.Cut(rate, "/")
+	if !ok {
+		value, _ := strconv.ParseFloat(rate, 64)
+		return value
+	}
+	n, err1 := strconv.ParseFloat(num, 64)
+	d, err2 := strconv.ParseFloat(den, 64)
+	if err1 != nil || err2 != nil || d == 0 {
+		return 0
+	}
+	return n / d
+}
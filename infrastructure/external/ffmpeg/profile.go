@@ -0,0 +1,157 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"sort"
+)
+
+// VideoCodec 输出视频编码
+type VideoCodec string
+
+const (
+	CodecH264 VideoCodec = "h264"
+	CodecH265 VideoCodec = "h265"
+	CodecVP9  VideoCodec = "vp9"
+)
+
+// OutputProfile 成片输出配置
+// 所有片段会先按配置统一缩放/补边到目标分辨率与帧率，再以目标编码输出
+type OutputProfile struct {
+	Name         string     `json:"name"`
+	Label        string     `json:"label"`
+	Width        int        `json:"width"`
+	Height       int        `json:"height"`
+	FPS          int        `json:"fps"`
+	Codec        VideoCodec `json:"codec"`
+	VideoBitrate string     `json:"video_bitrate,omitempty"` // 目标码率，如 "6M"；为空时使用CRF
+	CRF          int        `json:"crf"`
+	Preset       string     `json:"preset,omitempty"`
+	AudioBitrate string     `json:"audio_bitrate"`
+	BurnIn       bool       `json:"burn_in,omitempty"` // 烧录镜头编号与时间码（预览配置）
+	// LoudnessTarget 片段响度统一的目标响度（LUFS），为0时保持片段原有音量
+	LoudnessTarget float64 `json:"loudness_target,omitempty"`
+}
+
+// DefaultOutputProfile 未指定配置时的输出配置
+const DefaultOutputProfile = "landscape_1080p"
+
+var outputProfiles = map[string]OutputProfile{
+	"vertical_1080p": {
+		Name: "vertical_1080p", Label: "竖屏 9:16 1080x1920（短视频平台）",
+		Width: 1080, Height: 1920, FPS: 30, Codec: CodecH264, VideoBitrate: "6M", CRF: 23, Preset: "medium", AudioBitrate: "128k",
+	},
+	"vertical_720p": {
+		Name: "vertical_720p", Label: "竖屏 9:16 720x1280",
+		Width: 720, Height: 1280, FPS: 30, Codec: CodecH264, VideoBitrate: "3M", CRF: 23, Preset: "medium", AudioBitrate: "128k",
+	},
+	"landscape_1080p": {
+		Name: "landscape_1080p", Label: "横屏 16:9 1920x1080",
+		Width: 1920, Height: 1080, FPS: 30, Codec: CodecH264, CRF: 23, Preset: "medium", AudioBitrate: "128k",
+	},
+	"landscape_1080p_h265": {
+		Name: "landscape_1080p_h265", Label: "横屏 16:9 1920x1080 H.265",
+		Width: 1920, Height: 1080, FPS: 30, Codec: CodecH265, CRF: 28, Preset: "medium", AudioBitrate: "128k",
+	},
+	"landscape_720p": {
+		Name: "landscape_720p", Label: "横屏 16:9 1280x720",
+		Width: 1280, Height: 720, FPS: 30, Codec: CodecH264, CRF: 23, Preset: "medium", AudioBitrate: "128k",
+	},
+	"square_1080p": {
+		Name: "square_1080p", Label: "方形 1:1 1080x1080",
+		Width: 1080, Height: 1080, FPS: 30, Codec: CodecH264, CRF: 23, Preset: "medium", AudioBitrate: "128k",
+	},
+	"web_1080p_vp9": {
+		Name: "web_1080p_vp9", Label: "横屏 16:9 1920x1080 VP9（WebM）",
+		Width: 1920, Height: 1080, FPS: 30, Codec: CodecVP9, VideoBitrate: "4M", CRF: 31, AudioBitrate: "128k",
+	},
+}
+
+// GetOutputProfile 根据名称获取输出配置，名称为空时返回默认配置
+func GetOutputProfile(name string) (*OutputProfile, error) {
+	if name == "" {
+		name = DefaultOutputProfile
+	}
+	profile, ok := outputProfiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown output profile: %s", name)
+	}
+	return &profile, nil
+}
+
+// ListOutputProfiles 返回所有可用输出配置（按名称排序）
+func ListOutputProfiles() []OutputProfile {
+	profiles := make([]OutputProfile, 0, len(outputProfiles))
+	for _, profile := range outputProfiles {
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles
+}
+
+// Extension 输出文件扩展名
+func (p *OutputProfile) Extension() string {
+	if p.Codec == CodecVP9 {
+		return ".webm"
+	}
+	return ".mp4"
+}
+
+// cacheKey 参与裁剪缓存key计算，配置变化时缓存失效
+func (p *OutputProfile) cacheKey() string {
+	return fmt.Sprintf("%dx%d@%d/%s/%s/%d/%s/%s/%t/%.1f", p.Width, p.Height, p.FPS, p.Codec, p.VideoBitrate, p.CRF, p.Preset, p.AudioBitrate, p.BurnIn, p.LoudnessTarget)
+}
+
+// NormalizeFilter 将任意分辨率的画面等比缩放并补黑边到目标尺寸，统一像素比与帧率
+func (p *OutputProfile) NormalizeFilter() string {
+	return fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,setsar=1,fps=%d",
+		p.Width, p.Height, p.Width, p.Height, p.FPS)
+}
+
+// VideoArgs 视频编码参数
+func (p *OutputProfile) VideoArgs() []string {
+	var args []string
+	switch p.Codec {
+	case CodecH265:
+		args = []string{"-c:v", "libx265", "-tag:v", "hvc1"}
+	case CodecVP9:
+		args = []string{"-c:v", "libvpx-vp9", "-row-mt", "1"}
+	default:
+		args = []string{"-c:v", "libx264"}
+	}
+
+	if p.Preset != "" && p.Codec != CodecVP9 {
+		args = append(args, "-preset", p.Preset)
+	}
+
+	if p.VideoBitrate != "" {
+		// 限制码率：以CRF保证质量，同时不超过目标码率
+		args = append(args, "-crf", fmt.Sprintf("%d", p.CRF), "-b:v", p.VideoBitrate)
+		if p.Codec != CodecVP9 {
+			args = append(args, "-maxrate", p.VideoBitrate, "-bufsize", p.VideoBitrate)
+		}
+	} else {
+		args = append(args, "-crf", fmt.Sprintf("%d", p.CRF))
+		if p.Codec == CodecVP9 {
+			args = append(args, "-b:v", "0")
+		}
+	}
+
+	return append(args, "-pix_fmt", "yuv420p")
+}
+
+// AudioArgs 音频编码参数（统一为48kHz立体声，便于片段直接拼接）
+func (p *OutputProfile) AudioArgs() []string {
+	codec := "aac"
+	if p.Codec == CodecVP9 {
+		codec = "libopus"
+	}
+	return []string{"-c:a", codec, "-b:a", p.AudioBitrate, "-ar", "48000", "-ac", "2"}
+}
+
+// ContainerArgs 容器相关参数
+func (p *OutputProfile) ContainerArgs() []string {
+	if p.Codec == CodecVP9 {
+		return nil
+	}
+	return []string{"-movflags", "+faststart"}
+}
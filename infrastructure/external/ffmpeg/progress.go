@@ -0,0 +1,79 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ProgressFunc 进度回调，percent 取值 0-100
+type ProgressFunc func(percent float64)
+
+// run 执行ffmpeg命令，解析 -progress 输出并按 totalDuration 换算为 0-1 的完成比例回调
+// ctx 被取消时进程会被终止，返回 ctx.Err()
+func (f *FFmpeg) run(ctx context.Context, totalDuration float64, onProgress func(fraction float64), args ...string) ([]byte, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	fullArgs := append([]string{"-progress", "pipe:1", "-nostats"}, args...)
+	cmd := exec.CommandContext(ctx, "ffmpeg", fullArgs...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach stdout: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok || onProgress == nil || totalDuration <= 0 {
+			continue
+		}
+
+		// out_time_ms 在ffmpeg中实际单位也是微秒
+		if key != "out_time_us" && key != "out_time_ms" {
+			if key == "progress" && value == "end" {
+				onProgress(1)
+			}
+			continue
+		}
+
+		us, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || us < 0 {
+			continue
+		}
+		fraction := float64(us) / 1e6 / totalDuration
+		if fraction > 1 {
+			fraction = 1
+		}
+		onProgress(fraction)
+	}
+
+	err = cmd.Wait()
+	if ctx.Err() != nil {
+		return stderr.Bytes(), ctx.Err()
+	}
+	return stderr.Bytes(), err
+}
+
+// stageProgress 将单条命令的完成比例映射到整体进度区间 [from, to]
+func stageProgress(onProgress ProgressFunc, from, to float64) func(float64) {
+	if onProgress == nil {
+		return nil
+	}
+	return func(fraction float64) {
+		onProgress(from + (to-from)*fraction)
+	}
+}
@@ -0,0 +1,117 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+const (
+	// 静音检测：低于门限且持续超过最短时长的区间视为停顿
+	silenceNoise    = "-35dB"
+	silenceMinPause = 0.5
+	// maxSpeechSpan 单段语音的最长时长（秒），超出时等分，避免一条字幕过长
+	maxSpeechSpan = 8.0
+	// minSpeechSpan 短于该时长的语音片段视为噪声丢弃
+	minSpeechSpan = 0.3
+)
+
+var (
+	silenceStartPattern = regexp.MustCompile(`silence_start:\s*(-?[\d.]+)`)
+	silenceEndPattern   = regexp.MustCompile(`silence_end:\s*(-?[\d.]+)`)
+)
+
+// SpeechSpan 一段有声区间（秒）
+type SpeechSpan struct {
+	Start float64
+	End   float64
+}
+
+// ExtractSpeechAudio 抽取单声道16kHz的低码率音频，供语音识别上传使用
+func (f *FFmpeg) ExtractSpeechAudio(ctx context.Context, inputPath, outputPath string) error {
+	if !f.hasAudioStream(inputPath) {
+		return fmt.Errorf("input has no audio stream")
+	}
+
+	output, err := f.run(ctx, 0, nil,
+		"-i", inputPath,
+		"-vn",
+		"-ac", "1",
+		"-ar", "16000",
+		"-c:a", "libmp3lame",
+		"-b:a", "32k",
+		"-y",
+		outputPath,
+	)
+	if err != nil {
+		os.Remove(outputPath)
+		if ctx != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		f.log.Errorw("FFmpeg speech audio extraction failed", "error", err, "output", string(output))
+		return fmt.Errorf("ffmpeg speech audio extraction failed: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
+// DetectSpeech 通过静音检测找出有声区间，过长的区间按 maxSpeechSpan 等分
+func (f *FFmpeg) DetectSpeech(ctx context.Context, inputPath string) ([]SpeechSpan, error) {
+	duration, err := f.GetDuration(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get duration: %w", err)
+	}
+	if !f.hasAudioStream(inputPath) {
+		return nil, nil
+	}
+
+	output, err := f.run(ctx, duration, nil,
+		"-i", inputPath,
+		"-vn",
+		"-af", fmt.Sprintf("silencedetect=noise=%s:d=%g", silenceNoise, silenceMinPause),
+		"-f", "null",
+		"-",
+	)
+	if err != nil {
+		if ctx != nil && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		f.log.Errorw("FFmpeg silence detection failed", "error", err, "output", string(output))
+		return nil, fmt.Errorf("ffmpeg silence detection failed: %w", err)
+	}
+
+	return speechSpans(string(output), duration), nil
+}
+
+// speechSpans 将 silencedetect 输出的静音区间取反为有声区间
+func speechSpans(log string, duration float64) []SpeechSpan {
+	starts := silenceStartPattern.FindAllStringSubmatch(log, -1)
+	ends := silenceEndPattern.FindAllStringSubmatch(log, -1)
+
+	var spans []SpeechSpan
+	cursor := 0.0
+	for i, match := range starts {
+		silenceStart, _ := strconv.ParseFloat(match[1], 64)
+		spans = appendSpeechSpan(spans, cursor, silenceStart)
+		// 静音持续到结尾时没有 silence_end
+		cursor = duration
+		if i < len(ends) {
+			cursor, _ = strconv.ParseFloat(ends[i][1], 64)
+		}
+	}
+	return appendSpeechSpan(spans, cursor, duration)
+}
+
+func appendSpeechSpan(spans []SpeechSpan, start, end float64) []SpeechSpan {
+	start = max(start, 0)
+	if end-start < minSpeechSpan {
+		return spans
+	}
+	pieces := int((end-start)/maxSpeechSpan) + 1
+	length := (end - start) / float64(pieces)
+	for i := 0; i < pieces; i++ {
+		spans = append(spans, SpeechSpan{Start: start + float64(i)*length, End: start + float64(i+1)*length})
+	}
+	return spans
+}
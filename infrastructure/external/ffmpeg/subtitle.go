@@ -0,0 +1,279 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SubtitleFormat 字幕文件格式
+type SubtitleFormat string
+
+const (
+	SubtitleSRT SubtitleFormat = "srt"
+	SubtitleASS SubtitleFormat = "ass"
+	SubtitleVTT SubtitleFormat = "vtt"
+)
+
+// ParseSubtitleFormat 解析字幕格式名称，为空时返回SRT
+func ParseSubtitleFormat(name string) (SubtitleFormat, error) {
+	switch strings.ToLower(strings.TrimPrefix(name, ".")) {
+	case "", "srt":
+		return SubtitleSRT, nil
+	case "ass", "ssa":
+		return SubtitleASS, nil
+	case "vtt", "webvtt":
+		return SubtitleVTT, nil
+	default:
+		return "", fmt.Errorf("unsupported subtitle format: %s", name)
+	}
+}
+
+// ContentType 字幕文件的MIME类型
+func (f SubtitleFormat) ContentType() string {
+	switch f {
+	case SubtitleASS:
+		return "text/x-ssa; charset=utf-8"
+	case SubtitleVTT:
+		return "text/vtt; charset=utf-8"
+	default:
+		return "application/x-subrip; charset=utf-8"
+	}
+}
+
+// SubtitleCue 单条字幕，时间为成片中的秒数
+type SubtitleCue struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// SubtitleStyle 字幕样式，用于ASS字幕与烧录
+type SubtitleStyle struct {
+	FontName     string  `json:"font_name"`     // 字体名称，默认 Noto Sans CJK SC
+	FontSize     int     `json:"font_size"`     // 字号（按画面高度计），默认画面高度的1/18
+	PrimaryColor string  `json:"primary_color"` // 文字颜色 #RRGGBB，默认白色
+	OutlineColor string  `json:"outline_color"` // 描边颜色 #RRGGBB，默认黑色
+	Outline      float64 `json:"outline"`       // 描边宽度，默认2
+	Bold         bool    `json:"bold"`
+	MarginV      int     `json:"margin_v"` // 距画面底部的距离，默认画面高度的1/12
+}
+
+// withDefaults 补全未设置的样式项
+func (s *SubtitleStyle) withDefaults(height int) SubtitleStyle {
+	style := SubtitleStyle{}
+	if s != nil {
+		style = *s
+	}
+	if style.FontName == "" {
+		style.FontName = "Noto Sans CJK SC"
+	}
+	if style.FontSize <= 0 {
+		style.FontSize = height / 18
+	}
+	if style.PrimaryColor == "" {
+		style.PrimaryColor = "#FFFFFF"
+	}
+	if style.OutlineColor == "" {
+		style.OutlineColor = "#000000"
+	}
+	if style.Outline <= 0 {
+		style.Outline = 2
+	}
+	if style.MarginV <= 0 {
+		style.MarginV = height / 12
+	}
+	return style
+}
+
+// ClipSpan 片段在成片中的起止时间（秒）
+type ClipSpan struct {
+	Start      float64
+	End        float64
+	Transition string // 与上一个片段之间的xfade转场类型，硬切时为空
+}
+
+// ClipSpans 计算合成后每个片段在成片中的位置，裁剪区间与转场重叠的处理方式与合成时一致
+func (f *FFmpeg) ClipSpans(clips []VideoClip) []ClipSpan {
+	spans := make([]ClipSpan, len(clips))
+	length := 0.0
+	for i, clip := range clips {
+		duration := clipDuration(clip)
+		start := length
+		var transitionType string
+		if i > 0 {
+			// 上一个片段的出场转场与当前片段重叠
+			tType, transition := f.resolveTransition(clips[i-1].Transition)
+			maxDuration := math.Min(clipDuration(clips[i-1]), duration) - 0.05
+			if transition > maxDuration {
+				transition = maxDuration
+			}
+			if transition > 0 {
+				start -= transition
+				transitionType = tType
+			}
+		}
+		spans[i] = ClipSpan{Start: start, End: start + duration, Transition: transitionType}
+		length = spans[i].End
+	}
+	return spans
+}
+
+// NormalizeCues 按开始时间排序，去掉空字幕，并截断与下一条重叠的部分（转场重叠时不同时显示两条）
+func NormalizeCues(cues []SubtitleCue) []SubtitleCue {
+	sort.SliceStable(cues, func(i, j int) bool { return cues[i].Start < cues[j].Start })
+
+	result := make([]SubtitleCue, 0, len(cues))
+	for i, cue := range cues {
+		cue.Text = strings.TrimSpace(cue.Text)
+		if cue.Text == "" {
+			continue
+		}
+		if i+1 < len(cues) && cues[i+1].Start < cue.End {
+			cue.End = cues[i+1].Start
+		}
+		if cue.End-cue.Start < 0.1 {
+			continue
+		}
+		result = append(result, cue)
+	}
+	return result
+}
+
+// BuildSubtitles 生成字幕文件内容，width/height 为成片分辨率（ASS坐标系与默认字号使用）
+func BuildSubtitles(cues []SubtitleCue, format SubtitleFormat, style *SubtitleStyle, width, height int) string {
+	switch format {
+	case SubtitleASS:
+		return buildASS(cues, style.withDefaults(height), width, height)
+	case SubtitleVTT:
+		return buildVTT(cues)
+	default:
+		return buildSRT(cues)
+	}
+}
+
+func buildSRT(cues []SubtitleCue) string {
+	var srt strings.Builder
+	for i, cue := range cues {
+		srt.WriteString(fmt.Sprintf("%d\n%s --> %s\n%s\n\n",
+			i+1, formatSRTTime(cue.Start), formatSRTTime(cue.End), cue.Text))
+	}
+	return srt.String()
+}
+
+func buildVTT(cues []SubtitleCue) string {
+	var vtt strings.Builder
+	vtt.WriteString("WEBVTT\n\n")
+	for _, cue := range cues {
+		vtt.WriteString(fmt.Sprintf("%s --> %s\n%s\n\n",
+			formatVTTTime(cue.Start), formatVTTTime(cue.End), cue.Text))
+	}
+	return vtt.String()
+}
+
+func buildASS(cues []SubtitleCue, style SubtitleStyle, width, height int) string {
+	bold := 0
+	if style.Bold {
+		bold = -1
+	}
+
+	var ass strings.Builder
+	ass.WriteString("[Script Info]\nScriptType: v4.00+\nWrapStyle: 0\nScaledBorderAndShadow: yes\n")
+	ass.WriteString(fmt.Sprintf("PlayResX: %d\nPlayResY: %d\n\n", width, height))
+	ass.WriteString("[V4+ Styles]\n")
+	ass.WriteString("Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding\n")
+	ass.WriteString(fmt.Sprintf("Style: Default,%s,%d,%s,&H000000FF,%s,&H80000000,%d,0,0,0,100,100,0,0,1,%.1f,0,2,%d,%d,%d,1\n\n",
+		style.FontName, style.FontSize, assColor(style.PrimaryColor), assColor(style.OutlineColor),
+		bold, style.Outline, width/20, width/20, style.MarginV))
+	ass.WriteString("[Events]\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n")
+	for _, cue := range cues {
+		text := strings.ReplaceAll(cue.Text, "\n", "\\N")
+		ass.WriteString(fmt.Sprintf("Dialogue: 0,%s,%s,Default,,0,0,0,,%s\n",
+			formatASSTime(cue.Start), formatASSTime(cue.End), text))
+	}
+	return ass.String()
+}
+
+// assColor 将 #RRGGBB 转换为ASS的 &H00BBGGRR，无法解析时返回白色
+func assColor(hex string) string {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) != 6 {
+		return "&H00FFFFFF"
+	}
+	return strings.ToUpper(fmt.Sprintf("&H00%s%s%s", hex[4:6], hex[2:4], hex[0:2]))
+}
+
+// formatSRTTime 格式化为 HH:MM:SS,mmm
+func formatSRTTime(seconds float64) string {
+	return strings.Replace(formatVTTTime(seconds), ".", ",", 1)
+}
+
+// formatASSTime 格式化为 H:MM:SS.cc
+func formatASSTime(seconds float64) string {
+	cs := int64(math.Round(seconds * 100))
+	return fmt.Sprintf("%d:%02d:%02d.%02d", cs/360000, cs/6000%60, cs/100%60, cs%100)
+}
+
+type SubtitleApplyOptions struct {
+	InputPath    string
+	OutputPath   string
+	SubtitlePath string         // 烧录时使用ASS文件，封装软字幕时使用SRT文件
+	Burn         bool           // true：烧录到画面；false：作为可开关的字幕流封装
+	Language     string         // 软字幕语言代码，默认 chi
+	Profile      *OutputProfile // 烧录时的视频编码配置，为空时按输出容器选择默认配置
+	Context      context.Context
+	OnProgress   ProgressFunc
+}
+
+// ApplySubtitles 将字幕烧录进画面或封装为软字幕流，音频直接复制
+func (f *FFmpeg) ApplySubtitles(opts *SubtitleApplyOptions) error {
+	duration, _ := f.GetDuration(opts.InputPath)
+	webm := strings.EqualFold(filepath.Ext(opts.OutputPath), ".webm")
+
+	var args []string
+	if opts.Burn {
+		profile := profileForOutput(opts.Profile, opts.OutputPath)
+		args = []string{
+			"-i", opts.InputPath,
+			"-vf", fmt.Sprintf("subtitles='%s'", escapeFilterPath(opts.SubtitlePath)),
+		}
+		args = append(args, profile.VideoArgs()...)
+		args = append(args, "-c:a", "copy")
+		args = append(args, profile.ContainerArgs()...)
+	} else {
+		language := opts.Language
+		if language == "" {
+			language = "chi"
+		}
+		codec := "mov_text"
+		if webm {
+			codec = "webvtt"
+		}
+		args = []string{
+			"-i", opts.InputPath,
+			"-i", opts.SubtitlePath,
+			"-map", "0:v", "-map", "0:a?", "-map", "1:s",
+			"-c:v", "copy", "-c:a", "copy", "-c:s", codec,
+			"-metadata:s:s:0", "language=" + language,
+		}
+		if !webm {
+			args = append(args, "-movflags", "+faststart")
+		}
+	}
+	args = append(args, "-y", opts.OutputPath)
+
+	output, err := f.run(opts.Context, duration, stageProgress(opts.OnProgress, 0, 100), args...)
+	if err != nil {
+		os.Remove(opts.OutputPath)
+		if opts.Context != nil && opts.Context.Err() != nil {
+			return opts.Context.Err()
+		}
+		f.log.Errorw("FFmpeg subtitle processing failed", "error", err, "output", string(output))
+		return fmt.Errorf("ffmpeg subtitle processing failed: %w, output: %s", err, string(output))
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// ExtractPoster 截取单帧作为封面，at 为截取时间点（秒），width 为输出宽度（高度等比）
+func (f *FFmpeg) ExtractPoster(ctx context.Context, inputPath, outputPath string, at float64, width int) error {
+	if at < 0 {
+		at = 0
+	}
+
+	output, err := f.run(ctx, 0, nil,
+		"-ss", fmt.Sprintf("%.3f", at),
+		"-i", inputPath,
+		"-frames:v", "1",
+		"-vf", fmt.Sprintf("scale=%d:-2", width),
+		"-q:v", "2",
+		"-y",
+		outputPath,
+	)
+	if err != nil {
+		os.Remove(outputPath)
+		f.log.Errorw("FFmpeg poster extraction failed", "error", err, "output", string(output))
+		return fmt.Errorf("ffmpeg poster extraction failed: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
+// ScaleImage 将图片等比缩放为指定宽度的缩略图
+func (f *FFmpeg) ScaleImage(ctx context.Context, inputPath, outputPath string, width int) error {
+	output, err := f.run(ctx, 0, nil,
+		"-i", inputPath,
+		"-vf", fmt.Sprintf("scale='min(%d,iw)':-2", width),
+		"-q:v", "3",
+		"-y",
+		outputPath,
+	)
+	if err != nil {
+		os.Remove(outputPath)
+		f.log.Errorw("FFmpeg image scaling failed", "error", err, "output", string(output))
+		return fmt.Errorf("ffmpeg image scaling failed: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
+type SpriteOptions struct {
+	InputPath  string
+	OutputPath string
+	TileWidth  int // 单个缩略图宽度，默认160
+	Columns    int // 每行缩略图数量，默认10
+	MaxTiles   int // 最多缩略图数量，默认100；视频较长时自动拉大采样间隔
+	Context    context.Context
+}
+
+// SpriteSheet 拖动预览雪碧图信息，用于生成WebVTT缩略图轨道
+type SpriteSheet struct {
+	Path       string
+	Interval   float64 // 相邻缩略图的时间间隔（秒）
+	Count      int
+	Columns    int
+	TileWidth  int
+	TileHeight int
+	Duration   float64
+}
+
+// GenerateSprite 按固定间隔采样画面并拼接为一张雪碧图
+func (f *FFmpeg) GenerateSprite(opts *SpriteOptions) (*SpriteSheet, error) {
+	if opts.TileWidth <= 0 {
+		opts.TileWidth = 160
+	}
+	if opts.Columns <= 0 {
+		opts.Columns = 10
+	}
+	if opts.MaxTiles <= 0 {
+		opts.MaxTiles = 100
+	}
+
+	duration, err := f.GetDuration(opts.InputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe input: %w", err)
+	}
+	if duration <= 0 {
+		return nil, fmt.Errorf("video has no duration")
+	}
+
+	// 默认每秒一张，超过 MaxTiles 时加大间隔
+	interval := math.Max(1, math.Ceil(duration/float64(opts.MaxTiles)))
+	count := int(math.Ceil(duration / interval))
+	rows := int(math.Ceil(float64(count) / float64(opts.Columns)))
+	columns := opts.Columns
+	if count < columns {
+		columns = count
+	}
+
+	width, height := f.getVideoResolution(opts.InputPath)
+	tileHeight := int(math.Round(float64(opts.TileWidth)*float64(height)/float64(width)/2)) * 2
+
+	output, err := f.run(opts.Context, duration, nil,
+		"-i", opts.InputPath,
+		"-vf", fmt.Sprintf("fps=1/%g,scale=%d:%d,tile=%dx%d", interval, opts.TileWidth, tileHeight, columns, rows),
+		"-frames:v", "1",
+		"-q:v", "4",
+		"-y",
+		opts.OutputPath,
+	)
+	if err != nil {
+		os.Remove(opts.OutputPath)
+		f.log.Errorw("FFmpeg sprite generation failed", "error", err, "output", string(output))
+		return nil, fmt.Errorf("ffmpeg sprite generation failed: %w, output: %s", err, string(output))
+	}
+
+	return &SpriteSheet{
+		Path:       opts.OutputPath,
+		Interval:   interval,
+		Count:      count,
+		Columns:    columns,
+		TileWidth:  opts.TileWidth,
+		TileHeight: tileHeight,
+		Duration:   duration,
+	}, nil
+}
+
+// BuildThumbnailVTT 生成WebVTT缩略图轨道，每个片段指向雪碧图中的一个区域
+func (s *SpriteSheet) BuildThumbnailVTT(spriteURL string) string {
+	var vtt strings.Builder
+	vtt.WriteString("WEBVTT\n\n")
+
+	for i := 0; i < s.Count; i++ {
+		start := float64(i) * s.Interval
+		end := math.Min(start+s.Interval, s.Duration)
+		x := (i % s.Columns) * s.TileWidth
+		y := (i / s.Columns) * s.TileHeight
+
+		vtt.WriteString(fmt.Sprintf("%s --> %s\n%s#xywh=%d,%d,%d,%d\n\n",
+			formatVTTTime(start), formatVTTTime(end), spriteURL, x, y, s.TileWidth, s.TileHeight))
+	}
+
+	return vtt.String()
+}
+
+// formatVTTTime 格式化为 HH:MM:SS.mmm
+func formatVTTTime(seconds float64) string {
+	ms := int64(math.Round(seconds * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
@@ -0,0 +1,466 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 多轨时间线渲染
+// 所有轨道在同一个 filter_complex 中完成：
+//   - 视频轨道按 Order 从低到高依次叠加到黑色画布上（Order 越大越靠上）
+//   - 视频片段自带的音频与音频轨道一起混音，音量 = 片段音量 × 轨道音量
+//   - 文字轨道通过 drawtext 烧录到最终画面
+//   - 片段特效映射为 eq/gblur/hue 等滤镜，淡入淡出与转场映射为 alpha fade
+
+type RenderTrackType string
+
+const (
+	RenderTrackVideo RenderTrackType = "video"
+	RenderTrackAudio RenderTrackType = "audio"
+	RenderTrackText  RenderTrackType = "text"
+)
+
+type TimelineRenderOptions struct {
+	OutputPath string
+	Width      int
+	Height     int
+	FPS        int
+	Duration   float64 // 时间线总时长（秒）
+	FontFile   string  // drawtext 使用的字体文件，为空时使用系统默认字体
+	Tracks     []RenderTrack
+	Context    context.Context
+	OnProgress ProgressFunc
+	Profile    *OutputProfile // 输出配置，设置后覆盖 Width/Height/FPS 与编码参数
+}
+
+type RenderTrack struct {
+	Type    RenderTrackType
+	Order   int
+	Volume  float64 // 1.0 为原始音量
+	IsMuted bool
+	Clips   []RenderClip
+}
+
+type RenderClip struct {
+	URL       string // 视频/音频源地址（文字轨道为空）
+	Text      string // 文字轨道的显示文本
+	Label     string // 镜头标识，预览配置下烧录在画面上
+	ImageURL  string // 视频轨道片段缺少视频时使用的静态图片，生成运镜画面
+	Motion    KenBurnsMotion
+	Start     float64 // 在时间线上的开始时间（秒）
+	Duration  float64 // 在时间线上的持续时间（秒）
+	TrimStart float64 // 源素材入点（秒）
+	TrimEnd   float64 // 源素材出点（秒），0 表示不限制
+	Speed     float64
+	Volume    float64
+	IsMuted   bool
+	FadeIn    float64
+	FadeOut   float64
+	Effects   []RenderEffect
+}
+
+// sourceDuration 从入点起读取的源素材时长：片段时长按速度换算，不超过出点
+func (c RenderClip) sourceDuration(speed float64) float64 {
+	duration := c.Duration * speed
+	if c.TrimEnd > c.TrimStart && c.TrimEnd-c.TrimStart < duration {
+		duration = c.TrimEnd - c.TrimStart
+	}
+	return duration
+}
+
+type RenderEffect struct {
+	Type   string
+	Config map[string]interface{}
+}
+
+// RenderTimeline 渲染多轨时间线为单个视频文件
+func (f *FFmpeg) RenderTimeline(opts *TimelineRenderOptions) (string, error) {
+	if opts.Duration <= 0 {
+		return "", fmt.Errorf("timeline is empty")
+	}
+	if opts.Profile != nil {
+		opts.Width, opts.Height, opts.FPS = opts.Profile.Width, opts.Profile.Height, opts.Profile.FPS
+	}
+	if opts.Width <= 0 || opts.Height <= 0 {
+		opts.Width, opts.Height = 1920, 1080
+	}
+	if opts.FPS <= 0 {
+		opts.FPS = 30
+	}
+	profile := opts.Profile
+	if profile == nil {
+		profile, _ = GetOutputProfile(DefaultOutputProfile)
+	}
+
+	f.log.Infow("Starting timeline render",
+		"tracks", len(opts.Tracks),
+		"duration", opts.Duration,
+		"resolution", fmt.Sprintf("%dx%d", opts.Width, opts.Height))
+
+	// 获取所有源素材（同一URL只获取一次，远程素材走本地缓存）
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	localPaths := make(map[string]string)
+	var tempFiles []string
+	defer func() { f.cleanup(tempFiles) }()
+
+	for _, track := range opts.Tracks {
+		if track.Type == RenderTrackText {
+			continue
+		}
+		for _, clip := range track.Clips {
+			source := renderClipSource(track, clip)
+			if source == "" {
+				continue
+			}
+			if _, ok := localPaths[source]; ok {
+				continue
+			}
+			localPath, err := f.fetchSource(ctx, source)
+			if err != nil {
+				if ctx.Err() != nil {
+					return "", ctx.Err()
+				}
+				return "", fmt.Errorf("failed to download %s: %w", truncateSource(source), err)
+			}
+			localPaths[source] = localPath
+		}
+	}
+
+	args := []string{
+		"-f", "lavfi", "-i", fmt.Sprintf("color=c=black:s=%dx%d:r=%d:d=%.3f", opts.Width, opts.Height, opts.FPS, opts.Duration),
+		"-f", "lavfi", "-i", fmt.Sprintf("anullsrc=channel_layout=stereo:sample_rate=44100:d=%.3f", opts.Duration),
+	}
+	inputIndex := 2
+
+	var filters []string
+	videoLabel := "[0:v]"
+	audioLabels := []string{"[1:a]"}
+	overlayCount := 0
+	audioCount := 0
+
+	tracks := sortTracksByOrder(opts.Tracks)
+
+	for _, track := range tracks {
+		if track.Type == RenderTrackText {
+			continue
+		}
+		for _, clip := range track.Clips {
+			source := renderClipSource(track, clip)
+			if source == "" || clip.Duration <= 0 {
+				continue
+			}
+			speed := clip.Speed
+			if speed <= 0 {
+				speed = 1.0
+			}
+
+			localPath := localPaths[source]
+			args = append(args, "-i", localPath)
+			idx := inputIndex
+			inputIndex++
+
+			if track.Type == RenderTrackVideo {
+				clipLabel := fmt.Sprintf("[tv%d]", overlayCount)
+				filters = append(filters, fmt.Sprintf("[%d:v]%s%s", idx, f.buildVideoClipFilter(clip, speed, opts), clipLabel))
+
+				outLabel := fmt.Sprintf("[ov%d]", overlayCount)
+				filters = append(filters, fmt.Sprintf("%s%soverlay=eof_action=pass:format=auto%s", videoLabel, clipLabel, outLabel))
+				videoLabel = outLabel
+				overlayCount++
+			}
+
+			// 视频片段自带音频以及音频轨道片段参与混音
+			if track.IsMuted || clip.IsMuted {
+				continue
+			}
+			if !f.hasAudioStream(localPath) {
+				continue
+			}
+			volume := clip.Volume * track.Volume
+			audioLabel := fmt.Sprintf("[ta%d]", audioCount)
+			filters = append(filters, fmt.Sprintf("[%d:a]%s%s", idx, buildAudioClipFilter(clip, speed, volume), audioLabel))
+			audioLabels = append(audioLabels, audioLabel)
+			audioCount++
+		}
+	}
+
+	// 文字轨道
+	for _, track := range tracks {
+		if track.Type != RenderTrackText || track.IsMuted {
+			continue
+		}
+		for _, clip := range track.Clips {
+			if strings.TrimSpace(clip.Text) == "" || clip.Duration <= 0 {
+				continue
+			}
+			textFile := filepath.Join(f.tempDir, fmt.Sprintf("text_%d_%d.txt", time.Now().UnixNano(), overlayCount))
+			if err := os.WriteFile(textFile, []byte(clip.Text), 0644); err != nil {
+				return "", fmt.Errorf("failed to write text file: %w", err)
+			}
+			tempFiles = append(tempFiles, textFile)
+
+			outLabel := fmt.Sprintf("[ov%d]", overlayCount)
+			filters = append(filters, fmt.Sprintf("%s%s%s", videoLabel, buildDrawText(textFile, clip, opts), outLabel))
+			videoLabel = outLabel
+			overlayCount++
+		}
+	}
+
+	// 预览配置：视频片段所在区间烧录镜头编号，全片烧录时间码
+	if profile.BurnIn {
+		var burnIn []string
+		for _, track := range tracks {
+			if track.Type != RenderTrackVideo {
+				continue
+			}
+			for _, clip := range track.Clips {
+				if clip.Label == "" || clip.Duration <= 0 {
+					continue
+				}
+				burnIn = append(burnIn, fmt.Sprintf("%s:enable='between(t,%.3f,%.3f)'",
+					profile.shotLabelFilter(clip.Label), clip.Start, clip.Start+clip.Duration))
+			}
+		}
+		burnIn = append(burnIn, profile.timecodeFilter())
+		outLabel := fmt.Sprintf("[ov%d]", overlayCount)
+		filters = append(filters, fmt.Sprintf("%s%s%s", videoLabel, strings.Join(burnIn, ","), outLabel))
+		videoLabel = outLabel
+	}
+
+	filters = append(filters, fmt.Sprintf("%sformat=yuv420p[outv]", videoLabel))
+	filters = append(filters, fmt.Sprintf("%samix=inputs=%d:duration=first:dropout_transition=0:normalize=0[outa]",
+		strings.Join(audioLabels, ""), len(audioLabels)))
+
+	filterComplex := strings.Join(filters, ";")
+
+	outputDir := filepath.Dir(opts.OutputPath)
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	args = append(args,
+		"-filter_complex", filterComplex,
+		"-map", "[outv]",
+		"-map", "[outa]",
+		"-t", fmt.Sprintf("%.3f", opts.Duration),
+	)
+	args = append(args, profile.VideoArgs()...)
+	args = append(args, profile.AudioArgs()...)
+	args = append(args, profile.ContainerArgs()...)
+	args = append(args, "-y", opts.OutputPath)
+
+	f.log.Infow("Running FFmpeg timeline render", "filter", filterComplex)
+
+	output, err := f.run(opts.Context, opts.Duration, stageProgress(opts.OnProgress, 0, 100), args...)
+	if err != nil {
+		os.Remove(opts.OutputPath)
+		if opts.Context != nil && opts.Context.Err() != nil {
+			return "", opts.Context.Err()
+		}
+		f.log.Errorw("FFmpeg timeline render failed", "error", err, "output", string(output))
+		return "", fmt.Errorf("ffmpeg timeline render failed: %w, output: %s", err, string(output))
+	}
+
+	f.log.Infow("Timeline rendered successfully", "output", opts.OutputPath)
+	return opts.OutputPath, nil
+}
+
+// buildVideoClipFilter 裁剪、变速、缩放、特效、淡入淡出，并平移到时间线上的位置
+// 静态图片片段按片段时长生成运镜画面
+func (f *FFmpeg) buildVideoClipFilter(clip RenderClip, speed float64, opts *TimelineRenderOptions) string {
+	sourceDuration := clip.sourceDuration(speed)
+	if clip.URL != "" {
+		// 出点早于片段结束时按实际播放时长计算淡出位置
+		clip.Duration = sourceDuration / speed
+	}
+
+	parts := []string{
+		fmt.Sprintf("trim=start=%.3f:duration=%.3f", clip.TrimStart, sourceDuration),
+		fmt.Sprintf("setpts=(PTS-STARTPTS)/%.4f", speed),
+		fmt.Sprintf("fps=%d", opts.FPS),
+		fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease", opts.Width, opts.Height),
+		fmt.Sprintf("pad=%d:%d:(ow-iw)/2:(oh-ih)/2", opts.Width, opts.Height),
+		"setsar=1",
+	}
+	if clip.URL == "" {
+		parts = []string{kenBurnsFilter(clip.Motion, opts.Width, opts.Height, opts.FPS, clip.Duration)}
+	}
+
+	parts = append(parts, f.mapEffects(clip.Effects)...)
+
+	parts = append(parts, "format=yuva420p")
+	if clip.FadeIn > 0 {
+		parts = append(parts, fmt.Sprintf("fade=t=in:st=0:d=%.3f:alpha=1", clip.FadeIn))
+	}
+	if clip.FadeOut > 0 {
+		start := clip.Duration - clip.FadeOut
+		if start < 0 {
+			start = 0
+		}
+		parts = append(parts, fmt.Sprintf("fade=t=out:st=%.3f:d=%.3f:alpha=1", start, clip.FadeOut))
+	}
+
+	parts = append(parts, fmt.Sprintf("setpts=PTS+%.3f/TB", clip.Start))
+	return strings.Join(parts, ",")
+}
+
+// renderClipSource 片段的素材地址，视频轨道片段缺少视频时使用静态图片
+func renderClipSource(track RenderTrack, clip RenderClip) string {
+	if clip.URL == "" && track.Type == RenderTrackVideo {
+		return clip.ImageURL
+	}
+	return clip.URL
+}
+
+// buildAudioClipFilter 裁剪、变速、音量、淡入淡出，并延迟到时间线上的位置
+func buildAudioClipFilter(clip RenderClip, speed, volume float64) string {
+	sourceDuration := clip.sourceDuration(speed)
+	clip.Duration = sourceDuration / speed
+
+	parts := []string{
+		fmt.Sprintf("atrim=start=%.3f:duration=%.3f", clip.TrimStart, sourceDuration),
+		"asetpts=PTS-STARTPTS",
+		"aresample=44100",
+		"aformat=channel_layouts=stereo",
+	}
+	parts = append(parts, atempoChain(speed)...)
+	parts = append(parts, fmt.Sprintf("volume=%.3f", volume))
+
+	if clip.FadeIn > 0 {
+		parts = append(parts, fmt.Sprintf("afade=t=in:st=0:d=%.3f", clip.FadeIn))
+	}
+	if clip.FadeOut > 0 {
+		start := clip.Duration - clip.FadeOut
+		if start < 0 {
+			start = 0
+		}
+		parts = append(parts, fmt.Sprintf("afade=t=out:st=%.3f:d=%.3f", start, clip.FadeOut))
+	}
+
+	delayMs := int(clip.Start * 1000)
+	parts = append(parts, fmt.Sprintf("adelay=%d|%d", delayMs, delayMs))
+	return strings.Join(parts, ",")
+}
+
+// atempoChain atempo 单级仅支持 0.5~2.0，超出范围时串联多级
+func atempoChain(speed float64) []string {
+	if speed == 1.0 {
+		return nil
+	}
+	var chain []string
+	for speed > 2.0 {
+		chain = append(chain, "atempo=2.0")
+		speed /= 2.0
+	}
+	for speed < 0.5 {
+		chain = append(chain, "atempo=0.5")
+		speed /= 0.5
+	}
+	return append(chain, fmt.Sprintf("atempo=%.4f", speed))
+}
+
+// mapEffects 将片段特效映射为FFmpeg滤镜
+// brightness/contrast/saturation 读取 config.value（与 eq 滤镜取值范围一致），
+// blur 读取 config.sigma，color 读取 config.hue，filter 读取 config.name 作为预设名
+func (f *FFmpeg) mapEffects(effects []RenderEffect) []string {
+	var filters []string
+	for _, effect := range effects {
+		switch effect.Type {
+		case "brightness":
+			filters = append(filters, fmt.Sprintf("eq=brightness=%.3f", configFloat(effect.Config, "value", 0)))
+		case "contrast":
+			filters = append(filters, fmt.Sprintf("eq=contrast=%.3f", configFloat(effect.Config, "value", 1)))
+		case "saturation":
+			filters = append(filters, fmt.Sprintf("eq=saturation=%.3f", configFloat(effect.Config, "value", 1)))
+		case "blur":
+			filters = append(filters, fmt.Sprintf("gblur=sigma=%.2f", configFloat(effect.Config, "sigma", 5)))
+		case "color":
+			filters = append(filters, fmt.Sprintf("hue=h=%.2f", configFloat(effect.Config, "hue", 0)))
+		case "filter":
+			name, _ := effect.Config["name"].(string)
+			switch strings.ToLower(name) {
+			case "grayscale", "mono":
+				filters = append(filters, "hue=s=0")
+			case "sepia":
+				filters = append(filters, "colorchannelmixer=.393:.769:.189:0:.349:.686:.168:0:.272:.534:.131")
+			case "vignette":
+				filters = append(filters, "vignette")
+			case "negate":
+				filters = append(filters, "negate")
+			default:
+				f.log.Warnw("Unknown filter preset, ignored", "name", name)
+			}
+		default:
+			f.log.Warnw("Unsupported effect type, ignored", "type", effect.Type)
+		}
+	}
+	return filters
+}
+
+func buildDrawText(textFile string, clip RenderClip, opts *TimelineRenderOptions) string {
+	fontSize := opts.Height / 18
+	params := []string{
+		fmt.Sprintf("textfile='%s'", escapeFilterPath(textFile)),
+		fmt.Sprintf("fontsize=%d", fontSize),
+		"fontcolor=white",
+		"box=1",
+		"boxcolor=black@0.5",
+		"boxborderw=10",
+		"x=(w-text_w)/2",
+		fmt.Sprintf("y=h-text_h-%d", opts.Height/12),
+		fmt.Sprintf("enable='between(t,%.3f,%.3f)'", clip.Start, clip.Start+clip.Duration),
+	}
+	if opts.FontFile != "" {
+		params = append([]string{fmt.Sprintf("fontfile='%s'", escapeFilterPath(opts.FontFile))}, params...)
+	}
+	return "drawtext=" + strings.Join(params, ":")
+}
+
+// escapeFilterPath 转义滤镜参数中的路径（Windows盘符冒号、反斜杠、单引号）
+func escapeFilterPath(path string) string {
+	path = filepath.ToSlash(path)
+	path = strings.ReplaceAll(path, "'", "'\\''")
+	return strings.ReplaceAll(path, ":", "\\:")
+}
+
+func configFloat(config map[string]interface{}, key string, def float64) float64 {
+	if config == nil {
+		return def
+	}
+	switch v := config[key].(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	}
+	return def
+}
+
+func sortTracksByOrder(tracks []RenderTrack) []RenderTrack {
+	sorted := make([]RenderTrack, len(tracks))
+	copy(sorted, tracks)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Order < sorted[j].Order
+	})
+	return sorted
+}
+
+func mediaExt(url string) string {
+	path := url
+	if idx := strings.Index(path, "?"); idx != -1 {
+		path = path[:idx]
+	}
+	ext := filepath.Ext(path)
+	if ext == "" || len(ext) > 5 {
+		return ".mp4"
+	}
+	return ext
+}
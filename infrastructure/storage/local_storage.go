@@ -27,14 +27,18 @@ func NewLocalStorage(basePath, baseURL string) (*LocalStorage, error) {
 }
 
 func (s *LocalStorage) Upload(file io.Reader, filename string, category string) (string, error) {
+	timestamp := time.Now().Format("20060102_150405")
+	return s.Save(file, fmt.Sprintf("%s_%s", timestamp, filename), category)
+}
+
+// Save 按原文件名保存文件，用于文件之间按名称相互引用的产物（如HLS播放列表与切片）
+func (s *LocalStorage) Save(file io.Reader, filename string, category string) (string, error) {
 	dir := filepath.Join(s.basePath, category)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create category directory: %w", err)
 	}
 
-	timestamp := time.Now().Format("20060102_150405")
-	newFilename := fmt.Sprintf("%s_%s", timestamp, filename)
-	filePath := filepath.Join(dir, newFilename)
+	filePath := filepath.Join(dir, filename)
 
 	dst, err := os.Create(filePath)
 	if err != nil {
@@ -46,7 +50,7 @@ func (s *LocalStorage) Upload(file io.Reader, filename string, category string)
 		return "", fmt.Errorf("failed to save file: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/%s/%s", s.baseURL, category, newFilename)
+	url := fmt.Sprintf("%s/%s/%s", s.baseURL, category, filename)
 	return url, nil
 }
 
@@ -58,6 +62,23 @@ func (s *LocalStorage) GetURL(path string) string {
 	return fmt.Sprintf("%s/%s", s.baseURL, path)
 }
 
+// ResolvePath 将本地存储的访问URL还原为磁盘路径，非本地存储的URL以及指向存储目录之外的路径返回false
+func (s *LocalStorage) ResolvePath(url string) (string, bool) {
+	prefix := strings.TrimRight(s.baseURL, "/") + "/"
+	if s.baseURL == "" || !strings.HasPrefix(url, prefix) {
+		return "", false
+	}
+	localPath := filepath.Clean(filepath.Join(s.basePath, filepath.FromSlash(strings.TrimPrefix(url, prefix))))
+	rel, err := filepath.Rel(filepath.Clean(s.basePath), localPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	if _, err := os.Stat(localPath); err != nil {
+		return "", false
+	}
+	return localPath, true
+}
+
 // DownloadFromURL 从远程URL下载文件到本地存储
 func (s *LocalStorage) DownloadFromURL(url, category string) (string, error) {
 	// 发送HTTP请求下载文件
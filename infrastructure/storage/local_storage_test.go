@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolvePath(t *testing.T) {
+	root := t.TempDir()
+	basePath := filepath.Join(root, "storage")
+	s, err := NewLocalStorage(basePath, "http://localhost:5678/static")
+	if err != nil {
+		t.Fatalf("NewLocalStorage() error = %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(basePath, "videos"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{filepath.Join(basePath, "videos", "a.mp4"), filepath.Join(root, "secret.txt"), filepath.Join(root, "storage-other.txt")} {
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		url    string
+		want   string
+		wantOK bool
+	}{
+		{"存储目录内的文件", "http://localhost:5678/static/videos/a.mp4", filepath.Join(basePath, "videos", "a.mp4"), true},
+		{"目录内的冗余路径被清理", "http://localhost:5678/static/videos/../videos/./a.mp4", filepath.Join(basePath, "videos", "a.mp4"), true},
+		{"上级目录越界", "http://localhost:5678/static/../secret.txt", "", false},
+		{"多级上级目录越界", "http://localhost:5678/static/videos/../../secret.txt", "", false},
+		{"同名前缀的兄弟目录越界", "http://localhost:5678/static/../storage-other.txt", "", false},
+		{"文件不存在", "http://localhost:5678/static/videos/missing.mp4", "", false},
+		{"非本地存储的URL", "https://cdn.example.com/static/videos/a.mp4", "", false},
+		{"本地路径", filepath.Join(basePath, "videos", "a.mp4"), "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := s.ResolvePath(tt.url)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ResolvePath(%q) = (%q, %v), want (%q, %v)", tt.url, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
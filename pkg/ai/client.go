@@ -1,7 +1,14 @@
 package ai
 
+import "context"
+
+// StreamHandler 流式生成时接收增量文本
+type StreamHandler func(delta string)
+
 // AIClient 定义文本生成客户端接口
 type AIClient interface {
 	GenerateText(prompt string, systemPrompt string, options ...func(*ChatCompletionRequest)) (string, error)
+	// GenerateTextStream 流式生成，每收到一段文本回调 onDelta，返回完整文本
+	GenerateTextStream(ctx context.Context, prompt string, systemPrompt string, onDelta StreamHandler, options ...func(*ChatCompletionRequest)) (string, error)
 	TestConnection() error
 }
@@ -2,6 +2,7 @@ package ai
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -80,35 +81,13 @@ func NewGeminiClient(baseURL, apiKey, model, endpoint string) *GeminiClient {
 }
 
 func (c *GeminiClient) GenerateText(prompt string, systemPrompt string, options ...func(*ChatCompletionRequest)) (string, error) {
-	model := c.Model
-
-	// 构建请求体
-	reqBody := GeminiTextRequest{
-		Contents: []GeminiContent{
-			{
-				Parts: []GeminiPart{{Text: prompt}},
-				Role:  "user",
-			},
-		},
-	}
-
-	// 使用 systemInstruction 字段处理系统提示
-	if systemPrompt != "" {
-		reqBody.SystemInstruction = &GeminiInstruction{
-			Parts: []GeminiPart{{Text: systemPrompt}},
-		}
-	}
-
-	jsonData, err := json.Marshal(reqBody)
+	jsonData, err := json.Marshal(buildGeminiRequest(prompt, systemPrompt))
 	if err != nil {
 		fmt.Printf("Gemini: Failed to marshal request: %v\n", err)
 		return "", fmt.Errorf("marshal request: %w", err)
 	}
 
-	// 替换端点中的 {model} 占位符
-	endpoint := c.BaseURL + c.Endpoint
-	endpoint = strings.ReplaceAll(endpoint, "{model}", model)
-	url := fmt.Sprintf("%s?key=%s", endpoint, c.APIKey)
+	url := fmt.Sprintf("%s?key=%s", c.endpointURL(c.Endpoint), c.APIKey)
 
 	// 打印请求信息（隐藏 API Key）
 	safeURL := strings.Replace(url, c.APIKey, "***", 1)
@@ -183,6 +162,101 @@ func (c *GeminiClient) GenerateText(prompt string, systemPrompt string, options
 	return responseText, nil
 }
 
+// GenerateTextStream 使用 streamGenerateContent 的 SSE 模式逐段回调增量文本
+// 自定义端点不是 generateContent 形式时退化为普通请求，整段文本作为一次增量
+func (c *GeminiClient) GenerateTextStream(ctx context.Context, prompt string, systemPrompt string, onDelta StreamHandler, options ...func(*ChatCompletionRequest)) (string, error) {
+	if !strings.Contains(c.Endpoint, ":generateContent") {
+		text, err := c.GenerateText(prompt, systemPrompt, options...)
+		if err != nil {
+			return "", err
+		}
+		onDelta(text)
+		return text, nil
+	}
+
+	jsonData, err := json.Marshal(buildGeminiRequest(prompt, systemPrompt))
+	if err != nil {
+		return "", fmt.Errorf("marshal request: %w", err)
+	}
+
+	streamEndpoint := strings.Replace(c.Endpoint, ":generateContent", ":streamGenerateContent", 1)
+	url := fmt.Sprintf("%s?alt=sse&key=%s", c.endpointURL(streamEndpoint), c.APIKey)
+	fmt.Printf("Gemini: Sending stream request to: %s\n", strings.Replace(url, c.APIKey, "***", 1))
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("Gemini: API error (status %d): %s\n", resp.StatusCode, string(body))
+		return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var text strings.Builder
+	err = readSSE(resp.Body, func(data string) bool {
+		var chunk GeminiTextResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			fmt.Printf("Gemini: Skipping malformed stream chunk: %v\n", err)
+			return true
+		}
+		if len(chunk.Candidates) == 0 {
+			return true
+		}
+		for _, part := range chunk.Candidates[0].Content.Parts {
+			if part.Text != "" {
+				text.WriteString(part.Text)
+				onDelta(part.Text)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", fmt.Errorf("read stream: %w", err)
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no candidates in response")
+	}
+
+	fmt.Printf("Gemini: Stream completed, length: %d\n", text.Len())
+	return text.String(), nil
+}
+
+func buildGeminiRequest(prompt string, systemPrompt string) GeminiTextRequest {
+	reqBody := GeminiTextRequest{
+		Contents: []GeminiContent{
+			{
+				Parts: []GeminiPart{{Text: prompt}},
+				Role:  "user",
+			},
+		},
+	}
+
+	// 使用 systemInstruction 字段处理系统提示
+	if systemPrompt != "" {
+		reqBody.SystemInstruction = &GeminiInstruction{
+			Parts: []GeminiPart{{Text: systemPrompt}},
+		}
+	}
+	return reqBody
+}
+
+// endpointURL 拼接完整地址并替换端点中的 {model} 占位符
+func (c *GeminiClient) endpointURL(endpoint string) string {
+	return strings.ReplaceAll(c.BaseURL+endpoint, "{model}", c.Model)
+}
+
 func (c *GeminiClient) TestConnection() error {
 	fmt.Printf("Gemini: TestConnection called with BaseURL=%s, Model=%s, Endpoint=%s\n", c.BaseURL, c.Model, c.Endpoint)
 	_, err := c.GenerateText("Hello", "")
@@ -2,10 +2,12 @@ package ai
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -51,6 +53,22 @@ type ChatCompletionResponse struct {
 	} `json:"usage"`
 }
 
+// ChatCompletionChunk 流式响应中的一个增量
+type ChatCompletionChunk struct {
+	ID      string `json:"id"`
+	Choices []struct {
+		Index int `json:"index"`
+		Delta struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
 type ErrorResponse struct {
 	Error struct {
 		Message string `json:"message"`
@@ -181,30 +199,132 @@ func WithTopP(topP float64) func(*ChatCompletionRequest) {
 }
 
 func (c *OpenAIClient) GenerateText(prompt string, systemPrompt string, options ...func(*ChatCompletionRequest)) (string, error) {
-	messages := []ChatMessage{}
+	resp, err := c.ChatCompletion(buildMessages(prompt, systemPrompt), options...)
+	if err != nil {
+		return "", err
+	}
 
-	if systemPrompt != "" {
-		messages = append(messages, ChatMessage{
-			Role:    "system",
-			Content: systemPrompt,
-		})
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from API")
 	}
 
-	messages = append(messages, ChatMessage{
-		Role:    "user",
-		Content: prompt,
-	})
+	return resp.Choices[0].Message.Content, nil
+}
+
+// GenerateTextStream 以 SSE 方式请求 chat/completions，逐段回调增量文本
+func (c *OpenAIClient) GenerateTextStream(ctx context.Context, prompt string, systemPrompt string, onDelta StreamHandler, options ...func(*ChatCompletionRequest)) (string, error) {
+	req := &ChatCompletionRequest{
+		Model:    c.Model,
+		Messages: buildMessages(prompt, systemPrompt),
+	}
+	for _, option := range options {
+		option(req)
+	}
+	req.Stream = true
 
-	resp, err := c.ChatCompletion(messages, options...)
+	jsonData, err := json.Marshal(req)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	if len(resp.Choices) == 0 {
+	url := c.BaseURL + c.Endpoint
+	fmt.Printf("OpenAI: Sending stream request to: %s, Model=%s\n", url, c.Model)
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
+	httpReq.Header.Set("Authorization", "Bearer "+c.APIKey)
+
+	resp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("OpenAI: API error (status %d): %s\n", resp.StatusCode, string(body))
+		var errResp ErrorResponse
+		if err := json.Unmarshal(body, &errResp); err != nil || errResp.Error.Message == "" {
+			return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		}
+		return "", fmt.Errorf("API error: %s", errResp.Error.Message)
+	}
+
+	// 服务不支持流式时按普通响应处理，整段文本作为一次增量
+	if !isEventStream(resp.Header.Get("Content-Type")) {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", fmt.Errorf("failed to read response: %w", err)
+		}
+		var chatResp ChatCompletionResponse
+		if err := json.Unmarshal(body, &chatResp); err != nil {
+			return "", fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+		if len(chatResp.Choices) == 0 {
+			return "", fmt.Errorf("no response from API")
+		}
+		text := chatResp.Choices[0].Message.Content
+		onDelta(text)
+		return text, nil
+	}
+
+	var text strings.Builder
+	var streamErr error
+	err = readSSE(resp.Body, func(data string) bool {
+		if data == sseDone {
+			return false
+		}
+		var chunk ChatCompletionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			fmt.Printf("OpenAI: Skipping malformed stream chunk: %v\n", err)
+			return true
+		}
+		if chunk.Error != nil {
+			streamErr = fmt.Errorf("API error: %s", chunk.Error.Message)
+			return false
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Index == 0 && choice.Delta.Content != "" {
+				text.WriteString(choice.Delta.Content)
+				onDelta(choice.Delta.Content)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", fmt.Errorf("failed to read stream: %w", err)
+	}
+	if streamErr != nil {
+		return "", streamErr
+	}
+	if text.Len() == 0 {
 		return "", fmt.Errorf("no response from API")
 	}
 
-	return resp.Choices[0].Message.Content, nil
+	fmt.Printf("OpenAI: Stream completed, length: %d\n", text.Len())
+	return text.String(), nil
+}
+
+func buildMessages(prompt string, systemPrompt string) []ChatMessage {
+	messages := []ChatMessage{}
+	if systemPrompt != "" {
+		messages = append(messages, ChatMessage{
+			Role:    "system",
+			Content: systemPrompt,
+		})
+	}
+	return append(messages, ChatMessage{
+		Role:    "user",
+		Content: prompt,
+	})
 }
 
 func (c *OpenAIClient) TestConnection() error {
@@ -0,0 +1,54 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+)
+
+// sseDone OpenAI 流结束标记
+const sseDone = "[DONE]"
+
+// readSSE 逐个读取 Server-Sent Events 的 data 字段，多行 data 按规范以换行拼接
+// onData 返回 false 时停止读取
+func readSSE(body io.Reader, onData func(data string) bool) error {
+	scanner := bufio.NewScanner(body)
+	// 单个事件可能很长（整段JSON），放宽行长度限制
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	var data bytes.Buffer
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if data.Len() > 0 {
+				if !onData(data.String()) {
+					return nil
+				}
+				data.Reset()
+			}
+			continue
+		}
+		value, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			// event、id、retry 及注释行不影响文本内容
+			continue
+		}
+		if data.Len() > 0 {
+			data.WriteByte('\n')
+		}
+		data.WriteString(strings.TrimPrefix(value, " "))
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if data.Len() > 0 {
+		onData(data.String())
+	}
+	return nil
+}
+
+// isEventStream 兼容服务可能忽略 stream 参数直接返回完整JSON
+func isEventStream(contentType string) bool {
+	return strings.HasPrefix(strings.ToLower(contentType), "text/event-stream")
+}
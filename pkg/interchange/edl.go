@@ -0,0 +1,97 @@
+package interchange
+
+import (
+	"fmt"
+	"strings"
+)
+
+// edlRecordStart EDL录制时间码起点 01:00:00:00
+const edlRecordStart = 3600
+
+// edlReel 生成素材没有磁带号，统一使用AX并以注释标注源文件
+const edlReel = "AX"
+
+// WriteEDL 将序列的第一条视频轨道写为CMX3600 EDL
+// 转场按EDL惯例从剪辑点开始：前一事件提前结束，溶解事件从前一片段的出点溶解到下一片段
+func WriteEDL(seq *Sequence) string {
+	var edl strings.Builder
+	edl.WriteString(fmt.Sprintf("TITLE: %s\n", edlTitle(seq.Name)))
+	edl.WriteString("FCM: NON-DROP FRAME\n\n")
+
+	track := seq.VideoTrack()
+	if track == nil {
+		return edl.String()
+	}
+
+	recordStart := edlRecordStart * seq.FPS
+	tc := func(frames int) string { return timecode(frames, seq.FPS) }
+
+	event := 0
+	for i := range track.Clips {
+		clip := &track.Clips[i]
+		sourceIn, recordIn := clip.SourceIn, clip.RecordIn
+		sourceOut, recordOut := clip.SourceOut, clip.RecordOut()
+
+		// EDL的转场从剪辑点开始：把以剪辑点为界的转场整体移到剪辑点之后
+		var incoming *Transition
+		if i > 0 && track.Clips[i-1].Transition != nil && track.Clips[i-1].RecordOut() == clip.RecordIn {
+			incoming = track.Clips[i-1].Transition
+			sourceIn -= incoming.In
+			recordIn -= incoming.In
+		}
+		if clip.Transition != nil && i+1 < len(track.Clips) && recordOut == track.Clips[i+1].RecordIn {
+			sourceOut -= clip.Transition.In
+			recordOut -= clip.Transition.In
+		}
+
+		event++
+		channel := "V"
+		if clip.HasAudio {
+			channel = "AA/V"
+		}
+
+		if incoming == nil {
+			edl.WriteString(fmt.Sprintf("%03d  %-8s %-5s C        %s %s %s %s\n",
+				event, edlReel, channel,
+				tc(sourceIn), tc(sourceOut), tc(recordStart+recordIn), tc(recordStart+recordOut)))
+		} else {
+			prev := &track.Clips[i-1]
+			prevOut := prev.SourceOut - incoming.In
+			code, name := edlTransition(incoming.Type)
+			edl.WriteString(fmt.Sprintf("%03d  %-8s %-5s C        %s %s %s %s\n",
+				event, edlReel, channel,
+				tc(prevOut), tc(prevOut), tc(recordStart+recordIn), tc(recordStart+recordIn)))
+			edl.WriteString(fmt.Sprintf("%03d  %-8s %-5s %-4s %03d %s %s %s %s\n",
+				event, edlReel, channel, code, incoming.Frames(),
+				tc(sourceIn), tc(sourceOut), tc(recordStart+recordIn), tc(recordStart+recordOut)))
+			edl.WriteString(fmt.Sprintf("* EFFECT NAME: %s\n", name))
+			edl.WriteString(fmt.Sprintf("* FROM CLIP NAME: %s\n", prev.Name))
+			edl.WriteString(fmt.Sprintf("* TO CLIP NAME: %s\n", clip.Name))
+			edl.WriteString(fmt.Sprintf("* SOURCE FILE: %s\n\n", clip.MediaURL))
+			continue
+		}
+		edl.WriteString(fmt.Sprintf("* FROM CLIP NAME: %s\n", clip.Name))
+		edl.WriteString(fmt.Sprintf("* SOURCE FILE: %s\n\n", clip.MediaURL))
+	}
+
+	return edl.String()
+}
+
+// edlTransition 将xfade转场映射为EDL转场代码：擦除/滑动类为W001，其余为溶解
+func edlTransition(transitionType string) (string, string) {
+	switch {
+	case strings.HasPrefix(transitionType, "wipe"), strings.HasPrefix(transitionType, "slide"):
+		return "W001", "WIPE"
+	default:
+		return "D", "CROSS DISSOLVE"
+	}
+}
+
+// edlTitle EDL标题不能换行，长度按惯例限制在70字符以内
+func edlTitle(name string) string {
+	name = strings.Join(strings.Fields(name), " ")
+	if runes := []rune(name); len(runes) > 70 {
+		name = string(runes[:70])
+	}
+	return name
+}
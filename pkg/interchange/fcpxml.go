@@ -0,0 +1,287 @@
+package interchange
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// fcpxmlVersion 输出的FCPXML版本（1.9起支持media-rep，Resolve 17+/Final Cut Pro 10.4.9+可导入）
+const fcpxmlVersion = "1.9"
+
+// fcpxmlCrossDissolveUID Final Cut Pro内置交叉溶解转场
+const fcpxmlCrossDissolveUID = "FxPlug:4731E73A-8DAC-4113-9A30-AE85B1761265"
+
+type fcpxmlDocument struct {
+	XMLName   xml.Name        `xml:"fcpxml"`
+	Version   string          `xml:"version,attr"`
+	Resources fcpxmlResources `xml:"resources"`
+	Library   fcpxmlLibrary   `xml:"library"`
+}
+
+type fcpxmlResources struct {
+	Format  fcpxmlFormat   `xml:"format"`
+	Assets  []fcpxmlAsset  `xml:"asset"`
+	Effects []fcpxmlEffect `xml:"effect"`
+}
+
+type fcpxmlFormat struct {
+	ID            string `xml:"id,attr"`
+	FrameDuration string `xml:"frameDuration,attr"`
+	Width         int    `xml:"width,attr"`
+	Height        int    `xml:"height,attr"`
+}
+
+type fcpxmlAsset struct {
+	ID            string           `xml:"id,attr"`
+	Name          string           `xml:"name,attr"`
+	Start         string           `xml:"start,attr"`
+	Duration      string           `xml:"duration,attr"`
+	HasVideo      int              `xml:"hasVideo,attr"`
+	HasAudio      int              `xml:"hasAudio,attr,omitempty"`
+	Format        string           `xml:"format,attr"`
+	AudioSources  int              `xml:"audioSources,attr,omitempty"`
+	AudioChannels int              `xml:"audioChannels,attr,omitempty"`
+	MediaRep      fcpxmlMediaRep   `xml:"media-rep"`
+	Metadata      *fcpxmlMetadatas `xml:"metadata,omitempty"`
+}
+
+type fcpxmlMediaRep struct {
+	Kind string `xml:"kind,attr"`
+	Src  string `xml:"src,attr"`
+}
+
+type fcpxmlMetadatas struct {
+	Items []fcpxmlMetadata `xml:"md"`
+}
+
+type fcpxmlMetadata struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type fcpxmlEffect struct {
+	ID   string `xml:"id,attr"`
+	Name string `xml:"name,attr"`
+	UID  string `xml:"uid,attr"`
+}
+
+type fcpxmlLibrary struct {
+	Event fcpxmlEvent `xml:"event"`
+}
+
+type fcpxmlEvent struct {
+	Name    string        `xml:"name,attr"`
+	Project fcpxmlProject `xml:"project"`
+}
+
+type fcpxmlProject struct {
+	Name     string         `xml:"name,attr"`
+	Sequence fcpxmlSequence `xml:"sequence"`
+}
+
+type fcpxmlSequence struct {
+	Format      string      `xml:"format,attr"`
+	Duration    string      `xml:"duration,attr"`
+	TCStart     string      `xml:"tcStart,attr"`
+	TCFormat    string      `xml:"tcFormat,attr"`
+	AudioLayout string      `xml:"audioLayout,attr"`
+	AudioRate   string      `xml:"audioRate,attr"`
+	Spine       fcpxmlSpine `xml:"spine"`
+}
+
+type fcpxmlSpine struct {
+	Items []interface{}
+}
+
+type fcpxmlAssetClip struct {
+	XMLName  xml.Name `xml:"asset-clip"`
+	Ref      string   `xml:"ref,attr"`
+	Offset   string   `xml:"offset,attr"`
+	Name     string   `xml:"name,attr"`
+	Start    string   `xml:"start,attr"`
+	Duration string   `xml:"duration,attr"`
+	Format   string   `xml:"format,attr"`
+	TCFormat string   `xml:"tcFormat,attr"`
+}
+
+type fcpxmlGap struct {
+	XMLName  xml.Name `xml:"gap"`
+	Name     string   `xml:"name,attr"`
+	Offset   string   `xml:"offset,attr"`
+	Start    string   `xml:"start,attr"`
+	Duration string   `xml:"duration,attr"`
+}
+
+type fcpxmlTransition struct {
+	XMLName  xml.Name          `xml:"transition"`
+	Name     string            `xml:"name,attr"`
+	Offset   string            `xml:"offset,attr"`
+	Duration string            `xml:"duration,attr"`
+	Filter   fcpxmlFilterVideo `xml:"filter-video"`
+}
+
+type fcpxmlFilterVideo struct {
+	Ref  string `xml:"ref,attr"`
+	Name string `xml:"name,attr"`
+}
+
+// WriteFCPXML 将序列的第一条视频轨道写为FCPXML，每个媒体文件对应一个asset，转场统一使用交叉溶解
+// 资源编号：r1 序列格式，r2 转场效果，r3 起为媒体
+func WriteFCPXML(seq *Sequence) ([]byte, error) {
+	rt := func(frames int) string { return fcpxmlTime(frames, seq.FPS) }
+
+	doc := fcpxmlDocument{
+		Version: fcpxmlVersion,
+		Resources: fcpxmlResources{
+			Format: fcpxmlFormat{ID: "r1", FrameDuration: fmt.Sprintf("1/%ds", seq.FPS), Width: seq.Width, Height: seq.Height},
+		},
+	}
+	sequence := fcpxmlSequence{
+		Format:      "r1",
+		Duration:    rt(seq.Frames()),
+		TCStart:     "0s",
+		TCFormat:    "NDF",
+		AudioLayout: "stereo",
+		AudioRate:   "48k",
+	}
+
+	track := seq.VideoTrack()
+	if track != nil {
+		assetIDs := make(map[string]string)
+		cursor := 0
+		for i := range track.Clips {
+			clip := &track.Clips[i]
+			if clip.RecordIn > cursor {
+				sequence.Spine.Items = append(sequence.Spine.Items, fcpxmlGap{
+					Name: "Gap", Offset: rt(cursor), Start: "0s", Duration: rt(clip.RecordIn - cursor),
+				})
+			}
+
+			assetID, exists := assetIDs[clip.MediaURL]
+			if !exists {
+				assetID = fmt.Sprintf("r%d", len(doc.Resources.Assets)+3)
+				assetIDs[clip.MediaURL] = assetID
+				doc.Resources.Assets = append(doc.Resources.Assets, fcpxmlMediaAsset(assetID, clip, track.Clips, seq.FPS))
+			}
+
+			sequence.Spine.Items = append(sequence.Spine.Items, fcpxmlAssetClip{
+				Ref:      assetID,
+				Offset:   rt(clip.RecordIn),
+				Name:     clip.Name,
+				Start:    rt(clip.SourceIn),
+				Duration: rt(clip.Frames()),
+				Format:   "r1",
+				TCFormat: "NDF",
+			})
+			cursor = clip.RecordOut()
+
+			if clip.Transition != nil && i+1 < len(track.Clips) && cursor == track.Clips[i+1].RecordIn {
+				if len(doc.Resources.Effects) == 0 {
+					doc.Resources.Effects = append(doc.Resources.Effects, fcpxmlEffect{ID: "r2", Name: "Cross Dissolve", UID: fcpxmlCrossDissolveUID})
+				}
+				sequence.Spine.Items = append(sequence.Spine.Items, fcpxmlTransition{
+					Name:     "Cross Dissolve",
+					Offset:   rt(cursor - clip.Transition.In),
+					Duration: rt(clip.Transition.Frames()),
+					Filter:   fcpxmlFilterVideo{Ref: "r2", Name: "Cross Dissolve"},
+				})
+			}
+		}
+	}
+
+	doc.Library = fcpxmlLibrary{Event: fcpxmlEvent{
+		Name:    seq.Name,
+		Project: fcpxmlProject{Name: seq.Name, Sequence: sequence},
+	}}
+
+	output, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode fcpxml: %w", err)
+	}
+	return append([]byte(xml.Header+"<!DOCTYPE fcpxml>\n"), append(output, '\n')...), nil
+}
+
+// fcpxmlMediaAsset 构建媒体资源；媒体总时长未知时使用引用该媒体的片段（含转场余量）中最晚的出点
+func fcpxmlMediaAsset(id string, clip *Clip, clips []Clip, fps int) fcpxmlAsset {
+	frames := clip.MediaFrames
+	if frames <= 0 {
+		for i := range clips {
+			if clips[i].MediaURL != clip.MediaURL {
+				continue
+			}
+			out := clips[i].SourceOut
+			if clips[i].Transition != nil {
+				out += clips[i].Transition.Out
+			}
+			frames = max(frames, out)
+		}
+	}
+
+	asset := fcpxmlAsset{
+		ID:       id,
+		Name:     clip.Name,
+		Start:    "0s",
+		Duration: fcpxmlTime(frames, fps),
+		HasVideo: 1,
+		Format:   "r1",
+		MediaRep: fcpxmlMediaRep{Kind: "original-media", Src: fileURL(clip.MediaURL)},
+	}
+	if clip.HasAudio {
+		asset.HasAudio = 1
+		asset.AudioSources = 1
+		asset.AudioChannels = 2
+	}
+	if len(clip.Metadata) > 0 {
+		asset.Metadata = &fcpxmlMetadatas{}
+		for _, key := range sortedKeys(clip.Metadata) {
+			asset.Metadata.Items = append(asset.Metadata.Items, fcpxmlMetadata{Key: "com.drama-generator." + key, Value: fmt.Sprint(clip.Metadata[key])})
+		}
+	}
+	return asset
+}
+
+// MarshalXML 按顺序输出spine中的片段、空隙与转场
+func (s fcpxmlSpine) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, item := range s.Items {
+		if err := e.Encode(item); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// fcpxmlTime 帧数转换为FCPXML有理数时间，如 90/30s，整秒时简化为 3s
+func fcpxmlTime(frames, fps int) string {
+	if frames%fps == 0 {
+		return fmt.Sprintf("%ds", frames/fps)
+	}
+	return fmt.Sprintf("%d/%ds", frames, fps)
+}
+
+// fileURL 本地路径转换为 file:// URL，已是URL时原样返回
+func fileURL(location string) string {
+	if strings.Contains(location, "://") {
+		return location
+	}
+	path := filepath.ToSlash(location)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,317 @@
+package interchange
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+)
+
+// otioMetadataKey OTIO元数据中本系统使用的命名空间
+const otioMetadataKey = "drama_generator"
+
+type otioRationalTime struct {
+	Schema string  `json:"OTIO_SCHEMA"`
+	Rate   float64 `json:"rate"`
+	Value  float64 `json:"value"`
+}
+
+type otioTimeRange struct {
+	Schema    string           `json:"OTIO_SCHEMA"`
+	StartTime otioRationalTime `json:"start_time"`
+	Duration  otioRationalTime `json:"duration"`
+}
+
+// otioObject OTIO对象的通用结构，按 OTIO_SCHEMA 区分具体类型，只读写本系统使用的字段
+type otioObject struct {
+	Schema         string                 `json:"OTIO_SCHEMA"`
+	Name           string                 `json:"name"`
+	Metadata       map[string]interface{} `json:"metadata"`
+	Kind           string                 `json:"kind,omitempty"`
+	SourceRange    *otioTimeRange         `json:"source_range,omitempty"`
+	MediaReference *otioObject            `json:"media_reference,omitempty"`
+	// Clip.2 起使用多个媒体引用
+	MediaReferences         map[string]*otioObject `json:"media_references,omitempty"`
+	ActiveMediaReferenceKey string                 `json:"active_media_reference_key,omitempty"`
+	TargetURL               string                 `json:"target_url,omitempty"`
+	AvailableRange          *otioTimeRange         `json:"available_range,omitempty"`
+	TransitionType          string                 `json:"transition_type,omitempty"`
+	InOffset                *otioRationalTime      `json:"in_offset,omitempty"`
+	OutOffset               *otioRationalTime      `json:"out_offset,omitempty"`
+	Children                []otioObject           `json:"children,omitempty"`
+	Tracks                  *otioObject            `json:"tracks,omitempty"`
+	Effects                 []interface{}          `json:"effects,omitempty"`
+	Markers                 []interface{}          `json:"markers,omitempty"`
+}
+
+// WriteOTIO 将序列写为OpenTimelineIO（.otio）JSON
+// 视频轨道之外，为带音频的片段生成一条对应的音频轨道，便于剪辑软件同时导入片段自带的声音
+func WriteOTIO(seq *Sequence) ([]byte, error) {
+	fps := float64(seq.FPS)
+	rt := func(frames int) otioRationalTime {
+		return otioRationalTime{Schema: "RationalTime.1", Rate: fps, Value: float64(frames)}
+	}
+	tr := func(start, duration int) *otioTimeRange {
+		return &otioTimeRange{Schema: "TimeRange.1", StartTime: rt(start), Duration: rt(duration)}
+	}
+
+	stack := &otioObject{Schema: "Stack.1", Name: "tracks", Metadata: map[string]interface{}{}}
+	for _, track := range seq.Tracks {
+		stack.Children = append(stack.Children, otioTrack(track, rt, tr))
+		if track.Kind == TrackVideo && hasAudio(track) {
+			audio := Track{Kind: TrackAudio, Name: "A" + strings.TrimPrefix(track.Name, "V")}
+			for _, clip := range track.Clips {
+				if clip.HasAudio {
+					audio.Clips = append(audio.Clips, clip)
+				}
+			}
+			stack.Children = append(stack.Children, otioTrack(audio, rt, tr))
+		}
+	}
+
+	timeline := map[string]interface{}{
+		"OTIO_SCHEMA":       "Timeline.1",
+		"name":              seq.Name,
+		"global_start_time": rt(0),
+		"metadata": map[string]interface{}{
+			otioMetadataKey: map[string]interface{}{"width": seq.Width, "height": seq.Height},
+		},
+		"tracks": stack,
+	}
+
+	output, err := json.MarshalIndent(timeline, "", "    ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode otio: %w", err)
+	}
+	return append(output, '\n'), nil
+}
+
+func otioTrack(track Track, rt func(int) otioRationalTime, tr func(int, int) *otioTimeRange) otioObject {
+	kind := "Video"
+	if track.Kind == TrackAudio {
+		kind = "Audio"
+	}
+	result := otioObject{Schema: "Track.1", Name: track.Name, Kind: kind, Metadata: map[string]interface{}{}}
+
+	cursor := 0
+	for i := range track.Clips {
+		clip := &track.Clips[i]
+		if clip.RecordIn > cursor {
+			result.Children = append(result.Children, otioObject{
+				Schema: "Gap.1", Name: "", Metadata: map[string]interface{}{}, SourceRange: tr(0, clip.RecordIn-cursor),
+			})
+		}
+
+		reference := &otioObject{Schema: "ExternalReference.1", Name: clip.Name, Metadata: map[string]interface{}{}, TargetURL: fileURL(clip.MediaURL)}
+		if clip.MediaFrames > 0 {
+			reference.AvailableRange = tr(0, clip.MediaFrames)
+		}
+		metadata := map[string]interface{}{}
+		if len(clip.Metadata) > 0 {
+			metadata[otioMetadataKey] = clip.Metadata
+		}
+		result.Children = append(result.Children, otioObject{
+			Schema:         "Clip.1",
+			Name:           clip.Name,
+			Metadata:       metadata,
+			SourceRange:    tr(clip.SourceIn, clip.Frames()),
+			MediaReference: reference,
+			Effects:        []interface{}{},
+			Markers:        []interface{}{},
+		})
+		cursor = clip.RecordOut()
+
+		if clip.Transition != nil && i+1 < len(track.Clips) && cursor == track.Clips[i+1].RecordIn {
+			inOffset, outOffset := rt(clip.Transition.In), rt(clip.Transition.Out)
+			result.Children = append(result.Children, otioObject{
+				Schema:         "Transition.1",
+				Name:           "Cross Dissolve",
+				Metadata:       map[string]interface{}{otioMetadataKey: map[string]interface{}{"type": clip.Transition.Type}},
+				TransitionType: "SMPTE_Dissolve",
+				InOffset:       &inOffset,
+				OutOffset:      &outOffset,
+			})
+		}
+	}
+	return result
+}
+
+func hasAudio(track Track) bool {
+	for _, clip := range track.Clips {
+		if clip.HasAudio {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadOTIO 解析OpenTimelineIO（.otio）JSON，帧率取第一个片段的时间基准
+// 支持 Timeline、Stack、Track 中的 Clip、Gap、Transition；嵌套的Stack（复合片段）按空隙处理
+func ReadOTIO(data []byte) (*Sequence, error) {
+	var root otioObject
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid otio document: %w", err)
+	}
+
+	var tracks []otioObject
+	switch schemaName(root.Schema) {
+	case "Timeline":
+		if root.Tracks == nil {
+			return nil, errors.New("invalid otio document: timeline has no tracks")
+		}
+		tracks = root.Tracks.Children
+	case "Stack":
+		tracks = root.Children
+	case "Track":
+		tracks = []otioObject{root}
+	default:
+		return nil, fmt.Errorf("unsupported otio schema: %s", root.Schema)
+	}
+
+	seq := &Sequence{Name: root.Name, FPS: otioRate(tracks)}
+	if seq.FPS <= 0 {
+		return nil, errors.New("invalid otio document: no clips found")
+	}
+	if meta, ok := root.Metadata[otioMetadataKey].(map[string]interface{}); ok {
+		seq.Width = intValue(meta["width"])
+		seq.Height = intValue(meta["height"])
+	}
+
+	for _, track := range tracks {
+		if schemaName(track.Schema) != "Track" {
+			continue
+		}
+		kind := TrackVideo
+		if strings.EqualFold(track.Kind, "Audio") {
+			kind = TrackAudio
+		}
+		seq.Tracks = append(seq.Tracks, Track{Kind: kind, Name: track.Name, Clips: readOTIOTrack(track, seq.FPS)})
+	}
+	return seq, nil
+}
+
+func readOTIOTrack(track otioObject, fps int) []Clip {
+	var clips []Clip
+	cursor := 0
+	for _, child := range track.Children {
+		switch schemaName(child.Schema) {
+		case "Clip":
+			// 未设置source_range时使用媒体的完整区间
+			ref := child.mediaReference()
+			sourceRange := child.SourceRange
+			if sourceRange == nil && ref != nil {
+				sourceRange = ref.AvailableRange
+			}
+			if sourceRange == nil {
+				continue
+			}
+			clip := Clip{
+				Name:      child.Name,
+				SourceIn:  frames(sourceRange.StartTime, fps),
+				SourceOut: frames(sourceRange.StartTime, fps) + frames(sourceRange.Duration, fps),
+				RecordIn:  cursor,
+				HasAudio:  strings.EqualFold(track.Kind, "Audio"),
+			}
+			if meta, ok := child.Metadata[otioMetadataKey].(map[string]interface{}); ok {
+				clip.Metadata = meta
+			}
+			if ref != nil {
+				clip.MediaURL = filePath(ref.TargetURL)
+				if ref.AvailableRange != nil {
+					// 媒体起始时间码不为0时，入出点换算为相对文件开头的位置
+					start := frames(ref.AvailableRange.StartTime, fps)
+					clip.SourceIn -= start
+					clip.SourceOut -= start
+					clip.MediaFrames = frames(ref.AvailableRange.Duration, fps)
+				}
+			}
+			clips = append(clips, clip)
+			cursor = clip.RecordOut()
+		case "Transition":
+			if len(clips) == 0 || clips[len(clips)-1].RecordOut() != cursor || child.InOffset == nil || child.OutOffset == nil {
+				continue
+			}
+			transitionType := "fade"
+			if meta, ok := child.Metadata[otioMetadataKey].(map[string]interface{}); ok {
+				if t, ok := meta["type"].(string); ok && t != "" {
+					transitionType = t
+				}
+			}
+			clips[len(clips)-1].Transition = &Transition{
+				Type: transitionType,
+				In:   frames(*child.InOffset, fps),
+				Out:  frames(*child.OutOffset, fps),
+			}
+		default:
+			// Gap 及其他对象只占用时间
+			if child.SourceRange != nil {
+				cursor += frames(child.SourceRange.Duration, fps)
+			}
+		}
+	}
+
+	// 转场只能连接相邻的两个片段
+	for i := range clips {
+		if clips[i].Transition != nil && (i+1 >= len(clips) || clips[i].RecordOut() != clips[i+1].RecordIn) {
+			clips[i].Transition = nil
+		}
+	}
+	return clips
+}
+
+// mediaReference 片段当前使用的媒体引用
+func (o *otioObject) mediaReference() *otioObject {
+	if ref, ok := o.MediaReferences[o.ActiveMediaReferenceKey]; ok && ref != nil {
+		return ref
+	}
+	return o.MediaReference
+}
+
+// otioRate 取第一个片段的时间基准作为序列帧率
+func otioRate(tracks []otioObject) int {
+	for _, track := range tracks {
+		for _, child := range track.Children {
+			if schemaName(child.Schema) == "Clip" && child.SourceRange != nil && child.SourceRange.Duration.Rate > 0 {
+				return int(math.Round(child.SourceRange.Duration.Rate))
+			}
+		}
+	}
+	return 0
+}
+
+// schemaName 去掉 OTIO_SCHEMA 中的版本号，如 Clip.2 -> Clip
+func schemaName(schema string) string {
+	if idx := strings.LastIndex(schema, "."); idx != -1 {
+		return schema[:idx]
+	}
+	return schema
+}
+
+// frames 将OTIO时间换算为序列帧率下的帧数
+func frames(t otioRationalTime, fps int) int {
+	if t.Rate <= 0 {
+		return int(math.Round(t.Value))
+	}
+	return int(math.Round(t.Value / t.Rate * float64(fps)))
+}
+
+// filePath file:// URL 还原为本地路径，其他地址原样返回
+func filePath(location string) string {
+	if !strings.HasPrefix(location, "file://") {
+		return location
+	}
+	parsed, err := url.Parse(location)
+	if err != nil {
+		return strings.TrimPrefix(location, "file://")
+	}
+	return parsed.Path
+}
+
+func intValue(value interface{}) int {
+	if number, ok := value.(float64); ok {
+		return int(number)
+	}
+	return 0
+}
@@ -0,0 +1,205 @@
+// Package interchange 剪辑交换格式（CMX3600 EDL、FCPXML、OpenTimelineIO）的读写
+// 序列以帧为单位、按剪辑点对齐，转场以剪辑点为界分别占用前后片段的余量，与剪辑软件的表示方式一致
+package interchange
+
+import (
+	"fmt"
+	"math"
+)
+
+// TrackKind 轨道类型
+type TrackKind string
+
+const (
+	TrackVideo TrackKind = "video"
+	TrackAudio TrackKind = "audio"
+)
+
+// Sequence 剪辑序列
+type Sequence struct {
+	Name   string
+	FPS    int
+	Width  int
+	Height int
+	Tracks []Track
+}
+
+// Track 轨道，片段按 RecordIn 排序，片段之间可以有空隙
+type Track struct {
+	Kind  TrackKind
+	Name  string
+	Clips []Clip
+}
+
+// Clip 片段，时间均为帧
+type Clip struct {
+	Name        string
+	MediaURL    string // 媒体地址：本地路径或URL
+	MediaFrames int    // 媒体总帧数，未知时为0
+	HasAudio    bool
+	SourceIn    int         // 剪辑点处的源入点
+	SourceOut   int         // 剪辑点处的源出点（不含）
+	RecordIn    int         // 在序列中的位置
+	Transition  *Transition // 与下一个片段之间的转场
+	Metadata    map[string]interface{}
+}
+
+// Transition 转场，跨越 [剪辑点-In, 剪辑点+Out]
+// 前一片段需要出点之后 Out 帧的余量，后一片段需要入点之前 In 帧的余量
+type Transition struct {
+	Type string // xfade转场名称
+	In   int
+	Out  int
+}
+
+// Frames 转场总帧数
+func (t *Transition) Frames() int {
+	return t.In + t.Out
+}
+
+// Frames 片段在序列中的帧数
+func (c *Clip) Frames() int {
+	return c.SourceOut - c.SourceIn
+}
+
+// RecordOut 片段在序列中的结束位置（不含）
+func (c *Clip) RecordOut() int {
+	return c.RecordIn + c.Frames()
+}
+
+// VideoTrack 返回第一条视频轨道，没有时返回nil
+func (s *Sequence) VideoTrack() *Track {
+	for i := range s.Tracks {
+		if s.Tracks[i].Kind == TrackVideo {
+			return &s.Tracks[i]
+		}
+	}
+	return nil
+}
+
+// Frames 序列总帧数
+func (s *Sequence) Frames() int {
+	total := 0
+	for _, track := range s.Tracks {
+		for i := range track.Clips {
+			total = max(total, track.Clips[i].RecordOut())
+		}
+	}
+	return total
+}
+
+// Edit 合成时的一个片段：完整的源素材区间，与下一个片段的转场以重叠方式（xfade）占用本片段尾部与下一片段头部
+type Edit struct {
+	Name          string
+	MediaURL      string
+	MediaDuration float64 // 媒体总时长（秒），未知时为0
+	HasAudio      bool
+	SourceIn      float64 // 源入点（秒）
+	SourceOut     float64 // 源出点（秒）
+	Transition    string  // 与下一个片段之间的转场类型，硬切时为空
+	Overlap       float64 // 与下一个片段重叠的时长（秒）
+	Metadata      map[string]interface{}
+}
+
+// NewSequence 将合成片段转换为单视频轨道的剪辑序列，转场重叠区间的中点作为剪辑点
+func NewSequence(name string, fps, width, height int, edits []Edit) *Sequence {
+	clips := make([]Clip, len(edits))
+	for i, edit := range edits {
+		clips[i] = Clip{
+			Name:        edit.Name,
+			MediaURL:    edit.MediaURL,
+			MediaFrames: toFrames(edit.MediaDuration, fps),
+			HasAudio:    edit.HasAudio,
+			SourceIn:    toFrames(edit.SourceIn, fps),
+			SourceOut:   toFrames(edit.SourceOut, fps),
+			Metadata:    edit.Metadata,
+		}
+	}
+
+	for i := 0; i+1 < len(clips); i++ {
+		overlap := toFrames(edits[i].Overlap, fps)
+		// 剪辑点两侧至少保留1帧
+		overlap = min(overlap, 2*(clips[i].Frames()-1), 2*(clips[i+1].Frames()-1))
+		if overlap <= 0 {
+			continue
+		}
+		transition := &Transition{Type: edits[i].Transition, In: overlap / 2, Out: overlap - overlap/2}
+		clips[i].SourceOut -= transition.Out
+		clips[i+1].SourceIn += transition.In
+		clips[i].Transition = transition
+	}
+
+	record := 0
+	for i := range clips {
+		clips[i].RecordIn = record
+		record += clips[i].Frames()
+	}
+
+	return &Sequence{
+		Name:   name,
+		FPS:    fps,
+		Width:  width,
+		Height: height,
+		Tracks: []Track{{Kind: TrackVideo, Name: "V1", Clips: clips}},
+	}
+}
+
+// Offset 将所有片段在序列中整体后移，为成片中正片之前的片头留出位置
+func (s *Sequence) Offset(seconds float64) {
+	frames := toFrames(seconds, s.FPS)
+	if frames <= 0 {
+		return
+	}
+	for i := range s.Tracks {
+		for j := range s.Tracks[i].Clips {
+			s.Tracks[i].Clips[j].RecordIn += frames
+		}
+	}
+}
+
+// Edits 将轨道还原为重叠方式的片段：转场前后片段分别延长到转场结束/开始处，与合成时的xfade一致
+// 返回的片段与轨道片段一一对应，同时返回各片段在序列中的起始时间（秒）
+func (t *Track) Edits(fps int) ([]Edit, []float64) {
+	edits := make([]Edit, len(t.Clips))
+	starts := make([]float64, len(t.Clips))
+	for i := range t.Clips {
+		clip := &t.Clips[i]
+		sourceIn, sourceOut, recordIn := clip.SourceIn, clip.SourceOut, clip.RecordIn
+		if i > 0 && t.Clips[i-1].Transition != nil && t.Clips[i-1].RecordOut() == clip.RecordIn {
+			prev := t.Clips[i-1].Transition
+			sourceIn -= prev.In
+			recordIn -= prev.In
+		}
+		edits[i] = Edit{
+			Name:          clip.Name,
+			MediaURL:      clip.MediaURL,
+			MediaDuration: toSeconds(clip.MediaFrames, fps),
+			HasAudio:      clip.HasAudio,
+			Metadata:      clip.Metadata,
+		}
+		if clip.Transition != nil && i+1 < len(t.Clips) && clip.RecordOut() == t.Clips[i+1].RecordIn {
+			sourceOut += clip.Transition.Out
+			edits[i].Transition = clip.Transition.Type
+			edits[i].Overlap = toSeconds(clip.Transition.Frames(), fps)
+		}
+		edits[i].SourceIn = toSeconds(max(sourceIn, 0), fps)
+		edits[i].SourceOut = toSeconds(sourceOut, fps)
+		starts[i] = toSeconds(max(recordIn, 0), fps)
+	}
+	return edits, starts
+}
+
+func toFrames(seconds float64, fps int) int {
+	return int(math.Round(seconds * float64(fps)))
+}
+
+func toSeconds(frames, fps int) float64 {
+	return float64(frames) / float64(fps)
+}
+
+// timecode 帧数转换为非丢帧时间码 HH:MM:SS:FF
+func timecode(frames, fps int) string {
+	ff := frames % fps
+	seconds := frames / fps
+	return fmt.Sprintf("%02d:%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60, ff)
+}
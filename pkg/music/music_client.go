@@ -0,0 +1,51 @@
+package music
+
+// MusicClient 背景音乐生成客户端，生成为异步任务，需轮询结果
+type MusicClient interface {
+	GenerateMusic(prompt string, opts ...MusicOption) (*MusicResult, error)
+	GetTaskStatus(taskID string) (*MusicResult, error)
+}
+
+type MusicResult struct {
+	TaskID    string
+	Status    string
+	AudioURL  string
+	Title     string
+	Tags      string  // 服务返回的风格标签
+	Duration  float64 // 音频时长（秒），服务未返回时为0
+	Error     string
+	Completed bool
+}
+
+type MusicOptions struct {
+	Model        string
+	Title        string
+	Tags         string // 风格标签，如 "cinematic, piano, sad"
+	Instrumental bool   // 纯音乐（无人声）
+}
+
+type MusicOption func(*MusicOptions)
+
+func WithModel(model string) MusicOption {
+	return func(o *MusicOptions) {
+		o.Model = model
+	}
+}
+
+func WithTitle(title string) MusicOption {
+	return func(o *MusicOptions) {
+		o.Title = title
+	}
+}
+
+func WithTags(tags string) MusicOption {
+	return func(o *MusicOptions) {
+		o.Tags = tags
+	}
+}
+
+func WithInstrumental(instrumental bool) MusicOption {
+	return func(o *MusicOptions) {
+		o.Instrumental = instrumental
+	}
+}
@@ -0,0 +1,189 @@
+package music
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SunoClient Suno 兼容的音乐生成接口（chatfire 等中转服务的 /suno/submit/music、/suno/fetch/{taskId}）
+type SunoClient struct {
+	BaseURL       string
+	APIKey        string
+	Model         string
+	Endpoint      string
+	QueryEndpoint string
+	HTTPClient    *http.Client
+}
+
+type SunoRequest struct {
+	Prompt               string `json:"prompt,omitempty"`
+	GptDescriptionPrompt string `json:"gpt_description_prompt,omitempty"`
+	Mv                   string `json:"mv,omitempty"`
+	Title                string `json:"title,omitempty"`
+	Tags                 string `json:"tags,omitempty"`
+	MakeInstrumental     bool   `json:"make_instrumental"`
+}
+
+type SunoSubmitResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Data    string `json:"data"` // 任务ID
+}
+
+type SunoClip struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	AudioURL string `json:"audio_url"`
+	Status   string `json:"status"`
+	Metadata struct {
+		Tags     string  `json:"tags"`
+		Duration float64 `json:"duration"`
+	} `json:"metadata"`
+}
+
+type SunoTaskResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		TaskID     string     `json:"task_id"`
+		Status     string     `json:"status"` // NOT_START、SUBMITTED、QUEUED、IN_PROGRESS、SUCCESS、FAILURE
+		FailReason string     `json:"fail_reason"`
+		Data       []SunoClip `json:"data"`
+	} `json:"data"`
+}
+
+func NewSunoClient(baseURL, apiKey, model, endpoint, queryEndpoint string) *SunoClient {
+	if endpoint == "" {
+		endpoint = "/suno/submit/music"
+	}
+	if queryEndpoint == "" {
+		queryEndpoint = "/suno/fetch/{taskId}"
+	}
+	return &SunoClient{
+		BaseURL:       baseURL,
+		APIKey:        apiKey,
+		Model:         model,
+		Endpoint:      endpoint,
+		QueryEndpoint: queryEndpoint,
+		HTTPClient: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}
+}
+
+func (c *SunoClient) GenerateMusic(prompt string, opts ...MusicOption) (*MusicResult, error) {
+	options := &MusicOptions{Model: c.Model, Instrumental: true}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	// 灵感模式：只给描述，由服务生成歌词与编曲；纯音乐不需要歌词
+	reqBody := SunoRequest{
+		GptDescriptionPrompt: prompt,
+		Mv:                   options.Model,
+		Title:                options.Title,
+		Tags:                 options.Tags,
+		MakeInstrumental:     options.Instrumental,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	body, err := c.do("POST", c.BaseURL+c.Endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	var result SunoSubmitResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("parse response: %w, body: %s", err, string(body))
+	}
+	if result.Code != "success" || result.Data == "" {
+		return nil, fmt.Errorf("submit failed: %s", result.Message)
+	}
+
+	return &MusicResult{
+		TaskID: result.Data,
+		Status: "processing",
+	}, nil
+}
+
+func (c *SunoClient) GetTaskStatus(taskID string) (*MusicResult, error) {
+	queryPath := strings.ReplaceAll(c.QueryEndpoint, "{taskId}", taskID)
+
+	body, err := c.do("GET", c.BaseURL+queryPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result SunoTaskResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("parse response: %w, body: %s", err, string(body))
+	}
+	if result.Code != "success" {
+		return nil, fmt.Errorf("query failed: %s", result.Message)
+	}
+
+	musicResult := &MusicResult{
+		TaskID: taskID,
+		Status: strings.ToLower(result.Data.Status),
+	}
+	switch result.Data.Status {
+	case "SUCCESS":
+		// 一次生成返回多首候选，取第一首可用的
+		for _, clip := range result.Data.Data {
+			if clip.AudioURL == "" {
+				continue
+			}
+			musicResult.Completed = true
+			musicResult.AudioURL = clip.AudioURL
+			musicResult.Title = clip.Title
+			musicResult.Tags = clip.Metadata.Tags
+			musicResult.Duration = clip.Metadata.Duration
+			break
+		}
+		if !musicResult.Completed {
+			musicResult.Error = "task completed but no audio URL"
+		}
+	case "FAILURE":
+		musicResult.Error = result.Data.FailReason
+		if musicResult.Error == "" {
+			musicResult.Error = "music generation failed"
+		}
+	}
+
+	return musicResult, nil
+}
+
+func (c *SunoClient) do(method, url string, reqBody io.Reader) ([]byte, error) {
+	req, err := http.NewRequest(method, url, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
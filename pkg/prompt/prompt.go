@@ -0,0 +1,44 @@
+package prompt
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// variablePattern 模板中的变量占位符：{{name}}，花括号内可带空格
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// Variables 按出现顺序返回模板引用的变量名（去重）
+func Variables(content string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, match := range variablePattern.FindAllStringSubmatch(content, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// Render 将模板中的变量替换为对应的值，引用了未提供的变量时返回错误
+func Render(content string, vars map[string]string) (string, error) {
+	for _, name := range Variables(content) {
+		if _, ok := vars[name]; !ok {
+			return "", fmt.Errorf("undefined variable: %s", name)
+		}
+	}
+	return variablePattern.ReplaceAllStringFunc(content, func(placeholder string) string {
+		return vars[variablePattern.FindStringSubmatch(placeholder)[1]]
+	}), nil
+}
+
+// Validate 检查模板只引用了定义中声明的变量
+func Validate(def *Definition, content string) error {
+	for _, name := range Variables(content) {
+		if !def.HasVariable(name) {
+			return fmt.Errorf("unknown variable: %s", name)
+		}
+	}
+	return nil
+}
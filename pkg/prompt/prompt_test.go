@@ -0,0 +1,111 @@
+package prompt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVariables(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"按出现顺序返回", "{{b}} 与 {{a}}", []string{"b", "a"}},
+		{"重复引用只返回一次", "{{a}}{{b}}{{a}}", []string{"a", "b"}},
+		{"花括号内允许空格", "{{ a }} {{b  }} {{\tc}}", []string{"a", "b", "c"}},
+		{"下划线与数字", "{{drama_title}} {{_x1}}", []string{"drama_title", "_x1"}},
+		{"非法变量名不算变量", "{{1a}} {{a-b}} {{}} {a}", nil},
+		{"没有变量", "纯文本", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Variables(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Variables(%q) = %v, want %v", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	vars := map[string]string{"name": "林晓", "empty": "", "brace": "{{name}}"}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr string
+	}{
+		{"替换变量", "你好，{{name}}。", "你好，林晓。", ""},
+		{"花括号内带空格", "你好，{{ name }}/{{name  }}。", "你好，林晓/林晓。", ""},
+		{"空值变量", "[{{empty}}]", "[]", ""},
+		{"变量值不再被展开", "{{brace}}", "{{name}}", ""},
+		{"未提供的变量", "{{name}} {{missing}}", "", "undefined variable: missing"},
+		{"没有变量", "纯文本", "纯文本", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.content, vars)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Render(%q) error = %v, want %q", tt.content, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render(%q) error = %v", tt.content, err)
+			}
+			if got != tt.want {
+				t.Errorf("Render(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	def := &Definition{Key: "test", Variables: []Variable{{Name: "script"}}}
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"模板特有变量", "{{script}}", ""},
+		{"剧本通用变量", "{{ drama_title }}{{drama_genre}}", ""},
+		{"未声明的变量", "{{script}} {{scenes}}", "unknown variable: scenes"},
+		{"没有变量", "纯文本", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(def, tt.content)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate(%q) error = %v", tt.content, err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Validate(%q) error = %v, want %q", tt.content, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuiltinTemplates(t *testing.T) {
+	for _, def := range Definitions() {
+		t.Run(def.Key, func(t *testing.T) {
+			if err := Validate(&def, def.Content); err != nil {
+				t.Errorf("builtin template %s: %v", def.Key, err)
+			}
+			if got, ok := Lookup(def.Key); !ok || got.Key != def.Key {
+				t.Errorf("Lookup(%q) = %v, %v", def.Key, got, ok)
+			}
+		})
+	}
+	if _, ok := Lookup("missing"); ok {
+		t.Error(`Lookup("missing") ok = true, want false`)
+	}
+}
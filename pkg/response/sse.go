@@ -0,0 +1,80 @@
+package response
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SSE 事件类型
+const (
+	EventDelta  = "delta"  // 增量文本：{"text": "..."}
+	EventResult = "result" // 最终结果，结构与对应非流式接口的 data 相同
+	EventError  = "error"  // 流开始后发生的错误
+)
+
+// SSEStream Server-Sent Events 推送，首个事件发出时才写响应头，
+// 在此之前出错仍可按普通JSON错误响应返回
+type SSEStream struct {
+	c       *gin.Context
+	started bool
+}
+
+func NewSSEStream(c *gin.Context) *SSEStream {
+	return &SSEStream{c: c}
+}
+
+// Started 是否已开始推送
+func (s *SSEStream) Started() bool {
+	return s.started
+}
+
+func (s *SSEStream) begin() {
+	if s.started {
+		return
+	}
+	s.started = true
+	header := s.c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // 关闭 nginx 缓冲
+	s.c.Status(http.StatusOK)
+}
+
+func (s *SSEStream) send(event string, data interface{}) {
+	// 客户端已断开时不再写入
+	if s.c.Request.Context().Err() != nil {
+		return
+	}
+	s.begin()
+	s.c.SSEvent(event, data)
+	s.c.Writer.Flush()
+}
+
+// Delta 推送增量文本
+func (s *SSEStream) Delta(text string) {
+	s.send(EventDelta, gin.H{"text": text})
+}
+
+// Result 推送最终结果
+func (s *SSEStream) Result(data interface{}) {
+	s.send(EventResult, Response{
+		Success:   true,
+		Data:      data,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// Error 推送错误事件
+func (s *SSEStream) Error(errCode string, message string) {
+	s.send(EventError, Response{
+		Success: false,
+		Error: &ErrorInfo{
+			Code:    errCode,
+			Message: message,
+		},
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	})
+}
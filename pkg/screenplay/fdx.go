@@ -0,0 +1,141 @@
+package screenplay
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// fdxDocument Final Draft 文件中解析用到的部分
+type fdxDocument struct {
+	XMLName      xml.Name       `xml:"FinalDraft"`
+	DocumentType string         `xml:"DocumentType,attr,omitempty"`
+	Template     string         `xml:"Template,attr,omitempty"`
+	Version      string         `xml:"Version,attr,omitempty"`
+	Content      []fdxParagraph `xml:"Content>Paragraph"`
+	TitlePage    []fdxParagraph `xml:"TitlePage>Content>Paragraph"`
+}
+
+type fdxParagraph struct {
+	Type      string    `xml:"Type,attr,omitempty"`
+	Alignment string    `xml:"Alignment,attr,omitempty"`
+	Texts     []fdxText `xml:"Text"`
+}
+
+type fdxText struct {
+	Value string `xml:",chardata"`
+}
+
+// text 段落内多个 Text 片段（不同样式）拼接为一段
+func (p fdxParagraph) text() string {
+	var sb strings.Builder
+	for _, t := range p.Texts {
+		sb.WriteString(t.Value)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// ParseFDX 解析 Final Draft（.fdx）剧本
+// New Act 段落或形如 第1集 / Episode 1 的段落分集
+func ParseFDX(data []byte) (*Screenplay, error) {
+	var doc fdxDocument
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid fdx document: %w", err)
+	}
+
+	b := newBuilder()
+	for _, p := range doc.TitlePage {
+		if text := p.text(); text != "" {
+			b.sp.Title = text
+			break
+		}
+	}
+
+	character := ""
+	parenthetical := ""
+	for _, p := range doc.Content {
+		text := p.text()
+		if text == "" {
+			continue
+		}
+
+		switch p.Type {
+		case "New Act", "End of Act", "Act Break":
+			if ep, ok := newEpisode(text); ok {
+				b.startEpisode(ep)
+			} else if p.Type == "New Act" {
+				b.startEpisode(Episode{Title: text})
+			}
+		case "Scene Heading":
+			b.startScene(text)
+		case "Character":
+			character = characterName(text)
+			parenthetical = ""
+		case "Parenthetical":
+			parenthetical = trimParens(text)
+		case "Dialogue":
+			b.add(Element{Type: ElementDialogue, Character: character, Parenthetical: parenthetical, Text: text})
+			parenthetical = ""
+		case "Transition":
+			b.add(Element{Type: ElementTransition, Text: text})
+		default:
+			// Action / General / Shot 等按动作处理，单独一行的 第1集 分集
+			if ep, ok := newEpisode(text); ok && utf8.RuneCountInString(text) <= 40 {
+				b.startEpisode(ep)
+				continue
+			}
+			b.add(Element{Type: ElementAction, Text: text})
+		}
+	}
+
+	return b.result(), nil
+}
+
+// WriteFDX 导出 Final Draft（.fdx）剧本，每集以 New Act 段落开始
+func WriteFDX(sp *Screenplay) ([]byte, error) {
+	doc := fdxDocument{DocumentType: "Script", Template: "No", Version: "5"}
+	paragraph := func(kind, text string) fdxParagraph {
+		return fdxParagraph{Type: kind, Texts: []fdxText{{Value: text}}}
+	}
+
+	if sp.Title != "" {
+		title := paragraph("", sp.Title)
+		title.Alignment = "Center"
+		doc.TitlePage = append(doc.TitlePage, title)
+	}
+
+	for _, ep := range sp.Episodes {
+		if heading := ep.Heading(); heading != "" {
+			doc.Content = append(doc.Content, paragraph("New Act", heading))
+		}
+		for _, scene := range ep.Scenes {
+			if scene.Heading != "" {
+				doc.Content = append(doc.Content, paragraph("Scene Heading", scene.Heading))
+			}
+			for _, el := range scene.Elements {
+				switch {
+				case el.Type == ElementDialogue && el.Character != "":
+					doc.Content = append(doc.Content, paragraph("Character", el.Character))
+					if el.Parenthetical != "" {
+						doc.Content = append(doc.Content, paragraph("Parenthetical", "("+el.Parenthetical+")"))
+					}
+					doc.Content = append(doc.Content, paragraph("Dialogue", el.Text))
+				case el.Type == ElementTransition:
+					doc.Content = append(doc.Content, paragraph("Transition", el.Text))
+				default:
+					doc.Content = append(doc.Content, paragraph("Action", el.Text))
+				}
+			}
+		}
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode fdx: %w", err)
+	}
+	return append([]byte(xml.Header), data...), nil
+}
@@ -0,0 +1,102 @@
+package screenplay
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFDX(t *testing.T) {
+	data := []byte(`<?xml version="1.0" encoding="UTF-8" standalone="no" ?>
+<FinalDraft DocumentType="Script" Template="No" Version="5">
+  <Content>
+    <Paragraph Type="New Act"><Text>第1集 相遇</Text></Paragraph>
+    <Paragraph Type="Scene Heading"><Text>INT. CAFE - DAY</Text></Paragraph>
+    <Paragraph Type="Action"><Text>John </Text><Text Style="Bold">sits</Text><Text>.</Text></Paragraph>
+    <Paragraph Type="Character"><Text>JOHN (CONT'D)</Text></Paragraph>
+    <Paragraph Type="Parenthetical"><Text>(quietly)</Text></Paragraph>
+    <Paragraph Type="Dialogue"><Text>Hello.</Text></Paragraph>
+    <Paragraph Type="Transition"><Text>CUT TO:</Text></Paragraph>
+    <Paragraph Type="Action"><Text>第二集</Text></Paragraph>
+    <Paragraph Type="Scene Heading"><Text>外景 街道 夜</Text></Paragraph>
+    <Paragraph Type="Character"><Text>林晓</Text></Paragraph>
+    <Paragraph Type="Dialogue"><Text>你好。</Text></Paragraph>
+    <Paragraph Type="Dialogue"><Text>再见。</Text></Paragraph>
+    <Paragraph Type="Action"><Text>   </Text></Paragraph>
+  </Content>
+  <TitlePage>
+    <Content>
+      <Paragraph Alignment="Center"><Text>测试剧</Text></Paragraph>
+    </Content>
+  </TitlePage>
+</FinalDraft>`)
+
+	sp, err := ParseFDX(data)
+	if err != nil {
+		t.Fatalf("ParseFDX() error = %v", err)
+	}
+	if sp.Title != "测试剧" {
+		t.Errorf("Title = %q, want %q", sp.Title, "测试剧")
+	}
+	if len(sp.Episodes) != 2 {
+		t.Fatalf("len(Episodes) = %d, want 2", len(sp.Episodes))
+	}
+
+	tests := []struct {
+		episode  int
+		number   int
+		title    string
+		heading  string
+		elements []Element
+	}{
+		{
+			episode: 0, number: 1, title: "相遇", heading: "INT. CAFE - DAY",
+			elements: []Element{
+				{Type: ElementAction, Text: "John sits."},
+				{Type: ElementDialogue, Character: "JOHN", Parenthetical: "quietly", Text: "Hello."},
+				{Type: ElementTransition, Text: "CUT TO:"},
+			},
+		},
+		{
+			episode: 1, number: 2, title: "", heading: "外景 街道 夜",
+			elements: []Element{
+				{Type: ElementDialogue, Character: "林晓", Text: "你好。 再见。"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		ep := sp.Episodes[tt.episode]
+		if ep.Number != tt.number || ep.Title != tt.title {
+			t.Errorf("episode %d = (%d, %q), want (%d, %q)", tt.episode, ep.Number, ep.Title, tt.number, tt.title)
+		}
+		if len(ep.Scenes) != 1 {
+			t.Fatalf("episode %d has %d scenes, want 1", tt.episode, len(ep.Scenes))
+		}
+		if ep.Scenes[0].Heading != tt.heading {
+			t.Errorf("episode %d heading = %q, want %q", tt.episode, ep.Scenes[0].Heading, tt.heading)
+		}
+		if !reflect.DeepEqual(ep.Scenes[0].Elements, tt.elements) {
+			t.Errorf("episode %d elements = %+v, want %+v", tt.episode, ep.Scenes[0].Elements, tt.elements)
+		}
+	}
+}
+
+func TestParseFDXInvalid(t *testing.T) {
+	if _, err := ParseFDX([]byte("not xml")); err == nil {
+		t.Error("ParseFDX() error = nil, want error for invalid document")
+	}
+}
+
+func TestFDXRoundTrip(t *testing.T) {
+	want := roundTripScreenplay()
+	data, err := WriteFDX(want)
+	if err != nil {
+		t.Fatalf("WriteFDX() error = %v", err)
+	}
+	got, err := ParseFDX(data)
+	if err != nil {
+		t.Fatalf("ParseFDX() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseFDX(WriteFDX()) =\n%+v\nwant\n%+v", got, want)
+	}
+}
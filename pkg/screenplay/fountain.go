@@ -0,0 +1,221 @@
+package screenplay
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	fountainBoneyardPattern  = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	fountainNotePattern      = regexp.MustCompile(`(?s)\[\[.*?\]\]`)
+	fountainTitleKeyPattern  = regexp.MustCompile(`^([A-Za-z][A-Za-z ]*):\s*(.*)$`)
+	fountainTransitionSuffix = regexp.MustCompile(`TO:$`)
+)
+
+// ParseFountain 解析 Fountain 纯文本剧本
+// 一级章节（# 第1集 / # Episode 1）分集；中文角色名不是大写字母，需使用 @角色名 强制标记
+func ParseFountain(text string) *Screenplay {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = fountainBoneyardPattern.ReplaceAllString(text, "")
+	text = fountainNotePattern.ReplaceAllString(text, "")
+	lines := strings.Split(text, "\n")
+
+	b := newBuilder()
+	start := parseFountainTitlePage(lines, b.sp)
+
+	var action []string
+	flushAction := func() {
+		if len(action) > 0 {
+			b.add(Element{Type: ElementAction, Text: stripEmphasis(strings.Join(action, "\n"))})
+			action = nil
+		}
+	}
+
+	for i := start; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+		trimmed := strings.TrimSpace(line)
+		blankBefore := i == start || strings.TrimSpace(lines[i-1]) == ""
+		blankAfter := i+1 >= len(lines) || strings.TrimSpace(lines[i+1]) == ""
+
+		switch {
+		case trimmed == "":
+			flushAction()
+			continue
+		case strings.HasPrefix(trimmed, "#"):
+			flushAction()
+			if ep, ok := newEpisode(strings.TrimSpace(strings.TrimLeft(trimmed, "#"))); ok {
+				b.startEpisode(ep)
+			}
+			continue
+		case strings.HasPrefix(trimmed, "==="), strings.HasPrefix(trimmed, "="):
+			// 分页符与梗概不属于正文
+			continue
+		case strings.HasPrefix(trimmed, ".") && !strings.HasPrefix(trimmed, ".."):
+			flushAction()
+			b.startScene(trimmed[1:])
+			continue
+		case blankBefore && isFountainSceneHeading(trimmed):
+			flushAction()
+			b.startScene(trimmed)
+			continue
+		case strings.HasPrefix(trimmed, ">") && strings.HasSuffix(trimmed, "<"):
+			// 居中文字按动作处理
+			action = append(action, strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(trimmed, ">"), "<")))
+			continue
+		case strings.HasPrefix(trimmed, ">"):
+			flushAction()
+			b.add(Element{Type: ElementTransition, Text: strings.TrimSpace(trimmed[1:])})
+			continue
+		case blankBefore && blankAfter && isUpperLine(trimmed) && fountainTransitionSuffix.MatchString(trimmed):
+			flushAction()
+			b.add(Element{Type: ElementTransition, Text: trimmed})
+			continue
+		case strings.HasPrefix(trimmed, "!"):
+			action = append(action, trimmed[1:])
+			continue
+		case strings.HasPrefix(trimmed, "~"):
+			action = append(action, strings.TrimSpace(trimmed[1:]))
+			continue
+		}
+
+		if blankBefore && !blankAfter && (strings.HasPrefix(trimmed, "@") || isUpperLine(trimmed)) {
+			flushAction()
+			i = parseFountainDialogue(lines, i, b)
+			continue
+		}
+
+		action = append(action, trimmed)
+	}
+	flushAction()
+
+	return b.result()
+}
+
+// parseFountainTitlePage 解析标题页的 Title 字段，返回正文起始行
+func parseFountainTitlePage(lines []string, sp *Screenplay) int {
+	i := 0
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	if i >= len(lines) || !fountainTitleKeyPattern.MatchString(lines[i]) {
+		return 0
+	}
+
+	key := ""
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" {
+			return i + 1
+		}
+		if match := fountainTitleKeyPattern.FindStringSubmatch(line); match != nil && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			key = strings.ToLower(match[1])
+			if key == "title" && match[2] != "" {
+				sp.Title = stripEmphasis(match[2])
+			}
+			continue
+		}
+		// 缩进的续行
+		if key == "title" && sp.Title == "" {
+			sp.Title = stripEmphasis(line)
+		}
+	}
+	return i
+}
+
+// isFountainSceneHeading INT./EXT. 开头，或中文 内景/外景 开头（可带场号）
+func isFountainSceneHeading(line string) bool {
+	rest := sceneNumberPattern.ReplaceAllString(line, "")
+	return englishHeadingPattern.MatchString(rest) || chineseHeadingPattern.MatchString(rest)
+}
+
+// parseFountainDialogue 解析角色名之后的对白块，返回块最后一行的下标
+func parseFountainDialogue(lines []string, i int, b *builder) int {
+	name := strings.TrimSpace(lines[i])
+	name = characterName(strings.TrimPrefix(name, "@"))
+
+	var text []string
+	parenthetical := ""
+	flush := func() {
+		if len(text) > 0 {
+			b.add(Element{Type: ElementDialogue, Character: name, Parenthetical: parenthetical, Text: stripEmphasis(strings.Join(text, " "))})
+			text = nil
+			parenthetical = ""
+		}
+	}
+
+	for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+		i++
+		line := strings.TrimSpace(lines[i])
+		if isParenthetical(line) {
+			flush()
+			parenthetical = trimParens(line)
+			continue
+		}
+		text = append(text, strings.TrimPrefix(line, "~"))
+	}
+	flush()
+	return i
+}
+
+func isParenthetical(line string) bool {
+	return (strings.HasPrefix(line, "(") && strings.HasSuffix(line, ")")) ||
+		(strings.HasPrefix(line, "（") && strings.HasSuffix(line, "）"))
+}
+
+func trimParens(line string) string {
+	line = strings.TrimSuffix(strings.TrimSuffix(line, ")"), "）")
+	return strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(line, "("), "（"))
+}
+
+// WriteFountain 导出 Fountain 剧本，非大写的角色名与非 INT./EXT. 的场景标题使用强制标记
+func WriteFountain(sp *Screenplay) string {
+	var sb strings.Builder
+	if sp.Title != "" {
+		sb.WriteString("Title: " + sp.Title + "\n\n")
+	}
+
+	for _, ep := range sp.Episodes {
+		if heading := ep.Heading(); heading != "" {
+			sb.WriteString("# " + heading + "\n\n")
+		}
+		for _, scene := range ep.Scenes {
+			if scene.Heading != "" {
+				if isFountainSceneHeading(scene.Heading) {
+					sb.WriteString(scene.Heading + "\n\n")
+				} else {
+					sb.WriteString("." + scene.Heading + "\n\n")
+				}
+			}
+			for _, el := range scene.Elements {
+				sb.WriteString(fountainElement(el) + "\n\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+func fountainElement(el Element) string {
+	switch {
+	case el.Type == ElementDialogue && el.Character != "":
+		name := el.Character
+		if !isUpperLine(name) {
+			name = "@" + name
+		}
+		lines := []string{name}
+		if el.Parenthetical != "" {
+			lines = append(lines, "("+el.Parenthetical+")")
+		}
+		return strings.Join(append(lines, el.Text), "\n")
+	case el.Type == ElementTransition:
+		if isUpperLine(el.Text) && fountainTransitionSuffix.MatchString(el.Text) {
+			return el.Text
+		}
+		return "> " + el.Text
+	default:
+		// 全大写、形似场景标题或以标记符开头的动作会被误认为其他段落，强制为动作
+		if isUpperLine(el.Text) || isFountainSceneHeading(el.Text) || strings.IndexAny(el.Text, "#.@>=~!") == 0 {
+			return "!" + el.Text
+		}
+		return el.Text
+	}
+}
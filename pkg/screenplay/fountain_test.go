@@ -0,0 +1,176 @@
+package screenplay
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFountainElements(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []Element
+	}{
+		{
+			name: "大写角色名与表演提示",
+			body: "JOHN\n(quietly)\nHello.",
+			want: []Element{{Type: ElementDialogue, Character: "JOHN", Parenthetical: "quietly", Text: "Hello."}},
+		},
+		{
+			name: "中文角色名使用强制标记",
+			body: "@林晓\n你好。",
+			want: []Element{{Type: ElementDialogue, Character: "林晓", Text: "你好。"}},
+		},
+		{
+			name: "角色名扩展标记被去掉",
+			body: "JOHN (V.O.)\nI remember.",
+			want: []Element{{Type: ElementDialogue, Character: "JOHN", Text: "I remember."}},
+		},
+		{
+			name: "多行台词合并为一段",
+			body: "JOHN\nFirst line\nsecond line.",
+			want: []Element{{Type: ElementDialogue, Character: "JOHN", Text: "First line second line."}},
+		},
+		{
+			name: "台词中间的表演提示拆分段落",
+			body: "JOHN\nWait.\n(beat)\nGo.",
+			want: []Element{
+				{Type: ElementDialogue, Character: "JOHN", Text: "Wait."},
+				{Type: ElementDialogue, Character: "JOHN", Parenthetical: "beat", Text: "Go."},
+			},
+		},
+		{
+			name: "TO: 结尾的大写行是转场",
+			body: "CUT TO:",
+			want: []Element{{Type: ElementTransition, Text: "CUT TO:"}},
+		},
+		{
+			name: "强制转场",
+			body: "> 淡出",
+			want: []Element{{Type: ElementTransition, Text: "淡出"}},
+		},
+		{
+			name: "强制动作",
+			body: "!JOHN ENTERS.\nHe sits.",
+			want: []Element{{Type: ElementAction, Text: "JOHN ENTERS.\nHe sits."}},
+		},
+		{
+			name: "注释与备注被忽略",
+			body: "John sits. [[note]]\n\n/* 删掉的段落 */",
+			want: []Element{{Type: ElementAction, Text: "John sits."}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sceneElements(t, ParseFountain("INT. CAFE - DAY\n\n"+tt.body+"\n"))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFountain(%q) elements = %+v, want %+v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFountainStructure(t *testing.T) {
+	text := "Title: 测试剧\nAuthor: 编剧\n\n# 第1集 相遇\n\nINT. CAFE - DAY\n\nJohn sits.\n\n.咖啡馆 夜\n\n@林晓\n你好。\n\n# 第2集\n\n内景 街道 - 日\n\n张伟走过。\n"
+	sp := ParseFountain(text)
+
+	if sp.Title != "测试剧" {
+		t.Errorf("Title = %q, want %q", sp.Title, "测试剧")
+	}
+	if len(sp.Episodes) != 2 {
+		t.Fatalf("len(Episodes) = %d, want 2", len(sp.Episodes))
+	}
+
+	tests := []struct {
+		episode, scene int
+		heading        string
+		interior       string
+		location       string
+		time           string
+	}{
+		{0, 0, "INT. CAFE - DAY", "INT", "CAFE", "DAY"},
+		{0, 1, "咖啡馆 夜", "", "咖啡馆", "夜"},
+		{1, 0, "内景 街道 - 日", "内", "街道", "日"},
+	}
+	for _, tt := range tests {
+		ep := sp.Episodes[tt.episode]
+		if tt.scene >= len(ep.Scenes) {
+			t.Fatalf("episode %d has %d scenes, want more than %d", tt.episode, len(ep.Scenes), tt.scene)
+		}
+		scene := ep.Scenes[tt.scene]
+		if scene.Heading != tt.heading || scene.Interior != tt.interior || scene.Location != tt.location || scene.Time != tt.time {
+			t.Errorf("episode %d scene %d = (%q, %q, %q, %q), want (%q, %q, %q, %q)", tt.episode, tt.scene,
+				scene.Heading, scene.Interior, scene.Location, scene.Time, tt.heading, tt.interior, tt.location, tt.time)
+		}
+	}
+	if sp.Episodes[0].Number != 1 || sp.Episodes[0].Title != "相遇" || sp.Episodes[1].Number != 2 {
+		t.Errorf("episodes = (%d, %q), (%d, %q)", sp.Episodes[0].Number, sp.Episodes[0].Title, sp.Episodes[1].Number, sp.Episodes[1].Title)
+	}
+}
+
+// roundTripScreenplay 覆盖各类需要强制标记的段落
+func roundTripScreenplay() *Screenplay {
+	scene := func(heading string, elements ...Element) Scene {
+		s := newScene(heading)
+		s.Elements = elements
+		return s
+	}
+	return &Screenplay{
+		Title: "测试剧",
+		Episodes: []Episode{
+			{Number: 1, Title: "相遇", Scenes: []Scene{
+				scene("INT. CAFE - DAY",
+					Element{Type: ElementAction, Text: "John sits."},
+					Element{Type: ElementAction, Text: "BOOM!"},
+					Element{Type: ElementAction, Text: ".45 口径的枪躺在桌上。"},
+					Element{Type: ElementDialogue, Character: "JOHN", Parenthetical: "quietly", Text: "Hello."},
+					Element{Type: ElementTransition, Text: "CUT TO:"},
+				),
+				scene("内景 咖啡馆 - 夜",
+					Element{Type: ElementDialogue, Character: "林晓", Text: "你好。"},
+					Element{Type: ElementAction, Text: "INT. 这不是场景标题"},
+					Element{Type: ElementTransition, Text: "淡出"},
+				),
+			}},
+			{Number: 2, Title: "重逢", Scenes: []Scene{
+				scene("咖啡馆 日",
+					Element{Type: ElementDialogue, Character: "张伟", Parenthetical: "微笑", Text: "好久不见。"},
+				),
+			}},
+		},
+	}
+}
+
+func TestFountainRoundTrip(t *testing.T) {
+	want := roundTripScreenplay()
+	got := ParseFountain(WriteFountain(want))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseFountain(WriteFountain()) =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestFountainElement(t *testing.T) {
+	tests := []struct {
+		name string
+		el   Element
+		want string
+	}{
+		{"小写角色名加强制标记", Element{Type: ElementDialogue, Character: "林晓", Text: "你好。"}, "@林晓\n你好。"},
+		{"大写角色名不加标记", Element{Type: ElementDialogue, Character: "JOHN", Parenthetical: "beat", Text: "Hi."}, "JOHN\n(beat)\nHi."},
+		{"标准转场", Element{Type: ElementTransition, Text: "CUT TO:"}, "CUT TO:"},
+		{"非标准转场加强制标记", Element{Type: ElementTransition, Text: "淡出"}, "> 淡出"},
+		{"全大写动作加强制标记", Element{Type: ElementAction, Text: "BOOM!"}, "!BOOM!"},
+		{"以标记符开头的动作加强制标记", Element{Type: ElementAction, Text: "@ 符号"}, "!@ 符号"},
+		{"普通动作", Element{Type: ElementAction, Text: "他坐下。"}, "他坐下。"},
+		{"空动作", Element{Type: ElementAction}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fountainElement(tt.el); got != tt.want {
+				t.Errorf("fountainElement(%+v) = %q, want %q", tt.el, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package screenplay
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// markdownDialoguePattern **角色**：台词 / 角色（情绪）：台词 / JOHN: line
+	markdownDialoguePattern = regexp.MustCompile(`^\*{0,2}([^*：:（(，。！？,.!?"“”]{1,20}?)\*{0,2}\s*(?:[（(]([^）)]*)[）)])?\s*\*{0,2}[：:]\*{0,2}\s*(.+)$`)
+	markdownListPattern     = regexp.MustCompile(`^(?:[-*+]|\d+[.)])\s+`)
+	// quotedParentheticalPattern 剧集正文中的 “台词”（表演提示）
+	quotedParentheticalPattern = regexp.MustCompile(`^[“"](.+)[”"]\s*[（(]([^）)]*)[）)]$`)
+	dialogueQuotes             = strings.NewReplacer("“", "", "”", "", "「", "", "」", "", "\"", "")
+)
+
+// markdownMetaKeys 场景说明中常见的 键：值 字段，不是对白
+var markdownMetaKeys = map[string]bool{
+	"时间": true, "地点": true, "场景": true, "场次": true, "人物": true, "出场人物": true, "角色": true,
+	"天气": true, "道具": true, "服装": true, "时长": true, "景别": true, "镜头": true, "画面": true,
+	"音效": true, "配乐": true, "备注": true, "简介": true, "梗概": true, "剧情": true, "标题": true,
+	"TIME": true, "LOCATION": true, "SETTING": true, "SCENE": true, "CAST": true, "CHARACTERS": true, "NOTE": true, "NOTES": true,
+}
+
+// ParseMarkdown 解析结构化 Markdown 剧本
+// # 剧名，## 第1集 标题，### 内景 咖啡馆 - 日；对白写作 角色：台词，> 开头为转场
+func ParseMarkdown(text string) *Screenplay {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	b := newBuilder()
+
+	var action []string
+	flushAction := func() {
+		if len(action) > 0 {
+			b.add(Element{Type: ElementAction, Text: stripEmphasis(strings.Join(action, "\n"))})
+			action = nil
+		}
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "---" || trimmed == "***" {
+			flushAction()
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "#") {
+			flushAction()
+			level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+			parseMarkdownHeading(b, level, stripEmphasis(strings.TrimLeft(trimmed, "# ")))
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, ">") {
+			flushAction()
+			b.add(Element{Type: ElementTransition, Text: stripEmphasis(strings.TrimLeft(trimmed, "> "))})
+			continue
+		}
+
+		trimmed = markdownListPattern.ReplaceAllString(trimmed, "")
+		if isFountainSceneHeading(stripEmphasis(trimmed)) {
+			flushAction()
+			b.startScene(stripEmphasis(trimmed))
+			continue
+		}
+
+		if match := markdownDialoguePattern.FindStringSubmatch(trimmed); match != nil && !markdownMetaKeys[strings.ToUpper(characterName(match[1]))] {
+			flushAction()
+			el := Element{Type: ElementDialogue, Character: characterName(match[1]), Parenthetical: strings.TrimSpace(match[2])}
+			text := stripEmphasis(match[3])
+			if quoted := quotedParentheticalPattern.FindStringSubmatch(text); quoted != nil && el.Parenthetical == "" {
+				text, el.Parenthetical = quoted[1], strings.TrimSpace(quoted[2])
+			}
+			el.Text = dialogueQuotes.Replace(text)
+			b.add(el)
+			continue
+		}
+
+		action = append(action, trimmed)
+	}
+	flushAction()
+
+	return b.result()
+}
+
+// parseMarkdownHeading 一级标题为剧名，二级标题为集，三级及以下为场景
+func parseMarkdownHeading(b *builder, level int, heading string) {
+	if heading == "" {
+		return
+	}
+	if ep, ok := newEpisode(heading); ok {
+		b.startEpisode(ep)
+		return
+	}
+	switch {
+	case level >= 3 || isFountainSceneHeading(heading):
+		b.startScene(heading)
+	case level == 2:
+		b.startEpisode(Episode{Title: heading})
+	case b.sp.Title == "":
+		b.sp.Title = heading
+	}
+}
@@ -0,0 +1,128 @@
+package screenplay
+
+import (
+	"reflect"
+	"testing"
+)
+
+// sceneElements 返回第一集第一场的段落
+func sceneElements(t *testing.T, sp *Screenplay) []Element {
+	t.Helper()
+	if len(sp.Episodes) == 0 || len(sp.Episodes[0].Scenes) == 0 {
+		t.Fatalf("no scene parsed: %+v", sp)
+	}
+	return sp.Episodes[0].Scenes[0].Elements
+}
+
+func TestParseMarkdownElements(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []Element
+	}{
+		{
+			name: "加粗说话人与中文引号",
+			line: "**林晓**：“你来了。”",
+			want: []Element{{Type: ElementDialogue, Character: "林晓", Text: "你来了。"}},
+		},
+		{
+			name: "说话人后的表演提示",
+			line: "张伟（微笑）：好久不见。",
+			want: []Element{{Type: ElementDialogue, Character: "张伟", Parenthetical: "微笑", Text: "好久不见。"}},
+		},
+		{
+			name: "台词后的表演提示",
+			line: "张伟：“好久不见。”（低声）",
+			want: []Element{{Type: ElementDialogue, Character: "张伟", Parenthetical: "低声", Text: "好久不见。"}},
+		},
+		{
+			name: "列表项中的英文对白",
+			line: "- JOHN: Hello there",
+			want: []Element{{Type: ElementDialogue, Character: "JOHN", Text: "Hello there"}},
+		},
+		{
+			name: "场景说明字段不是对白",
+			line: "时间：傍晚",
+			want: []Element{{Type: ElementAction, Text: "时间：傍晚"}},
+		},
+		{
+			name: "加粗的场景说明字段不是对白",
+			line: "**地点**：咖啡馆",
+			want: []Element{{Type: ElementAction, Text: "地点：咖啡馆"}},
+		},
+		{
+			name: "英文场景说明字段不是对白",
+			line: "Location: Cafe",
+			want: []Element{{Type: ElementAction, Text: "Location: Cafe"}},
+		},
+		{
+			name: "转场",
+			line: "> 切至",
+			want: []Element{{Type: ElementTransition, Text: "切至"}},
+		},
+		{
+			name: "动作",
+			line: "林晓推门而入，环顾四周。",
+			want: []Element{{Type: ElementAction, Text: "林晓推门而入，环顾四周。"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sceneElements(t, ParseMarkdown("### 内景 咖啡馆 - 日\n\n"+tt.line+"\n"))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseMarkdown(%q) elements = %+v, want %+v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMarkdownStructure(t *testing.T) {
+	text := "# 剧名\n\n## 第1集 相遇\n\n### 内景 咖啡馆 - 日\n\n林晓：你好。\n\n## 第二集\n\n### 外景 街道 夜\n\n张伟：再见。\n"
+	sp := ParseMarkdown(text)
+
+	if sp.Title != "剧名" {
+		t.Errorf("Title = %q, want %q", sp.Title, "剧名")
+	}
+	if len(sp.Episodes) != 2 {
+		t.Fatalf("len(Episodes) = %d, want 2", len(sp.Episodes))
+	}
+
+	tests := []struct {
+		episode  int
+		number   int
+		title    string
+		heading  string
+		interior string
+		location string
+		time     string
+	}{
+		{0, 1, "相遇", "内景 咖啡馆 - 日", "内", "咖啡馆", "日"},
+		{1, 2, "", "外景 街道 夜", "外", "街道", "夜"},
+	}
+	for _, tt := range tests {
+		ep := sp.Episodes[tt.episode]
+		if ep.Number != tt.number || ep.Title != tt.title {
+			t.Errorf("episode %d = (%d, %q), want (%d, %q)", tt.episode, ep.Number, ep.Title, tt.number, tt.title)
+		}
+		if len(ep.Scenes) != 1 {
+			t.Fatalf("episode %d has %d scenes, want 1", tt.episode, len(ep.Scenes))
+		}
+		scene := ep.Scenes[0]
+		if scene.Heading != tt.heading || scene.Interior != tt.interior || scene.Location != tt.location || scene.Time != tt.time {
+			t.Errorf("episode %d scene = (%q, %q, %q, %q), want (%q, %q, %q, %q)", tt.episode,
+				scene.Heading, scene.Interior, scene.Location, scene.Time, tt.heading, tt.interior, tt.location, tt.time)
+		}
+	}
+}
+
+func TestParseMarkdownCharacters(t *testing.T) {
+	text := "### 内景 咖啡馆 - 日\n\n时间：傍晚\n人物：林晓、张伟\n\n林晓：你好。\n旁白：那一年，他们初次相遇。\n张伟：好久不见。\n林晓：嗯。\n"
+	sp := ParseMarkdown(text)
+
+	got := sp.Episodes[0].Characters()
+	want := []string{"林晓", "张伟"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Characters() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,289 @@
+package screenplay
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// 行业剧本排版：US Letter，12磅字，每行12磅，每页54行；单位为磅（1英寸=72磅）
+const (
+	pdfPageWidth      = 612.0
+	pdfPageHeight     = 792.0
+	pdfTopMargin      = 72.0
+	pdfLineHeight     = 12.0
+	pdfLinesPerPage   = 54
+	pdfLeft           = 108.0 // 场景标题与动作 1.5英寸
+	pdfRight          = 540.0 // 右边距 1英寸
+	pdfCharacterX     = 266.4 // 角色名 3.7英寸
+	pdfParentheticalX = 223.2 // 表演提示 3.1英寸
+	pdfDialogueX      = 180.0 // 对白 2.5英寸
+	pdfDialogueWidth  = 252.0 // 对白宽 3.5英寸
+	pdfParenWidth     = 180.0
+	pdfLatinWidth     = 7.2  // Courier 12磅的字宽
+	pdfWideWidth      = 12.0 // 中文等全角字符的字宽
+)
+
+// pdfNoBreakBefore 不能出现在行首的标点
+const pdfNoBreakBefore = "，。、；：？！”’）》」』…,.;:?!)"
+
+type pdfLine struct {
+	x    float64
+	text string
+}
+
+// pdfLayout 按页累积排版好的行
+type pdfLayout struct {
+	pages      [][]pdfLine
+	titlePages int
+}
+
+func (l *pdfLayout) newPage() {
+	l.pages = append(l.pages, nil)
+}
+
+func (l *pdfLayout) remaining() int {
+	if len(l.pages) == 0 {
+		return 0
+	}
+	return pdfLinesPerPage - len(l.pages[len(l.pages)-1])
+}
+
+func (l *pdfLayout) line(x float64, text string) {
+	if l.remaining() == 0 {
+		l.newPage()
+	}
+	l.pages[len(l.pages)-1] = append(l.pages[len(l.pages)-1], pdfLine{x: x, text: text})
+}
+
+// blank 段落间空行，页首不留空行
+func (l *pdfLayout) blank() {
+	if l.remaining() > 0 && l.remaining() < pdfLinesPerPage {
+		l.line(0, "")
+	}
+}
+
+// keep 剩余行数不足时换页，避免标题与正文、角色名与对白分离
+func (l *pdfLayout) keep(lines int) {
+	if l.remaining() < lines {
+		l.newPage()
+	}
+}
+
+func (l *pdfLayout) block(x, width float64, text string) {
+	for _, line := range wrapText(text, width) {
+		l.line(x, line)
+	}
+}
+
+func (l *pdfLayout) centered(text string) {
+	for _, line := range wrapText(text, pdfRight-pdfLeft) {
+		l.line((pdfPageWidth-textWidth(line))/2, line)
+	}
+}
+
+// WritePDF 导出行业排版的剧本 PDF：标题页之后每集另起一页，
+// 拉丁字符使用 Courier，中文使用阅读器内置的 STSong-Light，不嵌入字体
+func WritePDF(sp *Screenplay) []byte {
+	layout := layoutScreenplay(sp)
+
+	var buf bytes.Buffer
+	var offsets []int
+	writeObject := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1 目录，2 页面树，3-6 字体，之后每页依次为页面对象与内容流
+	kids := make([]string, len(layout.pages))
+	for i := range layout.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 7+2*i)
+	}
+	writeObject("<< /Type /Catalog /Pages 2 0 R >>")
+	writeObject(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	writeObject("<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H /DescendantFonts [5 0 R] >>")
+	writeObject("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light " +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >> /FontDescriptor 6 0 R /DW 1000 >>")
+	writeObject("<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880] " +
+		"/ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>")
+
+	for i, lines := range layout.pages {
+		var content strings.Builder
+		for row, line := range lines {
+			writePDFText(&content, line.x, pdfPageHeight-pdfTopMargin-float64(row)*pdfLineHeight, line.text)
+		}
+		// 页码在右上角，标题页与正文第一页不标
+		if number := i - layout.titlePages + 1; number > 1 {
+			label := fmt.Sprintf("%d.", number)
+			writePDFText(&content, pdfRight-textWidth(label), pdfPageHeight-36, label)
+		}
+
+		writeObject(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %g %g] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, len(offsets)+2))
+		writeObject(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes()
+}
+
+// layoutScreenplay 按剧本格式排版各段落
+func layoutScreenplay(sp *Screenplay) *pdfLayout {
+	layout := &pdfLayout{}
+	if sp.Title != "" {
+		layout.newPage()
+		for i := 0; i < pdfLinesPerPage/3; i++ {
+			layout.line(0, "")
+		}
+		layout.centered(sp.Title)
+		layout.titlePages = 1
+	}
+
+	for _, ep := range sp.Episodes {
+		layout.newPage()
+		if heading := ep.Heading(); heading != "" {
+			layout.centered(heading)
+		}
+		for _, scene := range ep.Scenes {
+			if scene.Heading != "" {
+				layout.keep(4)
+				layout.blank()
+				layout.block(pdfLeft, pdfRight-pdfLeft, strings.ToUpper(scene.Heading))
+			}
+			for _, el := range scene.Elements {
+				layoutElement(layout, el)
+			}
+		}
+	}
+
+	if len(layout.pages) == 0 {
+		layout.newPage()
+	}
+	return layout
+}
+
+func layoutElement(layout *pdfLayout, el Element) {
+	switch {
+	case el.Type == ElementDialogue && el.Character != "":
+		name := strings.ToUpper(el.Character)
+		var parenthetical []string
+		if el.Parenthetical != "" {
+			parenthetical = wrapText("("+el.Parenthetical+")", pdfParenWidth)
+		}
+		dialogue := wrapText(el.Text, pdfDialogueWidth)
+
+		layout.keep(2 + len(parenthetical) + min(len(dialogue), 2))
+		layout.blank()
+		layout.line(pdfCharacterX, name)
+		for _, line := range parenthetical {
+			layout.line(pdfParentheticalX, line)
+		}
+		for i, line := range dialogue {
+			// 对白跨页时在页尾标注 (MORE)，下一页重复角色名
+			if layout.remaining() == 1 && i < len(dialogue)-1 {
+				layout.line(pdfCharacterX, "(MORE)")
+				layout.newPage()
+				layout.line(pdfCharacterX, name+" (CONT'D)")
+			}
+			layout.line(pdfDialogueX, line)
+		}
+	case el.Type == ElementTransition:
+		layout.keep(2)
+		layout.blank()
+		for _, line := range wrapText(strings.ToUpper(el.Text), pdfRight-pdfLeft) {
+			layout.line(pdfRight-textWidth(line), line)
+		}
+	default:
+		layout.keep(2)
+		layout.blank()
+		layout.block(pdfLeft, pdfRight-pdfLeft, el.Text)
+	}
+}
+
+// wrapText 按宽度折行：中文可在任意字间断开，英文在空格处断开，避头标点留在上一行
+func wrapText(text string, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\t", " "), "\n") {
+		runes := []rune(strings.TrimSpace(paragraph))
+		for len(runes) > 0 {
+			cut := len(runes)
+			lastSpace := -1
+			w := 0.0
+			for i, r := range runes {
+				if r == ' ' {
+					lastSpace = i
+				}
+				w += runeWidth(r)
+				if w <= width || i == 0 {
+					continue
+				}
+				cut = i
+				for cut < len(runes) && strings.ContainsRune(pdfNoBreakBefore, runes[cut]) {
+					cut++
+				}
+				if cut < len(runes) && lastSpace > 0 && isLatinLetter(runes[cut]) && isLatinLetter(runes[cut-1]) {
+					cut = lastSpace
+				}
+				break
+			}
+			lines = append(lines, strings.TrimSpace(string(runes[:cut])))
+			runes = []rune(strings.TrimSpace(string(runes[cut:])))
+		}
+	}
+	return lines
+}
+
+func isLatinLetter(r rune) bool {
+	return r < 0x80 && r != ' '
+}
+
+func runeWidth(r rune) float64 {
+	if r < 0x80 {
+		return pdfLatinWidth
+	}
+	return pdfWideWidth
+}
+
+func textWidth(text string) float64 {
+	w := 0.0
+	for _, r := range text {
+		w += runeWidth(r)
+	}
+	return w
+}
+
+// writePDFText 按字符类别分段输出：ASCII 使用 Courier（F1），其余使用 UCS-2 编码的中文字体（F2）
+func writePDFText(sb *strings.Builder, x, y float64, text string) {
+	runes := []rune(text)
+	for start := 0; start < len(runes); {
+		latin := runes[start] < 0x80
+		end := start
+		for end < len(runes) && (runes[end] < 0x80) == latin {
+			end++
+		}
+		run := runes[start:end]
+		if latin {
+			escaped := strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(string(run))
+			fmt.Fprintf(sb, "BT /F1 12 Tf %.2f %.2f Td (%s) Tj ET\n", x, y, escaped)
+		} else {
+			var hex strings.Builder
+			for _, r := range run {
+				if r > 0xFFFF {
+					r = '?'
+				}
+				fmt.Fprintf(&hex, "%04X", r)
+			}
+			fmt.Fprintf(sb, "BT /F2 12 Tf %.2f %.2f Td <%s> Tj ET\n", x, y, hex.String())
+		}
+		x += textWidth(string(run))
+		start = end
+	}
+}
@@ -0,0 +1,146 @@
+package screenplay
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width float64
+		want  []string
+	}{
+		{"未超宽不折行", "Hello world", 100, []string{"Hello world"}},
+		{"英文在空格处断开", "Hello world again", 12 * pdfLatinWidth, []string{"Hello world", "again"}},
+		{"中文可在任意字间断开", "一二三四五六七", 3 * pdfWideWidth, []string{"一二三", "四五六", "七"}},
+		{"避头标点留在上一行", "一二三。四五", 3 * pdfWideWidth, []string{"一二三。", "四五"}},
+		{"连续避头标点都留在上一行", "一二三。”四五", 3 * pdfWideWidth, []string{"一二三。”", "四五"}},
+		{"中英混排不拆开英文单词", "他说Hello world", 6 * pdfWideWidth, []string{"他说Hello", "world"}},
+		{"单字超宽时仍输出", "一二", 1, []string{"一", "二"}},
+		{"保留原有换行并去掉首尾空白", " 第一行 \n\n第二行", 100, []string{"第一行", "第二行"}},
+		{"空文本", "", 100, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wrapText(tt.text, tt.width); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("wrapText(%q, %g) = %q, want %q", tt.text, tt.width, got, tt.want)
+			}
+		})
+	}
+}
+
+// pageTexts 返回每页的文字行
+func pageTexts(layout *pdfLayout) [][]string {
+	pages := make([][]string, len(layout.pages))
+	for i, lines := range layout.pages {
+		for _, line := range lines {
+			pages[i] = append(pages[i], line.text)
+		}
+	}
+	return pages
+}
+
+func TestLayoutDialoguePageBreak(t *testing.T) {
+	// 对白宽 21 个全角字，84 个字折成 4 行
+	dialogue := strings.Repeat("字", 84)
+
+	tests := []struct {
+		name      string
+		actions   int // 对白前的单行动作数，页首的动作占 1 行，其余连同空行占 2 行
+		wantPages int
+		wantMore  bool
+	}{
+		{"整段对白放得下", 10, 1, false},
+		{"对白跨页时标注 MORE 与 CONT'D", 25, 2, true},
+		{"剩余行数不足时整段换页", 26, 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scene := Scene{}
+			for i := 0; i < tt.actions; i++ {
+				scene.Elements = append(scene.Elements, Element{Type: ElementAction, Text: "动作"})
+			}
+			scene.Elements = append(scene.Elements, Element{Type: ElementDialogue, Character: "林晓", Text: dialogue})
+
+			pages := pageTexts(layoutScreenplay(&Screenplay{Episodes: []Episode{{Scenes: []Scene{scene}}}}))
+			if len(pages) != tt.wantPages {
+				t.Fatalf("pages = %d, want %d", len(pages), tt.wantPages)
+			}
+			for _, page := range pages {
+				if len(page) > pdfLinesPerPage {
+					t.Errorf("page has %d lines, want at most %d", len(page), pdfLinesPerPage)
+				}
+			}
+
+			first, last := pages[0], pages[len(pages)-1]
+			more := first[len(first)-1] == "(MORE)"
+			if more != tt.wantMore {
+				t.Fatalf("last line of first page = %q, want (MORE): %v", first[len(first)-1], tt.wantMore)
+			}
+			if tt.wantMore {
+				if last[0] != "林晓 (CONT'D)" {
+					t.Errorf("first line of next page = %q, want %q", last[0], "林晓 (CONT'D)")
+				}
+				if first[len(first)-2] == "林晓" {
+					t.Error("character name left alone before (MORE)")
+				}
+			}
+
+			// 对白各行都被排出，且顺序不变
+			var lines []string
+			for _, page := range pages {
+				for _, line := range page {
+					if strings.HasPrefix(line, "字") {
+						lines = append(lines, line)
+					}
+				}
+			}
+			if strings.Join(lines, "") != dialogue {
+				t.Errorf("dialogue lines = %q, want all of the dialogue", lines)
+			}
+		})
+	}
+}
+
+func TestLayoutScreenplayPages(t *testing.T) {
+	sp := &Screenplay{
+		Title: "测试剧",
+		Episodes: []Episode{
+			{Number: 1, Title: "相遇", Scenes: []Scene{{Heading: "int. cafe - day", Elements: []Element{{Type: ElementTransition, Text: "cut to:"}}}}},
+			{Number: 2},
+		},
+	}
+	layout := layoutScreenplay(sp)
+	pages := pageTexts(layout)
+
+	if layout.titlePages != 1 || len(pages) != 3 {
+		t.Fatalf("titlePages = %d, pages = %d, want 1 and 3", layout.titlePages, len(pages))
+	}
+	if !reflect.DeepEqual(pages[1], []string{"第1集 相遇", "", "INT. CAFE - DAY", "", "CUT TO:"}) {
+		t.Errorf("episode page = %q", pages[1])
+	}
+	if got := layout.pages[1][4].x; got != pdfRight-textWidth("CUT TO:") {
+		t.Errorf("transition x = %g, want right aligned", got)
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	data := WritePDF(roundTripScreenplay())
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatalf("WritePDF() is not a complete PDF document")
+	}
+	if !bytes.Contains(data, []byte("/Count 3")) {
+		t.Errorf("WritePDF() should contain a title page and one page per episode")
+	}
+
+	empty := WritePDF(&Screenplay{})
+	if !bytes.Contains(empty, []byte("/Count 1")) {
+		t.Errorf("WritePDF() of an empty screenplay should contain one blank page")
+	}
+}
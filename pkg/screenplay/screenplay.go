@@ -0,0 +1,445 @@
+// Package screenplay 剧本格式（Fountain、Final Draft FDX、Markdown）的解析与导出
+// 各格式统一解析为 剧集 → 场 → 段落 的结构，场景标题拆分为内外景、地点与时间；
+// 导出支持 Fountain、FDX 与行业排版的 PDF
+package screenplay
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Format 剧本文件格式
+type Format string
+
+const (
+	FormatFountain Format = "fountain"
+	FormatFDX      Format = "fdx"
+	FormatMarkdown Format = "markdown"
+	FormatPDF      Format = "pdf" // 仅导出
+)
+
+// ElementType 段落类型
+type ElementType string
+
+const (
+	ElementAction     ElementType = "action"
+	ElementDialogue   ElementType = "dialogue"
+	ElementTransition ElementType = "transition"
+)
+
+// Screenplay 解析后的剧本
+type Screenplay struct {
+	Title    string
+	Episodes []Episode
+}
+
+// Episode 一集，Number 为0表示原文未标注集数
+type Episode struct {
+	Number int
+	Title  string
+	Scenes []Scene
+}
+
+// Scene 一场戏，场景标题之前的段落归入 Heading 为空的开场
+type Scene struct {
+	Heading  string
+	Interior string // INT/EXT/INT/EXT，中文剧本为 内/外/内外
+	Location string
+	Time     string
+	Elements []Element
+}
+
+// Element 剧本段落
+type Element struct {
+	Type          ElementType
+	Character     string // 对白的说话人，已去掉 (V.O.) 等扩展标记
+	Parenthetical string // 对白的表演提示
+	Text          string
+}
+
+var (
+	// episodeHeadingPattern 匹配 第1集 标题 / 第一集：标题 / Episode 1 - Title / EP01 Title
+	episodeHeadingPattern = regexp.MustCompile(`(?i)^(?:第\s*([0-9零一二三四五六七八九十百两]+)\s*[集话]|episode\s*(\d+)|ep\.?\s*(\d+))\s*[:：.\-—、]?\s*(.*)$`)
+	// sceneNumberPattern 场景标题前的场号：1. / 1、 / 场1 / 第1场
+	sceneNumberPattern = regexp.MustCompile(`^(?:第?\s*\d+\s*场\s*[:：]?|场\s*\d+\s*[:：]?|\d+\s*[.、:：])\s*`)
+	// sceneSuffixNumberPattern Fountain 场号 #1#
+	sceneSuffixNumberPattern = regexp.MustCompile(`\s*#[^#]*#\s*$`)
+	// englishHeadingPattern INT. / EXT. / INT./EXT. / I/E / EST.
+	englishHeadingPattern = regexp.MustCompile(`(?i)^(int\.?/ext\.?|ext\.?/int\.?|i/e|int\.?|ext\.?|est\.?)\s+(.*)$`)
+	// chineseHeadingPattern 内景 / 外景 / 内外景 / 内 / 外
+	chineseHeadingPattern = regexp.MustCompile(`^(内外景|内景|外景|内/外|内|外)[\s.、:：]+(.*)$`)
+	// characterExtensionPattern 说话人后的扩展标记：(V.O.) (O.S.) (CONT'D) （画外音）
+	characterExtensionPattern = regexp.MustCompile(`\s*[（(][^）)]*[）)]\s*$`)
+)
+
+// timeWords 场景标题末尾可识别的时间
+var timeWords = map[string]bool{
+	"日": true, "夜": true, "晨": true, "昏": true, "黄昏": true, "清晨": true, "早晨": true, "上午": true,
+	"中午": true, "下午": true, "傍晚": true, "夜晚": true, "深夜": true, "凌晨": true, "白天": true, "日/夜": true,
+	"DAY": true, "NIGHT": true, "MORNING": true, "EVENING": true, "AFTERNOON": true, "DAWN": true, "DUSK": true,
+	"LATER": true, "CONTINUOUS": true, "MOMENTS LATER": true, "SAME": true,
+}
+
+// narratorNames 旁白不是角色
+var narratorNames = map[string]bool{"旁白": true, "画外音": true, "NARRATOR": true}
+
+// ParseFormat 解析格式名称
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "fountain", "spmd":
+		return FormatFountain, nil
+	case "fdx", "finaldraft", "final_draft":
+		return FormatFDX, nil
+	case "markdown", "md":
+		return FormatMarkdown, nil
+	case "pdf":
+		return FormatPDF, nil
+	default:
+		return "", fmt.Errorf("unsupported screenplay format: %s", name)
+	}
+}
+
+// Extension 导出文件的扩展名
+func (f Format) Extension() string {
+	if f == FormatMarkdown {
+		return "md"
+	}
+	return string(f)
+}
+
+// ContentType 导出文件的 Content-Type
+func (f Format) ContentType() string {
+	switch f {
+	case FormatFDX:
+		return "application/xml; charset=utf-8"
+	case FormatPDF:
+		return "application/pdf"
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+// DetectFormat 根据文件扩展名判断格式，无法判断时检查内容
+func DetectFormat(filename string, data []byte) Format {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".fdx", ".xml":
+		return FormatFDX
+	case ".md", ".markdown":
+		return FormatMarkdown
+	case ".fountain", ".spmd":
+		return FormatFountain
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("<?xml")) || bytes.HasPrefix(trimmed, []byte("<FinalDraft")) {
+		return FormatFDX
+	}
+	for _, line := range strings.Split(string(trimmed), "\n") {
+		if strings.HasPrefix(line, "## ") || strings.HasPrefix(line, "### ") {
+			return FormatMarkdown
+		}
+	}
+	return FormatFountain
+}
+
+// Parse 按格式解析剧本
+func Parse(format Format, data []byte) (*Screenplay, error) {
+	var (
+		sp  *Screenplay
+		err error
+	)
+	switch format {
+	case FormatFountain:
+		sp = ParseFountain(string(data))
+	case FormatFDX:
+		sp, err = ParseFDX(data)
+	case FormatMarkdown:
+		sp = ParseMarkdown(string(data))
+	default:
+		return nil, fmt.Errorf("unsupported screenplay format: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sp.compact()
+	if len(sp.Episodes) == 0 {
+		return nil, fmt.Errorf("no scenes or dialogue found in screenplay")
+	}
+	return sp, nil
+}
+
+// Write 按格式导出剧本
+func Write(format Format, sp *Screenplay) ([]byte, error) {
+	switch format {
+	case FormatFountain:
+		return []byte(WriteFountain(sp)), nil
+	case FormatFDX:
+		return WriteFDX(sp)
+	case FormatPDF:
+		return WritePDF(sp), nil
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// compact 去掉没有内容的场和集
+func (sp *Screenplay) compact() {
+	episodes := sp.Episodes[:0]
+	for _, ep := range sp.Episodes {
+		scenes := ep.Scenes[:0]
+		for _, scene := range ep.Scenes {
+			if scene.Heading != "" || len(scene.Elements) > 0 {
+				scenes = append(scenes, scene)
+			}
+		}
+		ep.Scenes = scenes
+		if len(ep.Scenes) > 0 {
+			episodes = append(episodes, ep)
+		}
+	}
+	sp.Episodes = episodes
+}
+
+// Characters 本集出场角色，按首次出场顺序
+func (e *Episode) Characters() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, scene := range e.Scenes {
+		for _, el := range scene.Elements {
+			if el.Type != ElementDialogue || el.Character == "" || seen[el.Character] || narratorNames[strings.ToUpper(el.Character)] {
+				continue
+			}
+			seen[el.Character] = true
+			names = append(names, el.Character)
+		}
+	}
+	return names
+}
+
+// Heading 集标题：第1集 标题
+func (e *Episode) Heading() string {
+	if e.Number == 0 {
+		return e.Title
+	}
+	heading := fmt.Sprintf("第%d集", e.Number)
+	if e.Title == "" || e.Title == heading {
+		return heading
+	}
+	return heading + " " + e.Title
+}
+
+// Text 转为剧集剧本正文：对白使用 角色：“台词” 的形式，与分镜对白的解析规则一致
+func (e *Episode) Text() string {
+	var blocks []string
+	for _, scene := range e.Scenes {
+		var lines []string
+		if scene.Heading != "" {
+			lines = append(lines, scene.Heading)
+		}
+		for _, el := range scene.Elements {
+			switch el.Type {
+			case ElementDialogue:
+				line := fmt.Sprintf("%s：“%s”", el.Character, el.Text)
+				if el.Character == "" {
+					line = el.Text
+				}
+				if el.Parenthetical != "" {
+					line += "（" + el.Parenthetical + "）"
+				}
+				lines = append(lines, line)
+			default:
+				lines = append(lines, el.Text)
+			}
+		}
+		blocks = append(blocks, strings.Join(lines, "\n"))
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// newEpisode 从集标题解析集数与标题
+func newEpisode(heading string) (Episode, bool) {
+	match := episodeHeadingPattern.FindStringSubmatch(strings.TrimSpace(heading))
+	if match == nil {
+		return Episode{}, false
+	}
+	var number int
+	switch {
+	case match[1] != "":
+		number = parseChineseNumber(match[1])
+	case match[2] != "":
+		number, _ = strconv.Atoi(match[2])
+	default:
+		number, _ = strconv.Atoi(match[3])
+	}
+	return Episode{Number: number, Title: strings.TrimSpace(match[4])}, true
+}
+
+// newScene 拆分场景标题：场号、内外景、地点、时间
+func newScene(heading string) Scene {
+	heading = strings.TrimSpace(sceneSuffixNumberPattern.ReplaceAllString(heading, ""))
+	scene := Scene{Heading: heading}
+
+	rest := sceneNumberPattern.ReplaceAllString(heading, "")
+	if match := englishHeadingPattern.FindStringSubmatch(rest); match != nil {
+		scene.Interior = strings.ToUpper(strings.TrimSuffix(strings.ReplaceAll(match[1], ".", ""), "/"))
+		rest = match[2]
+	} else if match := chineseHeadingPattern.FindStringSubmatch(rest); match != nil {
+		scene.Interior = strings.TrimSuffix(match[1], "景")
+		rest = match[2]
+	}
+
+	// 中文剧本常把内外景写在末尾：咖啡馆 日 内
+	if scene.Interior == "" {
+		if fields := strings.Fields(rest); len(fields) > 1 && (fields[len(fields)-1] == "内" || fields[len(fields)-1] == "外") {
+			scene.Interior = fields[len(fields)-1]
+			rest = strings.Join(fields[:len(fields)-1], " ")
+		}
+	}
+
+	// 时间在最后一个带空格的破折号之后，或是紧跟分隔符/空格的时间词
+	for _, sep := range []string{" - ", " – ", " — ", "——"} {
+		if idx := strings.LastIndex(rest, sep); idx > 0 {
+			scene.Location = strings.TrimSpace(rest[:idx])
+			scene.Time = strings.TrimSpace(rest[idx+len(sep):])
+			return scene
+		}
+	}
+	if idx := strings.LastIndexAny(rest, "-－，, "); idx > 0 {
+		_, size := utf8.DecodeRuneInString(rest[idx:])
+		if tail := strings.TrimSpace(rest[idx+size:]); timeWords[strings.ToUpper(tail)] {
+			scene.Location = strings.TrimSpace(rest[:idx])
+			scene.Time = tail
+			return scene
+		}
+	}
+	scene.Location = strings.TrimSpace(rest)
+	return scene
+}
+
+// characterName 去掉说话人的扩展标记与双人对白标记
+func characterName(name string) string {
+	name = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(name), "^"))
+	for {
+		stripped := characterExtensionPattern.ReplaceAllString(name, "")
+		if stripped == name || stripped == "" {
+			return name
+		}
+		name = stripped
+	}
+}
+
+// isUpperLine 全大写且至少包含一个字母（Fountain 的角色名与转场）
+func isUpperLine(line string) bool {
+	hasLetter := false
+	for _, r := range line {
+		if unicode.IsLetter(r) {
+			if !unicode.IsUpper(r) {
+				return false
+			}
+			hasLetter = true
+		}
+	}
+	return hasLetter
+}
+
+// stripEmphasis 去掉 Markdown/Fountain 的强调标记
+func stripEmphasis(text string) string {
+	replacer := strings.NewReplacer("***", "", "**", "", "*", "", "_", "")
+	return strings.TrimSpace(replacer.Replace(text))
+}
+
+// parseChineseNumber 解析集数：阿拉伯数字或一百以内的中文数字
+func parseChineseNumber(text string) int {
+	if n, err := strconv.Atoi(text); err == nil {
+		return n
+	}
+	digits := map[rune]int{'零': 0, '一': 1, '二': 2, '两': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9}
+	total, current := 0, 0
+	for _, r := range text {
+		switch r {
+		case '十':
+			if current == 0 {
+				current = 1
+			}
+			total += current * 10
+			current = 0
+		case '百':
+			if current == 0 {
+				current = 1
+			}
+			total += current * 100
+			current = 0
+		default:
+			current = digits[r]
+		}
+	}
+	return total + current
+}
+
+// builder 解析时逐段累积剧集与场次
+type builder struct {
+	sp *Screenplay
+}
+
+func newBuilder() *builder {
+	return &builder{sp: &Screenplay{}}
+}
+
+func (b *builder) episode() *Episode {
+	if len(b.sp.Episodes) == 0 {
+		b.sp.Episodes = append(b.sp.Episodes, Episode{})
+	}
+	return &b.sp.Episodes[len(b.sp.Episodes)-1]
+}
+
+func (b *builder) scene() *Scene {
+	ep := b.episode()
+	if len(ep.Scenes) == 0 {
+		ep.Scenes = append(ep.Scenes, Scene{})
+	}
+	return &ep.Scenes[len(ep.Scenes)-1]
+}
+
+func (b *builder) startEpisode(ep Episode) {
+	// 第一集标题之前只有标题页等内容时不单独成集
+	if len(b.sp.Episodes) == 1 && len(b.sp.Episodes[0].Scenes) == 0 && b.sp.Episodes[0].Number == 0 {
+		b.sp.Episodes[0] = ep
+		return
+	}
+	b.sp.Episodes = append(b.sp.Episodes, ep)
+}
+
+func (b *builder) startScene(heading string) {
+	ep := b.episode()
+	ep.Scenes = append(ep.Scenes, newScene(heading))
+}
+
+func (b *builder) add(el Element) {
+	el.Text = strings.TrimSpace(el.Text)
+	if el.Text == "" {
+		return
+	}
+	scene := b.scene()
+	// 同一说话人连续的对白段落合并
+	if n := len(scene.Elements); n > 0 && el.Type == ElementDialogue && el.Parenthetical == "" {
+		last := &scene.Elements[n-1]
+		if last.Type == ElementDialogue && last.Character == el.Character && el.Character != "" {
+			last.Text += " " + el.Text
+			return
+		}
+	}
+	scene.Elements = append(scene.Elements, el)
+}
+
+func (b *builder) result() *Screenplay {
+	return b.sp
+}
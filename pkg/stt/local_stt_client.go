@@ -0,0 +1,32 @@
+package stt
+
+// SpeechDetector 找出音频中的有声区间
+type SpeechDetector func(audioPath string) ([]Segment, error)
+
+// LocalSTTClient 本地替代实现：不调用任何服务，只通过有声区间检测给出分段时间，文本留空
+// 用于未配置语音识别服务时打通字幕轨道与对白回填流程，文本可在识别结果中手动补充
+type LocalSTTClient struct {
+	detect SpeechDetector
+}
+
+func NewLocalSTTClient(detect SpeechDetector) *LocalSTTClient {
+	return &LocalSTTClient{detect: detect}
+}
+
+func (c *LocalSTTClient) Transcribe(audioPath string, opts ...STTOption) (*Transcript, error) {
+	options := applyOptions(STTOptions{}, opts)
+
+	segments, err := c.detect(audioPath)
+	if err != nil {
+		return nil, err
+	}
+
+	transcript := &Transcript{
+		Language: options.Language,
+		Segments: segments,
+	}
+	if len(segments) > 0 {
+		transcript.Duration = segments[len(segments)-1].End
+	}
+	return transcript, nil
+}
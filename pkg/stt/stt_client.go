@@ -0,0 +1,54 @@
+package stt
+
+// STTClient 语音识别客户端
+type STTClient interface {
+	Transcribe(audioPath string, opts ...STTOption) (*Transcript, error)
+}
+
+// Segment 带时间戳的一段识别结果（秒）
+type Segment struct {
+	Start float64
+	End   float64
+	Text  string
+}
+
+type Transcript struct {
+	Text     string
+	Language string  // 识别出的语言，服务未返回时为空
+	Duration float64 // 音频时长（秒），服务未返回时为0
+	Segments []Segment
+}
+
+type STTOptions struct {
+	Model    string
+	Language string // ISO-639-1 语言代码，为空时由服务自动识别
+	Prompt   string // 提示词：人名、专有名词等，提高识别准确率
+}
+
+type STTOption func(*STTOptions)
+
+func WithModel(model string) STTOption {
+	return func(o *STTOptions) {
+		o.Model = model
+	}
+}
+
+func WithLanguage(language string) STTOption {
+	return func(o *STTOptions) {
+		o.Language = language
+	}
+}
+
+func WithPrompt(prompt string) STTOption {
+	return func(o *STTOptions) {
+		o.Prompt = prompt
+	}
+}
+
+func applyOptions(defaults STTOptions, opts []STTOption) *STTOptions {
+	options := defaults
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &options
+}
@@ -0,0 +1,152 @@
+package stt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// WhisperClient OpenAI兼容的 /audio/transcriptions 接口
+type WhisperClient struct {
+	BaseURL    string
+	APIKey     string
+	Model      string
+	Endpoint   string
+	HTTPClient *http.Client
+}
+
+// WhisperResponse verbose_json 格式的识别结果
+type WhisperResponse struct {
+	Text     string  `json:"text"`
+	Language string  `json:"language"`
+	Duration float64 `json:"duration"`
+	Segments []struct {
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+		Text  string  `json:"text"`
+	} `json:"segments"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+func NewWhisperClient(baseURL, apiKey, model, endpoint string) *WhisperClient {
+	if endpoint == "" {
+		endpoint = "/audio/transcriptions"
+	}
+	if model == "" {
+		model = "whisper-1"
+	}
+	return &WhisperClient{
+		BaseURL:  baseURL,
+		APIKey:   apiKey,
+		Model:    model,
+		Endpoint: endpoint,
+		HTTPClient: &http.Client{
+			Timeout: 10 * time.Minute,
+		},
+	}
+}
+
+func (c *WhisperClient) Transcribe(audioPath string, opts ...STTOption) (*Transcript, error) {
+	options := applyOptions(STTOptions{Model: c.Model}, opts)
+
+	body, contentType, err := c.buildForm(audioPath, options)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", c.BaseURL+c.Endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	var result WhisperResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("parse response: %w", err)
+	}
+	if result.Error != nil {
+		return nil, fmt.Errorf("transcription failed: %s", result.Error.Message)
+	}
+
+	transcript := &Transcript{
+		Text:     strings.TrimSpace(result.Text),
+		Language: result.Language,
+		Duration: result.Duration,
+	}
+	for _, seg := range result.Segments {
+		if text := strings.TrimSpace(seg.Text); text != "" {
+			transcript.Segments = append(transcript.Segments, Segment{Start: seg.Start, End: seg.End, Text: text})
+		}
+	}
+	// 部分兼容服务不返回分段，整段文本作为一个分段
+	if len(transcript.Segments) == 0 && transcript.Text != "" {
+		transcript.Segments = []Segment{{Start: 0, End: result.Duration, Text: transcript.Text}}
+	}
+	return transcript, nil
+}
+
+func (c *WhisperClient) buildForm(audioPath string, options *STTOptions) (*bytes.Buffer, string, error) {
+	file, err := os.Open(audioPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("open audio: %w", err)
+	}
+	defer file.Close()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	part, err := writer.CreateFormFile("file", filepath.Base(audioPath))
+	if err != nil {
+		return nil, "", fmt.Errorf("create form file: %w", err)
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return nil, "", fmt.Errorf("read audio: %w", err)
+	}
+
+	fields := map[string]string{
+		"model":                     options.Model,
+		"response_format":           "verbose_json",
+		"timestamp_granularities[]": "segment",
+		"language":                  options.Language,
+		"prompt":                    options.Prompt,
+	}
+	for key, value := range fields {
+		if value == "" {
+			continue
+		}
+		if err := writer.WriteField(key, value); err != nil {
+			return nil, "", fmt.Errorf("write form field: %w", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", fmt.Errorf("close form: %w", err)
+	}
+
+	return &body, writer.FormDataContentType(), nil
+}
@@ -0,0 +1,60 @@
+package tts
+
+import (
+	"bytes"
+	"encoding/binary"
+	"unicode/utf8"
+)
+
+const (
+	// localSampleRate 本地替代音频的采样率
+	localSampleRate = 16000
+	// localCharsPerSecond 正常语速下每秒朗读的字数
+	localCharsPerSecond = 4.5
+	// localPadding 句末停顿（秒）
+	localPadding = 0.3
+)
+
+// LocalTTSClient 本地替代实现：不调用任何服务，按台词字数与语速生成等长的静音WAV
+// 用于未配置语音服务时打通配音、时间线与合成流程，音频时长与真实朗读大致相当
+type LocalTTSClient struct{}
+
+func NewLocalTTSClient() *LocalTTSClient {
+	return &LocalTTSClient{}
+}
+
+func (c *LocalTTSClient) Synthesize(text string, opts ...TTSOption) (*TTSResult, error) {
+	options := applyOptions(TTSOptions{}, opts)
+
+	duration := float64(utf8.RuneCountInString(text))/localCharsPerSecond/options.Speed + localPadding
+	duration = max(duration, 1)
+
+	return &TTSResult{
+		Audio:    silentWAV(duration),
+		Format:   "wav",
+		Duration: duration,
+	}, nil
+}
+
+// silentWAV 生成指定时长的单声道16位PCM静音WAV
+func silentWAV(duration float64) []byte {
+	samples := int(duration * localSampleRate)
+	dataSize := samples * 2
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+dataSize))
+	buf.WriteString("WAVE")
+	buf.WriteString("fmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16))
+	binary.Write(&buf, binary.LittleEndian, uint16(1)) // PCM
+	binary.Write(&buf, binary.LittleEndian, uint16(1)) // 单声道
+	binary.Write(&buf, binary.LittleEndian, uint32(localSampleRate))
+	binary.Write(&buf, binary.LittleEndian, uint32(localSampleRate*2))
+	binary.Write(&buf, binary.LittleEndian, uint16(2))
+	binary.Write(&buf, binary.LittleEndian, uint16(16))
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(dataSize))
+	buf.Write(make([]byte, dataSize))
+	return buf.Bytes()
+}
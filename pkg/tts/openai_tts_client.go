@@ -0,0 +1,107 @@
+package tts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAITTSClient OpenAI兼容的 /audio/speech 接口
+type OpenAITTSClient struct {
+	BaseURL    string
+	APIKey     string
+	Model      string
+	Endpoint   string
+	HTTPClient *http.Client
+}
+
+type OpenAISpeechRequest struct {
+	Model          string  `json:"model"`
+	Input          string  `json:"input"`
+	Voice          string  `json:"voice"`
+	ResponseFormat string  `json:"response_format,omitempty"`
+	Speed          float64 `json:"speed,omitempty"`
+	Instructions   string  `json:"instructions,omitempty"`
+}
+
+func NewOpenAITTSClient(baseURL, apiKey, model, endpoint string) *OpenAITTSClient {
+	if endpoint == "" {
+		endpoint = "/audio/speech"
+	}
+	if model == "" {
+		model = "tts-1"
+	}
+	return &OpenAITTSClient{
+		BaseURL:  baseURL,
+		APIKey:   apiKey,
+		Model:    model,
+		Endpoint: endpoint,
+		HTTPClient: &http.Client{
+			Timeout: 2 * time.Minute,
+		},
+	}
+}
+
+func (c *OpenAITTSClient) Synthesize(text string, opts ...TTSOption) (*TTSResult, error) {
+	options := applyOptions(TTSOptions{Model: c.Model, Voice: "alloy"}, opts)
+	if options.Voice == "" {
+		options.Voice = "alloy"
+	}
+
+	reqBody := OpenAISpeechRequest{
+		Model:          options.Model,
+		Input:          text,
+		Voice:          options.Voice,
+		ResponseFormat: options.Format,
+		Speed:          options.Speed,
+	}
+	if supportsInstructions(options.Model) {
+		reqBody.Instructions = options.Instructions
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", c.BaseURL+c.Endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	}
+	if len(body) == 0 {
+		return nil, fmt.Errorf("no audio generated")
+	}
+
+	return &TTSResult{
+		Audio:  body,
+		Format: options.Format,
+	}, nil
+}
+
+// supportsInstructions 语气指令仅 gpt-4o-mini-tts 一类模型支持，tts-1/tts-1-hd 不接受该字段
+func supportsInstructions(model string) bool {
+	model = strings.ToLower(model)
+	return strings.HasPrefix(model, "gpt-4o") && strings.Contains(model, "tts")
+}
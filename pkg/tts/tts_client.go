@@ -0,0 +1,66 @@
+package tts
+
+// TTSClient 语音合成客户端
+type TTSClient interface {
+	Synthesize(text string, opts ...TTSOption) (*TTSResult, error)
+}
+
+type TTSResult struct {
+	Audio    []byte
+	Format   string  // 音频格式：mp3、wav 等，对应文件扩展名
+	Duration float64 // 音频时长（秒），服务未返回时为0
+}
+
+type TTSOptions struct {
+	Model        string
+	Voice        string  // 音色ID，为空时使用服务的默认音色
+	Speed        float64 // 语速倍率，1.0 为正常语速
+	Format       string  // 输出格式，默认 mp3
+	Instructions string  // 语气/风格描述（支持的服务才生效）
+}
+
+type TTSOption func(*TTSOptions)
+
+func WithModel(model string) TTSOption {
+	return func(o *TTSOptions) {
+		o.Model = model
+	}
+}
+
+func WithVoice(voice string) TTSOption {
+	return func(o *TTSOptions) {
+		o.Voice = voice
+	}
+}
+
+func WithSpeed(speed float64) TTSOption {
+	return func(o *TTSOptions) {
+		o.Speed = speed
+	}
+}
+
+func WithFormat(format string) TTSOption {
+	return func(o *TTSOptions) {
+		o.Format = format
+	}
+}
+
+func WithInstructions(instructions string) TTSOption {
+	return func(o *TTSOptions) {
+		o.Instructions = instructions
+	}
+}
+
+func applyOptions(defaults TTSOptions, opts []TTSOption) *TTSOptions {
+	options := defaults
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.Speed <= 0 {
+		options.Speed = 1
+	}
+	if options.Format == "" {
+		options.Format = "mp3"
+	}
+	return &options
+}
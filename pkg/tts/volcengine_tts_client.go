@@ -0,0 +1,151 @@
+package tts
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// volcengineSuccessCode 火山引擎语音合成成功返回码
+const volcengineSuccessCode = 3000
+
+// VolcEngineTTSClient 火山引擎（豆包）语音合成 HTTP 接口
+type VolcEngineTTSClient struct {
+	BaseURL    string
+	AppID      string
+	Token      string
+	Cluster    string
+	Endpoint   string
+	HTTPClient *http.Client
+}
+
+type VolcEngineTTSRequest struct {
+	App struct {
+		AppID   string `json:"appid"`
+		Token   string `json:"token"`
+		Cluster string `json:"cluster"`
+	} `json:"app"`
+	User struct {
+		UID string `json:"uid"`
+	} `json:"user"`
+	Audio struct {
+		VoiceType  string  `json:"voice_type"`
+		Encoding   string  `json:"encoding"`
+		SpeedRatio float64 `json:"speed_ratio"`
+	} `json:"audio"`
+	Request struct {
+		ReqID     string `json:"reqid"`
+		Text      string `json:"text"`
+		Operation string `json:"operation"`
+	} `json:"request"`
+}
+
+type VolcEngineTTSResponse struct {
+	ReqID    string `json:"reqid"`
+	Code     int    `json:"code"`
+	Message  string `json:"message"`
+	Data     string `json:"data"` // base64编码的音频
+	Addition struct {
+		Duration string `json:"duration"` // 毫秒
+	} `json:"addition"`
+}
+
+func NewVolcEngineTTSClient(baseURL, appID, token, cluster, endpoint string) *VolcEngineTTSClient {
+	if baseURL == "" {
+		baseURL = "https://openspeech.bytedance.com"
+	}
+	if endpoint == "" {
+		endpoint = "/api/v1/tts"
+	}
+	if cluster == "" {
+		cluster = "volcano_tts"
+	}
+	return &VolcEngineTTSClient{
+		BaseURL:  baseURL,
+		AppID:    appID,
+		Token:    token,
+		Cluster:  cluster,
+		Endpoint: endpoint,
+		HTTPClient: &http.Client{
+			Timeout: 2 * time.Minute,
+		},
+	}
+}
+
+func (c *VolcEngineTTSClient) Synthesize(text string, opts ...TTSOption) (*TTSResult, error) {
+	options := applyOptions(TTSOptions{Voice: "BV700_streaming"}, opts)
+	if options.Voice == "" {
+		options.Voice = "BV700_streaming"
+	}
+
+	var reqBody VolcEngineTTSRequest
+	reqBody.App.AppID = c.AppID
+	reqBody.App.Token = c.Token
+	reqBody.App.Cluster = c.Cluster
+	reqBody.User.UID = "drama-generator"
+	reqBody.Audio.VoiceType = options.Voice
+	reqBody.Audio.Encoding = options.Format
+	reqBody.Audio.SpeedRatio = options.Speed
+	reqBody.Request.ReqID = uuid.New().String()
+	reqBody.Request.Text = text
+	reqBody.Request.Operation = "query"
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", c.BaseURL+c.Endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	// 火山引擎鉴权格式为 "Bearer;{token}"
+	req.Header.Set("Authorization", "Bearer;"+c.Token)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	var result VolcEngineTTSResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("parse response: %w, body: %s", err, string(body))
+	}
+	if resp.StatusCode != http.StatusOK || result.Code != volcengineSuccessCode {
+		return nil, fmt.Errorf("API error (status %d, code %d): %s", resp.StatusCode, result.Code, result.Message)
+	}
+
+	audio, err := base64.StdEncoding.DecodeString(result.Data)
+	if err != nil {
+		return nil, fmt.Errorf("decode audio: %w", err)
+	}
+	if len(audio) == 0 {
+		return nil, fmt.Errorf("no audio generated")
+	}
+
+	var duration float64
+	if ms, err := strconv.ParseFloat(result.Addition.Duration, 64); err == nil {
+		duration = ms / 1000
+	}
+
+	return &TTSResult{
+		Audio:    audio,
+		Format:   options.Format,
+		Duration: duration,
+	}, nil
+}